@@ -126,6 +126,32 @@ export default class BrowserContext {
     return existingPage;
   }
 
+  /**
+   * Get the page of a specific tab without making it the current tab.
+   * @param tabId The ID of the tab to inspect
+   * @returns The attached page of the tab
+   */
+  public async getPageByTabId(tabId: number): Promise<Page> {
+    const existingPage = this._attachedPages.get(tabId);
+    if (existingPage) {
+      return existingPage;
+    }
+
+    const tab = await chrome.tabs.get(tabId);
+    const page = await this._getOrCreatePage(tab);
+    await this.attachPage(page);
+    return page;
+  }
+
+  /**
+   * Get the page a request targets: the given tab if any, otherwise the current tab.
+   * @param tabId The ID of the tab to act on, e.g. the tab of an MCP session
+   * @returns The attached page of the tab
+   */
+  public async getTargetPage(tabId?: number): Promise<Page> {
+    return tabId === undefined ? this.getCurrentPage() : this.getPageByTabId(tabId);
+  }
+
   /**
    * Get all tab IDs from the browser across all windows.
    * @returns A set of tab IDs.
@@ -332,8 +358,8 @@ export default class BrowserContext {
     return tabInfos;
   }
 
-  public async getState(useVision = false, cacheClickableElementsHashes = false): Promise<BrowserState> {
-    const currentPage = await this.getCurrentPage();
+  public async getState(useVision = false, cacheClickableElementsHashes = false, tabId?: number): Promise<BrowserState> {
+    const currentPage = tabId !== undefined ? await this.getPageByTabId(tabId) : await this.getCurrentPage();
 
     const pageState = !currentPage
       ? build_initial_state()
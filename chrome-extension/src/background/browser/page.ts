@@ -298,6 +298,135 @@ export default class Page {
     }
   }
 
+  /**
+   * Capture a screenshot of the viewport, the full page or a single element.
+   * @param options Capture options; when element is set, only that element is captured
+   * @returns The base64-encoded image
+   */
+  async captureScreenshot(options: {
+    fullPage?: boolean;
+    format: 'png' | 'jpeg';
+    quality?: number;
+    element?: ElementHandle;
+  }): Promise<string> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+
+    const screenshotOptions = {
+      encoding: 'base64' as const,
+      type: options.format,
+      // Quality is only supported for JPEG
+      ...(options.format === 'jpeg' && options.quality !== undefined ? { quality: options.quality } : {}),
+    };
+
+    if (options.element) {
+      await options.element.scrollIntoView();
+      return (await options.element.screenshot(screenshotOptions)) as string;
+    }
+
+    return (await this._puppeteerPage.screenshot({
+      ...screenshotOptions,
+      fullPage: options.fullPage ?? false,
+    })) as string;
+  }
+
+  /**
+   * Wait for an element to become visible, or to be removed or hidden.
+   * @param selector CSS selector, or XPath expression when options.xpath is set
+   * @param options The state to wait for; a timeout of 0 waits until the signal aborts
+   */
+  async waitForSelector(
+    selector: string,
+    options: { xpath: boolean; state: 'appear' | 'disappear'; timeout: number; signal?: AbortSignal },
+  ): Promise<void> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+
+    await this._puppeteerPage.waitForSelector(options.xpath ? `::-p-xpath(${selector})` : selector, {
+      visible: options.state === 'appear',
+      hidden: options.state === 'disappear',
+      timeout: options.timeout,
+      signal: options.signal,
+    });
+  }
+
+  /**
+   * Wait for text to show up in the visible text of the page.
+   * @param text The text to wait for
+   * @param options A timeout of 0 waits until the signal aborts
+   */
+  async waitForText(text: string, options: { timeout: number; signal?: AbortSignal }): Promise<void> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+
+    await this._puppeteerPage.waitForFunction(
+      (expected: string) => document.body?.innerText.includes(expected) ?? false,
+      { polling: 100, ...options },
+      text,
+    );
+  }
+
+  /**
+   * Wait for the page URL to match a regular expression. The wait carries on
+   * across navigations.
+   * @param pattern The regular expression source
+   * @param options A timeout of 0 waits until the signal aborts
+   */
+  async waitForUrl(pattern: string, options: { timeout: number; signal?: AbortSignal }): Promise<void> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+
+    await this._puppeteerPage.waitForFunction(
+      (source: string) => new RegExp(source).test(window.location.href),
+      { polling: 100, ...options },
+      pattern,
+    );
+  }
+
+  /**
+   * Wait until the DOM has not changed for quietMs. Mutations are recorded by
+   * an observer installed on the first check.
+   * @param quietMs How long the DOM must stay unchanged
+   * @param options A timeout of 0 waits until the signal aborts
+   */
+  async waitForDomStable(quietMs: number, options: { timeout: number; signal?: AbortSignal }): Promise<void> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+
+    await this._puppeteerPage.waitForFunction(
+      (quiet: number) => {
+        const state = window as unknown as { __algoniusLastMutation?: number };
+        if (state.__algoniusLastMutation === undefined) {
+          state.__algoniusLastMutation = performance.now();
+          new MutationObserver(() => {
+            state.__algoniusLastMutation = performance.now();
+          }).observe(document, { subtree: true, childList: true, attributes: true, characterData: true });
+        }
+        return performance.now() - state.__algoniusLastMutation >= quiet;
+      },
+      { polling: 50, ...options },
+      quietMs,
+    );
+  }
+
+  /**
+   * Wait until no network request has been in flight for idleMs.
+   * @param idleMs How long the network must stay idle
+   * @param options A timeout of 0 waits until the signal aborts
+   */
+  async waitForNetworkIdle(idleMs: number, options: { timeout: number; signal?: AbortSignal }): Promise<void> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+
+    await this._puppeteerPage.waitForNetworkIdle({ idleTime: idleMs, concurrency: 0, ...options });
+  }
+
   async takeScreenshot(fullPage = false): Promise<string | null> {
     if (!this._puppeteerPage) {
       throw new Error('Puppeteer page is not connected');
@@ -449,6 +578,49 @@ export default class Page {
     }
   }
 
+  /**
+   * Go back or forward in the tab's history, or reload the page, and wait for it to load
+   *
+   * @param action 'hard_reload' reloads bypassing the browser cache
+   * @param timeout How long to wait for the page to load, in milliseconds
+   * @returns false if there is no history entry to go back or forward to
+   */
+  async navigateHistory(action: 'back' | 'forward' | 'reload' | 'hard_reload', timeout: number): Promise<boolean> {
+    if (!this._puppeteerPage) {
+      throw new Error('Puppeteer page is not connected');
+    }
+    const page = this._puppeteerPage;
+    const options = { timeout, waitUntil: 'load' as const };
+
+    const session = await page.createCDPSession();
+    try {
+      let navigation: Promise<unknown>;
+      if (action === 'back' || action === 'forward') {
+        const history = await session.send('Page.getNavigationHistory');
+        const index = history.currentIndex + (action === 'back' ? -1 : 1);
+        if (index < 0 || index >= history.entries.length) {
+          return false;
+        }
+        navigation = action === 'back' ? page.goBack(options) : page.goForward(options);
+      } else if (action === 'hard_reload') {
+        navigation = Promise.all([page.waitForNavigation(options), session.send('Page.reload', { ignoreCache: true })]);
+      } else {
+        navigation = page.reload(options);
+      }
+
+      await Promise.all([this.waitForPageAndFramesLoad(), navigation]);
+      logger.info(`History navigation ${action} completed`);
+      return true;
+    } catch (error) {
+      if (error instanceof Error && error.name === 'TimeoutError') {
+        throw new Error(`Navigation timeout after ${timeout}ms`);
+      }
+      throw error;
+    } finally {
+      await session.detach().catch(() => undefined);
+    }
+  }
+
   async scrollDown(amount?: number): Promise<void> {
     if (this._puppeteerPage) {
       if (amount) {
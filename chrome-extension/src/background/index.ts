@@ -1,7 +1,7 @@
 import 'webextension-polyfill';
 import BrowserContext from './browser/context';
 import { createLogger } from './log';
-import { McpHostManager } from './mcp/host-manager';
+import { McpHostManager, type RpcHandler } from './mcp/host-manager';
 import {
   GetBrowserStateHandler,
   GetDomStateHandler,
@@ -11,6 +11,9 @@ import {
 } from './task';
 import { ScrollPageHandler } from './task/scroll-page-handler';
 import { ClickElementHandler } from './task/click-element-handler';
+import { TakeScreenshotHandler } from './task/take-screenshot-handler';
+import { WaitForHandler } from './task/wait-for-handler';
+import { NavigateHistoryHandler } from './task/navigate-history-handler';
 
 const logger = createLogger('background');
 
@@ -27,18 +30,53 @@ const scrollPageHandler = new ScrollPageHandler(browserContext);
 const clickElementHandler = new ClickElementHandler(browserContext);
 const manageTabsHandler = new ManageTabsHandler(browserContext);
 const typeValueHandler = new TypeValueHandler(browserContext);
+const takeScreenshotHandler = new TakeScreenshotHandler(browserContext);
+const waitForHandler = new WaitForHandler(browserContext);
+const navigateHistoryHandler = new NavigateHistoryHandler(browserContext);
 
 // Register RPC method handlers
 mcpHostManager.registerRpcMethod('navigate_to', navigateToHandler.handleNavigateTo.bind(navigateToHandler));
+mcpHostManager.registerRpcMethod(
+  'navigate_history',
+  navigateHistoryHandler.handleNavigateHistory.bind(navigateHistoryHandler),
+);
 mcpHostManager.registerRpcMethod(
   'get_browser_state',
   getBrowserStateHandler.handleGetBrowserState.bind(getBrowserStateHandler),
 );
 mcpHostManager.registerRpcMethod('get_dom_state', getDomStateHandler.handleGetDomState.bind(getDomStateHandler));
-mcpHostManager.registerRpcMethod('scroll_page', scrollPageHandler.handleScrollPage.bind(scrollPageHandler));
-mcpHostManager.registerRpcMethod('click_element', clickElementHandler.handleClickElement.bind(clickElementHandler));
+mcpHostManager.registerRpcMethod(
+  'scroll_page',
+  notifyDomChangeAfter(scrollPageHandler.handleScrollPage.bind(scrollPageHandler)),
+);
+mcpHostManager.registerRpcMethod(
+  'click_element',
+  notifyDomChangeAfter(clickElementHandler.handleClickElement.bind(clickElementHandler)),
+);
 mcpHostManager.registerRpcMethod('manage_tabs', manageTabsHandler.handleManageTabs.bind(manageTabsHandler));
-mcpHostManager.registerRpcMethod('type_value', typeValueHandler.handleTypeValue.bind(typeValueHandler));
+mcpHostManager.registerRpcMethod(
+  'type_value',
+  notifyDomChangeAfter(typeValueHandler.handleTypeValue.bind(typeValueHandler)),
+);
+mcpHostManager.registerRpcMethod(
+  'take_screenshot',
+  takeScreenshotHandler.handleTakeScreenshot.bind(takeScreenshotHandler),
+);
+mcpHostManager.registerRpcMethod('wait_for', waitForHandler.handleWaitFor.bind(waitForHandler));
+
+/**
+ * Wraps an RPC handler that interacts with the page so that a successful call
+ * reports a dom_changed event to the MCP Host.
+ */
+function notifyDomChangeAfter(handler: RpcHandler): RpcHandler {
+  return async (request, signal, reportProgress) => {
+    const response = await handler(request, signal, reportProgress);
+    if (!response.error) {
+      notifyStateChange('dom_changed');
+    }
+    return response;
+  };
+}
 
 // Function to check if script is already injected
 async function isScriptInjected(tabId: number): Promise<boolean> {
@@ -78,6 +116,24 @@ chrome.tabs.onUpdated.addListener(async (tabId, changeInfo, tab) => {
   if (tabId && changeInfo.status === 'complete' && tab.url?.startsWith('http')) {
     await injectBuildDomTree(tabId);
   }
+  if (changeInfo.status === 'complete' || changeInfo.url || changeInfo.title) {
+    notifyStateChange('tab_updated', tabId, tab.url);
+  }
+});
+
+/**
+ * Tells the MCP Host that browser state changed so it can notify subscribed clients.
+ */
+function notifyStateChange(event: string, tabId?: number, url?: string) {
+  mcpHostManager.sendMessage('browser_state_changed', { event, tabId, url });
+}
+
+chrome.tabs.onCreated.addListener(tab => {
+  notifyStateChange('tab_created', tab.id, tab.url);
+});
+
+chrome.tabs.onActivated.addListener(activeInfo => {
+  notifyStateChange('tab_activated', activeInfo.tabId);
 });
 
 // Listen for debugger detached event
@@ -93,6 +149,7 @@ chrome.debugger.onDetach.addListener(async (source, reason) => {
 // Cleanup when tab is closed
 chrome.tabs.onRemoved.addListener(tabId => {
   browserContext.removeAttachedPage(tabId);
+  notifyStateChange('tab_removed', tabId);
 });
 
 logger.info('background loaded');
@@ -104,6 +161,11 @@ chrome.runtime.onMessage.addListener((message, sender, sendResponse) => {
     // Start MCP Host process
     logger.info('Received request to start MCP Host:', message.options);
 
+    // The sender may pick the WebSocket transport for a host started on its own
+    if (message.transport) {
+      mcpHostManager.setTransport(message.transport);
+    }
+
     // Use Promise-based connect method
     mcpHostManager
       .connect()
@@ -6,6 +6,7 @@
  * It also supports bidirectional RPC communication with the MCP Host.
  */
 import { McpErrorCode, createMcpError } from '@extension/shared';
+import { openWebSocketPort, type HostPort } from './websocket-port';
 
 // Define RPC types
 export interface RpcRequest {
@@ -60,9 +61,38 @@ export interface RpcRequestOptions {
 }
 
 /**
- * A function that handles an RPC request and returns a promise of RpcResponse
+ * A progress update for a running RPC request
  */
-export type RpcHandler = (request: RpcRequest) => Promise<RpcResponse>;
+export interface RpcProgress {
+  /**
+   * Current progress, must increase with every update
+   */
+  progress: number;
+  /**
+   * Value of progress when the operation is done, if known
+   */
+  total?: number;
+  /**
+   * Human-readable description of the current stage
+   */
+  message?: string;
+}
+
+/**
+ * Reports progress of a running RPC request to the MCP Host (rpc_progress)
+ */
+export type ProgressReporter = (update: RpcProgress) => void;
+
+/**
+ * A function that handles an RPC request and returns a promise of RpcResponse.
+ * The signal is aborted when the MCP Host cancels the request (rpc_cancel).
+ * Long-running handlers can report progress, which the host relays to the MCP client.
+ */
+export type RpcHandler = (
+  request: RpcRequest,
+  signal?: AbortSignal,
+  reportProgress?: ProgressReporter,
+) => Promise<RpcResponse>;
 
 // Define the MCP Host status interface
 export interface McpHostStatus {
@@ -74,6 +104,11 @@ export interface McpHostStatus {
   uptime?: string;
   ssePort?: string;
   sseBaseURL?: string;
+  authToken?: string;
+  /**
+   * Set when the host rejected the init handshake, says whether the extension or the host needs an update
+   */
+  incompatibility?: string;
 }
 
 // Define the MCP Host configuration options
@@ -83,11 +118,80 @@ export interface McpHostOptions {
   logLevel?: string;
 }
 
+/**
+ * A tool this extension offers to MCP clients in addition to the host's own.
+ * Calls are sent to the RPC method named like the tool.
+ */
+export interface McpToolDefinition {
+  name: string;
+  description: string;
+  /**
+   * JSON Schema of the tool arguments
+   */
+  inputSchema?: Record<string, unknown>;
+  /**
+   * True if the tool only inspects the page and may run alongside other calls
+   */
+  readOnly?: boolean;
+}
+
+/**
+ * How the extension reaches the MCP Host. Native messaging lets Chrome start the host;
+ * a WebSocket connects to a host that was started on its own (MCP_EXTENSION_TRANSPORT=websocket).
+ */
+export interface McpTransportOptions {
+  type: 'native' | 'websocket';
+  /**
+   * WebSocket URL of the host, defaults to DEFAULT_WEBSOCKET_URL
+   */
+  url?: string;
+  /**
+   * Pairing token from the host's extension token file, required for WebSocket
+   */
+  token?: string;
+}
+
+/**
+ * WebSocket URL the MCP Host listens on by default
+ */
+export const DEFAULT_WEBSOCKET_URL = 'ws://127.0.0.1:9334/extension';
+
 // Type for status change event listeners
 export type StatusListener = (status: McpHostStatus) => void;
 
+/**
+ * Encodes bytes as base64, the payload encoding of chunk messages
+ */
+function bytesToBase64(bytes: Uint8Array): string {
+  let binary = '';
+  for (let i = 0; i < bytes.length; i += 0x8000) {
+    binary += String.fromCharCode(...bytes.subarray(i, i + 0x8000));
+  }
+  return btoa(binary);
+}
+
+/**
+ * Decodes the base64 payload of a chunk message
+ */
+function base64ToBytes(base64: string): Uint8Array {
+  const binary = atob(base64);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes;
+}
+
+/**
+ * Native messaging protocol versions spoken by this extension, announced in the init handshake.
+ * Version 2 adds heartbeats and chunked messages.
+ */
+export const PROTOCOL_VERSION = 2;
+export const MIN_PROTOCOL_VERSION = 2;
+
 export class McpHostManager {
-  private port: chrome.runtime.Port | null = null;
+  private port: HostPort | null = null;
+  private transport: McpTransportOptions = { type: 'native' };
   private status: McpHostStatus = {
     isConnected: false,
     startTime: null,
@@ -103,6 +207,10 @@ export class McpHostManager {
   private readonly GRACEFUL_SHUTDOWN_TIMEOUT_MS = 1000; // 1 second
   // RPC-related properties
   private rpcMethodHandlers: Map<string, RpcHandler> = new Map();
+  // Tools advertised to the MCP Host in the init handshake
+  private advertisedTools: Map<string, McpToolDefinition> = new Map();
+  // Abort controllers for RPC requests from the MCP Host that are still being handled
+  private inFlightRpcRequests: Map<string, AbortController> = new Map();
   private pendingRequests = new Map<
     string,
     {
@@ -112,9 +220,29 @@ export class McpHostManager {
     }
   >();
   private readonly RPC_TIMEOUT_MS = 5000; // 5 seconds default timeout for RPC requests
+  // Chunked messages, for payloads over the native messaging size limit of the host
+  private readonly MAX_MESSAGE_SIZE = 1024 * 1024; // Larger messages are sent in chunks
+  private readonly CHUNK_PAYLOAD_SIZE = 512 * 1024; // Message bytes per chunk, before base64
+  private readonly CHUNK_ASSEMBLY_TIMEOUT_MS = 60000; // Incomplete chunked messages are dropped after 1 minute
+  private incomingChunks = new Map<
+    string,
+    {
+      total: number;
+      parts: Map<number, Uint8Array>;
+      timeoutId: any;
+    }
+  >();
 
   /**
-   * Establishes a connection to the MCP Host Native Messaging host.
+   * Selects how the next connect() reaches the MCP Host.
+   * @param transport Native messaging, or a WebSocket URL and pairing token
+   */
+  public setTransport(transport: McpTransportOptions): void {
+    this.transport = { ...transport };
+  }
+
+  /**
+   * Establishes a connection to the MCP Host over the selected transport.
    * @returns {Promise<boolean>} Promise that resolves to true if connection was established successfully
    * @throws Will reject with an error if connection fails (e.g., host not installed)
    */
@@ -124,6 +252,10 @@ export class McpHostManager {
       return Promise.resolve(false);
     }
 
+    if (this.transport.type === 'websocket') {
+      return this.connectWebSocket();
+    }
+
     return new Promise((resolve, reject) => {
       // Connect to the native messaging host
       // Note: connectNative always returns a Port object even if the host doesn't exist
@@ -172,6 +304,9 @@ export class McpHostManager {
           // Start heartbeat
           this.startHeartbeat();
 
+          // Tell the host which protocol version and RPC methods this extension supports
+          this.initialize().catch(error => console.error('MCP Host init handshake failed:', error));
+
           // Resolve the promise
           resolve(true);
 
@@ -181,6 +316,42 @@ export class McpHostManager {
     });
   }
 
+  /**
+   * Connects to an MCP Host listening for the extension on a WebSocket.
+   * @returns {Promise<boolean>} Promise that resolves to true once the connection is open
+   * @throws Will reject with an error if the host is not running or refuses the pairing token
+   */
+  private async connectWebSocket(): Promise<boolean> {
+    const { url = DEFAULT_WEBSOCKET_URL, token } = this.transport;
+    if (!token) {
+      throw createMcpError(McpErrorCode.CONNECTION_FAILED, 'A pairing token is required to connect over WebSocket');
+    }
+
+    let port: HostPort;
+    try {
+      port = await openWebSocketPort(url, token);
+    } catch (error) {
+      const errorMessage = error instanceof Error ? error.message : String(error);
+      console.error(`WebSocket connection failed: ${errorMessage}`);
+      throw createMcpError(McpErrorCode.CONNECTION_FAILED, `WebSocket connection failed: ${errorMessage}`, { url });
+    }
+
+    // Another connect() may have finished while the socket was opening
+    if (this.port) {
+      port.disconnect();
+      return false;
+    }
+
+    this.port = port;
+    this.port.onMessage.addListener(this.handleMessage.bind(this));
+    this.port.onDisconnect.addListener(() => this.handleDisconnect());
+
+    this.updateStatus({ isConnected: true });
+    this.startHeartbeat();
+    this.initialize().catch(error => console.error('MCP Host init handshake failed:', error));
+    return true;
+  }
+
   /**
    * Disconnects from the MCP Host.
    */
@@ -250,27 +421,54 @@ export class McpHostManager {
       }
 
       // Send init RPC request
-      const response = await this.rpcRequest({
-        method: 'init',
-        params: options,
-      });
-
-      // Check if init was successful
-      if (response && response.result && response.result.status === 'initialized') {
-        console.log('MCP Host initialized successfully');
+      const initialized = await this.initialize(options);
+      if (initialized) {
         // Set the start time when the host is successfully initialized
         this.updateStatus({ startTime: Date.now() });
-        return true;
-      } else {
-        console.error('MCP Host init failed:', response.error || 'Unknown error');
-        return false;
       }
+      return initialized;
     } catch (error) {
       console.error('Failed to start MCP Host:', error);
       throw error;
     }
   }
 
+  /**
+   * Performs the init handshake: announces the protocol versions and RPC methods of this
+   * extension, and records why the host rejected them if the versions do not overlap.
+   * @param options Optional run options for the host
+   * @returns True if the host accepted the extension
+   */
+  private async initialize(options?: McpHostOptions): Promise<boolean> {
+    const response = await this.rpcRequest({
+      method: 'init',
+      params: {
+        ...options,
+        extensionVersion: chrome.runtime.getManifest().version,
+        protocolVersion: PROTOCOL_VERSION,
+        minProtocolVersion: MIN_PROTOCOL_VERSION,
+        methods: Array.from(this.rpcMethodHandlers.keys()),
+        tools: Array.from(this.advertisedTools.values()),
+      },
+    });
+
+    const result = response?.result;
+    if (result?.status === 'initialized') {
+      console.log(`MCP Host ${result.hostVersion} initialized with protocol v${result.protocolVersion}`);
+      this.updateStatus({ incompatibility: undefined });
+      return true;
+    }
+
+    if (result?.status === 'incompatible') {
+      console.error('MCP Host is not compatible with this extension:', result.message);
+      this.updateStatus({ incompatibility: result.message });
+      return false;
+    }
+
+    console.error('MCP Host init failed:', response?.error || 'Unknown error');
+    return false;
+  }
+
   /**
    * Stops the MCP Host process using RPC.
    * @returns {Promise<boolean>} True if the Host was stopped successfully.
@@ -348,6 +546,54 @@ export class McpHostManager {
     this.rpcMethodHandlers.set(method, handler);
   }
 
+  /**
+   * Offers a tool to MCP clients through the MCP Host. If the host is connected the
+   * handshake is repeated, so the host picks up the tool without a restart.
+   * @param definition The tool name, description and input schema
+   * @param handler The function to handle calls of the tool
+   */
+  public registerTool(definition: McpToolDefinition, handler: RpcHandler): void {
+    console.debug(`[McpHostManager] Registering tool: ${definition.name}`);
+    this.advertisedTools.set(definition.name, definition);
+    this.rpcMethodHandlers.set(definition.name, handler);
+    this.reannounce();
+  }
+
+  /**
+   * Withdraws a tool registered with registerTool.
+   * @param name The tool name
+   */
+  public unregisterTool(name: string): void {
+    if (!this.advertisedTools.delete(name)) {
+      return;
+    }
+    console.debug(`[McpHostManager] Unregistering tool: ${name}`);
+    this.rpcMethodHandlers.delete(name);
+    this.reannounce();
+  }
+
+  /**
+   * Repeats the init handshake if connected, so the host sees the current tools.
+   */
+  private reannounce(): void {
+    if (!this.port || !this.status.isConnected) {
+      return;
+    }
+    this.initialize().catch(error => console.error('MCP Host init handshake failed:', error));
+  }
+
+  /**
+   * Sends a one-way message to the MCP Host. Messages are dropped while disconnected.
+   * @param type The message type
+   * @param data The message payload
+   */
+  public sendMessage(type: string, data: unknown): void {
+    if (!this.port || !this.status.isConnected) {
+      return;
+    }
+    this.postMessage({ type, data });
+  }
+
   /**
    * Sends an RPC request to the MCP Host and returns a promise for the response.
    * @param rpc The RPC request to send
@@ -380,7 +626,7 @@ export class McpHostManager {
       this.pendingRequests.set(id, { resolve, reject, timeoutId });
 
       // Send the RPC request message
-      this.port?.postMessage({
+      this.postMessage({
         type: 'rpc_request',
         id,
         method,
@@ -389,6 +635,77 @@ export class McpHostManager {
     });
   }
 
+  /**
+   * Posts a message to the MCP Host, splitting it into chunk messages when its
+   * JSON is larger than MAX_MESSAGE_SIZE. The host joins the chunks again.
+   * @param message The message to send
+   */
+  private postMessage(message: Record<string, unknown>): void {
+    if (!this.port) {
+      return;
+    }
+
+    const bytes = new TextEncoder().encode(JSON.stringify(message));
+    if (bytes.length <= this.MAX_MESSAGE_SIZE) {
+      this.port.postMessage(message);
+      return;
+    }
+
+    const chunkId = 'chunk_' + Math.random().toString(36).substring(2, 15) + Math.random().toString(36).substring(2, 15);
+    const total = Math.ceil(bytes.length / this.CHUNK_PAYLOAD_SIZE);
+    console.debug(`[McpHostManager] Sending ${message.type} message of ${bytes.length} bytes in ${total} chunks`);
+    for (let seq = 0; seq < total; seq++) {
+      const part = bytes.subarray(seq * this.CHUNK_PAYLOAD_SIZE, (seq + 1) * this.CHUNK_PAYLOAD_SIZE);
+      this.port.postMessage({
+        type: 'chunk',
+        data: { chunkId, seq, total, payload: bytesToBase64(part) },
+      });
+    }
+  }
+
+  /**
+   * Stores a chunk of a message from the MCP Host and handles the message once all chunks arrived.
+   * @param data The chunk message data
+   */
+  private handleChunk(data: any): void {
+    const { chunkId, seq, total, payload } = data ?? {};
+    if (typeof chunkId !== 'string' || !Number.isInteger(seq) || !Number.isInteger(total) || seq < 0 || seq >= total) {
+      console.warn('[McpHostManager] Dropping invalid message chunk:', { chunkId, seq, total });
+      return;
+    }
+
+    let pending = this.incomingChunks.get(chunkId);
+    if (!pending) {
+      const timeoutId = setTimeout(() => {
+        console.warn(`[McpHostManager] Dropping incomplete chunked message: ${chunkId}`);
+        this.incomingChunks.delete(chunkId);
+      }, this.CHUNK_ASSEMBLY_TIMEOUT_MS);
+      pending = { total, parts: new Map(), timeoutId };
+      this.incomingChunks.set(chunkId, pending);
+    }
+    pending.parts.set(seq, base64ToBytes(payload));
+    if (pending.parts.size < pending.total) {
+      return;
+    }
+
+    clearTimeout(pending.timeoutId);
+    this.incomingChunks.delete(chunkId);
+
+    const parts = Array.from({ length: pending.total }, (_, i) => pending.parts.get(i) ?? new Uint8Array());
+    const joined = new Uint8Array(parts.reduce((size, part) => size + part.length, 0));
+    let offset = 0;
+    for (const part of parts) {
+      joined.set(part, offset);
+      offset += part.length;
+    }
+
+    try {
+      this.handleMessage(JSON.parse(new TextDecoder().decode(joined)));
+    } catch (error) {
+      console.error(`[McpHostManager] Invalid chunked message ${chunkId}:`, error);
+    }
+  }
+
   /**
    * Generates a unique ID for RPC requests
    * @returns A unique string ID
@@ -410,7 +727,7 @@ export class McpHostManager {
 
     if (!handler) {
       console.warn(`[McpHostManager] No handler registered for RPC method: ${method}`);
-      this.port?.postMessage({
+      this.postMessage({
         type: 'rpc_response',
         id,
         error: {
@@ -422,22 +739,44 @@ export class McpHostManager {
     }
 
     // Call the handler and send the response
+    const controller = new AbortController();
+    this.inFlightRpcRequests.set(id, controller);
+    const reportProgress: ProgressReporter = update => {
+      if (controller.signal.aborted) {
+        return;
+      }
+      this.postMessage({
+        type: 'rpc_progress',
+        id,
+        data: update,
+      });
+    };
     try {
       const request: RpcRequest = { id, method, params };
-      const response = await handler(request);
+      const response = await handler(request, controller.signal, reportProgress);
+
+      // The host stopped waiting for this request, so there is no one to answer
+      if (controller.signal.aborted) {
+        console.log(`[McpHostManager] Dropping response for cancelled RPC request: ${method} (id: ${id})`);
+        return;
+      }
 
       // Make sure the response includes the request ID
       response.id = id;
 
       console.log(`[McpHostManager] Send RPC response:`, response);
 
-      this.port?.postMessage({
+      this.postMessage({
         type: 'rpc_response',
         ...response,
       });
     } catch (error) {
+      if (controller.signal.aborted) {
+        console.log(`[McpHostManager] RPC request aborted after cancellation: ${method} (id: ${id})`);
+        return;
+      }
       console.error(`[McpHostManager] Error handling RPC method ${method}:`, error);
-      this.port?.postMessage({
+      this.postMessage({
         type: 'rpc_response',
         id,
         error: {
@@ -445,9 +784,27 @@ export class McpHostManager {
           message: error instanceof Error ? error.message : String(error),
         },
       });
+    } finally {
+      this.inFlightRpcRequests.delete(id);
     }
   }
 
+  /**
+   * Aborts an in-flight RPC request after the MCP Host cancelled it.
+   * @param data The rpc_cancel message
+   */
+  private handleRpcCancel(data: any): void {
+    const { id } = data;
+    const controller = this.inFlightRpcRequests.get(id);
+    if (!controller) {
+      console.debug(`[McpHostManager] No in-flight RPC request to cancel for ID: ${id}`);
+      return;
+    }
+
+    console.log(`[McpHostManager] Cancelling RPC request ${id} (reason: ${data.data?.reason ?? 'unknown'})`);
+    controller.abort();
+  }
+
   /**
    * Processes an incoming RPC response from the MCP Host.
    * @param data The RPC response data
@@ -492,6 +849,17 @@ export class McpHostManager {
       case 'rpc_response':
         this.handleRpcResponse(message);
         break;
+      case 'rpc_cancel':
+        this.handleRpcCancel(message);
+        break;
+      // Answer host heartbeats so the host knows the extension is alive
+      case 'ping':
+        this.postMessage({ type: 'pong', id: message.id });
+        break;
+      // Parts of a message too large to send at once
+      case 'chunk':
+        this.handleChunk(message.data);
+        break;
       default:
         console.log('Unknown message from MCP Host:', message);
     }
@@ -503,6 +871,10 @@ export class McpHostManager {
    */
   private handleDisconnect(): void {
     this.stopHeartbeat();
+    this.inFlightRpcRequests.forEach(controller => controller.abort());
+    this.inFlightRpcRequests.clear();
+    this.incomingChunks.forEach(pending => clearTimeout(pending.timeoutId));
+    this.incomingChunks.clear();
     this.port = null;
     this.updateStatus({
       isConnected: false,
@@ -657,6 +1029,7 @@ export class McpHostManager {
             uptime: statusData.uptime || null,
             ssePort: statusData.sse_port || null,
             sseBaseURL: statusData.sse_base_url || null,
+            authToken: statusData.auth_token || null,
           });
         }
       })
@@ -0,0 +1,108 @@
+/**
+ * WebSocket Port
+ *
+ * Connects to an MCP Host started on its own (MCP_EXTENSION_TRANSPORT=websocket) and exposes
+ * the connection through the same interface as a native messaging port, so the host manager
+ * speaks the same protocol over either transport.
+ */
+
+/**
+ * The parts of chrome.runtime.Port the host manager uses
+ */
+export interface HostPort {
+  postMessage(message: unknown): void;
+  disconnect(): void;
+  onMessage: HostPortEvent<(message: any) => void>;
+  onDisconnect: HostPortEvent<() => void>;
+}
+
+/**
+ * A minimal event, shaped like chrome.events.Event
+ */
+export interface HostPortEvent<T extends (...args: any[]) => void> {
+  addListener(listener: T): void;
+  removeListener(listener: T): void;
+}
+
+/**
+ * Creates an event whose listeners can be dispatched to
+ */
+function createEvent<T extends (...args: any[]) => void>(): HostPortEvent<T> & { dispatch: (...args: Parameters<T>) => void } {
+  const listeners: T[] = [];
+  return {
+    addListener: listener => {
+      listeners.push(listener);
+    },
+    removeListener: listener => {
+      const index = listeners.indexOf(listener);
+      if (index !== -1) {
+        listeners.splice(index, 1);
+      }
+    },
+    dispatch: (...args) => {
+      [...listeners].forEach(listener => listener(...args));
+    },
+  };
+}
+
+/**
+ * Opens a WebSocket to the MCP Host and pairs with the token the host printed to its log.
+ * @param url WebSocket URL of the host, e.g. ws://127.0.0.1:9334/extension
+ * @param token Pairing token from the host's extension token file
+ * @returns A port that resolves once the connection is open
+ * @throws Will reject if the host is not running or refuses the token
+ */
+export function openWebSocketPort(url: string, token: string): Promise<HostPort> {
+  return new Promise((resolve, reject) => {
+    const socketUrl = new URL(url);
+    socketUrl.searchParams.set('token', token);
+
+    const socket = new WebSocket(socketUrl.toString());
+    const onMessage = createEvent<(message: any) => void>();
+    const onDisconnect = createEvent<() => void>();
+    let opened = false;
+    let closed = false;
+
+    const port: HostPort = {
+      postMessage: message => {
+        if (socket.readyState === WebSocket.OPEN) {
+          socket.send(JSON.stringify(message));
+        }
+      },
+      disconnect: () => {
+        // Like a native port, the side calling disconnect gets no onDisconnect event
+        closed = true;
+        socket.close();
+      },
+      onMessage,
+      onDisconnect,
+    };
+
+    socket.onopen = () => {
+      opened = true;
+      resolve(port);
+    };
+
+    socket.onmessage = event => {
+      if (typeof event.data !== 'string') {
+        return;
+      }
+      try {
+        onMessage.dispatch(JSON.parse(event.data));
+      } catch (error) {
+        console.error('[WebSocketPort] Failed to parse message from MCP Host:', error);
+      }
+    };
+
+    socket.onclose = event => {
+      if (!opened) {
+        reject(new Error(`WebSocket connection to ${url} failed (code ${event.code})`));
+        return;
+      }
+      if (!closed) {
+        closed = true;
+        onDisconnect.dispatch();
+      }
+    };
+  });
+}
@@ -8,7 +8,7 @@
 import type BrowserContext from '../browser/context';
 import { createLogger } from '../log';
 import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
-import { findElementByHighlightIndex } from './dom-utils';
+import { findElementByHighlightIndex, isValidTabId } from './dom-utils';
 import type { DOMElementNode } from '../dom/views';
 
 /**
@@ -69,8 +69,17 @@ export class ClickElementHandler {
         };
       }
 
-      // Get current page
-      const currentPage = await this.browserContext.getCurrentPage();
+      // Act on the tab of the calling MCP session, or the current tab
+      const tabId = request.params?.tab_id;
+      if (!isValidTabId(tabId)) {
+        return {
+          error: {
+            code: -32602,
+            message: 'Invalid tab_id: must be an integer',
+          },
+        };
+      }
+      const currentPage = await this.browserContext.getTargetPage(tabId);
       if (!currentPage) {
         return {
           error: {
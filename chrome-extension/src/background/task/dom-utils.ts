@@ -7,6 +7,19 @@
 
 import type { DOMElementNode } from '../dom/views';
 
+/**
+ * Check the optional tab_id parameter that targets a specific tab
+ *
+ * The MCP host sets tab_id to the tab of the calling session, so that
+ * concurrent clients act on their own tab instead of the active one.
+ *
+ * @param tabId The tab_id parameter of the request
+ * @returns true if the tab_id is absent or an integer
+ */
+export function isValidTabId(tabId: unknown): tabId is number | undefined {
+  return tabId === undefined || (typeof tabId === 'number' && Number.isInteger(tabId));
+}
+
 /**
  * Find a DOM element by its highlightIndex in the element tree
  *
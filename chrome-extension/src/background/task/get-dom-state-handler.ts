@@ -9,6 +9,7 @@ import type BrowserContext from '../browser/context';
 import { createLogger } from '../log';
 import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
 import { DOMElementNode } from '../dom/views';
+import { isValidTabId } from './dom-utils';
 
 /**
  * Handler for the 'get_dom_state' RPC method
@@ -36,8 +37,19 @@ export class GetDomStateHandler {
     this.logger.debug('Received get_dom_state request:', request);
 
     try {
+      // Inspect a specific tab when requested, otherwise the current tab
+      const tabId = request.params?.tab_id;
+      if (!isValidTabId(tabId)) {
+        return {
+          error: {
+            code: -32602,
+            message: 'Invalid tab_id: must be an integer',
+          },
+        };
+      }
+
       // Get the browser state with vision enabled for better DOM coverage
-      const browserState = await this.browserContext.getState(true);
+      const browserState = await this.browserContext.getState(true, false, tabId);
 
       if (!browserState.elementTree) {
         return {
@@ -0,0 +1,152 @@
+/**
+ * Navigate History Handler for MCP Host RPC Requests
+ *
+ * This file implements the navigate_history RPC method handler for the browser extension.
+ * It goes back or forward in the history of a tab, or reloads its page.
+ */
+
+import type BrowserContext from '../browser/context';
+import { createLogger } from '../log';
+import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
+import { isValidTabId } from './dom-utils';
+
+type HistoryAction = 'back' | 'forward' | 'reload' | 'hard_reload';
+
+const HISTORY_ACTIONS: HistoryAction[] = ['back', 'forward', 'reload', 'hard_reload'];
+
+/**
+ * Interface for navigate_history request parameters
+ */
+interface NavigateHistoryParams {
+  action: HistoryAction;
+  /**
+   * Navigation timeout: 'auto' for intelligent detection or timeout in milliseconds (e.g. '5000')
+   */
+  timeout?: string;
+  tab_id?: number;
+}
+
+/**
+ * Handler for the 'navigate_history' RPC method
+ *
+ * This handler processes history navigation requests from the MCP Host and
+ * moves the tab through its history or reloads it, waiting for the page to load.
+ */
+export class NavigateHistoryHandler {
+  private logger = createLogger('NavigateHistoryHandler');
+
+  /**
+   * Creates a new NavigateHistoryHandler instance
+   *
+   * @param browserContext The browser context for accessing the target page
+   */
+  constructor(private readonly browserContext: BrowserContext) {}
+
+  /**
+   * Parse timeout parameter and return appropriate timeout value
+   *
+   * @param timeoutStr The timeout string parameter
+   * @returns Timeout in milliseconds
+   */
+  private parseTimeout(timeoutStr: string = 'auto'): number {
+    if (timeoutStr === 'auto') {
+      // Auto mode: Use 30 seconds as a reasonable default for navigation
+      return 30000;
+    }
+
+    const parsedTimeout = parseInt(timeoutStr, 10);
+    if (isNaN(parsedTimeout) || parsedTimeout < 1000 || parsedTimeout > 120000) {
+      throw new Error('timeout must be between 1000 and 120000 milliseconds');
+    }
+
+    return parsedTimeout;
+  }
+
+  /**
+   * Handle a navigate_history RPC request
+   *
+   * @param request RPC request containing the action, optional timeout and tab_id
+   * @returns Promise resolving to an RPC response with the page shown afterwards
+   */
+  public handleNavigateHistory: RpcHandler = async (request: RpcRequest): Promise<RpcResponse> => {
+    this.logger.debug('Received navigate_history request:', request);
+
+    try {
+      const params = (request.params || {}) as NavigateHistoryParams;
+
+      if (!HISTORY_ACTIONS.includes(params.action)) {
+        return {
+          error: {
+            code: -32602,
+            message: `Invalid action: must be one of ${HISTORY_ACTIONS.join(', ')}`,
+          },
+        };
+      }
+
+      let timeoutMs: number;
+      try {
+        timeoutMs = this.parseTimeout(params.timeout);
+      } catch (error) {
+        return {
+          error: {
+            code: -32602,
+            message: error instanceof Error ? error.message : 'Invalid timeout parameter',
+          },
+        };
+      }
+
+      // Navigate the tab of the calling MCP session, or the current tab
+      if (!isValidTabId(params.tab_id)) {
+        return {
+          error: {
+            code: -32602,
+            message: 'Invalid tab_id: must be an integer',
+          },
+        };
+      }
+
+      const page = await this.browserContext.getTargetPage(params.tab_id);
+      if (!page) {
+        return {
+          error: {
+            code: -32000,
+            message: 'No active page available',
+          },
+        };
+      }
+
+      const navigated = await page.navigateHistory(params.action, timeoutMs);
+      if (!navigated) {
+        return {
+          error: {
+            code: -32000,
+            message: `Cannot go ${params.action}: no page in the tab's history`,
+          },
+        };
+      }
+
+      const url = page.url();
+      return {
+        result: {
+          success: true,
+          message: `History action ${params.action} completed on ${url}`,
+          action: params.action,
+          url,
+          title: await page.title(),
+          strategy: params.timeout || 'auto',
+          timeoutUsed: timeoutMs,
+        },
+      };
+    } catch (error) {
+      this.logger.error('Error navigating history:', error);
+
+      return {
+        error: {
+          code: -32603,
+          message: error instanceof Error ? error.message : 'Unknown error during history navigation',
+          data: { stack: error instanceof Error ? error.stack : undefined },
+        },
+      };
+    }
+  };
+}
@@ -7,7 +7,8 @@
 
 import type BrowserContext from '../browser/context';
 import { createLogger } from '../log';
-import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
+import type { ProgressReporter, RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
+import { isValidTabId } from './dom-utils';
 
 /**
  * Interface for navigate_to request parameters
@@ -21,6 +22,22 @@ interface NavigateToParams {
    * Navigation timeout: 'auto' for intelligent detection or timeout in milliseconds (e.g. '5000')
    */
   timeout?: string;
+  tab_id?: number;
+}
+
+/**
+ * Load stages reported as navigation progress, in order
+ */
+const NAVIGATION_STAGES = ['Navigation started', 'Page loading', 'Page loaded'];
+
+/**
+ * Error raised when the MCP Host cancels a navigation before the page has loaded
+ */
+class NavigationCancelledError extends Error {
+  constructor(message: string) {
+    super(message);
+    this.name = 'NavigationCancelledError';
+  }
 }
 
 /**
@@ -65,19 +82,42 @@ export class NavigateToHandler {
    *
    * @param url The URL to navigate to
    * @param timeoutMs Timeout in milliseconds
+   * @param targetTabId Tab to navigate instead of the current tab, e.g. the tab of an MCP session
+   * @param signal Aborted when the MCP Host cancels the request, which stops waiting for the load
+   * @param reportProgress Optional reporter for the load stages reached
    */
-  private async navigateWithTimeout(url: string, timeoutMs: number): Promise<void> {
-    const page = await this.browserContext.getCurrentPage();
+  private async navigateWithTimeout(
+    url: string,
+    timeoutMs: number,
+    targetTabId?: number,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
+  ): Promise<void> {
+    let stagesReached = 0;
+    const reachStage = (stage: number) => {
+      if (stage <= stagesReached) return;
+      stagesReached = stage;
+      reportProgress?.({
+        progress: stage,
+        total: NAVIGATION_STAGES.length,
+        message: NAVIGATION_STAGES[stage - 1],
+      });
+    };
+
+    const page = await this.browserContext.getTargetPage(targetTabId);
+    reachStage(1);
 
     if (!page) {
       // If no current page, create a new tab
       await this.browserContext.openTab(url);
+      reachStage(3);
       return;
     }
 
     // If page is attached (using Puppeteer), use Puppeteer navigation
     if (page.attached) {
       await page.navigateTo(url);
+      reachStage(3);
       return;
     }
 
@@ -92,10 +132,22 @@ export class NavigateToHandler {
       const timeoutId = setTimeout(() => {
         if (!hasResolved) {
           hasResolved = true;
+          chrome.tabs.onUpdated.removeListener(onUpdatedHandler);
+          signal?.removeEventListener('abort', onAbort);
           reject(new Error(`Navigation timeout after ${timeoutMs}ms`));
         }
       }, timeoutMs);
 
+      // Stop waiting as soon as the MCP host cancels the request
+      const onAbort = () => {
+        if (!hasResolved) {
+          hasResolved = true;
+          clearTimeout(timeoutId);
+          chrome.tabs.onUpdated.removeListener(onUpdatedHandler);
+          reject(new NavigationCancelledError('Navigation cancelled by MCP host'));
+        }
+      };
+
       // Track navigation completion
       let hasUrl = false;
       let hasTitle = false;
@@ -106,6 +158,7 @@ export class NavigateToHandler {
           hasResolved = true;
           clearTimeout(timeoutId);
           chrome.tabs.onUpdated.removeListener(onUpdatedHandler);
+          signal?.removeEventListener('abort', onAbort);
           resolve();
         }
       };
@@ -115,16 +168,22 @@ export class NavigateToHandler {
 
         if (changeInfo.url) hasUrl = true;
         if (changeInfo.title) hasTitle = true;
+        if (changeInfo.status === 'loading') reachStage(2);
         if (changeInfo.status === 'complete') isComplete = true;
 
         checkCompletion();
       };
 
       chrome.tabs.onUpdated.addListener(onUpdatedHandler);
+      if (signal?.aborted) {
+        onAbort();
+        return;
+      }
+      signal?.addEventListener('abort', onAbort);
 
-      // Start navigation
+      // Start navigation, leaving a targeted tab in the background
       chrome.tabs
-        .update(tabId, { url, active: true })
+        .update(tabId, { url, active: targetTabId === undefined })
         .then(() => {
           // Check if already complete
           chrome.tabs
@@ -143,6 +202,7 @@ export class NavigateToHandler {
                 hasResolved = true;
                 clearTimeout(timeoutId);
                 chrome.tabs.onUpdated.removeListener(onUpdatedHandler);
+                signal?.removeEventListener('abort', onAbort);
                 reject(err);
               }
             });
@@ -152,27 +212,36 @@ export class NavigateToHandler {
             hasResolved = true;
             clearTimeout(timeoutId);
             chrome.tabs.onUpdated.removeListener(onUpdatedHandler);
+            signal?.removeEventListener('abort', onAbort);
             reject(err);
           }
         });
     });
 
     await navigationPromise;
+    reachStage(3);
 
     // Reattach the page after navigation completes
     const updatedTab = await chrome.tabs.get(tabId);
     const updatedPage = await (this.browserContext as any)._getOrCreatePage(updatedTab, true);
     await this.browserContext.attachPage(updatedPage);
-    (this.browserContext as any)._currentTabId = tabId;
+    if (targetTabId === undefined) {
+      (this.browserContext as any)._currentTabId = tabId;
+    }
   }
 
   /**
    * Handle a navigate_to RPC request
    *
-   * @param request RPC request containing the URL and optional timeout
+   * @param request RPC request containing the URL, optional timeout and tab_id
+   * @param signal Aborted when the MCP Host cancels the request
    * @returns Promise resolving to an RPC response with the navigation result
    */
-  public handleNavigateTo: RpcHandler = async (request: RpcRequest): Promise<RpcResponse> => {
+  public handleNavigateTo: RpcHandler = async (
+    request: RpcRequest,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
+  ): Promise<RpcResponse> => {
     this.logger.debug('Received navigate_to request:', request);
 
     try {
@@ -200,6 +269,16 @@ export class NavigateToHandler {
         };
       }
 
+      // Navigate the tab of the calling MCP session, or the current tab
+      if (!isValidTabId(params.tab_id)) {
+        return {
+          error: {
+            code: -32602,
+            message: 'Invalid tab_id: must be an integer',
+          },
+        };
+      }
+
       // Validate URL format
       let url = params.url;
       try {
@@ -225,7 +304,7 @@ export class NavigateToHandler {
       });
 
       // Navigate to the URL with enhanced timeout handling
-      await this.navigateWithTimeout(url, timeoutMs);
+      await this.navigateWithTimeout(url, timeoutMs, params.tab_id, signal, reportProgress);
 
       return {
         result: {
@@ -237,6 +316,16 @@ export class NavigateToHandler {
         },
       };
     } catch (error) {
+      if (error instanceof NavigationCancelledError) {
+        this.logger.info('Navigation cancelled by MCP host');
+        return {
+          error: {
+            code: -32000,
+            message: error.message,
+          },
+        };
+      }
+
       this.logger.error('Error navigating to URL:', error);
 
       return {
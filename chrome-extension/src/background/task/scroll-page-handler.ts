@@ -8,7 +8,7 @@
 import type BrowserContext from '../browser/context';
 import { createLogger } from '../log';
 import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
-import { findElementByHighlightIndex } from './dom-utils';
+import { findElementByHighlightIndex, isValidTabId } from './dom-utils';
 
 /**
  * Handler for the 'scroll_page' RPC method
@@ -235,8 +235,17 @@ export class ScrollPageHandler {
         };
       }
 
-      // Get current page
-      const currentPage = await this.browserContext.getCurrentPage();
+      // Act on the tab of the calling MCP session, or the current tab
+      const tabId = request.params?.tab_id;
+      if (!isValidTabId(tabId)) {
+        return {
+          error: {
+            code: -32602,
+            message: 'Invalid tab_id: must be an integer',
+          },
+        };
+      }
+      const currentPage = await this.browserContext.getTargetPage(tabId);
       if (!currentPage) {
         return {
           error: {
@@ -0,0 +1,120 @@
+/**
+ * Take Screenshot Handler for MCP Host RPC Requests
+ *
+ * This file implements the take_screenshot RPC method handler for the browser extension.
+ * It captures the visible viewport, the full page or a single element of the target page.
+ */
+
+import type BrowserContext from '../browser/context';
+import { createLogger } from '../log';
+import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
+import { findElementByHighlightIndex, isValidTabId } from './dom-utils';
+
+type ScreenshotMode = 'viewport' | 'full_page' | 'element';
+type ScreenshotFormat = 'png' | 'jpeg';
+
+/**
+ * Handler for the 'take_screenshot' RPC method
+ *
+ * This handler processes screenshot requests from the MCP Host and returns
+ * the captured image as base64 data together with its MIME type.
+ */
+export class TakeScreenshotHandler {
+  private logger = createLogger('TakeScreenshotHandler');
+
+  /**
+   * Creates a new TakeScreenshotHandler instance
+   *
+   * @param browserContext The browser context for accessing the target page
+   */
+  constructor(private readonly browserContext: BrowserContext) {}
+
+  /**
+   * Handle a take_screenshot RPC request
+   *
+   * @param request RPC request with mode, element_index, format, quality and tab_id parameters
+   * @returns Promise resolving to an RPC response with the base64 image
+   */
+  public handleTakeScreenshot: RpcHandler = async (request: RpcRequest): Promise<RpcResponse> => {
+    this.logger.debug('Received take_screenshot request:', request);
+
+    const params = request.params || {};
+    const mode: ScreenshotMode = params.mode || 'viewport';
+    const format: ScreenshotFormat = params.format || 'png';
+    const quality: number | undefined = params.quality;
+
+    // Capture the tab of the calling MCP session, or the current tab
+    const tabId = params.tab_id;
+    if (!isValidTabId(tabId)) {
+      return {
+        error: {
+          code: -32602,
+          message: 'Invalid tab_id: must be an integer',
+        },
+      };
+    }
+
+    try {
+      // Background tabs are not rendered, so their captures would hang or come back blank
+      if (tabId !== undefined && !(await chrome.tabs.get(tabId)).active) {
+        return {
+          error: {
+            code: -32000,
+            message: `Tab ${tabId} is in the background; switch to it with manage_tabs before taking a screenshot`,
+          },
+        };
+      }
+
+      const page = await this.browserContext.getTargetPage(tabId);
+
+      let data: string;
+      if (mode === 'element') {
+        const elementIndex = params.element_index;
+        if (typeof elementIndex !== 'number') {
+          return {
+            error: {
+              code: -32602,
+              message: 'element_index is required for element screenshots',
+            },
+          };
+        }
+
+        const domElement = await findElementByHighlightIndex(page, elementIndex);
+        if (!domElement) {
+          throw new Error(`Element with highlightIndex ${elementIndex} not found in DOM state`);
+        }
+
+        const elementHandle = await page.locateElement(domElement);
+        if (!elementHandle) {
+          throw new Error(`Element with index ${elementIndex} could not be located on the page`);
+        }
+
+        data = await page.captureScreenshot({ format, quality, element: elementHandle });
+      } else {
+        data = await page.captureScreenshot({ format, quality, fullPage: mode === 'full_page' });
+      }
+
+      this.logger.debug(`Captured ${mode} screenshot as ${format}`);
+
+      return {
+        result: {
+          data,
+          mimeType: format === 'jpeg' ? 'image/jpeg' : 'image/png',
+          mode,
+          url: page.url(),
+          title: await page.title(),
+        },
+      };
+    } catch (error) {
+      this.logger.error('Error taking screenshot:', error);
+
+      return {
+        error: {
+          code: -32603,
+          message: error instanceof Error ? error.message : 'Unknown error taking screenshot',
+          data: { stack: error instanceof Error ? error.stack : undefined },
+        },
+      };
+    }
+  };
+}
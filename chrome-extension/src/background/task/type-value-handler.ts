@@ -7,9 +7,9 @@
 
 import type BrowserContext from '../browser/context';
 import { createLogger } from '../log';
-import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
+import type { ProgressReporter, RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
 import { DOMElementNode } from '../dom/views';
-import { findElementByHighlightIndex } from './dom-utils';
+import { findElementByHighlightIndex, isValidTabId } from './dom-utils';
 import { type KeyInput } from 'puppeteer-core/lib/esm/puppeteer/puppeteer-core-browser.js';
 
 /**
@@ -577,7 +577,11 @@ export class TypeValueHandler {
    * @param request RPC request with typing parameters
    * @returns Promise resolving to an RPC response confirming the typing action
    */
-  public handleTypeValue: RpcHandler = async (request: RpcRequest): Promise<RpcResponse> => {
+  public handleTypeValue: RpcHandler = async (
+    request: RpcRequest,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
+  ): Promise<RpcResponse> => {
     this.logger.debug('Received type_value request:', request);
 
     try {
@@ -629,8 +633,17 @@ export class TypeValueHandler {
         };
       }
 
-      // Get current page
-      const currentPage = await this.browserContext.getCurrentPage();
+      // Act on the tab of the calling MCP session, or the current tab
+      const tabId = request.params?.tab_id;
+      if (!isValidTabId(tabId)) {
+        return {
+          error: {
+            code: -32602,
+            message: 'Invalid tab_id: must be an integer',
+          },
+        };
+      }
+      const currentPage = await this.browserContext.getTargetPage(tabId);
       if (!currentPage) {
         return {
           error: {
@@ -672,7 +685,14 @@ export class TypeValueHandler {
         try {
           // Attempt keyboard mode
           this.logger.debug('Attempting keyboard mode input');
-          const keyboardResult = await this.handleKeyboardInput(currentPage, elementNode!, value, finalOptions);
+          const keyboardResult = await this.handleKeyboardInput(
+            currentPage,
+            elementNode!,
+            value,
+            finalOptions,
+            signal,
+            reportProgress,
+          );
 
           // Keyboard mode succeeded
           this.logger.info('Keyboard mode succeeded');
@@ -720,6 +740,11 @@ export class TypeValueHandler {
             },
           };
         } catch (keyboardError) {
+          // The MCP host cancelled the request, so do not retry in text mode
+          if (signal?.aborted) {
+            throw keyboardError;
+          }
+
           // Keyboard mode failed, automatically fall back to text mode
           this.logger.warning('Keyboard mode failed, falling back to text mode:', keyboardError);
 
@@ -728,7 +753,14 @@ export class TypeValueHandler {
             await this.resetElementState(currentPage, elementNode!, finalOptions);
 
             // Execute text mode input
-            const textResult = await this.handleTextModeInput(currentPage, elementNode!, value, finalOptions);
+            const textResult = await this.handleTextModeInput(
+          currentPage,
+          elementNode!,
+          value,
+          finalOptions,
+          signal,
+          reportProgress,
+        );
             this.logger.info('Auto-fallback to text mode succeeded');
 
             // Use optimized wait time
@@ -794,7 +826,14 @@ export class TypeValueHandler {
       } else {
         // Directly use text mode
         this.logger.debug('Using text mode input');
-        const textResult = await this.handleTextModeInput(currentPage, elementNode!, value, finalOptions);
+        const textResult = await this.handleTextModeInput(
+          currentPage,
+          elementNode!,
+          value,
+          finalOptions,
+          signal,
+          reportProgress,
+        );
 
         const strategy = this.determineInputStrategy(elementNode!, value);
         // Use optimized wait time based on element type
@@ -930,6 +969,8 @@ export class TypeValueHandler {
     elementNode: DOMElementNode,
     value: any,
     options: any,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
   ): Promise<{ actualValue: any }> {
     const strategy = this.determineInputStrategy(elementNode!, value);
     if (!strategy.canHandle) {
@@ -937,7 +978,7 @@ export class TypeValueHandler {
       // but good to have a guard.
       throw new Error(`Cannot handle element type for text input: ${strategy.elementType}`);
     }
-    return await this.executeValueSetting(page, elementNode!, value, strategy, options);
+    return await this.executeValueSetting(page, elementNode!, value, strategy, options, signal, reportProgress);
   }
 
   /**
@@ -1081,6 +1122,8 @@ export class TypeValueHandler {
     elementNode: DOMElementNode,
     value: string,
     options: any,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
   ): Promise<{ operationsPerformed: any[] }> {
     // Get element handle
     const elementHandle = await page.locateElement(elementNode);
@@ -1112,9 +1155,15 @@ export class TypeValueHandler {
     // Parse keyboard operations
     const operations = this.parseKeyboardInput(value);
     const operationsPerformed = [];
+    let operationsDone = 0;
 
     // Execute each operation
     for (const op of operations) {
+      // Stop typing as soon as the MCP host cancels the request
+      if (signal?.aborted) {
+        throw new Error('Keyboard input cancelled by MCP host');
+      }
+
       try {
         switch (op.type) {
           case 'text':
@@ -1155,6 +1204,13 @@ export class TypeValueHandler {
             break;
         }
 
+        operationsDone++;
+        reportProgress?.({
+          progress: operationsDone,
+          total: operations.length,
+          message: `Performed ${operationsDone} of ${operations.length} keyboard operations`,
+        });
+
         // Small delay between operations for stability
         await new Promise(resolve => setTimeout(resolve, 50));
       } catch (error) {
@@ -1353,6 +1409,8 @@ export class TypeValueHandler {
     value: any,
     strategy: InputStrategy,
     options: any,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
   ): Promise<{ actualValue: any }> {
     const elementHandle = await page.locateElement(elementNode);
     if (!elementHandle) {
@@ -1393,7 +1451,7 @@ export class TypeValueHandler {
     // Execute strategy-specific value setting
     switch (strategy.method) {
       case 'type':
-        return await this.handleTextInput(elementHandle, value, options);
+        return await this.handleTextInput(elementHandle, value, options, signal, reportProgress);
 
       case 'single-select':
         return await this.handleSingleSelect(elementHandle, value);
@@ -1412,7 +1470,13 @@ export class TypeValueHandler {
   /**
    * Handle text input (input, textarea, contenteditable) with progressive typing for long text
    */
-  private async handleTextInput(elementHandle: any, value: any, options: any): Promise<{ actualValue: string }> {
+  private async handleTextInput(
+    elementHandle: any,
+    value: any,
+    options: any,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
+  ): Promise<{ actualValue: string }> {
     const stringValue = String(value);
 
     // Clear existing content if requested
@@ -1429,7 +1493,7 @@ export class TypeValueHandler {
 
     // Use progressive typing for long text (> 100 characters)
     if (stringValue.length > 100) {
-      await this.handleLongTextInput(elementHandle, stringValue);
+      await this.handleLongTextInput(elementHandle, stringValue, signal, reportProgress);
     } else {
       // Standard typing for short text
       await elementHandle.type(stringValue, { delay: 50 });
@@ -1466,7 +1530,12 @@ export class TypeValueHandler {
   /**
    * Handle long text input with optimized progressive typing strategy
    */
-  private async handleLongTextInput(elementHandle: any, value: string): Promise<void> {
+  private async handleLongTextInput(
+    elementHandle: any,
+    value: string,
+    signal?: AbortSignal,
+    reportProgress?: ProgressReporter,
+  ): Promise<void> {
     // Optimized parameters for better reliability
     const CHUNK_SIZE = 80; // Reduced from 100 to 80 for better stability
     const CHUNK_DELAY = 250; // Increased from 200 to 250ms for better processing
@@ -1481,6 +1550,11 @@ export class TypeValueHandler {
       const chunk = value.substring(i, Math.min(i + CHUNK_SIZE, value.length));
       const chunkIndex = Math.floor(i / CHUNK_SIZE);
 
+      // Stop typing as soon as the MCP host cancels the request
+      if (signal?.aborted) {
+        throw new Error('Text input cancelled by MCP host');
+      }
+
       try {
         // Type chunk with optimized character delay
         await elementHandle.type(chunk, { delay: 35 }); // Increased from 30 to 35ms
@@ -1505,6 +1579,13 @@ export class TypeValueHandler {
         }
 
         this.logger.debug(`Processed chunk ${chunkIndex + 1}/${Math.ceil(value.length / CHUNK_SIZE)}`);
+
+        const typed = Math.min(i + CHUNK_SIZE, value.length);
+        reportProgress?.({
+          progress: typed,
+          total: value.length,
+          message: `Typed ${typed} of ${value.length} characters`,
+        });
       } catch (chunkError) {
         this.logger.warning(`Error in chunk ${chunkIndex}, retrying once...`, chunkError);
 
@@ -0,0 +1,256 @@
+/**
+ * Wait For Handler for MCP Host RPC Requests
+ *
+ * This file implements the wait_for RPC method handler for the browser extension.
+ * It blocks until a selector, text, URL, DOM or network condition holds on the page.
+ */
+
+import type BrowserContext from '../browser/context';
+import type Page from '../browser/page';
+import { createLogger } from '../log';
+import type { RpcHandler, RpcRequest, RpcResponse } from '../mcp/host-manager';
+import { isValidTabId } from './dom-utils';
+
+/**
+ * Interface for wait_for request parameters. At least one condition is required.
+ */
+interface WaitForParams {
+  selector?: string;
+  selector_type?: 'css' | 'xpath';
+  selector_state?: 'appear' | 'disappear';
+  text?: string;
+  url_pattern?: string;
+  dom_stable_ms?: number;
+  network_idle_ms?: number;
+  /**
+   * How long to wait for a condition, in milliseconds
+   */
+  timeout?: number;
+  tab_id?: number;
+}
+
+/**
+ * A condition being waited for
+ */
+interface WaitCondition {
+  /**
+   * Name reported when the condition is met first
+   */
+  name: 'selector' | 'text' | 'url' | 'dom_stable' | 'network_idle';
+  /**
+   * Description used in timeout errors
+   */
+  description: string;
+  wait: (signal: AbortSignal) => Promise<void>;
+}
+
+const DEFAULT_TIMEOUT = 10000;
+const MAX_TIMEOUT = 120000;
+
+/**
+ * Error thrown when no condition is met before the timeout
+ */
+class WaitTimeoutError extends Error {
+  constructor(message: string) {
+    super(message);
+    this.name = 'WaitTimeoutError';
+  }
+}
+
+/**
+ * Handler for the 'wait_for' RPC method
+ *
+ * This handler processes wait requests from the MCP Host, watches all the
+ * requested conditions at once and answers as soon as one of them is met.
+ */
+export class WaitForHandler {
+  private logger = createLogger('WaitForHandler');
+
+  /**
+   * Creates a new WaitForHandler instance
+   *
+   * @param browserContext The browser context for accessing the target page
+   */
+  constructor(private readonly browserContext: BrowserContext) {}
+
+  /**
+   * Build the conditions requested by the parameters
+   *
+   * @param page The page to watch
+   * @param params The request parameters
+   * @returns The conditions, in the order they were listed
+   */
+  private buildConditions(page: Page, params: WaitForParams): WaitCondition[] {
+    const conditions: WaitCondition[] = [];
+    // The handler enforces the timeout for all conditions, so each waits until aborted
+    const waitOptions = (signal: AbortSignal) => ({ timeout: 0, signal });
+
+    if (params.selector) {
+      const selector = params.selector;
+      const state = params.selector_state === 'disappear' ? 'disappear' : 'appear';
+      conditions.push({
+        name: 'selector',
+        description: `selector '${selector}' to ${state}`,
+        wait: signal =>
+          page.waitForSelector(selector, { xpath: params.selector_type === 'xpath', state, ...waitOptions(signal) }),
+      });
+    }
+
+    if (params.text) {
+      const text = params.text;
+      conditions.push({
+        name: 'text',
+        description: `text '${text}'`,
+        wait: signal => page.waitForText(text, waitOptions(signal)),
+      });
+    }
+
+    if (params.url_pattern) {
+      const pattern = params.url_pattern;
+      // Fail before waiting if the browser cannot compile the pattern
+      new RegExp(pattern);
+      conditions.push({
+        name: 'url',
+        description: `URL matching /${pattern}/`,
+        wait: signal => page.waitForUrl(pattern, waitOptions(signal)),
+      });
+    }
+
+    if (typeof params.dom_stable_ms === 'number') {
+      const quietMs = params.dom_stable_ms;
+      conditions.push({
+        name: 'dom_stable',
+        description: `DOM unchanged for ${quietMs}ms`,
+        wait: signal => page.waitForDomStable(quietMs, waitOptions(signal)),
+      });
+    }
+
+    if (typeof params.network_idle_ms === 'number') {
+      const idleMs = params.network_idle_ms;
+      conditions.push({
+        name: 'network_idle',
+        description: `network idle for ${idleMs}ms`,
+        wait: signal => page.waitForNetworkIdle(idleMs, waitOptions(signal)),
+      });
+    }
+
+    return conditions;
+  }
+
+  /**
+   * Handle a wait_for RPC request
+   *
+   * @param request RPC request with the conditions, timeout and optional tab_id
+   * @param signal Aborted when the MCP Host cancels the request
+   * @returns Promise resolving to an RPC response naming the condition met
+   */
+  public handleWaitFor: RpcHandler = async (request: RpcRequest, signal?: AbortSignal): Promise<RpcResponse> => {
+    this.logger.debug('Received wait_for request:', request);
+
+    const params: WaitForParams = request.params || {};
+    const timeout = params.timeout ?? DEFAULT_TIMEOUT;
+    if (typeof timeout !== 'number' || timeout <= 0 || timeout > MAX_TIMEOUT) {
+      return {
+        error: {
+          code: -32602,
+          message: `timeout must be a number between 1 and ${MAX_TIMEOUT} milliseconds`,
+        },
+      };
+    }
+
+    // Watch the tab of the calling MCP session, or the current tab
+    if (!isValidTabId(params.tab_id)) {
+      return {
+        error: {
+          code: -32602,
+          message: 'Invalid tab_id: must be an integer',
+        },
+      };
+    }
+
+    // Stops the conditions still being watched once the wait is over
+    const controller = new AbortController();
+    const abort = () => controller.abort();
+    signal?.addEventListener('abort', abort);
+
+    try {
+      const page = await this.browserContext.getTargetPage(params.tab_id);
+      if (!page) {
+        return {
+          error: {
+            code: -32000,
+            message: 'No active page available',
+          },
+        };
+      }
+
+      const conditions = this.buildConditions(page, params);
+      if (conditions.length === 0) {
+        return {
+          error: {
+            code: -32602,
+            message: 'At least one condition is required: selector, text, url_pattern, dom_stable_ms or network_idle_ms',
+          },
+        };
+      }
+
+      const startTime = Date.now();
+      const condition = await new Promise<WaitCondition>((resolve, reject) => {
+        const timer = setTimeout(() => {
+          const waitedFor = conditions.map(c => c.description).join(' or ');
+          reject(new WaitTimeoutError(`Timed out after ${timeout}ms waiting for ${waitedFor}`));
+        }, timeout);
+
+        for (const c of conditions) {
+          c.wait(controller.signal).then(
+            () => {
+              clearTimeout(timer);
+              resolve(c);
+            },
+            error => {
+              clearTimeout(timer);
+              reject(error);
+            },
+          );
+        }
+      });
+      const elapsed = Date.now() - startTime;
+
+      this.logger.debug(`Condition ${condition.name} met after ${elapsed}ms`);
+
+      return {
+        result: {
+          success: true,
+          message: `Waited ${elapsed}ms for ${condition.description}`,
+          condition: condition.name,
+          elapsed_ms: elapsed,
+          url: page.url(),
+          title: await page.title(),
+        },
+      };
+    } catch (error) {
+      if (error instanceof WaitTimeoutError) {
+        this.logger.debug(error.message);
+        return {
+          error: {
+            code: -32000,
+            message: error.message,
+          },
+        };
+      }
+
+      this.logger.error('Error waiting for condition:', error);
+
+      return {
+        error: {
+          code: -32603,
+          message: error instanceof Error ? error.message : 'Unknown error waiting for condition',
+          data: { stack: error instanceof Error ? error.stack : undefined },
+        },
+      };
+    } finally {
+      controller.abort();
+      signal?.removeEventListener('abort', abort);
+    }
+  };
+}
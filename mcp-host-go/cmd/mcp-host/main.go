@@ -2,12 +2,21 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/auth"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/bridge"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/cdpbrowser"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/fakebrowser"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/handlers"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/htmlbrowser"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/messaging"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/resources"
@@ -29,6 +38,18 @@ const (
 
 	// Default SSE base URL
 	DefaultSSEBaseURL = "http://localhost:9333/sse"
+
+	// StdioCommand is the subcommand that runs the stdio MCP bridge
+	StdioCommand = "stdio"
+)
+
+// Extension transports selectable with MCP_EXTENSION_TRANSPORT
+const (
+	ExtensionTransportNative    = "native"    // Chrome starts the host and talks over stdin/stdout
+	ExtensionTransportWebSocket = "websocket" // The host runs on its own and the extension connects to it
+	ExtensionTransportCDP       = "cdp"       // No extension; the host drives Chromium over the DevTools Protocol
+	ExtensionTransportFake      = "fake"      // No browser; scripted pages are served from a fixture
+	ExtensionTransportStatic    = "static"    // No browser; pages are fetched over HTTP and parsed without running scripts
 )
 
 // Container is a dependency injection container
@@ -36,23 +57,33 @@ type Container struct {
 	Logger              logger.Logger
 	Messaging           types.Messaging
 	Server              *sse.SSEServer
-	NavigateTool        types.Tool
-	ScrollPageTool      types.Tool
-	GetDomExtraElements types.Tool
-	ClickElementTool    types.Tool
-	TypeValueTool       types.Tool
-	ManageTabsTool      types.Tool
+	Tools               *tools.Registry // The host's own tools and those advertised by the extension
 	CurrentStateRes     types.Resource
 	DomStateRes         types.Resource
+	TabDomStateRes      types.ResourceTemplate
+	CurrentStateJSONRes types.Resource
+	DomStateJSONRes     types.Resource
+	TabDomStateJSONRes  types.ResourceTemplate
+	HostStatusRes       types.Resource
+	OutputSchemaRes     *resources.OutputSchemaResource
 	StatusHandler       *handlers.StatusHandler
 	InitHandler         *handlers.InitHandler
+	Capabilities        *types.ExtensionCapabilities // What the extension announced in the init handshake
 	ShutdownHandler     *handlers.ShutdownHandler
+	StateChangeHandler  *handlers.StateChangeHandler
 	LogFilePath         string // Store the log file path for printing in shutdown messages
+	AuthToken           string // Bearer token required by the HTTP transports, empty when disabled
 	StartTime           time.Time
 	ShutdownChan        chan struct{} // Channel for graceful shutdown coordination
 }
 
 func main() {
+	// Chrome starts the host with the extension origin as argument, so only an
+	// explicit "stdio" subcommand switches to bridge mode
+	if len(os.Args) > 1 && os.Args[1] == StdioCommand {
+		os.Exit(runStdioBridge())
+	}
+
 	// Record start time
 	startTime := time.Now()
 
@@ -71,6 +102,9 @@ func main() {
 	container.Messaging.RegisterRpcMethod("init", container.InitHandler.HandleInit)
 	container.Messaging.RegisterRpcMethod("shutdown", container.ShutdownHandler.HandleShutdown)
 
+	// Forward browser state changes to MCP clients subscribed to resources
+	container.Messaging.RegisterHandler(handlers.StateChangeMessageType, container.StateChangeHandler.HandleStateChange)
+
 	// Start Native Messaging
 	if err := container.Messaging.Start(); err != nil {
 		container.Logger.Error("Failed to start Native Messaging", zap.Error(err))
@@ -88,34 +122,33 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Register tools
-	if err := container.Server.RegisterTool(container.NavigateTool); err != nil {
-		container.Logger.Error("Failed to register navigate_to tool", zap.Error(err))
+	if err := container.Server.RegisterResourceTemplate(container.TabDomStateRes); err != nil {
+		container.Logger.Error("Failed to register tab DOM state resource template", zap.Error(err))
 		os.Exit(1)
 	}
 
-	if err := container.Server.RegisterTool(container.ScrollPageTool); err != nil {
-		container.Logger.Error("Failed to register scroll_page tool", zap.Error(err))
+	if err := container.Server.RegisterResource(container.CurrentStateJSONRes); err != nil {
+		container.Logger.Error("Failed to register current state JSON resource", zap.Error(err))
 		os.Exit(1)
 	}
 
-	if err := container.Server.RegisterTool(container.GetDomExtraElements); err != nil {
-		container.Logger.Error("Failed to register get_dom_extra_elements tool", zap.Error(err))
+	if err := container.Server.RegisterResource(container.DomStateJSONRes); err != nil {
+		container.Logger.Error("Failed to register DOM state JSON resource", zap.Error(err))
 		os.Exit(1)
 	}
 
-	if err := container.Server.RegisterTool(container.ClickElementTool); err != nil {
-		container.Logger.Error("Failed to register click_element tool", zap.Error(err))
+	if err := container.Server.RegisterResourceTemplate(container.TabDomStateJSONRes); err != nil {
+		container.Logger.Error("Failed to register tab DOM state JSON resource template", zap.Error(err))
 		os.Exit(1)
 	}
 
-	if err := container.Server.RegisterTool(container.TypeValueTool); err != nil {
-		container.Logger.Error("Failed to register type_value tool", zap.Error(err))
+	if err := container.Server.RegisterResource(container.HostStatusRes); err != nil {
+		container.Logger.Error("Failed to register host status resource", zap.Error(err))
 		os.Exit(1)
 	}
 
-	if err := container.Server.RegisterTool(container.ManageTabsTool); err != nil {
-		container.Logger.Error("Failed to register manage_tabs tool", zap.Error(err))
+	if err := container.Server.RegisterResourceTemplate(container.OutputSchemaRes); err != nil {
+		container.Logger.Error("Failed to register output schema resource template", zap.Error(err))
 		os.Exit(1)
 	}
 
@@ -127,9 +160,14 @@ func main() {
 
 	// Log server information
 	container.Logger.Info("SSE MCP server started successfully")
-	container.Logger.Info("SSE Server available at", zap.String("url", getSSEBaseURL()))
+	if isSSEEnabled() {
+		container.Logger.Info("SSE Server available at", zap.String("url", getSSEBaseURL()))
+	}
+	if isStreamableHTTPEnabled() {
+		container.Logger.Info("Streamable HTTP Server available at", zap.String("url", getStreamableHTTPURL()))
+	}
 	container.Logger.Info("External AI systems can connect via HTTP/SSE")
-	container.Logger.Info("Requests will be forwarded to Chrome extension via Native Messaging")
+	container.Logger.Info("Requests will be forwarded to the Chrome extension", zap.String("transport", getExtensionTransport()))
 	container.Logger.Info("Init, Status, and Shutdown RPC handlers registered for browser extension")
 
 	// Handle signals for graceful shutdown
@@ -157,6 +195,45 @@ func main() {
 		container.Logger.Error("Error during server shutdown", zap.Error(err))
 		fmt.Fprintf(os.Stderr, "Error during server shutdown: %v\n", err)
 	}
+
+	// Stop listening for the extension, or disconnect from the browser
+	if closer, ok := container.Messaging.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			container.Logger.Error("Error closing the extension connection", zap.Error(err))
+		}
+	}
+}
+
+// runStdioBridge relays MCP JSON-RPC between stdio and the running host's socket
+func runStdioBridge() int {
+	bridgeLogger, err := logger.NewLogger("stdio-bridge")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+		return 1
+	}
+
+	socketPath := getSocketPath()
+	if socketPath == "" {
+		fmt.Fprintf(os.Stderr, "MCP socket is disabled (MCP_SOCKET_ENABLED=false)\n")
+		return 1
+	}
+
+	stdioBridge, err := bridge.NewStdioBridge(bridge.StdioBridgeConfig{
+		Logger:     bridgeLogger,
+		SocketPath: socketPath,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create stdio bridge: %v\n", err)
+		return 1
+	}
+
+	if err := stdioBridge.Run(); err != nil {
+		bridgeLogger.Error("Stdio bridge failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Stdio bridge failed: %v\n", err)
+		return 1
+	}
+
+	return 0
 }
 
 // initContainer initializes the dependency injection container
@@ -165,6 +242,7 @@ func initContainer(startTime time.Time) (*Container, error) {
 	container := &Container{
 		StartTime:    startTime,
 		ShutdownChan: make(chan struct{}),
+		Capabilities: &types.ExtensionCapabilities{},
 	}
 
 	// Determine log file path to store for later use in shutdown messages
@@ -183,14 +261,23 @@ func initContainer(startTime time.Time) (*Container, error) {
 		return nil, fmt.Errorf("failed to create messaging logger: %w", err)
 	}
 
-	msg, err := messaging.NewNativeMessaging(messaging.NativeMessagingConfig{
-		Logger: msgLogger,
-	})
+	msg, err := newMessaging(msgLogger, container.Capabilities)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create messaging: %w", err)
 	}
 	container.Messaging = msg
 
+	// Load or generate the bearer token protecting the HTTP transports
+	if tokenPath := getAuthTokenPath(); tokenPath != "" {
+		container.AuthToken, err = auth.LoadOrCreateToken(tokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load auth token: %w", err)
+		}
+		log.Info("Bearer token authentication enabled", zap.String("tokenFile", tokenPath))
+	} else {
+		log.Warn("Bearer token authentication disabled (MCP_AUTH_ENABLED=false)")
+	}
+
 	// Create status handler for browser extension RPC calls
 	statusLogger, err := logger.NewLogger("status-handler")
 	if err != nil {
@@ -198,10 +285,13 @@ func initContainer(startTime time.Time) (*Container, error) {
 	}
 
 	statusHandler, err := handlers.NewStatusHandler(handlers.StatusHandlerConfig{
-		Logger:     statusLogger,
-		StartTime:  startTime,
-		SSEPort:    getSSEPort(),
-		SSEBaseURL: getSSEBaseURL(),
+		Logger:            statusLogger,
+		StartTime:         startTime,
+		SSEPort:           getSSEPort(),
+		SSEBaseURL:        getSSEBaseURL(),
+		StreamableHTTPURL: getStreamableHTTPURL(),
+		AuthToken:         container.AuthToken,
+		Messaging:         container.Messaging,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create status handler: %w", err)
@@ -215,7 +305,8 @@ func initContainer(startTime time.Time) (*Container, error) {
 	}
 
 	initHandler, err := handlers.NewInitHandler(handlers.InitHandlerConfig{
-		Logger: initLogger,
+		Logger:       initLogger,
+		Capabilities: container.Capabilities,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create init handler: %w", err)
@@ -244,10 +335,17 @@ func initContainer(startTime time.Time) (*Container, error) {
 	}
 
 	server, err := sse.NewSSEServer(sse.SSEServerConfig{
-		Logger:    serverLogger,
-		Messaging: container.Messaging,
-		Port:      getSSEPort(),
-		BaseURL:   getSSEBaseURL(),
+		Logger:               serverLogger,
+		Messaging:            container.Messaging,
+		Port:                 getSSEPort(),
+		BaseURL:              getSSEBaseURL(),
+		StreamableHTTPPath:   getStreamableHTTPPath(),
+		SocketPath:           getSocketPath(),
+		AuthToken:            container.AuthToken,
+		AllowedHosts:         getAllowedHosts(),
+		EnableSSE:            isSSEEnabled(),
+		EnableStreamableHTTP: isStreamableHTTPEnabled(),
+		Capabilities:         container.Capabilities,
 		HostInfo: types.HostInfo{
 			Name:    Name,
 			Version: Version,
@@ -268,6 +366,7 @@ func initContainer(startTime time.Time) (*Container, error) {
 	currentState, err := resources.NewCurrentStateResource(resources.CurrentStateConfig{
 		Logger:    resourceLogger,
 		Messaging: container.Messaging,
+		Notifier:  container.Server,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create current state resource: %w", err)
@@ -277,78 +376,276 @@ func initContainer(startTime time.Time) (*Container, error) {
 	domState, err := resources.NewDomStateResource(resources.DomStateConfig{
 		Logger:    resourceLogger,
 		Messaging: container.Messaging,
+		Notifier:  container.Server,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create DOM state resource: %w", err)
 	}
 	container.DomStateRes = domState
 
-	// Create tools
-	toolLogger, err := logger.NewLogger("tool")
+	tabDomState, err := resources.NewTabDomStateResource(resources.TabDomStateConfig{
+		Logger:    resourceLogger,
+		Messaging: container.Messaging,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create tool logger: %w", err)
+		return nil, fmt.Errorf("failed to create tab DOM state resource: %w", err)
 	}
+	container.TabDomStateRes = tabDomState
 
-	navigateTool, err := tools.NewNavigateToTool(tools.NavigateToConfig{
-		Logger:      toolLogger,
-		Messaging:   container.Messaging,
-		DomStateRes: container.DomStateRes,
+	// JSON variants of the resources above
+	currentStateJSON, err := resources.NewCurrentStateResource(resources.CurrentStateConfig{
+		Logger:    resourceLogger,
+		Messaging: container.Messaging,
+		Notifier:  container.Server,
+		Format:    types.OutputFormatJSON,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create navigate_to tool: %w", err)
+		return nil, fmt.Errorf("failed to create current state JSON resource: %w", err)
 	}
-	container.NavigateTool = navigateTool
+	container.CurrentStateJSONRes = currentStateJSON
 
-	scrollPageTool, err := tools.NewScrollPageTool(tools.ScrollPageConfig{
-		Logger:      toolLogger,
-		Messaging:   container.Messaging,
-		DomStateRes: container.DomStateRes,
+	domStateJSON, err := resources.NewDomStateResource(resources.DomStateConfig{
+		Logger:    resourceLogger,
+		Messaging: container.Messaging,
+		Notifier:  container.Server,
+		Format:    types.OutputFormatJSON,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create scroll_page tool: %w", err)
+		return nil, fmt.Errorf("failed to create DOM state JSON resource: %w", err)
 	}
-	container.ScrollPageTool = scrollPageTool
+	container.DomStateJSONRes = domStateJSON
 
-	getDomExtraElements, err := tools.NewGetDomExtraElementsTool(tools.GetDomExtraElementsConfig{
-		Logger:    toolLogger,
+	tabDomStateJSON, err := resources.NewTabDomStateResource(resources.TabDomStateConfig{
+		Logger:    resourceLogger,
 		Messaging: container.Messaging,
+		Format:    types.OutputFormatJSON,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create get_dom_extra_elements tool: %w", err)
+		return nil, fmt.Errorf("failed to create tab DOM state JSON resource: %w", err)
 	}
-	container.GetDomExtraElements = getDomExtraElements
+	container.TabDomStateJSONRes = tabDomStateJSON
 
-	clickElementTool, err := tools.NewClickElementTool(tools.ClickElementConfig{
-		Logger:      toolLogger,
-		Messaging:   container.Messaging,
-		DomStateRes: container.DomStateRes,
+	hostStatus, err := resources.NewHostStatusResource(resources.HostStatusConfig{
+		Logger:       resourceLogger,
+		Messaging:    container.Messaging,
+		Capabilities: container.Capabilities,
+		Notifier:     container.Server,
+		HostInfo: types.HostInfo{
+			Name:    Name,
+			Version: Version,
+			RunMode: getRunMode(),
+		},
+		StartTime: startTime,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create click_element tool: %w", err)
+		return nil, fmt.Errorf("failed to create host status resource: %w", err)
 	}
-	container.ClickElementTool = clickElementTool
+	container.HostStatusRes = hostStatus
 
-	typeValueTool, err := tools.NewTypeValueTool(tools.TypeValueConfig{
-		Logger:    toolLogger,
-		Messaging: container.Messaging,
+	// Tell subscribed MCP clients when the extension disconnects or reconnects
+	container.Messaging.OnConnectionChange(func(status types.ConnectionStatus) {
+		container.HostStatusRes.NotifyStateChange(status)
+	})
+	container.Capabilities.OnChange(func(info types.ExtensionInfo) {
+		container.HostStatusRes.NotifyStateChange(info)
+	})
+
+	// Create state change handler for browser extension notifications
+	stateChangeLogger, err := logger.NewLogger("state-change-handler")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state change handler logger: %w", err)
+	}
+
+	stateChangeHandler, err := handlers.NewStateChangeHandler(handlers.StateChangeHandlerConfig{
+		Logger:              stateChangeLogger,
+		CurrentStateRes:     container.CurrentStateRes,
+		DomStateRes:         container.DomStateRes,
+		CurrentStateJSONRes: container.CurrentStateJSONRes,
+		DomStateJSONRes:     container.DomStateJSONRes,
+		OnTabRemoved:        container.Server.ForgetTab,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create type_value tool: %w", err)
+		return nil, fmt.Errorf("failed to create state change handler: %w", err)
 	}
-	container.TypeValueTool = typeValueTool
+	container.StateChangeHandler = stateChangeHandler
 
-	manageTabsTool, err := tools.NewManageTabsTool(tools.ManageTabsConfig{
+	// Create tools
+	toolLogger, err := logger.NewLogger("tool")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool logger: %w", err)
+	}
+
+	toolRegistry, err := tools.NewRegistry(tools.RegistryConfig{
 		Logger:    toolLogger,
 		Messaging: container.Messaging,
+		Registrar: container.Server,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool registry: %w", err)
+	}
+	container.Tools = toolRegistry
+
+	// Register the tools the extension advertises whenever it announces itself
+	container.Capabilities.OnChange(toolRegistry.SyncExtensionTools)
+
+	// The host's own tools, in the order they are listed to clients
+	builtinTools := []struct {
+		name   string
+		create func() (types.Tool, error)
+	}{
+		{"navigate_to", func() (types.Tool, error) {
+			return tools.NewNavigateToTool(tools.NavigateToConfig{
+				Logger:      toolLogger,
+				Messaging:   container.Messaging,
+				DomStateRes: container.DomStateRes,
+			})
+		}},
+		{"navigate_history", func() (types.Tool, error) {
+			return tools.NewNavigateHistoryTool(tools.NavigateHistoryConfig{
+				Logger:      toolLogger,
+				Messaging:   container.Messaging,
+				DomStateRes: container.DomStateRes,
+			})
+		}},
+		{"scroll_page", func() (types.Tool, error) {
+			return tools.NewScrollPageTool(tools.ScrollPageConfig{
+				Logger:      toolLogger,
+				Messaging:   container.Messaging,
+				DomStateRes: container.DomStateRes,
+			})
+		}},
+		{"get_dom_extra_elements", func() (types.Tool, error) {
+			return tools.NewGetDomExtraElementsTool(tools.GetDomExtraElementsConfig{
+				Logger:    toolLogger,
+				Messaging: container.Messaging,
+			})
+		}},
+		{"click_element", func() (types.Tool, error) {
+			return tools.NewClickElementTool(tools.ClickElementConfig{
+				Logger:      toolLogger,
+				Messaging:   container.Messaging,
+				DomStateRes: container.DomStateRes,
+			})
+		}},
+		{"type_value", func() (types.Tool, error) {
+			return tools.NewTypeValueTool(tools.TypeValueConfig{
+				Logger:    toolLogger,
+				Messaging: container.Messaging,
+			})
+		}},
+		{"manage_tabs", func() (types.Tool, error) {
+			return tools.NewManageTabsTool(tools.ManageTabsConfig{
+				Logger:    toolLogger,
+				Messaging: container.Messaging,
+			})
+		}},
+		{"take_screenshot", func() (types.Tool, error) {
+			return tools.NewTakeScreenshotTool(tools.TakeScreenshotConfig{
+				Logger:    toolLogger,
+				Messaging: container.Messaging,
+			})
+		}},
+		{"wait_for", func() (types.Tool, error) {
+			return tools.NewWaitForTool(tools.WaitForConfig{
+				Logger:      toolLogger,
+				Messaging:   container.Messaging,
+				DomStateRes: container.DomStateRes,
+			})
+		}},
+	}
+	for _, builtin := range builtinTools {
+		tool, err := builtin.create()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s tool: %w", builtin.name, err)
+		}
+		if err := toolRegistry.Add(tool); err != nil {
+			return nil, err
+		}
+	}
+
+	// Publish the schemas of the typed JSON outputs
+	outputSchemaRes, err := resources.NewOutputSchemaResource(resources.OutputSchemaConfig{
+		Logger: resourceLogger,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create manage_tabs tool: %w", err)
+		return nil, fmt.Errorf("failed to create output schema resource: %w", err)
 	}
-	container.ManageTabsTool = manageTabsTool
+	outputSchemaRes.AddSchema("current_state", currentState.GetOutputSchema())
+	outputSchemaRes.AddSchema("dom_state", domState.GetOutputSchema())
+	outputSchemaRes.AddSchema("host_status", hostStatus.GetOutputSchema())
+	for _, tool := range toolRegistry.Tools() {
+		if provider, ok := tool.(types.OutputSchemaProvider); ok {
+			outputSchemaRes.AddSchema(tool.GetName(), provider.GetOutputSchema())
+		}
+	}
+	container.OutputSchemaRes = outputSchemaRes
 
 	return container, nil
 }
 
+// newMessaging creates the link to the browser extension selected by MCP_EXTENSION_TRANSPORT
+func newMessaging(msgLogger logger.Logger, capabilities *types.ExtensionCapabilities) (types.Messaging, error) {
+	switch transport := getExtensionTransport(); transport {
+	case ExtensionTransportNative:
+		return messaging.NewNativeMessaging(messaging.NativeMessagingConfig{
+			Logger:            msgLogger,
+			HeartbeatInterval: getDurationEnv("MCP_HEARTBEAT_INTERVAL"),
+			HeartbeatTimeout:  getDurationEnv("MCP_HEARTBEAT_TIMEOUT"),
+			Workers:           getIntEnv("MCP_MESSAGE_WORKERS"),
+			QueueSize:         getIntEnv("MCP_MESSAGE_QUEUE_SIZE"),
+			Capabilities:      capabilities,
+		})
+	case ExtensionTransportWebSocket:
+		tokenPath := getExtensionTokenPath()
+		token, err := auth.LoadOrCreateToken(tokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load extension pairing token: %w", err)
+		}
+		msgLogger.Info("Extension pairing token loaded", zap.String("tokenFile", tokenPath))
+
+		return messaging.NewWebSocketMessaging(messaging.WebSocketMessagingConfig{
+			Logger:            msgLogger,
+			Addr:              os.Getenv("MCP_WEBSOCKET_ADDR"),
+			Token:             token,
+			HeartbeatInterval: getDurationEnv("MCP_HEARTBEAT_INTERVAL"),
+			HeartbeatTimeout:  getDurationEnv("MCP_HEARTBEAT_TIMEOUT"),
+			Workers:           getIntEnv("MCP_MESSAGE_WORKERS"),
+			QueueSize:         getIntEnv("MCP_MESSAGE_QUEUE_SIZE"),
+			Capabilities:      capabilities,
+		})
+	case ExtensionTransportCDP:
+		return cdpbrowser.NewBrowser(cdpbrowser.Config{
+			Logger:       msgLogger,
+			BrowserURL:   os.Getenv("MCP_CDP_URL"),
+			Capabilities: capabilities,
+		})
+	case ExtensionTransportFake:
+		scriptPath := os.Getenv("MCP_FAKE_BROWSER_SCRIPT")
+		if scriptPath == "" {
+			return nil, fmt.Errorf("MCP_FAKE_BROWSER_SCRIPT must name the script of the fake browser")
+		}
+		script, err := fakebrowser.LoadScript(scriptPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return fakebrowser.NewBrowser(fakebrowser.Config{
+			Logger:       msgLogger,
+			Script:       script,
+			Capabilities: capabilities,
+		})
+	case ExtensionTransportStatic:
+		return htmlbrowser.NewBrowser(htmlbrowser.Config{
+			Logger:       msgLogger,
+			StartURL:     os.Getenv("MCP_STATIC_START_URL"),
+			Capabilities: capabilities,
+		})
+	default:
+		return nil, fmt.Errorf("unknown extension transport %q (MCP_EXTENSION_TRANSPORT must be %q, %q, %q, %q or %q)",
+			transport, ExtensionTransportNative, ExtensionTransportWebSocket, ExtensionTransportCDP, ExtensionTransportFake, ExtensionTransportStatic)
+	}
+}
+
 // getLogFilePath returns the log file path configured or default
 func getLogFilePath() string {
 	// Check if LOG_FILE is specified directly
@@ -398,3 +695,130 @@ func getSSEBaseURL() string {
 	}
 	return baseURL
 }
+
+// getStreamableHTTPPath returns the Streamable HTTP endpoint path from environment or default
+func getStreamableHTTPPath() string {
+	path := os.Getenv("STREAMABLE_HTTP_PATH")
+	if path == "" {
+		return sse.DefaultStreamableHTTPPath
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	return path
+}
+
+// getStreamableHTTPURL returns the Streamable HTTP URL served on the SSE port
+func getStreamableHTTPURL() string {
+	if !isStreamableHTTPEnabled() {
+		return ""
+	}
+	return "http://localhost" + getSSEPort() + getStreamableHTTPPath()
+}
+
+// isSSEEnabled reports whether the legacy SSE transport is enabled (default: true)
+func isSSEEnabled() bool {
+	return getBoolEnv("SSE_ENABLED", true)
+}
+
+// isStreamableHTTPEnabled reports whether the Streamable HTTP transport is enabled (default: true)
+func isStreamableHTTPEnabled() bool {
+	return getBoolEnv("STREAMABLE_HTTP_ENABLED", true)
+}
+
+// getBoolEnv parses a boolean environment variable, falling back to the default
+func getBoolEnv(name string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getSocketPath returns the Unix socket path used by the stdio bridge, or empty when disabled
+func getSocketPath() string {
+	if !getBoolEnv("MCP_SOCKET_ENABLED", true) {
+		return ""
+	}
+
+	if socketPath := os.Getenv("MCP_SOCKET_PATH"); socketPath != "" {
+		return socketPath
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "mcp-host", "mcp-host.sock")
+	}
+
+	return filepath.Join(homeDir, ".mcp-host", "mcp-host.sock")
+}
+
+// getAuthTokenPath returns the file holding the HTTP bearer token, or empty when auth is disabled
+func getAuthTokenPath() string {
+	if !getBoolEnv("MCP_AUTH_ENABLED", true) {
+		return ""
+	}
+
+	if tokenPath := os.Getenv("MCP_AUTH_TOKEN_FILE"); tokenPath != "" {
+		return tokenPath
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "mcp-host", "auth-token")
+	}
+
+	return filepath.Join(homeDir, ".mcp-host", "auth-token")
+}
+
+// getExtensionTransport returns the extension transport from environment or default
+func getExtensionTransport() string {
+	transport := strings.ToLower(os.Getenv("MCP_EXTENSION_TRANSPORT"))
+	if transport == "" {
+		return ExtensionTransportNative
+	}
+	return transport
+}
+
+// getExtensionTokenPath returns the file holding the token the extension pairs with over WebSocket
+func getExtensionTokenPath() string {
+	if tokenPath := os.Getenv("MCP_EXTENSION_TOKEN_FILE"); tokenPath != "" {
+		return tokenPath
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "mcp-host", "extension-token")
+	}
+
+	return filepath.Join(homeDir, ".mcp-host", "extension-token")
+}
+
+// getAllowedHosts returns the comma-separated MCP_ALLOWED_HOSTS list, or nil for the server defaults
+func getAllowedHosts() []string {
+	var hosts []string
+	for _, host := range strings.Split(os.Getenv("MCP_ALLOWED_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// getDurationEnv parses a duration environment variable such as "10s", returning 0 for the default
+func getDurationEnv(name string) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// getIntEnv parses an integer environment variable, returning 0 for the default
+func getIntEnv(name string) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tokenBytes is the amount of random data in a generated token (hex-encoded to 64 chars)
+const tokenBytes = 32
+
+// LoadOrCreateToken reads the bearer token stored at path, generating and
+// persisting a new one with 0600 permissions if the file does not exist yet
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("auth token file %s is empty", path)
+		}
+
+		// Tighten permissions on files created by hand or by older versions
+		if err := os.Chmod(path, 0600); err != nil {
+			return "", fmt.Errorf("failed to restrict auth token file permissions: %w", err)
+		}
+		return token, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read auth token file: %w", err)
+	}
+
+	token, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("failed to create auth token directory: %w", err)
+	}
+
+	// Write the token to a temporary file and link it into place, so that a
+	// host starting at the same time never reads a partly written token and
+	// neither host overwrites the other's
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create auth token file: %w", err)
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+
+	_, err = file.WriteString(token + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write auth token file: %w", err)
+	}
+
+	if err := os.Link(tempPath, path); err != nil {
+		if os.IsExist(err) {
+			return LoadOrCreateToken(path)
+		}
+		return "", fmt.Errorf("failed to create auth token file: %w", err)
+	}
+
+	return token, nil
+}
+
+// GenerateToken returns a new random bearer token
+func GenerateToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate auth token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CheckBearerToken reports whether the request carries the expected bearer token
+func CheckBearerToken(r *http.Request, token string) bool {
+	header := r.Header.Get("Authorization")
+	scheme, value, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(value)), []byte(token)) == 1
+}
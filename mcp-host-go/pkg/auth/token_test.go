@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "auth-token")
+
+	token, err := LoadOrCreateToken(path)
+	require.NoError(t, err)
+	assert.Len(t, token, tokenBytes*2)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// A second load returns the persisted token
+	reloaded, err := LoadOrCreateToken(path)
+	require.NoError(t, err)
+	assert.Equal(t, token, reloaded)
+}
+
+func TestLoadOrCreateToken_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth-token")
+
+	// Hosts starting at once all end up with the one token that was stored
+	tokens := make([]string, 8)
+	errs := make([]error, len(tokens))
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], errs[i] = LoadOrCreateToken(path)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range tokens {
+		require.NoError(t, errs[i])
+		assert.Equal(t, tokens[0], tokens[i])
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary token files should be removed")
+}
+
+func TestLoadOrCreateToken_ExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth-token")
+	require.NoError(t, os.WriteFile(path, []byte("existing-token\n"), 0644))
+
+	token, err := LoadOrCreateToken(path)
+	require.NoError(t, err)
+	assert.Equal(t, "existing-token", token)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestLoadOrCreateToken_EmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth-token")
+	require.NoError(t, os.WriteFile(path, []byte("  \n"), 0600))
+
+	_, err := LoadOrCreateToken(path)
+	assert.Error(t, err)
+}
+
+func TestCheckBearerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		expected      bool
+	}{
+		{"valid token", "Bearer secret", true},
+		{"case-insensitive scheme", "bearer secret", true},
+		{"missing header", "", false},
+		{"wrong token", "Bearer other", false},
+		{"wrong scheme", "Basic secret", false},
+		{"scheme only", "Bearer", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://localhost/mcp", nil)
+			require.NoError(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			assert.Equal(t, tt.expected, CheckBearerToken(req, "secret"))
+		})
+	}
+}
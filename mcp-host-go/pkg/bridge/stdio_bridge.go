@@ -0,0 +1,91 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// StdioBridge relays MCP JSON-RPC between its own stdin/stdout and the local
+// socket of an already-running MCP host, so stdio-only MCP clients can use it
+type StdioBridge struct {
+	logger     logger.Logger
+	socketPath string
+	stdin      io.Reader
+	stdout     io.Writer
+}
+
+// StdioBridgeConfig contains configuration for StdioBridge
+type StdioBridgeConfig struct {
+	Logger     logger.Logger
+	SocketPath string
+	Stdin      io.Reader
+	Stdout     io.Writer
+}
+
+// NewStdioBridge creates a new StdioBridge instance
+func NewStdioBridge(config StdioBridgeConfig) (*StdioBridge, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.SocketPath == "" {
+		return nil, fmt.Errorf("socket path is required")
+	}
+
+	stdin := config.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	stdout := config.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	return &StdioBridge{
+		logger:     config.Logger,
+		socketPath: config.SocketPath,
+		stdin:      stdin,
+		stdout:     stdout,
+	}, nil
+}
+
+// Run connects to the host socket and relays messages until either side closes
+func (b *StdioBridge) Run() error {
+	b.logger.Info("Connecting stdio bridge to MCP host", zap.String("socketPath", b.socketPath))
+
+	conn, err := net.Dial("unix", b.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MCP host at %s (is the browser extension running?): %w", b.socketPath, err)
+	}
+	defer conn.Close()
+
+	b.logger.Info("Stdio bridge connected")
+
+	// Client -> host. Closing stdin half-closes the connection so the host can
+	// still deliver responses to requests that are in flight.
+	go func() {
+		if _, err := io.Copy(conn, b.stdin); err != nil {
+			b.logger.Warn("Error relaying stdin to MCP host", zap.Error(err))
+		}
+		if unixConn, ok := conn.(*net.UnixConn); ok {
+			unixConn.CloseWrite()
+		} else {
+			conn.Close()
+		}
+	}()
+
+	// Host -> client. Returns when the host closes the connection.
+	if _, err := io.Copy(b.stdout, conn); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("error relaying MCP host output: %w", err)
+	}
+
+	b.logger.Info("Stdio bridge disconnected")
+	return nil
+}
@@ -0,0 +1,78 @@
+package bridge
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStdioBridge_RelaysUntilHostCloses(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "mcp-host.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	const request = `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n"
+	const response = `{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}` + "\n"
+
+	// The host reads until the bridge half-closes the connection and only then
+	// answers, like a request still in flight when the client closes stdin
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+		conn.Write([]byte(response))
+	}()
+
+	var stdout bytes.Buffer
+	bridge, err := NewStdioBridge(StdioBridgeConfig{
+		Logger:     logger.NewLoggerFromZap(zap.NewNop()),
+		SocketPath: socketPath,
+		Stdin:      strings.NewReader(request),
+		Stdout:     &stdout,
+	})
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- bridge.Run() }()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("bridge did not stop after the host closed the connection")
+	}
+
+	assert.Equal(t, request, <-received)
+	assert.Equal(t, response, stdout.String())
+}
+
+func TestStdioBridge_FailsWithoutHost(t *testing.T) {
+	bridge, err := NewStdioBridge(StdioBridgeConfig{
+		Logger:     logger.NewLoggerFromZap(zap.NewNop()),
+		SocketPath: filepath.Join(t.TempDir(), "missing.sock"),
+		Stdin:      strings.NewReader(""),
+		Stdout:     io.Discard,
+	})
+	require.NoError(t, err)
+
+	err = bridge.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to MCP host")
+}
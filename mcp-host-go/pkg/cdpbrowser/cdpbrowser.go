@@ -0,0 +1,425 @@
+package cdpbrowser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/messaging"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// CDP backend defaults
+const (
+	DefaultBrowserURL        = "http://127.0.0.1:9222"
+	DefaultReconnectInterval = 2 * time.Second
+)
+
+// cdpConnectTimeout bounds connecting to the browser and reading its targets
+const cdpConnectTimeout = 10 * time.Second
+
+// cdpMethod serves one RPC method of the extension protocol
+type cdpMethod func(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error)
+
+// Browser implements the types.Messaging interface without the extension:
+// it serves the extension's RPC methods itself, driving a Chromium browser over
+// the Chrome DevTools Protocol. It is meant for CI and server-side runs against
+// a headless browser. Pages are numbered like extension tabs, and target events
+// are delivered to handlers as browser_state_changed messages.
+type Browser struct {
+	*messaging.LocalBackend
+	logger            logger.Logger
+	browserURL        string
+	reconnectInterval time.Duration
+	maxMessageSize    int
+	methods           map[string]cdpMethod
+
+	mutex     sync.Mutex
+	client    *cdpClient        // Nil while the browser is not connected
+	tabs      map[int]string    // Tab ID to target ID
+	tabIDs    map[string]int    // Target ID to tab ID
+	sessions  map[string]string // Target ID to the ID of the session attached to it
+	nextTabID int
+	activeTab int
+	closed    bool
+	stop      chan struct{}
+}
+
+// Config contains configuration for Browser
+type Config struct {
+	Logger            logger.Logger
+	BrowserURL        string        // DevTools HTTP endpoint or browser WebSocket URL, defaults to DefaultBrowserURL
+	ReconnectInterval time.Duration // Delay between connection attempts, defaults to DefaultReconnectInterval
+	MaxMessageSize    int           // Largest CDP message accepted, defaults to messaging.DefaultMaxMessageSize
+	Capabilities      *types.ExtensionCapabilities
+}
+
+// NewBrowser creates a new Browser instance
+func NewBrowser(config Config) (*Browser, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	browserURL := config.BrowserURL
+	if browserURL == "" {
+		browserURL = DefaultBrowserURL
+	}
+
+	reconnectInterval := config.ReconnectInterval
+	if reconnectInterval <= 0 {
+		reconnectInterval = DefaultReconnectInterval
+	}
+
+	maxMessageSize := config.MaxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = messaging.DefaultMaxMessageSize
+	}
+
+	b := &Browser{
+		logger:            config.Logger,
+		browserURL:        browserURL,
+		reconnectInterval: reconnectInterval,
+		maxMessageSize:    maxMessageSize,
+		tabs:              make(map[int]string),
+		tabIDs:            make(map[string]int),
+		sessions:          make(map[string]string),
+		nextTabID:         1,
+		stop:              make(chan struct{}),
+	}
+	b.LocalBackend = messaging.NewLocalBackend(messaging.LocalBackendConfig{
+		Logger:       config.Logger,
+		Name:         "CDP browser",
+		Capabilities: config.Capabilities,
+		LastPing:     b.lastPing,
+	})
+
+	b.methods = map[string]cdpMethod{
+		"get_browser_state": b.getBrowserState,
+		"get_dom_state":     b.getDomState,
+		"navigate_to":       b.navigateTo,
+		"navigate_history":  b.navigateHistory,
+		"click_element":     b.clickElement,
+		"type_value":        b.typeValue,
+		"scroll_page":       b.scrollPage,
+		"manage_tabs":       b.manageTabs,
+		"take_screenshot":   b.takeScreenshot,
+		"wait_for":          b.waitFor,
+	}
+
+	return b, nil
+}
+
+// Start connects to the browser in the background and reconnects whenever the
+// connection is lost. Until then RPC requests fail with ErrExtensionDisconnected.
+func (b *Browser) Start() error {
+	b.logger.Info("Starting CDP browser",
+		zap.String("browserURL", b.browserURL),
+		zap.Duration("reconnectInterval", b.reconnectInterval))
+
+	go b.run()
+	return nil
+}
+
+// Close disconnects from the browser and stops reconnecting
+func (b *Browser) Close() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.stop)
+	client := b.client
+	b.mutex.Unlock()
+
+	if client != nil {
+		client.close()
+	}
+	return nil
+}
+
+// run keeps a connection to the browser until Close
+func (b *Browser) run() {
+	for {
+		client, err := b.connect()
+		if err != nil {
+			b.logger.Debug("Browser is not reachable over CDP", zap.String("browserURL", b.browserURL), zap.Error(err))
+		} else {
+			<-client.done
+			b.setDisconnected(client)
+		}
+
+		select {
+		case <-b.stop:
+			return
+		case <-time.After(b.reconnectInterval):
+		}
+	}
+}
+
+// connect opens the DevTools connection, discovers the open pages and
+// announces the supported methods
+func (b *Browser) connect() (*cdpClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cdpConnectTimeout)
+	defer cancel()
+
+	wsURL, err := resolveCDPURL(ctx, b.browserURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dialCDP(ctx, b.logger, wsURL, b.maxMessageSize, b.handleEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	var version struct {
+		Product string `json:"product"`
+	}
+	err = client.call(ctx, "", "Browser.getVersion", nil, &version)
+	if err == nil {
+		err = client.call(ctx, "", "Target.setDiscoverTargets", map[string]interface{}{"discover": true}, nil)
+	}
+	if err == nil {
+		err = b.syncTargets(ctx, client)
+	}
+	if err != nil {
+		client.close()
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		client.close()
+		return nil, fmt.Errorf("CDP browser is closed")
+	}
+	b.client = client
+	b.mutex.Unlock()
+
+	b.logger.Info("Connected to browser over CDP", zap.String("browser", version.Product), zap.String("url", wsURL))
+	b.SetConnected(version.Product, messaging.MethodNames(b.methods))
+	return client, nil
+}
+
+// syncTargets numbers the open pages, opening one if there is none
+func (b *Browser) syncTargets(ctx context.Context, client *cdpClient) error {
+	var result struct {
+		TargetInfos []cdpTargetInfo `json:"targetInfos"`
+	}
+	if err := client.call(ctx, "", "Target.getTargets", nil, &result); err != nil {
+		return err
+	}
+
+	pages := make(map[string]bool)
+	for _, info := range result.TargetInfos {
+		if info.Type == "page" {
+			pages[info.TargetID] = true
+		}
+	}
+
+	if len(pages) == 0 {
+		var created struct {
+			TargetID string `json:"targetId"`
+		}
+		if err := client.call(ctx, "", "Target.createTarget", map[string]interface{}{"url": "about:blank"}, &created); err != nil {
+			return err
+		}
+		pages[created.TargetID] = true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// Tabs of a browser that went away are gone
+	for targetID, tabID := range b.tabIDs {
+		if !pages[targetID] {
+			delete(b.tabIDs, targetID)
+			delete(b.tabs, tabID)
+		}
+	}
+
+	for _, info := range result.TargetInfos {
+		if pages[info.TargetID] {
+			b.tabIDLocked(info.TargetID)
+		}
+	}
+	for targetID := range pages {
+		b.tabIDLocked(targetID)
+	}
+
+	if _, ok := b.tabs[b.activeTab]; !ok {
+		b.activeTab = 0
+		for tabID := range b.tabs {
+			if b.activeTab == 0 || tabID < b.activeTab {
+				b.activeTab = tabID
+			}
+		}
+	}
+	return nil
+}
+
+// setDisconnected forgets the sessions of a connection that ended
+func (b *Browser) setDisconnected(client *cdpClient) {
+	b.mutex.Lock()
+	if b.client != client {
+		b.mutex.Unlock()
+		return
+	}
+	b.client = nil
+	b.sessions = make(map[string]string)
+	reason := client.err
+	b.mutex.Unlock()
+
+	b.logger.Warn("Browser disconnected", zap.Error(reason))
+	b.SetDisconnected()
+}
+
+// lastPing returns when the browser last sent a message, in Unix milliseconds
+func (b *Browser) lastPing() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.client == nil {
+		return 0
+	}
+	return b.client.lastMessage.Load()
+}
+
+// RpcRequest serves an RPC method of the extension protocol against the browser
+func (b *Browser) RpcRequest(ctx context.Context, request types.RpcRequest, options types.RpcOptions) (types.RpcResponse, error) {
+	method, ok := b.methods[request.Method]
+	if !ok {
+		return types.RpcResponse{}, b.UnsupportedMethod(request.Method)
+	}
+
+	return b.Serve(ctx, request, options, func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+		b.mutex.Lock()
+		client := b.client
+		b.mutex.Unlock()
+		if client == nil {
+			return nil, fmt.Errorf("RPC request %s not sent, browser is not connected over CDP: %w", request.Method, types.ErrExtensionDisconnected)
+		}
+		return method(ctx, client, params)
+	})
+}
+
+// cdpTargetInfo describes a target of the browser
+type cdpTargetInfo struct {
+	TargetID string `json:"targetId"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+}
+
+// handleEvent keeps tabs and sessions up to date and reports tab changes to
+// the browser_state_changed handler
+func (b *Browser) handleEvent(event cdpEvent) {
+	var params struct {
+		TargetInfo cdpTargetInfo `json:"targetInfo"`
+		TargetID   string        `json:"targetId"`
+		SessionID  string        `json:"sessionId"`
+	}
+	switch event.Method {
+	case "Target.targetCreated", "Target.targetInfoChanged", "Target.targetDestroyed", "Target.detachedFromTarget":
+		if err := json.Unmarshal(event.Params, &params); err != nil {
+			b.logger.Warn("Ignoring invalid CDP event", zap.String("method", event.Method), zap.Error(err))
+			return
+		}
+	default:
+		return
+	}
+
+	b.mutex.Lock()
+	var stateChange map[string]interface{}
+	switch event.Method {
+	case "Target.targetCreated":
+		if params.TargetInfo.Type == "page" {
+			tabID := b.tabIDLocked(params.TargetInfo.TargetID)
+			stateChange = map[string]interface{}{"event": "tab_created", "tabId": tabID, "url": params.TargetInfo.URL}
+		}
+	case "Target.targetInfoChanged":
+		if tabID, ok := b.tabIDs[params.TargetInfo.TargetID]; ok {
+			stateChange = map[string]interface{}{"event": "tab_updated", "tabId": tabID, "url": params.TargetInfo.URL}
+		}
+	case "Target.targetDestroyed":
+		if tabID, ok := b.tabIDs[params.TargetID]; ok {
+			delete(b.tabIDs, params.TargetID)
+			delete(b.tabs, tabID)
+			delete(b.sessions, params.TargetID)
+			if b.activeTab == tabID {
+				b.activeTab = 0
+			}
+			stateChange = map[string]interface{}{"event": "tab_removed", "tabId": tabID}
+		}
+	case "Target.detachedFromTarget":
+		for targetID, sessionID := range b.sessions {
+			if sessionID == params.SessionID {
+				delete(b.sessions, targetID)
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	if stateChange != nil {
+		// Handlers may make RPC requests, which need the reader goroutine
+		go b.DeliverStateChanges(stateChange)
+	}
+}
+
+// tabIDLocked returns the tab ID of a page, numbering new pages; b.mutex must be held
+func (b *Browser) tabIDLocked(targetID string) int {
+	if tabID, ok := b.tabIDs[targetID]; ok {
+		return tabID
+	}
+	tabID := b.nextTabID
+	b.nextTabID++
+	b.tabIDs[targetID] = tabID
+	b.tabs[tabID] = targetID
+	return tabID
+}
+
+// targetTab returns the tab named by the tab_id param, or the active tab, and its target ID
+func (b *Browser) targetTab(params map[string]interface{}) (int, string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return messaging.TargetTab(params, b.tabs, b.activeTab)
+}
+
+// session returns the session attached to a page, attaching one if needed
+func (b *Browser) session(ctx context.Context, client *cdpClient, targetID string) (string, error) {
+	b.mutex.Lock()
+	sessionID, ok := b.sessions[targetID]
+	b.mutex.Unlock()
+	if ok {
+		return sessionID, nil
+	}
+
+	var attached struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := client.call(ctx, "", "Target.attachToTarget", map[string]interface{}{"targetId": targetID, "flatten": true}, &attached); err != nil {
+		return "", err
+	}
+
+	// Page events tell when navigations finish
+	if err := client.call(ctx, attached.SessionID, "Page.enable", nil, nil); err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	if existing, ok := b.sessions[targetID]; ok {
+		b.mutex.Unlock()
+		// Another request attached first; both sessions work, keep one
+		return existing, nil
+	}
+	b.sessions[targetID] = attached.SessionID
+	b.mutex.Unlock()
+
+	return attached.SessionID, nil
+}
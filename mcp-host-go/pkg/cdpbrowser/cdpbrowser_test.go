@@ -0,0 +1,547 @@
+package cdpbrowser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeBrowser serves the DevTools endpoints of a browser with scripted command results
+type fakeBrowser struct {
+	t        *testing.T
+	server   *httptest.Server
+	mutex    sync.Mutex
+	conn     *websocket.Conn
+	commands []cdpMessage
+	handlers map[string]func(message cdpMessage) (interface{}, error)
+	pages    []cdpTargetInfo
+	evaluate func(function string) interface{} // Result of a page script function
+}
+
+func newFakeBrowser(t *testing.T) *fakeBrowser {
+	f := &fakeBrowser{
+		t:     t,
+		pages: []cdpTargetInfo{{TargetID: "page-1", Type: "page", Title: "Start", URL: "https://example.com/"}},
+		evaluate: func(function string) interface{} {
+			return nil
+		},
+	}
+	f.handlers = map[string]func(message cdpMessage) (interface{}, error){
+		"Browser.getVersion": func(cdpMessage) (interface{}, error) {
+			return map[string]string{"product": "HeadlessChrome/120.0"}, nil
+		},
+		"Target.setDiscoverTargets": func(cdpMessage) (interface{}, error) { return map[string]string{}, nil },
+		"Target.getTargets": func(cdpMessage) (interface{}, error) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			return map[string]interface{}{"targetInfos": f.pages}, nil
+		},
+		"Target.attachToTarget": func(message cdpMessage) (interface{}, error) {
+			var params struct {
+				TargetID string `json:"targetId"`
+			}
+			require.NoError(t, json.Unmarshal(message.Params, &params))
+			return map[string]string{"sessionId": "session-" + params.TargetID}, nil
+		},
+		"Runtime.evaluate": func(message cdpMessage) (interface{}, error) {
+			var params struct {
+				Expression string `json:"expression"`
+			}
+			require.NoError(t, json.Unmarshal(message.Params, &params))
+			function := params.Expression[strings.LastIndex(params.Expression, `)["`)+3:]
+			function = function[:strings.Index(function, `"]`)]
+			return map[string]interface{}{"result": map[string]interface{}{"value": f.evaluate(function)}}, nil
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"webSocketDebuggerUrl": "ws://" + r.Host + "/devtools/browser/fake",
+		})
+	})
+	mux.HandleFunc("/devtools/browser/fake", f.serve)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// serve answers the commands of one DevTools connection
+func (f *fakeBrowser) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	f.mutex.Lock()
+	f.conn = conn
+	f.mutex.Unlock()
+
+	for {
+		_, data, err := conn.Read(context.Background())
+		if err != nil {
+			return
+		}
+		var message cdpMessage
+		require.NoError(f.t, json.Unmarshal(data, &message))
+
+		f.mutex.Lock()
+		f.commands = append(f.commands, message)
+		handler := f.handlers[message.Method]
+		f.mutex.Unlock()
+
+		response := map[string]interface{}{"id": message.ID}
+		if message.SessionID != "" {
+			response["sessionId"] = message.SessionID
+		}
+		result := interface{}(map[string]string{})
+		if handler != nil {
+			result, err = handler(message)
+		}
+		if err != nil {
+			response["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		f.write(response)
+	}
+}
+
+// write sends a message on the current connection
+func (f *fakeBrowser) write(message interface{}) {
+	data, err := json.Marshal(message)
+	require.NoError(f.t, err)
+
+	f.mutex.Lock()
+	conn := f.conn
+	f.mutex.Unlock()
+	require.NotNil(f.t, conn)
+	conn.Write(context.Background(), websocket.MessageText, data)
+}
+
+// emit sends an event
+func (f *fakeBrowser) emit(method string, sessionID string, params interface{}) {
+	f.write(map[string]interface{}{"method": method, "sessionId": sessionID, "params": params})
+}
+
+// disconnect drops the current connection
+func (f *fakeBrowser) disconnect() {
+	f.mutex.Lock()
+	conn := f.conn
+	f.mutex.Unlock()
+	conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// handle replaces the handler of a command
+func (f *fakeBrowser) handle(method string, handler func(message cdpMessage) (interface{}, error)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.handlers[method] = handler
+}
+
+// sent returns the commands received with a method
+func (f *fakeBrowser) sent(method string) []cdpMessage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var commands []cdpMessage
+	for _, command := range f.commands {
+		if command.Method == method {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// newTestBrowser starts a Browser connected to a fake browser
+func newTestBrowser(t *testing.T, browser *fakeBrowser) (*Browser, *types.ExtensionCapabilities, chan types.ConnectionStatus) {
+	capabilities := &types.ExtensionCapabilities{}
+	b, err := NewBrowser(Config{
+		Logger:            logger.NewLoggerFromZap(zap.NewNop()),
+		BrowserURL:        browser.server.URL,
+		ReconnectInterval: 50 * time.Millisecond,
+		Capabilities:      capabilities,
+	})
+	require.NoError(t, err)
+
+	changes := make(chan types.ConnectionStatus, 10)
+	b.OnConnectionChange(func(status types.ConnectionStatus) { changes <- status })
+
+	require.NoError(t, b.Start())
+	t.Cleanup(func() { b.Close() })
+
+	select {
+	case status := <-changes:
+		require.True(t, status.IsConnected)
+	case <-time.After(5 * time.Second):
+		t.Fatal("browser was not connected")
+	}
+	return b, capabilities, changes
+}
+
+func TestBrowser_BrowserStateAndTabEvents(t *testing.T) {
+	browser := newFakeBrowser(t)
+	b, capabilities, _ := newTestBrowser(t, browser)
+
+	info, known := capabilities.Get()
+	require.True(t, known)
+	assert.True(t, info.Compatible)
+	assert.Equal(t, "HeadlessChrome/120.0", info.Version)
+	assert.Contains(t, info.Methods, "navigate_to")
+	assert.Contains(t, info.Methods, "get_dom_state")
+
+	response, err := b.RpcRequest(context.Background(), types.RpcRequest{Method: "get_browser_state"}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	state := response.Result.(map[string]interface{})
+	tabs := state["tabs"].([]interface{})
+	require.Len(t, tabs, 1)
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "url": "https://example.com/", "title": "Start", "active": true}, tabs[0])
+	assert.Equal(t, tabs[0], state["activeTab"])
+
+	// New pages become tabs and are reported like the extension reports them
+	changes := make(chan map[string]interface{}, 10)
+	b.RegisterHandler("browser_state_changed", func(data interface{}) error {
+		changes <- data.(map[string]interface{})
+		return nil
+	})
+	browser.emit("Target.targetCreated", "", map[string]interface{}{
+		"targetInfo": map[string]interface{}{"targetId": "page-2", "type": "page", "url": "about:blank"},
+	})
+	select {
+	case change := <-changes:
+		assert.Equal(t, "tab_created", change["event"])
+		assert.Equal(t, 2, change["tabId"])
+	case <-time.After(time.Second):
+		t.Fatal("tab creation was not reported")
+	}
+
+	browser.emit("Target.targetDestroyed", "", map[string]interface{}{"targetId": "page-2"})
+	select {
+	case change := <-changes:
+		assert.Equal(t, "tab_removed", change["event"])
+		assert.Equal(t, 2, change["tabId"])
+	case <-time.After(time.Second):
+		t.Fatal("tab removal was not reported")
+	}
+}
+
+func TestBrowser_NavigateWaitsForLoad(t *testing.T) {
+	browser := newFakeBrowser(t)
+	b, _, _ := newTestBrowser(t, browser)
+
+	loaded := make(chan struct{})
+	browser.handle("Page.navigate", func(message cdpMessage) (interface{}, error) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(loaded)
+			browser.emit("Page.loadEventFired", message.SessionID, map[string]interface{}{"timestamp": 1})
+		}()
+		return map[string]string{"frameId": "frame", "loaderId": "loader"}, nil
+	})
+	browser.evaluate = func(function string) interface{} {
+		return map[string]string{"url": "https://example.com/next", "title": "Next"}
+	}
+
+	response, err := b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "navigate_to",
+		Params: map[string]interface{}{"url": "https://example.com/next", "timeout": "auto", "tab_id": 1},
+	}, types.RpcOptions{Timeout: 5000})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+
+	select {
+	case <-loaded:
+	default:
+		t.Fatal("navigate_to returned before the load event")
+	}
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, true, result["success"])
+	assert.Equal(t, "https://example.com/next", result["url"])
+
+	navigations := browser.sent("Page.navigate")
+	require.Len(t, navigations, 1)
+	assert.Equal(t, "session-page-1", navigations[0].SessionID)
+	assert.Len(t, browser.sent("Page.enable"), 1)
+
+	// Failed navigations are RPC errors, as with the extension
+	browser.handle("Page.navigate", func(message cdpMessage) (interface{}, error) {
+		return map[string]string{"frameId": "frame", "errorText": "net::ERR_NAME_NOT_RESOLVED"}, nil
+	})
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "navigate_to",
+		Params: map[string]interface{}{"url": "https://invalid.example"},
+	}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "ERR_NAME_NOT_RESOLVED")
+
+	// Unknown tabs are refused
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "navigate_to",
+		Params: map[string]interface{}{"url": "https://example.com", "tab_id": 42},
+	}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "tab 42 not found")
+}
+
+func TestBrowser_NavigateHistory(t *testing.T) {
+	browser := newFakeBrowser(t)
+	b, _, _ := newTestBrowser(t, browser)
+
+	browser.handle("Page.getNavigationHistory", func(message cdpMessage) (interface{}, error) {
+		return map[string]interface{}{
+			"currentIndex": 1,
+			"entries": []map[string]interface{}{
+				{"id": 7, "url": "https://example.com/"},
+				{"id": 8, "url": "https://example.com/next"},
+			},
+		}, nil
+	})
+	browser.handle("Page.navigateToHistoryEntry", func(message cdpMessage) (interface{}, error) {
+		go browser.emit("Page.loadEventFired", message.SessionID, map[string]interface{}{"timestamp": 1})
+		return map[string]string{}, nil
+	})
+	browser.handle("Page.reload", func(message cdpMessage) (interface{}, error) {
+		// Pages restored from the back-forward cache fire no load event
+		go browser.emit("Page.frameNavigated", message.SessionID, map[string]interface{}{
+			"frame": map[string]string{"id": "page-1", "url": "https://example.com/"},
+			"type":  "BackForwardCacheRestore",
+		})
+		return map[string]string{}, nil
+	})
+	browser.evaluate = func(function string) interface{} {
+		return map[string]string{"url": "https://example.com/", "title": "Start"}
+	}
+
+	response, err := b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "navigate_history",
+		Params: map[string]interface{}{"action": "back", "timeout": "5000"},
+	}, types.RpcOptions{Timeout: 10000})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, "back", result["action"])
+	assert.Equal(t, "https://example.com/", result["url"])
+
+	entries := browser.sent("Page.navigateToHistoryEntry")
+	require.Len(t, entries, 1)
+	assert.JSONEq(t, `{"entryId":7}`, string(entries[0].Params))
+
+	// There is nothing to go forward to from the last entry
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "navigate_history",
+		Params: map[string]interface{}{"action": "forward"},
+	}, types.RpcOptions{Timeout: 10000})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "cannot go forward")
+	assert.Len(t, browser.sent("Page.navigateToHistoryEntry"), 1)
+
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "navigate_history",
+		Params: map[string]interface{}{"action": "hard_reload"},
+	}, types.RpcOptions{Timeout: 10000})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	reloads := browser.sent("Page.reload")
+	require.Len(t, reloads, 1)
+	assert.JSONEq(t, `{"ignoreCache":true}`, string(reloads[0].Params))
+}
+
+func TestBrowser_ClickAndType(t *testing.T) {
+	browser := newFakeBrowser(t)
+	b, _, _ := newTestBrowser(t, browser)
+
+	browser.evaluate = func(function string) interface{} {
+		switch function {
+		case "locate", "focus":
+			return map[string]interface{}{"tagName": "input", "text": "", "type": "input", "x": 40, "y": 20}
+		case "value":
+			return "hello"
+		default:
+			return map[string]string{"url": "https://example.com/", "title": "Start"}
+		}
+	}
+
+	response, err := b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "click_element",
+		Params: map[string]interface{}{"element_index": 3, "wait_after": 0},
+	}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	assert.Equal(t, true, response.Result.(map[string]interface{})["success"])
+	assert.Equal(t, false, response.Result.(map[string]interface{})["page_changed"])
+
+	mouseEvents := browser.sent("Input.dispatchMouseEvent")
+	require.Len(t, mouseEvents, 3)
+	var pressed map[string]interface{}
+	require.NoError(t, json.Unmarshal(mouseEvents[1].Params, &pressed))
+	assert.Equal(t, map[string]interface{}{"type": "mousePressed", "x": float64(40), "y": float64(20), "button": "left", "clickCount": float64(1)}, pressed)
+
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "type_value",
+		Params: map[string]interface{}{
+			"element_index": 3,
+			"value":         "hello{Enter}",
+			"options":       map[string]interface{}{"clear_first": true, "wait_after": 0},
+		},
+	}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, "hello", result["actual_value"])
+	assert.Equal(t, "keyboard", result["input_method"])
+
+	inserted := browser.sent("Input.insertText")
+	require.Len(t, inserted, 1)
+	assert.JSONEq(t, `{"text":"hello"}`, string(inserted[0].Params))
+
+	keys := browser.sent("Input.dispatchKeyEvent")
+	require.Len(t, keys, 2)
+	var keyDown map[string]interface{}
+	require.NoError(t, json.Unmarshal(keys[0].Params, &keyDown))
+	assert.Equal(t, "keyDown", keyDown["type"])
+	assert.Equal(t, "Enter", keyDown["key"])
+
+	// An element that is gone is an RPC error
+	browser.evaluate = func(function string) interface{} {
+		if function == "locate" {
+			return nil
+		}
+		return map[string]string{"url": "https://example.com/", "title": "Start"}
+	}
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "click_element",
+		Params: map[string]interface{}{"element_index": 99, "wait_after": 0},
+	}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "not found")
+}
+
+func TestBrowser_WaitFor(t *testing.T) {
+	browser := newFakeBrowser(t)
+	b, _, _ := newTestBrowser(t, browser)
+
+	var checks int
+	browser.evaluate = func(function string) interface{} {
+		if function == "checkConditions" {
+			checks++
+			if checks < 3 {
+				return ""
+			}
+			return "text"
+		}
+		return map[string]string{"url": "https://example.com/done", "title": "Done"}
+	}
+
+	response, err := b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "wait_for",
+		Params: map[string]interface{}{"text": "Saved", "selector": "#toast", "timeout": float64(5000)},
+	}, types.RpcOptions{Timeout: 10000})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	result := response.Result.(map[string]interface{})
+	assert.Equal(t, "text", result["condition"])
+	assert.Equal(t, "https://example.com/done", result["url"])
+	assert.Equal(t, 3, checks)
+
+	// Requests in flight keep the network busy until they finish
+	browser.evaluate = func(function string) interface{} {
+		return map[string]string{"url": "https://example.com/done", "title": "Done"}
+	}
+	browser.handle("Network.enable", func(message cdpMessage) (interface{}, error) {
+		go func() {
+			browser.emit("Network.requestWillBeSent", message.SessionID, map[string]interface{}{"requestId": "request-1"})
+			time.Sleep(200 * time.Millisecond)
+			browser.emit("Network.loadingFinished", message.SessionID, map[string]interface{}{"requestId": "request-1"})
+		}()
+		return map[string]string{}, nil
+	})
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "wait_for",
+		Params: map[string]interface{}{"network_idle_ms": float64(100), "timeout": float64(5000)},
+	}, types.RpcOptions{Timeout: 10000})
+	require.NoError(t, err)
+	require.Nil(t, response.Error)
+	result = response.Result.(map[string]interface{})
+	assert.Equal(t, "network_idle", result["condition"])
+	assert.GreaterOrEqual(t, result["elapsed_ms"], float64(300))
+
+	// Conditions that never hold time out with an RPC error
+	browser.evaluate = func(function string) interface{} {
+		if function == "checkConditions" {
+			return ""
+		}
+		return map[string]string{"url": "https://example.com/", "title": "Start"}
+	}
+	response, err = b.RpcRequest(context.Background(), types.RpcRequest{
+		Method: "wait_for",
+		Params: map[string]interface{}{"text": "Never", "timeout": float64(300)},
+	}, types.RpcOptions{Timeout: 5000})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "timed out after 300ms waiting for text 'Never'")
+}
+
+func TestBrowser_DisconnectAndReconnect(t *testing.T) {
+	browser := newFakeBrowser(t)
+	b, _, changes := newTestBrowser(t, browser)
+
+	_, err := b.RpcRequest(context.Background(), types.RpcRequest{Method: "get_dom_extra_elements"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+
+	browser.disconnect()
+	select {
+	case status := <-changes:
+		assert.False(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("disconnect was not reported")
+	}
+
+	_, err = b.RpcRequest(context.Background(), types.RpcRequest{Method: "get_browser_state"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+
+	select {
+	case status := <-changes:
+		assert.True(t, status.IsConnected)
+	case <-time.After(5 * time.Second):
+		t.Fatal("browser was not reconnected")
+	}
+
+	response, err := b.RpcRequest(context.Background(), types.RpcRequest{Method: "get_browser_state"}, types.RpcOptions{})
+	require.NoError(t, err)
+	assert.Len(t, response.Result.(map[string]interface{})["tabs"], 1)
+}
+
+func TestParseKeyInput(t *testing.T) {
+	inputs := parseKeyInput("a{b}c{Enter}{Ctrl+A}{Unknown+X}")
+	require.Len(t, inputs, 4)
+
+	assert.Equal(t, "a{b}c", inputs[0].text)
+	require.NotNil(t, inputs[1].key)
+	assert.Equal(t, "Enter", inputs[1].key.key)
+	assert.Equal(t, "\r", inputs[1].key.text)
+
+	require.NotNil(t, inputs[2].key)
+	assert.Equal(t, "a", inputs[2].key.key)
+	assert.Equal(t, cdpModifierCtrl, inputs[2].key.modifiers)
+	assert.Empty(t, inputs[2].key.text)
+	assert.Equal(t, []string{"selectAll"}, inputs[2].key.commands)
+
+	assert.Equal(t, "{Unknown+X}", inputs[3].text)
+}
@@ -0,0 +1,310 @@
+package cdpbrowser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/coder/websocket"
+	"go.uber.org/zap"
+)
+
+// cdpWriteTimeout bounds writes so a stalled browser cannot block callers forever
+const cdpWriteTimeout = 10 * time.Second
+
+// cdpMessage is a command, response or event of the Chrome DevTools Protocol
+type cdpMessage struct {
+	ID        int64           `json:"id,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *cdpError       `json:"error,omitempty"`
+}
+
+// cdpError is the error of a failed CDP command
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (e *cdpError) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("%s (%d): %s", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// cdpEvent is an event from the browser or one of its sessions
+type cdpEvent struct {
+	Method    string
+	SessionID string
+	Params    json.RawMessage
+}
+
+// cdpSubscription receives the events of one method in one session
+type cdpSubscription struct {
+	sessionID string
+	method    string
+	events    chan json.RawMessage
+}
+
+// cdpClient sends commands to a browser over its DevTools WebSocket and
+// dispatches the events it sends back. Commands for a page carry the session
+// ID of the flat session attached to it.
+type cdpClient struct {
+	logger        logger.Logger
+	conn          *websocket.Conn
+	onEvent       func(event cdpEvent) // Called from the reader goroutine for every event
+	nextID        atomic.Int64
+	lastMessage   atomic.Int64 // Unix milliseconds of the last message from the browser
+	mutex         sync.Mutex
+	pending       map[int64]chan cdpMessage
+	subscriptions map[*cdpSubscription]struct{}
+	done          chan struct{} // Closed when the connection ends
+	err           error         // Why the connection ended; set before done is closed
+}
+
+// dialCDP connects to the browser at a DevTools WebSocket URL
+func dialCDP(ctx context.Context, log logger.Logger, wsURL string, maxMessageSize int, onEvent func(event cdpEvent)) (*cdpClient, error) {
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", wsURL, err)
+	}
+	conn.SetReadLimit(int64(maxMessageSize))
+
+	c := &cdpClient{
+		logger:        log,
+		conn:          conn,
+		onEvent:       onEvent,
+		pending:       make(map[int64]chan cdpMessage),
+		subscriptions: make(map[*cdpSubscription]struct{}),
+		done:          make(chan struct{}),
+	}
+	c.lastMessage.Store(time.Now().UnixMilli())
+
+	go c.readMessages()
+	return c, nil
+}
+
+// resolveCDPURL returns the browser's DevTools WebSocket URL. A ws:// URL is
+// used as is; for an http:// URL it is read from /json/version.
+func resolveCDPURL(ctx context.Context, browserURL string) (string, error) {
+	if strings.HasPrefix(browserURL, "ws://") {
+		return browserURL, nil
+	}
+	if !strings.HasPrefix(browserURL, "http://") && !strings.HasPrefix(browserURL, "https://") {
+		return "", fmt.Errorf("browser URL must be http://, https:// or ws://, got %q", browserURL)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(browserURL, "/")+"/json/version", nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the browser: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("browser returned %s for /json/version", response.Status)
+	}
+
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1024*1024)).Decode(&version); err != nil {
+		return "", fmt.Errorf("invalid /json/version response: %w", err)
+	}
+	if version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("browser did not report a DevTools WebSocket URL")
+	}
+
+	return version.WebSocketDebuggerURL, nil
+}
+
+// call sends a command and decodes its result into result, which may be nil.
+// An empty sessionID sends the command to the browser itself.
+func (c *cdpClient) call(ctx context.Context, sessionID string, method string, params interface{}, result interface{}) error {
+	id := c.nextID.Add(1)
+	message := cdpMessage{ID: id, Method: method, SessionID: sessionID}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		message.Params = data
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", method, err)
+	}
+
+	responses := make(chan cdpMessage, 1)
+	c.mutex.Lock()
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return c.closedError(method)
+	default:
+	}
+	c.pending[id] = responses
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, id)
+		c.mutex.Unlock()
+	}()
+
+	// A cancelled write closes the connection, so writes get their own deadline
+	writeCtx, cancelWrite := context.WithTimeout(context.Background(), cdpWriteTimeout)
+	err = c.conn.Write(writeCtx, websocket.MessageText, data)
+	cancelWrite()
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case response := <-responses:
+		if response.Error != nil {
+			return fmt.Errorf("%s failed: %w", method, response.Error)
+		}
+		if result != nil && len(response.Result) > 0 {
+			if err := json.Unmarshal(response.Result, result); err != nil {
+				return fmt.Errorf("invalid %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return c.closedError(method)
+	case <-ctx.Done():
+		return fmt.Errorf("%s cancelled: %w", method, ctx.Err())
+	}
+}
+
+// subscribe starts collecting events of a method in a session. Subscribe
+// before sending the command that causes the event, and cancel when done.
+func (c *cdpClient) subscribe(sessionID string, method string) (*cdpSubscription, func()) {
+	return c.subscribeBuffered(sessionID, method, 1)
+}
+
+// subscribeBuffered is subscribe for events that all matter, such as network
+// requests: up to size events are kept until they are taken
+func (c *cdpClient) subscribeBuffered(sessionID string, method string, size int) (*cdpSubscription, func()) {
+	subscription := &cdpSubscription{
+		sessionID: sessionID,
+		method:    method,
+		events:    make(chan json.RawMessage, size),
+	}
+
+	c.mutex.Lock()
+	c.subscriptions[subscription] = struct{}{}
+	c.mutex.Unlock()
+
+	return subscription, func() {
+		c.mutex.Lock()
+		delete(c.subscriptions, subscription)
+		c.mutex.Unlock()
+	}
+}
+
+// wait returns the params of the next event of a subscription
+func (c *cdpClient) wait(ctx context.Context, subscription *cdpSubscription) (json.RawMessage, error) {
+	select {
+	case params := <-subscription.events:
+		return params, nil
+	case <-c.done:
+		return nil, c.closedError(subscription.method)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for %s: %w", subscription.method, ctx.Err())
+	}
+}
+
+// close ends the connection to the browser
+func (c *cdpClient) close() {
+	c.conn.Close(websocket.StatusNormalClosure, "")
+	<-c.done
+}
+
+// readMessages dispatches messages from the browser until the connection ends
+func (c *cdpClient) readMessages() {
+	var err error
+	for {
+		var data []byte
+		_, data, err = c.conn.Read(context.Background())
+		if err != nil {
+			break
+		}
+		c.lastMessage.Store(time.Now().UnixMilli())
+
+		var message cdpMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			c.logger.Warn("Ignoring invalid CDP message", zap.Error(err))
+			continue
+		}
+
+		if message.ID != 0 {
+			c.mutex.Lock()
+			responses, ok := c.pending[message.ID]
+			c.mutex.Unlock()
+			if ok {
+				responses <- message
+			}
+			continue
+		}
+
+		c.dispatchEvent(cdpEvent{Method: message.Method, SessionID: message.SessionID, Params: message.Params})
+	}
+
+	c.conn.CloseNow()
+	if websocket.CloseStatus(err) != -1 || errors.Is(err, io.EOF) {
+		err = fmt.Errorf("browser closed the connection")
+	}
+
+	c.mutex.Lock()
+	c.err = err
+	close(c.done)
+	c.mutex.Unlock()
+}
+
+// dispatchEvent hands an event to its subscriptions and to onEvent
+func (c *cdpClient) dispatchEvent(event cdpEvent) {
+	c.mutex.Lock()
+	for subscription := range c.subscriptions {
+		if subscription.method != event.Method || subscription.sessionID != event.SessionID {
+			continue
+		}
+		// Drop the event if the waiter has not taken the previous ones
+		select {
+		case subscription.events <- event.Params:
+		default:
+		}
+	}
+	c.mutex.Unlock()
+
+	if c.onEvent != nil {
+		c.onEvent(event)
+	}
+}
+
+// closedError is returned by calls made after the connection ended
+func (c *cdpClient) closedError(method string) error {
+	c.mutex.Lock()
+	reason := c.err
+	c.mutex.Unlock()
+	return fmt.Errorf("%s failed, %v: %w", method, reason, types.ErrExtensionDisconnected)
+}
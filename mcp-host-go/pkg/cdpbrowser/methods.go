@@ -0,0 +1,1003 @@
+package cdpbrowser
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// cdpPageScript evaluates to the object of page functions of the CDP backend
+//
+//go:embed page.js
+var cdpPageScript string
+
+// cdpFormattedAttributes are the attributes shown in the formatted DOM, as the extension shows them
+var cdpFormattedAttributes = []string{"role", "aria-label", "placeholder", "name", "type", "href"}
+
+// cdpDefaultNavigationTimeout is how long navigate_to waits for the page to load in auto mode
+const cdpDefaultNavigationTimeout = 30 * time.Second
+
+// cdpElement is an interactive element found by the page script
+type cdpElement struct {
+	Index        int               `json:"index"`
+	TagName      string            `json:"tagName"`
+	Text         string            `json:"text"`
+	Attributes   map[string]string `json:"attributes"`
+	IsInViewport bool              `json:"isInViewport"`
+	Selector     string            `json:"selector"`
+}
+
+// cdpDomState is the result of the page script's getDomState
+type cdpDomState struct {
+	Elements    []cdpElement `json:"elements"`
+	URL         string       `json:"url"`
+	Title       string       `json:"title"`
+	PixelsAbove int          `json:"pixelsAbove"`
+	PixelsBelow int          `json:"pixelsBelow"`
+}
+
+// cdpElementInfo describes an element the page script acted on
+type cdpElementInfo struct {
+	TagName string  `json:"tagName"`
+	Text    string  `json:"text"`
+	Type    string  `json:"type"`
+	X       float64 `json:"x"` // Center in viewport coordinates, set by locate
+	Y       float64 `json:"y"`
+}
+
+// cdpPageInfo is the address and title of a page
+type cdpPageInfo struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// getBrowserState lists the open pages
+func (b *Browser) getBrowserState(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	var result struct {
+		TargetInfos []cdpTargetInfo `json:"targetInfos"`
+	}
+	if err := client.call(ctx, "", "Target.getTargets", nil, &result); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	activeTab := b.activeTab
+	tabs := make([]map[string]interface{}, 0, len(result.TargetInfos))
+	var active map[string]interface{}
+	for _, info := range result.TargetInfos {
+		if info.Type != "page" {
+			continue
+		}
+		tabID := b.tabIDLocked(info.TargetID)
+		tab := map[string]interface{}{
+			"id":     tabID,
+			"url":    info.URL,
+			"title":  info.Title,
+			"active": tabID == activeTab,
+		}
+		if tabID == activeTab {
+			active = tab
+		}
+		tabs = append(tabs, tab)
+	}
+	b.mutex.Unlock()
+
+	sort.Slice(tabs, func(i, j int) bool { return tabs[i]["id"].(int) < tabs[j]["id"].(int) })
+
+	return map[string]interface{}{
+		"activeTab": active,
+		"tabs":      tabs,
+	}, nil
+}
+
+// getDomState numbers the interactive elements of a page and describes them
+// in the format of the extension
+func (b *Browser) getDomState(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	tabID, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var state cdpDomState
+	if err := evaluatePage(ctx, client, sessionID, &state, "getDomState"); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"formattedDom":        formatDomState(state),
+		"interactiveElements": state.Elements,
+		"meta": map[string]interface{}{
+			"url":         state.URL,
+			"title":       state.Title,
+			"tabId":       tabID,
+			"pixelsAbove": state.PixelsAbove,
+			"pixelsBelow": state.PixelsBelow,
+		},
+	}, nil
+}
+
+// navigateTo loads a URL and waits for the load event
+func (b *Browser) navigateTo(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	url, _ := params["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	timeout, err := navigationTimeout(params)
+	if err != nil {
+		return nil, err
+	}
+
+	tabID, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, cancel := client.subscribe(sessionID, "Page.loadEventFired")
+	defer cancel()
+
+	var navigation struct {
+		LoaderID  string `json:"loaderId"`
+		ErrorText string `json:"errorText"`
+	}
+	if err := client.call(ctx, sessionID, "Page.navigate", map[string]interface{}{"url": url}, &navigation); err != nil {
+		return nil, err
+	}
+	if navigation.ErrorText != "" {
+		return nil, fmt.Errorf("navigation to %s failed: %s", url, navigation.ErrorText)
+	}
+
+	// Navigations within the document have no loader and fire no load event
+	if navigation.LoaderID != "" {
+		waitCtx, cancelWait := context.WithTimeout(ctx, timeout)
+		_, err := client.wait(waitCtx, loaded)
+		cancelWait()
+		if err != nil {
+			if errors.Is(err, types.ErrExtensionDisconnected) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("page did not finish loading within %s: %w", timeout, err)
+		}
+	}
+
+	var page cdpPageInfo
+	if err := evaluatePage(ctx, client, sessionID, &page, "page"); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Successfully navigated to %s", url),
+		"url":     page.URL,
+		"title":   page.Title,
+		"tabId":   tabID,
+	}, nil
+}
+
+// navigateHistory goes back or forward in the history of a page, or reloads
+// it, and waits until the page is shown
+func (b *Browser) navigateHistory(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	timeout, err := navigationTimeout(params)
+	if err != nil {
+		return nil, err
+	}
+
+	tabID, targetID, err := b.targetTab(params)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := b.session(ctx, client, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages restored from the back-forward cache and history entries of the
+	// same document fire no load event
+	loaded, cancelLoaded := client.subscribe(sessionID, "Page.loadEventFired")
+	defer cancelLoaded()
+	restored, cancelRestored := client.subscribe(sessionID, "Page.frameNavigated")
+	defer cancelRestored()
+	withinDocument, cancelWithinDocument := client.subscribe(sessionID, "Page.navigatedWithinDocument")
+	defer cancelWithinDocument()
+
+	switch action {
+	case "back", "forward":
+		var history struct {
+			CurrentIndex int `json:"currentIndex"`
+			Entries      []struct {
+				ID  int    `json:"id"`
+				URL string `json:"url"`
+			} `json:"entries"`
+		}
+		if err := client.call(ctx, sessionID, "Page.getNavigationHistory", nil, &history); err != nil {
+			return nil, err
+		}
+		index := history.CurrentIndex + 1
+		if action == "back" {
+			index = history.CurrentIndex - 1
+		}
+		if index < 0 || index >= len(history.Entries) {
+			return nil, fmt.Errorf("cannot go %s: no page in the tab's history", action)
+		}
+		if err := client.call(ctx, sessionID, "Page.navigateToHistoryEntry", map[string]interface{}{"entryId": history.Entries[index].ID}, nil); err != nil {
+			return nil, err
+		}
+	case "reload", "hard_reload":
+		if err := client.call(ctx, sessionID, "Page.reload", map[string]interface{}{"ignoreCache": action == "hard_reload"}, nil); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("action must be one of back, forward, reload or hard_reload")
+	}
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, timeout)
+	defer cancelWait()
+	for shown := false; !shown; {
+		select {
+		case <-loaded.events:
+			shown = true
+		case data := <-restored.events:
+			var navigated struct {
+				Frame struct {
+					ID string `json:"id"`
+				} `json:"frame"`
+				Type string `json:"type"`
+			}
+			shown = json.Unmarshal(data, &navigated) == nil && navigated.Frame.ID == targetID && navigated.Type == "BackForwardCacheRestore"
+		case data := <-withinDocument.events:
+			var navigated struct {
+				FrameID string `json:"frameId"`
+			}
+			shown = json.Unmarshal(data, &navigated) == nil && navigated.FrameID == targetID
+		case <-client.done:
+			return nil, client.closedError("Page.loadEventFired")
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("page did not finish loading within %s", timeout)
+		}
+	}
+
+	var page cdpPageInfo
+	if err := evaluatePage(ctx, client, sessionID, &page, "page"); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("History action %s completed on %s", action, page.URL),
+		"action":  action,
+		"url":     page.URL,
+		"title":   page.Title,
+		"tabId":   tabID,
+	}, nil
+}
+
+// clickElement clicks the center of an element with the mouse
+func (b *Browser) clickElement(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	index, err := elementIndexParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	waitAfter := 1000.0
+	if value, ok := params["wait_after"].(float64); ok {
+		waitAfter = value
+	}
+
+	_, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var before cdpPageInfo
+	if err := evaluatePage(ctx, client, sessionID, &before, "page"); err != nil {
+		return nil, err
+	}
+
+	var element *cdpElementInfo
+	if err := evaluatePage(ctx, client, sessionID, &element, "locate", index); err != nil {
+		return nil, err
+	}
+	if element == nil {
+		return nil, fmt.Errorf("element with index %d not found in DOM state", index)
+	}
+
+	for _, eventType := range []string{"mouseMoved", "mousePressed", "mouseReleased"} {
+		event := map[string]interface{}{"type": eventType, "x": element.X, "y": element.Y}
+		if eventType != "mouseMoved" {
+			event["button"] = "left"
+			event["clickCount"] = 1
+		}
+		if err := client.call(ctx, sessionID, "Input.dispatchMouseEvent", event, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := sleepContext(ctx, time.Duration(waitAfter)*time.Millisecond); err != nil {
+		return nil, err
+	}
+
+	// The page may be navigating away, which also counts as a change
+	var after cdpPageInfo
+	afterErr := evaluatePage(ctx, client, sessionID, &after, "page")
+
+	return map[string]interface{}{
+		"success":       true,
+		"message":       fmt.Sprintf("Successfully clicked element at index %d", index),
+		"element_index": index,
+		"page_changed":  afterErr != nil || after.URL != before.URL,
+		"element_info":  element.describe(),
+		"before_url":    before.URL,
+		"after_url":     after.URL,
+	}, nil
+}
+
+// typeValue types text and special keys into an element, or sets the choice
+// of a select, checkbox or radio button
+func (b *Browser) typeValue(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	index, err := elementIndexParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := params["value"]
+	if !ok {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	options, _ := params["options"].(map[string]interface{})
+	clearFirst := boolOption(options, "clear_first", true)
+	submit := boolOption(options, "submit", false)
+	waitAfter := 1.0 // Seconds
+	if seconds, ok := options["wait_after"].(float64); ok {
+		waitAfter = seconds
+	}
+
+	_, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var element *cdpElementInfo
+	if err := evaluatePage(ctx, client, sessionID, &element, "focus", index, clearFirst); err != nil {
+		return nil, err
+	}
+	if element == nil {
+		return nil, fmt.Errorf("element with index %d not found in DOM state", index)
+	}
+
+	var operations []map[string]interface{}
+	inputMethod := "keyboard"
+	switch element.Type {
+	case "select", "checkbox", "radio":
+		inputMethod = "text"
+		if err := evaluatePage(ctx, client, sessionID, nil, "choose", index, value); err != nil {
+			return nil, err
+		}
+		operations = append(operations, map[string]interface{}{"type": "select", "value": value})
+	default:
+		for _, input := range parseKeyInput(valueText(value)) {
+			if input.key == nil {
+				if err := client.call(ctx, sessionID, "Input.insertText", map[string]interface{}{"text": input.text}, nil); err != nil {
+					return nil, err
+				}
+				operations = append(operations, map[string]interface{}{"type": "text", "content": input.text})
+				continue
+			}
+			if err := pressKey(ctx, client, sessionID, *input.key); err != nil {
+				return nil, err
+			}
+			operations = append(operations, input.key.operation())
+		}
+	}
+
+	if submit {
+		enter, _ := parseKeyStroke("Enter")
+		if err := pressKey(ctx, client, sessionID, enter); err != nil {
+			return nil, err
+		}
+		operations = append(operations, enter.operation())
+	}
+
+	if err := sleepContext(ctx, time.Duration(waitAfter*float64(time.Second))); err != nil {
+		return nil, err
+	}
+
+	var actualValue interface{}
+	if err := evaluatePage(ctx, client, sessionID, &actualValue, "value", index); err != nil {
+		// A submit may have loaded another page
+		actualValue = nil
+	}
+
+	return map[string]interface{}{
+		"success":              true,
+		"message":              fmt.Sprintf("Successfully set %s at index %d", element.Type, index),
+		"element_index":        index,
+		"element_type":         element.Type,
+		"input_method":         inputMethod,
+		"actual_value":         actualValue,
+		"operations_performed": operations,
+		"element_info":         element.describe(),
+	}, nil
+}
+
+// scrollPage scrolls the page or brings an element into view
+func (b *Browser) scrollPage(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	pixels := 600.0
+	if value, ok := params["pixels"].(float64); ok {
+		pixels = value
+	}
+
+	index := -1
+	switch action {
+	case "up", "down", "to_top", "to_bottom":
+	case "to_element":
+		var err error
+		if index, err = elementIndexParam(params); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scroll action: %q", action)
+	}
+
+	_, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var scrolled *struct {
+		ScrollY int `json:"scrollY"`
+	}
+	if err := evaluatePage(ctx, client, sessionID, &scrolled, "scroll", action, pixels, index); err != nil {
+		return nil, err
+	}
+	if scrolled == nil {
+		return nil, fmt.Errorf("element with index %d not found in DOM state", index)
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"action":  action,
+		"scrollY": scrolled.ScrollY,
+	}, nil
+}
+
+// manageTabs opens, switches to and closes pages
+func (b *Browser) manageTabs(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	switch action {
+	case "open":
+		url, _ := params["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("url is required for open action")
+		}
+		background, _ := params["background"].(bool)
+
+		var created struct {
+			TargetID string `json:"targetId"`
+		}
+		if err := client.call(ctx, "", "Target.createTarget", map[string]interface{}{"url": url, "background": background}, &created); err != nil {
+			return nil, err
+		}
+
+		b.mutex.Lock()
+		tabID := b.tabIDLocked(created.TargetID)
+		if !background {
+			b.activeTab = tabID
+		}
+		b.mutex.Unlock()
+
+		return map[string]interface{}{
+			"success":    true,
+			"message":    fmt.Sprintf("Opened tab %d with URL: %s", tabID, url),
+			"new_tab_id": strconv.Itoa(tabID),
+		}, nil
+
+	case "switch", "close":
+		tabID, targetID, err := b.tabByID(params["tab_id"])
+		if err != nil {
+			return nil, err
+		}
+
+		if action == "switch" {
+			if err := client.call(ctx, "", "Target.activateTarget", map[string]interface{}{"targetId": targetID}, nil); err != nil {
+				return nil, err
+			}
+			b.mutex.Lock()
+			b.activeTab = tabID
+			b.mutex.Unlock()
+			return map[string]interface{}{"success": true, "message": fmt.Sprintf("Switched to tab %d", tabID)}, nil
+		}
+
+		if err := client.call(ctx, "", "Target.closeTarget", map[string]interface{}{"targetId": targetID}, nil); err != nil {
+			return nil, err
+		}
+		b.mutex.Lock()
+		delete(b.tabs, tabID)
+		delete(b.tabIDs, targetID)
+		delete(b.sessions, targetID)
+		if b.activeTab == tabID {
+			b.activeTab = 0
+			for id := range b.tabs {
+				if b.activeTab == 0 || id < b.activeTab {
+					b.activeTab = id
+				}
+			}
+		}
+		b.mutex.Unlock()
+		return map[string]interface{}{"success": true, "message": fmt.Sprintf("Closed tab %d", tabID)}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+// takeScreenshot captures the viewport, the whole page or one element
+func (b *Browser) takeScreenshot(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	mode, _ := params["mode"].(string)
+	if mode == "" {
+		mode = "viewport"
+	}
+	format, _ := params["format"].(string)
+	if format == "" {
+		format = "png"
+	}
+
+	_, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	capture := map[string]interface{}{"format": format}
+	if quality, ok := params["quality"].(float64); ok && format == "jpeg" {
+		capture["quality"] = int(quality)
+	}
+
+	switch mode {
+	case "viewport":
+	case "full_page":
+		var metrics struct {
+			CSSContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"cssContentSize"`
+		}
+		if err := client.call(ctx, sessionID, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return nil, err
+		}
+		capture["captureBeyondViewport"] = true
+		capture["clip"] = map[string]interface{}{
+			"x": 0, "y": 0, "scale": 1,
+			"width":  metrics.CSSContentSize.Width,
+			"height": metrics.CSSContentSize.Height,
+		}
+	case "element":
+		index, err := elementIndexParam(params)
+		if err != nil {
+			return nil, err
+		}
+		var bounds *struct {
+			X      float64 `json:"x"`
+			Y      float64 `json:"y"`
+			Width  float64 `json:"width"`
+			Height float64 `json:"height"`
+		}
+		if err := evaluatePage(ctx, client, sessionID, &bounds, "bounds", index); err != nil {
+			return nil, err
+		}
+		if bounds == nil {
+			return nil, fmt.Errorf("element with index %d not found in DOM state", index)
+		}
+		capture["captureBeyondViewport"] = true
+		capture["clip"] = map[string]interface{}{
+			"x": bounds.X, "y": bounds.Y, "scale": 1,
+			"width":  bounds.Width,
+			"height": bounds.Height,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported screenshot mode: %q", mode)
+	}
+
+	var screenshot struct {
+		Data string `json:"data"`
+	}
+	if err := client.call(ctx, sessionID, "Page.captureScreenshot", capture, &screenshot); err != nil {
+		return nil, err
+	}
+
+	var page cdpPageInfo
+	if err := evaluatePage(ctx, client, sessionID, &page, "page"); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"data":     screenshot.Data,
+		"mimeType": "image/" + format,
+		"mode":     mode,
+		"url":      page.URL,
+		"title":    page.Title,
+	}, nil
+}
+
+// navigationTimeout reads the timeout param of navigations: 'auto' or a
+// timeout in milliseconds
+func navigationTimeout(params map[string]interface{}) (time.Duration, error) {
+	raw, _ := params["timeout"].(string)
+	if raw == "" || raw == "auto" {
+		return cdpDefaultNavigationTimeout, nil
+	}
+	milliseconds, err := strconv.Atoi(raw)
+	if err != nil || milliseconds <= 0 {
+		return 0, fmt.Errorf("timeout must be 'auto' or a timeout in milliseconds")
+	}
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+// tabSession returns the tab a request acts on and the session attached to it
+func (b *Browser) tabSession(ctx context.Context, client *cdpClient, params map[string]interface{}) (int, string, error) {
+	tabID, targetID, err := b.targetTab(params)
+	if err != nil {
+		return 0, "", err
+	}
+
+	sessionID, err := b.session(ctx, client, targetID)
+	if err != nil {
+		return 0, "", err
+	}
+	return tabID, sessionID, nil
+}
+
+// tabByID returns the page of a tab ID given as a string, as manage_tabs does
+func (b *Browser) tabByID(raw interface{}) (int, string, error) {
+	id, ok := raw.(string)
+	if !ok || id == "" {
+		return 0, "", fmt.Errorf("tab_id is required")
+	}
+	tabID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid tab_id: %q", id)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	targetID, ok := b.tabs[tabID]
+	if !ok {
+		return 0, "", fmt.Errorf("tab %d not found", tabID)
+	}
+	return tabID, targetID, nil
+}
+
+// evaluatePage calls a function of the page script and decodes its result into
+// result, which may be nil
+func evaluatePage(ctx context.Context, client *cdpClient, sessionID string, result interface{}, function string, args ...interface{}) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments of %s: %w", function, err)
+	}
+
+	var evaluated struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	err = client.call(ctx, sessionID, "Runtime.evaluate", map[string]interface{}{
+		"expression":    fmt.Sprintf("(%s)[%q](...%s)", cdpPageScript, function, argsJSON),
+		"returnByValue": true,
+	}, &evaluated)
+	if err != nil {
+		return err
+	}
+
+	if details := evaluated.ExceptionDetails; details != nil {
+		message := details.Exception.Description
+		if message == "" {
+			message = details.Text
+		}
+		return fmt.Errorf("page script %s failed: %s", function, message)
+	}
+
+	if result == nil || len(evaluated.Result.Value) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(evaluated.Result.Value, result); err != nil {
+		return fmt.Errorf("invalid result of page script %s: %w", function, err)
+	}
+	return nil
+}
+
+// formatDomState renders the interactive elements like the extension's formattedDom
+func formatDomState(state cdpDomState) string {
+	if len(state.Elements) == 0 {
+		return "empty page"
+	}
+
+	var builder strings.Builder
+	if state.PixelsAbove > 0 {
+		fmt.Fprintf(&builder, "... %d pixels above - scroll up to see more ...\n", state.PixelsAbove)
+	} else {
+		builder.WriteString("[Start of page]\n")
+	}
+
+	for _, element := range state.Elements {
+		fmt.Fprintf(&builder, "[%d]<%s", element.Index, element.TagName)
+		for _, name := range cdpFormattedAttributes {
+			if value, ok := element.Attributes[name]; ok {
+				fmt.Fprintf(&builder, " %s=%q", name, value)
+			}
+		}
+		fmt.Fprintf(&builder, ">%s />\n", element.Text)
+	}
+
+	if state.PixelsBelow > 0 {
+		fmt.Fprintf(&builder, "... %d pixels below - scroll down to see more ...", state.PixelsBelow)
+	} else {
+		builder.WriteString("[End of page]\n")
+	}
+	return builder.String()
+}
+
+// describe returns the element_info of click_element and type_value results
+func (e *cdpElementInfo) describe() map[string]interface{} {
+	return map[string]interface{}{
+		"tag_name": e.TagName,
+		"text":     e.Text,
+		"type":     e.Type,
+	}
+}
+
+// elementIndexParam returns the element_index param
+func elementIndexParam(params map[string]interface{}) (int, error) {
+	index, ok := params["element_index"].(float64)
+	if !ok || index < 0 || index != float64(int(index)) {
+		return 0, fmt.Errorf("element_index must be a non-negative integer")
+	}
+	return int(index), nil
+}
+
+// boolOption returns a boolean option, or fallback if it is not set
+func boolOption(options map[string]interface{}, name string, fallback bool) bool {
+	if value, ok := options[name].(bool); ok {
+		return value
+	}
+	return fallback
+}
+
+// valueText returns the text typed for a type_value value
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CDP modifier flags of Input.dispatchKeyEvent
+const (
+	cdpModifierAlt   = 1
+	cdpModifierCtrl  = 2
+	cdpModifierMeta  = 4
+	cdpModifierShift = 8
+)
+
+// keyStroke is a key press for Input.dispatchKeyEvent
+type keyStroke struct {
+	key       string
+	code      string
+	keyCode   int
+	text      string   // Inserted by the key, empty for keys that insert nothing
+	modifiers int      // cdpModifier flags
+	commands  []string // Editing commands of shortcuts, which headless Chrome does not run itself
+}
+
+// keyInput is a part of a type_value value: text, or a key in braces
+type keyInput struct {
+	text string
+	key  *keyStroke
+}
+
+// cdpKeys are the special keys of type_value, by lower case name
+var cdpKeys = map[string]keyStroke{
+	"enter":      {key: "Enter", code: "Enter", keyCode: 13, text: "\r"},
+	"tab":        {key: "Tab", code: "Tab", keyCode: 9},
+	"escape":     {key: "Escape", code: "Escape", keyCode: 27},
+	"esc":        {key: "Escape", code: "Escape", keyCode: 27},
+	"backspace":  {key: "Backspace", code: "Backspace", keyCode: 8},
+	"delete":     {key: "Delete", code: "Delete", keyCode: 46},
+	"space":      {key: " ", code: "Space", keyCode: 32, text: " "},
+	"arrowup":    {key: "ArrowUp", code: "ArrowUp", keyCode: 38},
+	"arrowdown":  {key: "ArrowDown", code: "ArrowDown", keyCode: 40},
+	"arrowleft":  {key: "ArrowLeft", code: "ArrowLeft", keyCode: 37},
+	"arrowright": {key: "ArrowRight", code: "ArrowRight", keyCode: 39},
+	"up":         {key: "ArrowUp", code: "ArrowUp", keyCode: 38},
+	"down":       {key: "ArrowDown", code: "ArrowDown", keyCode: 40},
+	"left":       {key: "ArrowLeft", code: "ArrowLeft", keyCode: 37},
+	"right":      {key: "ArrowRight", code: "ArrowRight", keyCode: 39},
+	"home":       {key: "Home", code: "Home", keyCode: 36},
+	"end":        {key: "End", code: "End", keyCode: 35},
+	"pageup":     {key: "PageUp", code: "PageUp", keyCode: 33},
+	"pagedown":   {key: "PageDown", code: "PageDown", keyCode: 34},
+}
+
+// cdpModifiers are the modifier names of key combinations
+var cdpModifiers = map[string]int{
+	"alt":     cdpModifierAlt,
+	"option":  cdpModifierAlt,
+	"ctrl":    cdpModifierCtrl,
+	"control": cdpModifierCtrl,
+	"meta":    cdpModifierMeta,
+	"cmd":     cdpModifierMeta,
+	"command": cdpModifierMeta,
+	"shift":   cdpModifierShift,
+}
+
+// cdpShortcutCommands are the editing commands of Ctrl or Meta shortcuts
+var cdpShortcutCommands = map[string]string{
+	"a": "selectAll",
+	"c": "copy",
+	"x": "cut",
+	"v": "paste",
+	"z": "undo",
+}
+
+// keyPattern matches special keys like {Enter} and combinations like {Ctrl+A}
+var keyPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// parseKeyInput splits a type_value value into text and keys. Braces that do
+// not name a key are typed as text.
+func parseKeyInput(value string) []keyInput {
+	var inputs []keyInput
+	last := 0
+	for _, match := range keyPattern.FindAllStringSubmatchIndex(value, -1) {
+		key, ok := parseKeyStroke(value[match[2]:match[3]])
+		if !ok {
+			continue
+		}
+		if match[0] > last {
+			inputs = append(inputs, keyInput{text: value[last:match[0]]})
+		}
+		inputs = append(inputs, keyInput{key: &key})
+		last = match[1]
+	}
+	if last < len(value) {
+		inputs = append(inputs, keyInput{text: value[last:]})
+	}
+	return inputs
+}
+
+// parseKeyStroke parses a key name like "Enter" or a combination like "Ctrl+Shift+A"
+func parseKeyStroke(name string) (keyStroke, bool) {
+	parts := strings.Split(name, "+")
+	modifiers := 0
+	for _, part := range parts[:len(parts)-1] {
+		modifier, ok := cdpModifiers[strings.ToLower(strings.TrimSpace(part))]
+		if !ok {
+			return keyStroke{}, false
+		}
+		modifiers |= modifier
+	}
+
+	last := strings.TrimSpace(parts[len(parts)-1])
+	key, ok := cdpKeys[strings.ToLower(last)]
+	if !ok {
+		// A letter or digit, which only needs braces in a combination
+		if len(last) != 1 || modifiers == 0 {
+			return keyStroke{}, false
+		}
+		char := last[0]
+		switch {
+		case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z':
+			upper := strings.ToUpper(last)
+			key = keyStroke{key: strings.ToLower(last), code: "Key" + upper, keyCode: int(upper[0]), text: strings.ToLower(last)}
+			if modifiers&cdpModifierShift != 0 {
+				key.key, key.text = upper, upper
+			}
+		case char >= '0' && char <= '9':
+			key = keyStroke{key: last, code: "Digit" + last, keyCode: int(char), text: last}
+		default:
+			return keyStroke{}, false
+		}
+	}
+
+	key.modifiers = modifiers
+	if modifiers&(cdpModifierCtrl|cdpModifierMeta|cdpModifierAlt) != 0 {
+		// Shortcuts insert no text
+		key.text = ""
+		if modifiers&(cdpModifierCtrl|cdpModifierMeta) != 0 {
+			if command, ok := cdpShortcutCommands[strings.ToLower(last)]; ok {
+				key.commands = []string{command}
+			}
+		}
+	}
+	return key, true
+}
+
+// operation describes a key press in the operations_performed of type_value
+func (k keyStroke) operation() map[string]interface{} {
+	if k.modifiers == 0 {
+		return map[string]interface{}{"type": "specialKey", "key": k.key}
+	}
+
+	var modifiers []string
+	for _, modifier := range []struct {
+		flag int
+		name string
+	}{{cdpModifierCtrl, "Ctrl"}, {cdpModifierAlt, "Alt"}, {cdpModifierShift, "Shift"}, {cdpModifierMeta, "Meta"}} {
+		if k.modifiers&modifier.flag != 0 {
+			modifiers = append(modifiers, modifier.name)
+		}
+	}
+	return map[string]interface{}{"type": "modifierCombination", "modifiers": modifiers, "key": k.key}
+}
+
+// pressKey sends the key down and key up events of a key stroke
+func pressKey(ctx context.Context, client *cdpClient, sessionID string, key keyStroke) error {
+	down := map[string]interface{}{
+		"type":                  "rawKeyDown",
+		"key":                   key.key,
+		"code":                  key.code,
+		"windowsVirtualKeyCode": key.keyCode,
+		"modifiers":             key.modifiers,
+	}
+	if key.text != "" {
+		down["type"] = "keyDown"
+		down["text"] = key.text
+	}
+	if len(key.commands) > 0 {
+		down["commands"] = key.commands
+	}
+	if err := client.call(ctx, sessionID, "Input.dispatchKeyEvent", down, nil); err != nil {
+		return err
+	}
+
+	return client.call(ctx, sessionID, "Input.dispatchKeyEvent", map[string]interface{}{
+		"type":                  "keyUp",
+		"key":                   key.key,
+		"code":                  key.code,
+		"windowsVirtualKeyCode": key.keyCode,
+		"modifiers":             key.modifiers,
+	}, nil)
+}
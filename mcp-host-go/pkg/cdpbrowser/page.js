@@ -0,0 +1,269 @@
+// Page functions of the CDP backend. The host evaluates this object literal in
+// the inspected page and calls one of its functions. getDomState numbers the
+// visible interactive elements; the other functions find them by that index.
+({
+  indexAttribute: 'data-algonius-index',
+
+  interactiveSelector: [
+    'a[href]',
+    'button',
+    'input:not([type=hidden])',
+    'select',
+    'textarea',
+    'summary',
+    '[role=button]',
+    '[role=link]',
+    '[role=checkbox]',
+    '[role=radio]',
+    '[role=tab]',
+    '[role=menuitem]',
+    '[onclick]',
+    '[contenteditable=""]',
+    '[contenteditable=true]',
+    '[tabindex]:not([tabindex="-1"])',
+  ].join(','),
+
+  reportedAttributes: ['id', 'name', 'type', 'role', 'aria-label', 'placeholder', 'href', 'value', 'title', 'alt'],
+
+  getDomState() {
+    for (const element of document.querySelectorAll('[' + this.indexAttribute + ']')) {
+      element.removeAttribute(this.indexAttribute);
+    }
+
+    const elements = [];
+    for (const element of document.querySelectorAll(this.interactiveSelector)) {
+      if (!this.isVisible(element)) {
+        continue;
+      }
+      const index = elements.length;
+      element.setAttribute(this.indexAttribute, String(index));
+
+      const attributes = {};
+      for (const name of this.reportedAttributes) {
+        const value = element.getAttribute(name);
+        if (value !== null && value !== '') {
+          attributes[name] = value;
+        }
+      }
+
+      const rect = element.getBoundingClientRect();
+      elements.push({
+        index,
+        tagName: element.tagName.toLowerCase(),
+        text: this.text(element),
+        attributes,
+        isInViewport: rect.bottom > 0 && rect.right > 0 && rect.top < innerHeight && rect.left < innerWidth,
+        selector: this.selector(element),
+      });
+    }
+
+    const scrollHeight = document.documentElement ? document.documentElement.scrollHeight : 0;
+    return {
+      elements,
+      url: location.href,
+      title: document.title,
+      pixelsAbove: Math.round(scrollY),
+      pixelsBelow: Math.max(0, Math.round(scrollHeight - innerHeight - scrollY)),
+    };
+  },
+
+  isVisible(element) {
+    const rect = element.getBoundingClientRect();
+    if (rect.width === 0 || rect.height === 0) {
+      return false;
+    }
+    const style = getComputedStyle(element);
+    return style.visibility !== 'hidden' && style.display !== 'none';
+  },
+
+  text(element) {
+    const text = element.innerText || element.value || element.getAttribute('aria-label') || '';
+    return String(text).replace(/\s+/g, ' ').trim().slice(0, 100);
+  },
+
+  selector(element) {
+    const parts = [];
+    for (let current = element; current && current.nodeType === Node.ELEMENT_NODE; current = current.parentElement) {
+      if (current.id) {
+        parts.unshift('#' + CSS.escape(current.id));
+        break;
+      }
+      let part = current.tagName.toLowerCase();
+      const parent = current.parentElement;
+      if (parent) {
+        const siblings = Array.from(parent.children).filter(sibling => sibling.tagName === current.tagName);
+        if (siblings.length > 1) {
+          part += ':nth-of-type(' + (siblings.indexOf(current) + 1) + ')';
+        }
+      }
+      parts.unshift(part);
+    }
+    return parts.join(' > ');
+  },
+
+  find(index) {
+    return document.querySelector('[' + this.indexAttribute + '="' + index + '"]');
+  },
+
+  elementType(element) {
+    const tagName = element.tagName.toLowerCase();
+    if (tagName === 'input') {
+      const type = (element.getAttribute('type') || 'text').toLowerCase();
+      return type === 'checkbox' || type === 'radio' ? type : 'input';
+    }
+    if (tagName === 'select' || tagName === 'textarea') {
+      return tagName;
+    }
+    return element.isContentEditable ? 'contenteditable' : tagName;
+  },
+
+  describe(element) {
+    return {
+      tagName: element.tagName.toLowerCase(),
+      text: this.text(element),
+      type: this.elementType(element),
+    };
+  },
+
+  // Scrolls an element into view and returns its center in viewport coordinates
+  locate(index) {
+    const element = this.find(index);
+    if (!element) {
+      return null;
+    }
+    element.scrollIntoView({ block: 'center', inline: 'center' });
+    const rect = element.getBoundingClientRect();
+    return {
+      ...this.describe(element),
+      x: rect.left + rect.width / 2,
+      y: rect.top + rect.height / 2,
+    };
+  },
+
+  focus(index, clearFirst) {
+    const element = this.find(index);
+    if (!element) {
+      return null;
+    }
+    element.scrollIntoView({ block: 'center', inline: 'center' });
+    element.focus();
+    if (clearFirst) {
+      if (element.isContentEditable) {
+        element.textContent = '';
+      } else if ('value' in element && this.elementType(element) !== 'select') {
+        element.value = '';
+      }
+      element.dispatchEvent(new Event('input', { bubbles: true }));
+    }
+    return this.describe(element);
+  },
+
+  // Selects options of a select, or checks a checkbox or radio button
+  choose(index, value) {
+    const element = this.find(index);
+    if (!element) {
+      return null;
+    }
+    if (element.tagName.toLowerCase() === 'select') {
+      const wanted = (Array.isArray(value) ? value : [value]).map(String);
+      for (const option of element.options) {
+        option.selected = wanted.includes(option.value) || wanted.includes(option.text.trim());
+      }
+    } else {
+      element.checked = Boolean(value);
+    }
+    element.dispatchEvent(new Event('input', { bubbles: true }));
+    element.dispatchEvent(new Event('change', { bubbles: true }));
+    return this.value(index);
+  },
+
+  value(index) {
+    const element = this.find(index);
+    if (!element) {
+      return null;
+    }
+    if (element.tagName.toLowerCase() === 'select') {
+      const selected = Array.from(element.selectedOptions).map(option => option.value);
+      return element.multiple ? selected : selected[0] ?? '';
+    }
+    const type = this.elementType(element);
+    if (type === 'checkbox' || type === 'radio') {
+      return element.checked;
+    }
+    return element.isContentEditable ? element.textContent : element.value;
+  },
+
+  scroll(action, pixels, index) {
+    switch (action) {
+      case 'up':
+        scrollBy(0, -pixels);
+        break;
+      case 'down':
+        scrollBy(0, pixels);
+        break;
+      case 'to_top':
+        scrollTo(0, 0);
+        break;
+      case 'to_bottom':
+        scrollTo(0, document.documentElement.scrollHeight);
+        break;
+      case 'to_element': {
+        const element = this.find(index);
+        if (!element) {
+          return null;
+        }
+        element.scrollIntoView({ block: 'center', inline: 'center' });
+        break;
+      }
+    }
+    return { scrollY: Math.round(scrollY) };
+  },
+
+  // Returns the bounds of an element in document coordinates
+  bounds(index) {
+    const element = this.find(index);
+    if (!element) {
+      return null;
+    }
+    element.scrollIntoView({ block: 'center', inline: 'center' });
+    const rect = element.getBoundingClientRect();
+    return { x: rect.left + scrollX, y: rect.top + scrollY, width: rect.width, height: rect.height };
+  },
+
+  page() {
+    return { url: location.href, title: document.title };
+  },
+
+  // Returns the first wait_for condition that holds, or an empty string. DOM
+  // changes are recorded by an observer installed on the first check.
+  checkConditions(conditions) {
+    if (conditions.selector) {
+      const element = conditions.xpath
+        ? document.evaluate(conditions.selector, document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null)
+            .singleNodeValue
+        : document.querySelector(conditions.selector);
+      const visible = element instanceof Element && this.isVisible(element);
+      if (visible === (conditions.state === 'appear')) {
+        return 'selector';
+      }
+    }
+    if (conditions.text && document.body && document.body.innerText.includes(conditions.text)) {
+      return 'text';
+    }
+    if (conditions.urlPattern && new RegExp(conditions.urlPattern).test(location.href)) {
+      return 'url';
+    }
+    if (conditions.domStableMs) {
+      if (window.__algoniusLastMutation === undefined) {
+        window.__algoniusLastMutation = performance.now();
+        new MutationObserver(() => {
+          window.__algoniusLastMutation = performance.now();
+        }).observe(document, { subtree: true, childList: true, attributes: true, characterData: true });
+      }
+      if (performance.now() - window.__algoniusLastMutation >= conditions.domStableMs) {
+        return 'dom_stable';
+      }
+    }
+    return '';
+  },
+})
@@ -0,0 +1,205 @@
+package cdpbrowser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// wait_for defaults of the CDP backend
+const (
+	cdpDefaultWaitTimeout = 10 * time.Second
+	cdpWaitPollInterval   = 100 * time.Millisecond
+	cdpNetworkEventBuffer = 1024 // Network events kept between polls
+)
+
+// cdpWaitConditions are the page-side wait_for conditions checked by the page script
+type cdpWaitConditions struct {
+	Selector    string  `json:"selector,omitempty"`
+	XPath       bool    `json:"xpath,omitempty"`
+	State       string  `json:"state,omitempty"`
+	Text        string  `json:"text,omitempty"`
+	URLPattern  string  `json:"urlPattern,omitempty"`
+	DomStableMs float64 `json:"domStableMs,omitempty"`
+}
+
+// waitFor polls the page until a wait_for condition holds. Network idleness
+// is tracked from the requests the page makes after the wait starts.
+func (b *Browser) waitFor(ctx context.Context, client *cdpClient, params map[string]interface{}) (interface{}, error) {
+	conditions := cdpWaitConditions{
+		State: "appear",
+	}
+	var waitedFor []string
+	if selector, _ := params["selector"].(string); selector != "" {
+		conditions.Selector = selector
+		conditions.XPath = params["selector_type"] == "xpath"
+		if state, _ := params["selector_state"].(string); state == "disappear" {
+			conditions.State = state
+		}
+		waitedFor = append(waitedFor, fmt.Sprintf("selector '%s' to %s", selector, conditions.State))
+	}
+	if text, _ := params["text"].(string); text != "" {
+		conditions.Text = text
+		waitedFor = append(waitedFor, fmt.Sprintf("text '%s'", text))
+	}
+	if pattern, _ := params["url_pattern"].(string); pattern != "" {
+		conditions.URLPattern = pattern
+		waitedFor = append(waitedFor, fmt.Sprintf("URL matching /%s/", pattern))
+	}
+	if quiet, ok := params["dom_stable_ms"].(float64); ok && quiet > 0 {
+		conditions.DomStableMs = quiet
+		waitedFor = append(waitedFor, fmt.Sprintf("DOM unchanged for %.0fms", quiet))
+	}
+	networkIdle, _ := params["network_idle_ms"].(float64)
+	if networkIdle > 0 {
+		waitedFor = append(waitedFor, fmt.Sprintf("network idle for %.0fms", networkIdle))
+	}
+	if len(waitedFor) == 0 {
+		return nil, fmt.Errorf("at least one condition is required: selector, text, url_pattern, dom_stable_ms or network_idle_ms")
+	}
+
+	timeout := cdpDefaultWaitTimeout
+	if value, ok := params["timeout"].(float64); ok && value > 0 {
+		timeout = time.Duration(value) * time.Millisecond
+	}
+
+	_, sessionID, err := b.tabSession(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var network *cdpNetworkMonitor
+	if networkIdle > 0 {
+		network, err = watchNetwork(ctx, client, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		defer network.close()
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	pageSide := conditions.Selector != "" || conditions.Text != "" || conditions.URLPattern != "" || conditions.DomStableMs > 0
+	for {
+		met := ""
+		if pageSide {
+			if err := evaluatePage(ctx, client, sessionID, &met, "checkConditions", conditions); err != nil {
+				// Pages that are navigating have no context to evaluate in; check again on the next poll
+				var protocolErr *cdpError
+				if !errors.As(err, &protocolErr) {
+					return nil, err
+				}
+			}
+		}
+		if met == "" && network != nil && network.idleFor(time.Duration(networkIdle)*time.Millisecond) {
+			met = "network_idle"
+		}
+
+		if met != "" {
+			elapsed := time.Since(start).Milliseconds()
+			var page cdpPageInfo
+			if err := evaluatePage(ctx, client, sessionID, &page, "page"); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"success":    true,
+				"message":    fmt.Sprintf("Condition %s met after %dms", met, elapsed),
+				"condition":  met,
+				"elapsed_ms": elapsed,
+				"url":        page.URL,
+				"title":      page.Title,
+			}, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("timed out after %dms waiting for %s", timeout.Milliseconds(), strings.Join(waitedFor, " or "))
+		}
+		if err := sleepContext(ctx, min(cdpWaitPollInterval, time.Until(deadline))); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// cdpNetworkMonitor counts the requests of a page in flight
+type cdpNetworkMonitor struct {
+	started      *cdpSubscription
+	finished     *cdpSubscription
+	failed       *cdpSubscription
+	cancels      []func()
+	inFlight     map[string]struct{}
+	ended        map[string]struct{} // Requests whose end was taken before their start
+	lastActivity time.Time
+}
+
+// watchNetwork enables network events for a page and starts counting its requests
+func watchNetwork(ctx context.Context, client *cdpClient, sessionID string) (*cdpNetworkMonitor, error) {
+	monitor := &cdpNetworkMonitor{
+		inFlight:     make(map[string]struct{}),
+		ended:        make(map[string]struct{}),
+		lastActivity: time.Now(),
+	}
+	var cancel func()
+	monitor.started, cancel = client.subscribeBuffered(sessionID, "Network.requestWillBeSent", cdpNetworkEventBuffer)
+	monitor.cancels = append(monitor.cancels, cancel)
+	monitor.finished, cancel = client.subscribeBuffered(sessionID, "Network.loadingFinished", cdpNetworkEventBuffer)
+	monitor.cancels = append(monitor.cancels, cancel)
+	monitor.failed, cancel = client.subscribeBuffered(sessionID, "Network.loadingFailed", cdpNetworkEventBuffer)
+	monitor.cancels = append(monitor.cancels, cancel)
+
+	if err := client.call(ctx, sessionID, "Network.enable", nil, nil); err != nil {
+		monitor.close()
+		return nil, err
+	}
+	return monitor, nil
+}
+
+// idleFor takes the network events received so far and reports whether no
+// request has been in flight for d. Events of different methods may be taken
+// out of order, so an end can come before its start.
+func (n *cdpNetworkMonitor) idleFor(d time.Duration) bool {
+	var event struct {
+		RequestID string `json:"requestId"`
+	}
+	for {
+		select {
+		case params := <-n.started.events:
+			if json.Unmarshal(params, &event) == nil {
+				if _, ended := n.ended[event.RequestID]; ended {
+					delete(n.ended, event.RequestID)
+				} else {
+					n.inFlight[event.RequestID] = struct{}{}
+				}
+			}
+		case params := <-n.finished.events:
+			if json.Unmarshal(params, &event) == nil {
+				n.end(event.RequestID)
+			}
+		case params := <-n.failed.events:
+			if json.Unmarshal(params, &event) == nil {
+				n.end(event.RequestID)
+			}
+		default:
+			return len(n.inFlight) == 0 && time.Since(n.lastActivity) >= d
+		}
+		n.lastActivity = time.Now()
+	}
+}
+
+// end records the end of a request
+func (n *cdpNetworkMonitor) end(requestID string) {
+	if _, ok := n.inFlight[requestID]; ok {
+		delete(n.inFlight, requestID)
+		return
+	}
+	n.ended[requestID] = struct{}{}
+}
+
+// close stops collecting network events
+func (n *cdpNetworkMonitor) close() {
+	for _, cancel := range n.cancels {
+		cancel()
+	}
+}
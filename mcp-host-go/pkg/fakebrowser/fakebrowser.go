@@ -0,0 +1,217 @@
+package fakebrowser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/messaging"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Version is reported as the extension version by a fake browser
+const Version = "fakebrowser"
+
+// method serves one RPC method of the extension protocol; b.mutex is held
+type method func(params map[string]interface{}) (interface{}, error)
+
+// Call is an RPC request served by a fake browser
+type Call struct {
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Error  string                 `json:"error,omitempty"` // Message of the RPC error, if the request failed
+}
+
+// tab is an open tab showing a scripted page
+type tab struct {
+	id      int
+	page    *Page
+	scrollY int
+	values  map[int]interface{} // Element index to the value typed, chosen or checked
+	history []*Page             // Pages visited, for going back and forward
+	current int                 // Index of page in history
+}
+
+// Browser implements the types.Messaging interface without Chrome: it serves
+// the extension's RPC methods from the scripted pages of a Script. Clicks and
+// submits follow the script's outcomes, typed values are remembered per tab,
+// and tab changes are delivered to handlers as browser_state_changed messages.
+// Requests are answered at once; waits asked for by tools are skipped.
+type Browser struct {
+	*messaging.LocalBackend
+	logger  logger.Logger
+	script  *Script
+	pages   map[string]*Page
+	methods map[string]method
+
+	mutex     sync.Mutex
+	started   bool
+	tabs      map[int]*tab
+	nextTabID int
+	activeTab int
+	changes   []map[string]interface{} // browser_state_changed messages not yet delivered
+	calls     []Call
+}
+
+// Config contains configuration for Browser
+type Config struct {
+	Logger       logger.Logger
+	Script       *Script
+	Capabilities *types.ExtensionCapabilities
+}
+
+// NewBrowser creates a new fake browser serving a script
+func NewBrowser(config Config) (*Browser, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+	if config.Script == nil {
+		return nil, fmt.Errorf("script is required")
+	}
+	if err := config.Script.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+
+	b := &Browser{
+		LocalBackend: messaging.NewLocalBackend(messaging.LocalBackendConfig{
+			Logger:       config.Logger,
+			Name:         "fake browser",
+			Capabilities: config.Capabilities,
+		}),
+		logger:    config.Logger,
+		script:    config.Script,
+		pages:     make(map[string]*Page, len(config.Script.Pages)),
+		tabs:      make(map[int]*tab),
+		nextTabID: 1,
+	}
+	for i := range config.Script.Pages {
+		page := &config.Script.Pages[i]
+		b.pages[page.URL] = page
+	}
+
+	b.methods = map[string]method{
+		"get_browser_state": b.getBrowserState,
+		"get_dom_state":     b.getDomState,
+		"navigate_to":       b.navigateTo,
+		"navigate_history":  b.navigateHistory,
+		"click_element":     b.clickElement,
+		"type_value":        b.typeValue,
+		"scroll_page":       b.scrollPage,
+		"manage_tabs":       b.manageTabs,
+		"take_screenshot":   b.takeScreenshot,
+	}
+
+	return b, nil
+}
+
+// Start opens the first tab on the start page and reports the browser as connected
+func (b *Browser) Start() error {
+	b.mutex.Lock()
+	if b.started {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.started = true
+
+	startURL := b.script.StartURL
+	if startURL == "" {
+		startURL = b.script.Pages[0].URL
+	}
+	b.activeTab = b.openTabLocked(b.pages[startURL]).id
+	b.changes = nil
+	b.mutex.Unlock()
+
+	b.logger.Info("Starting fake browser",
+		zap.Int("pages", len(b.script.Pages)),
+		zap.String("startURL", startURL))
+
+	b.SetConnected(Version, messaging.MethodNames(b.methods))
+	return nil
+}
+
+// RpcRequest serves an RPC method of the extension protocol from the script
+func (b *Browser) RpcRequest(ctx context.Context, request types.RpcRequest, options types.RpcOptions) (types.RpcResponse, error) {
+	method, ok := b.methods[request.Method]
+	if !ok {
+		return types.RpcResponse{}, b.UnsupportedMethod(request.Method)
+	}
+
+	return b.Serve(ctx, request, options, func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+		b.mutex.Lock()
+		result, err := method(params)
+		call := Call{Method: request.Method, Params: params}
+		if err != nil {
+			call.Error = err.Error()
+		}
+		b.calls = append(b.calls, call)
+		changes := b.changes
+		b.changes = nil
+		b.mutex.Unlock()
+
+		b.DeliverStateChanges(changes...)
+		return result, err
+	})
+}
+
+// Calls returns the RPC requests served so far, for checking what an agent did
+func (b *Browser) Calls() []Call {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]Call(nil), b.calls...)
+}
+
+// openTabLocked opens a tab on a page; b.mutex must be held
+func (b *Browser) openTabLocked(page *Page) *tab {
+	t := &tab{id: b.nextTabID, page: page, values: make(map[int]interface{}), history: []*Page{page}}
+	b.nextTabID++
+	b.tabs[t.id] = t
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_created", "tabId": t.id, "url": page.URL})
+	return t
+}
+
+// loadLocked navigates a tab to a page, dropping the pages it could go forward to; b.mutex must be held
+func (b *Browser) loadLocked(t *tab, page *Page) {
+	t.history = append(t.history[:t.current+1], page)
+	t.current = len(t.history) - 1
+	b.showLocked(t, page)
+}
+
+// showLocked shows a page in a tab, forgetting what was typed and scrolled; b.mutex must be held
+func (b *Browser) showLocked(t *tab, page *Page) {
+	t.page = page
+	t.scrollY = 0
+	t.values = make(map[int]interface{})
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_updated", "tabId": t.id, "url": page.URL})
+}
+
+// closeTabLocked closes a tab, activating the lowest remaining one if it was active; b.mutex must be held
+func (b *Browser) closeTabLocked(t *tab) {
+	delete(b.tabs, t.id)
+	if b.activeTab == t.id {
+		b.activeTab = 0
+		for id := range b.tabs {
+			if b.activeTab == 0 || id < b.activeTab {
+				b.activeTab = id
+			}
+		}
+	}
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_removed", "tabId": t.id})
+}
+
+// page returns the scripted page at a URL
+func (b *Browser) page(rawURL string) (*Page, error) {
+	page, ok := b.pages[rawURL]
+	if !ok {
+		return nil, fmt.Errorf("net::ERR_NAME_NOT_RESOLVED: %s is not a scripted page", rawURL)
+	}
+	return page, nil
+}
+
+// targetTabLocked returns the tab named by a numeric tab_id param, or the active tab; b.mutex must be held
+func (b *Browser) targetTabLocked(params map[string]interface{}) (*tab, error) {
+	_, t, err := messaging.TargetTab(params, b.tabs, b.activeTab)
+	return t, err
+}
@@ -0,0 +1,227 @@
+package fakebrowser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestBrowser creates a fake browser serving the shop script
+func newTestBrowser(t *testing.T) (*Browser, *types.ExtensionCapabilities) {
+	script, err := LoadScript("testdata/shop.yaml")
+	require.NoError(t, err)
+
+	capabilities := &types.ExtensionCapabilities{}
+	browser, err := NewBrowser(Config{
+		Logger:       logger.NewLoggerFromZap(zap.NewNop()),
+		Script:       script,
+		Capabilities: capabilities,
+	})
+	require.NoError(t, err)
+	return browser, capabilities
+}
+
+// request makes an RPC request and returns its result, failing on errors
+func request(t *testing.T, browser *Browser, method string, params map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	response, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: method, Params: params}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.Nil(t, response.Error, "%s failed: %+v", method, response.Error)
+	return response.Result.(map[string]interface{})
+}
+
+// requestError makes an RPC request that must fail and returns the error message
+func requestError(t *testing.T, browser *Browser, method string, params map[string]interface{}) string {
+	t.Helper()
+	response, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: method, Params: params}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error, "%s did not fail", method)
+	return response.Error.Message
+}
+
+func TestParseScript(t *testing.T) {
+	script, err := ParseScript([]byte(`{"pages": [{"url": "https://a.example/", "elements": [{"tag": "a", "on_click": {"navigate": "/"}}]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "https://a.example/", script.Pages[0].URL)
+
+	tests := []struct {
+		name   string
+		script string
+		err    string
+	}{
+		{"no pages", `pages: []`, "no pages"},
+		{"unknown field", "pages:\n  - url: https://a.example/\n    titel: A", "field titel not found"},
+		{"duplicate page", "pages:\n  - url: https://a.example/\n  - url: https://a.example/", "scripted twice"},
+		{"unknown start", "start_url: https://b.example/\npages:\n  - url: https://a.example/", "start_url"},
+		{"element without tag", "pages:\n  - url: https://a.example/\n    elements: [{text: A}]", "has no tag"},
+		{"unscripted outcome", "pages:\n  - url: https://a.example/\n    elements: [{tag: a, on_click: {navigate: /b}}]", "https://a.example/b, which is not a scripted page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScript([]byte(tt.script))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestBrowser_ScriptedFlow(t *testing.T) {
+	browser, capabilities := newTestBrowser(t)
+
+	// Requests fail like requests to a disconnected extension until started
+	_, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: "get_browser_state"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+
+	var statuses []types.ConnectionStatus
+	browser.OnConnectionChange(func(status types.ConnectionStatus) { statuses = append(statuses, status) })
+	var changes []map[string]interface{}
+	browser.RegisterHandler("browser_state_changed", func(data interface{}) error {
+		changes = append(changes, data.(map[string]interface{}))
+		return nil
+	})
+
+	require.NoError(t, browser.Start())
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].IsConnected)
+	assert.True(t, browser.ConnectionStatus().IsConnected)
+
+	info, known := capabilities.Get()
+	require.True(t, known)
+	assert.True(t, info.Compatible)
+	assert.Equal(t, Version, info.Version)
+	assert.Contains(t, info.Methods, "click_element")
+
+	state := request(t, browser, "get_browser_state", nil)
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "url": "https://shop.example/", "title": "Example Shop", "active": true}, state["activeTab"])
+
+	dom := request(t, browser, "get_dom_state", nil)
+	assert.Contains(t, dom["formattedDom"], `[0]<input placeholder="Search products" name="q" type="search"> />`)
+	assert.Len(t, dom["interactiveElements"], 4)
+
+	// Typing Enter follows the submit outcome
+	typed := request(t, browser, "type_value", map[string]interface{}{"element_index": 0, "value": "lamp{Enter}"})
+	assert.Equal(t, "lamp", typed["actual_value"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "text", "content": "lamp"},
+		map[string]interface{}{"type": "specialKey", "key": "Enter"},
+	}, typed["operations_performed"])
+	assert.Equal(t, []map[string]interface{}{{"event": "tab_updated", "tabId": 1, "url": "https://shop.example/search?q=lamp"}}, changes)
+
+	// Scripted errors fail the click
+	assert.Equal(t, "Element is disabled", requestError(t, browser, "click_element", map[string]interface{}{"element_index": 1}))
+
+	clicked := request(t, browser, "click_element", map[string]interface{}{"element_index": 0})
+	assert.Equal(t, true, clicked["page_changed"])
+	assert.Equal(t, "https://shop.example/products/desk-lamp", clicked["after_url"])
+
+	// Choices are checked against the options and shown in the DOM state
+	assert.Contains(t, requestError(t, browser, "type_value", map[string]interface{}{"element_index": 0, "value": "red"}), `option "red" not found`)
+	chosen := request(t, browser, "type_value", map[string]interface{}{"element_index": 0, "value": "white"})
+	assert.Equal(t, "select", chosen["element_type"])
+	assert.Equal(t, "white", chosen["actual_value"])
+
+	clicked = request(t, browser, "click_element", map[string]interface{}{"element_index": 1})
+	assert.Equal(t, false, clicked["page_changed"])
+
+	dom = request(t, browser, "get_dom_state", nil)
+	elements := dom["interactiveElements"].([]interface{})
+	assert.Equal(t, "white", elements[0].(map[string]interface{})["attributes"].(map[string]interface{})["value"])
+	assert.Equal(t, "true", elements[1].(map[string]interface{})["attributes"].(map[string]interface{})["checked"])
+
+	assert.Contains(t, requestError(t, browser, "click_element", map[string]interface{}{"element_index": 7}), "element with index 7 not found")
+
+	calls := browser.Calls()
+	require.Len(t, calls, 10)
+	assert.Equal(t, "type_value", calls[2].Method)
+	assert.Equal(t, "Element is disabled", calls[3].Error)
+}
+
+func TestBrowser_NavigateHistory(t *testing.T) {
+	browser, _ := newTestBrowser(t)
+	require.NoError(t, browser.Start())
+
+	assert.Contains(t, requestError(t, browser, "navigate_history", map[string]interface{}{"action": "back"}), "cannot go back")
+
+	request(t, browser, "click_element", map[string]interface{}{"element_index": 1})
+	request(t, browser, "click_element", map[string]interface{}{"element_index": 0})
+	request(t, browser, "type_value", map[string]interface{}{"element_index": 0, "value": "white"})
+
+	back := request(t, browser, "navigate_history", map[string]interface{}{"action": "back"})
+	assert.Equal(t, "https://shop.example/search?q=lamp", back["url"])
+	forward := request(t, browser, "navigate_history", map[string]interface{}{"action": "forward"})
+	assert.Equal(t, "https://shop.example/products/desk-lamp", forward["url"])
+	assert.Contains(t, requestError(t, browser, "navigate_history", map[string]interface{}{"action": "forward"}), "cannot go forward")
+
+	// Pages are shown as freshly loaded, without what was chosen before
+	dom := request(t, browser, "get_dom_state", nil)
+	elements := dom["interactiveElements"].([]interface{})
+	assert.NotContains(t, elements[0].(map[string]interface{})["attributes"], "value")
+
+	// Navigating from an earlier page drops the pages after it
+	request(t, browser, "navigate_history", map[string]interface{}{"action": "back"})
+	request(t, browser, "navigate_history", map[string]interface{}{"action": "back"})
+	request(t, browser, "navigate_to", map[string]interface{}{"url": "https://shop.example/cart"})
+	assert.Contains(t, requestError(t, browser, "navigate_history", map[string]interface{}{"action": "forward"}), "cannot go forward")
+	back = request(t, browser, "navigate_history", map[string]interface{}{"action": "back"})
+	assert.Equal(t, "https://shop.example/", back["url"])
+
+	reloaded := request(t, browser, "navigate_history", map[string]interface{}{"action": "hard_reload"})
+	assert.Equal(t, "https://shop.example/", reloaded["url"])
+}
+
+func TestBrowser_TabsAndScrolling(t *testing.T) {
+	browser, _ := newTestBrowser(t)
+	require.NoError(t, browser.Start())
+
+	// Links with an open_tab outcome open an active tab
+	request(t, browser, "click_element", map[string]interface{}{"element_index": 2})
+	state := request(t, browser, "get_browser_state", nil)
+	assert.Len(t, state["tabs"], 2)
+	assert.Equal(t, "https://help.shop.example/", state["activeTab"].(map[string]interface{})["url"])
+
+	dom := request(t, browser, "get_dom_state", nil)
+	assert.Equal(t, "empty page", dom["formattedDom"])
+
+	opened := request(t, browser, "manage_tabs", map[string]interface{}{"action": "open", "url": "https://shop.example/cart", "background": true})
+	assert.Equal(t, "3", opened["new_tab_id"])
+	assert.Contains(t, requestError(t, browser, "manage_tabs", map[string]interface{}{"action": "open", "url": "https://elsewhere.example/"}), "not a scripted page")
+
+	request(t, browser, "manage_tabs", map[string]interface{}{"action": "close", "tab_id": "2"})
+	state = request(t, browser, "get_browser_state", nil)
+	assert.Len(t, state["tabs"], 2)
+	assert.Equal(t, float64(1), state["activeTab"].(map[string]interface{})["id"])
+
+	// tab_id targets a tab other than the active one
+	navigated := request(t, browser, "navigate_to", map[string]interface{}{"url": "https://shop.example/", "tab_id": 3})
+	assert.Equal(t, float64(3), navigated["tabId"])
+	assert.Contains(t, requestError(t, browser, "navigate_to", map[string]interface{}{"url": "https://shop.example/", "tab_id": 2}), "tab 2 not found")
+
+	// Elements far down the page come into view when scrolled to
+	dom = request(t, browser, "get_dom_state", nil)
+	elements := dom["interactiveElements"].([]interface{})
+	assert.Equal(t, false, elements[3].(map[string]interface{})["isInViewport"])
+	assert.Equal(t, float64(880), dom["meta"].(map[string]interface{})["pixelsBelow"])
+
+	scrolled := request(t, browser, "scroll_page", map[string]interface{}{"action": "to_element", "element_index": 3})
+	assert.Equal(t, float64(880), scrolled["scrollY"])
+	dom = request(t, browser, "get_dom_state", nil)
+	elements = dom["interactiveElements"].([]interface{})
+	assert.Equal(t, true, elements[3].(map[string]interface{})["isInViewport"])
+	assert.Contains(t, dom["formattedDom"], "... 880 pixels above")
+
+	scrolled = request(t, browser, "scroll_page", map[string]interface{}{"action": "up", "pixels": 2000})
+	assert.Equal(t, float64(0), scrolled["scrollY"])
+
+	screenshot := request(t, browser, "take_screenshot", map[string]interface{}{"mode": "full_page"})
+	assert.Equal(t, "image/png", screenshot["mimeType"])
+	assert.NotEmpty(t, screenshot["data"])
+
+	_, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: "get_dom_extra_elements"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+}
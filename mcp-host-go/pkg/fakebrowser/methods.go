@@ -0,0 +1,574 @@
+package fakebrowser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Viewport of the fake browser
+const (
+	viewportHeight = 720
+	defaultScroll  = 600 // Pixels scrolled by scroll_page up and down
+)
+
+// formattedAttributes are the attributes shown in the formatted DOM, as the extension shows them
+var formattedAttributes = []string{"role", "aria-label", "placeholder", "name", "type", "href", "value"}
+
+// keyPattern matches special keys like {Enter} and combinations like {Ctrl+A}
+var keyPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// specialKeys and modifierKeys are the key names type_value understands in braces
+var (
+	specialKeys = []string{
+		"Enter", "Tab", "Backspace", "Delete", "Escape", "Space", "Home", "End",
+		"PageUp", "PageDown", "ArrowUp", "ArrowDown", "ArrowLeft", "ArrowRight",
+	}
+	modifierKeys = []string{"Ctrl", "Control", "Alt", "Shift", "Meta", "Cmd"}
+)
+
+// screenshotPNG is the image every screenshot returns: one transparent pixel
+const screenshotPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII="
+
+// getBrowserState lists the open tabs
+func (b *Browser) getBrowserState(params map[string]interface{}) (interface{}, error) {
+	tabs := make([]map[string]interface{}, 0, len(b.tabs))
+	var active map[string]interface{}
+	for id := 1; id < b.nextTabID; id++ {
+		t, ok := b.tabs[id]
+		if !ok {
+			continue
+		}
+		info := map[string]interface{}{
+			"id":     t.id,
+			"url":    t.page.URL,
+			"title":  t.page.Title,
+			"active": t.id == b.activeTab,
+		}
+		if t.id == b.activeTab {
+			active = info
+		}
+		tabs = append(tabs, info)
+	}
+
+	return map[string]interface{}{
+		"activeTab": active,
+		"tabs":      tabs,
+	}, nil
+}
+
+// getDomState describes the elements of a tab's page in the format of the extension
+func (b *Browser) getDomState(params map[string]interface{}) (interface{}, error) {
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]map[string]interface{}, 0, len(t.page.Elements))
+	tagCounts := make(map[string]int)
+	for index, element := range t.page.Elements {
+		tagCounts[element.Tag]++
+		selector := fmt.Sprintf("%s:nth-of-type(%d)", element.Tag, tagCounts[element.Tag])
+		if id := element.Attributes["id"]; id != "" {
+			selector = "#" + id
+		}
+		elements = append(elements, map[string]interface{}{
+			"index":        index,
+			"tagName":      element.Tag,
+			"text":         element.Text,
+			"attributes":   t.attributes(index),
+			"isInViewport": element.Y >= t.scrollY && element.Y < t.scrollY+viewportHeight,
+			"selector":     selector,
+		})
+	}
+
+	pixelsBelow := t.page.height() - viewportHeight - t.scrollY
+	return map[string]interface{}{
+		"formattedDom":        t.formatDom(),
+		"interactiveElements": elements,
+		"meta": map[string]interface{}{
+			"url":         t.page.URL,
+			"title":       t.page.Title,
+			"tabId":       t.id,
+			"pixelsAbove": t.scrollY,
+			"pixelsBelow": pixelsBelow,
+		},
+	}, nil
+}
+
+// navigateTo loads a scripted page
+func (b *Browser) navigateTo(params map[string]interface{}) (interface{}, error) {
+	url, _ := params["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := b.page(url)
+	if err != nil {
+		return nil, fmt.Errorf("navigation to %s failed: %w", url, err)
+	}
+	b.loadLocked(t, page)
+
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Successfully navigated to %s", url),
+		"url":     page.URL,
+		"title":   page.Title,
+		"tabId":   t.id,
+	}, nil
+}
+
+// navigateHistory goes back or forward in the history of a tab, or reloads its
+// page. Every page is shown as freshly loaded.
+func (b *Browser) navigateHistory(params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "back":
+		if t.current == 0 {
+			return nil, fmt.Errorf("cannot go back: no page in the tab's history")
+		}
+		t.current--
+	case "forward":
+		if t.current == len(t.history)-1 {
+			return nil, fmt.Errorf("cannot go forward: no page in the tab's history")
+		}
+		t.current++
+	case "reload", "hard_reload":
+	default:
+		return nil, fmt.Errorf("action must be one of back, forward, reload or hard_reload")
+	}
+	b.showLocked(t, t.history[t.current])
+
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("History action %s completed on %s", action, t.page.URL),
+		"action":  action,
+		"url":     t.page.URL,
+		"title":   t.page.Title,
+		"tabId":   t.id,
+	}, nil
+}
+
+// clickElement follows the click outcome of an element, or toggles a checkbox or radio button
+func (b *Browser) clickElement(params map[string]interface{}) (interface{}, error) {
+	t, index, element, err := b.targetElementLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch elementType(element) {
+	case "checkbox":
+		checked, _ := t.values[index].(bool)
+		t.values[index] = !checked
+	case "radio":
+		t.values[index] = true
+	}
+
+	before := t.page.URL
+	if err := b.followLocked(t, element.OnClick); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success":       true,
+		"message":       fmt.Sprintf("Successfully clicked element at index %d", index),
+		"element_index": index,
+		"page_changed":  t.page.URL != before,
+		"element_info":  describe(element),
+		"before_url":    before,
+		"after_url":     t.page.URL,
+	}, nil
+}
+
+// typeValue types text and special keys into an element, or sets the choice
+// of a select, checkbox or radio button. Typing {Enter} or the submit option
+// follows the element's submit outcome.
+func (b *Browser) typeValue(params map[string]interface{}) (interface{}, error) {
+	t, index, element, err := b.targetElementLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := params["value"]
+	if !ok {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	options, _ := params["options"].(map[string]interface{})
+	clearFirst := true
+	if clear, ok := options["clear_first"].(bool); ok {
+		clearFirst = clear
+	}
+	submit, _ := options["submit"].(bool)
+
+	var operations []map[string]interface{}
+	inputMethod := "keyboard"
+	kind := elementType(element)
+	switch kind {
+	case "select":
+		inputMethod = "text"
+		choice := valueText(value)
+		if len(element.Options) > 0 && !slices.Contains(element.Options, choice) {
+			return nil, fmt.Errorf("option %q not found in select element, available options: %s", choice, strings.Join(element.Options, ", "))
+		}
+		t.values[index] = choice
+		operations = append(operations, map[string]interface{}{"type": "select", "value": choice})
+	case "checkbox", "radio":
+		inputMethod = "text"
+		checked, ok := value.(bool)
+		if !ok {
+			checked, _ = strconv.ParseBool(valueText(value))
+		}
+		t.values[index] = checked
+		operations = append(operations, map[string]interface{}{"type": "select", "value": checked})
+	default:
+		text := ""
+		if !clearFirst {
+			text, _ = t.values[index].(string)
+		}
+		input := valueText(value)
+		last := 0
+		for _, match := range keyPattern.FindAllStringSubmatchIndex(input, -1) {
+			key := input[match[2]:match[3]]
+			if !isKey(key) {
+				continue
+			}
+			if match[0] > last {
+				text += input[last:match[0]]
+				operations = append(operations, map[string]interface{}{"type": "text", "content": input[last:match[0]]})
+			}
+			last = match[1]
+
+			switch key {
+			case "Enter":
+				submit = true
+			case "Backspace":
+				if runes := []rune(text); len(runes) > 0 {
+					text = string(runes[:len(runes)-1])
+				}
+			}
+			operations = append(operations, keyOperation(key))
+		}
+		if last < len(input) {
+			text += input[last:]
+			operations = append(operations, map[string]interface{}{"type": "text", "content": input[last:]})
+		}
+		t.values[index] = text
+	}
+
+	actualValue := t.values[index]
+	if submit {
+		if err := b.followLocked(t, element.OnSubmit); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"success":              true,
+		"message":              fmt.Sprintf("Successfully set %s at index %d", kind, index),
+		"element_index":        index,
+		"element_type":         kind,
+		"input_method":         inputMethod,
+		"actual_value":         actualValue,
+		"operations_performed": operations,
+		"element_info":         describe(element),
+	}, nil
+}
+
+// scrollPage scrolls the page or brings an element into view
+func (b *Browser) scrollPage(params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	pixels := defaultScroll
+	if value, ok := params["pixels"].(float64); ok {
+		pixels = int(value)
+	}
+
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "up":
+		t.scrollY -= pixels
+	case "down":
+		t.scrollY += pixels
+	case "to_top":
+		t.scrollY = 0
+	case "to_bottom":
+		t.scrollY = t.page.height()
+	case "to_element":
+		_, _, element, err := b.targetElementLocked(params)
+		if err != nil {
+			return nil, err
+		}
+		t.scrollY = element.Y - viewportHeight/2
+	default:
+		return nil, fmt.Errorf("unsupported scroll action: %q", action)
+	}
+	t.scrollY = max(0, min(t.scrollY, t.page.height()-viewportHeight))
+
+	return map[string]interface{}{
+		"success": true,
+		"action":  action,
+		"scrollY": t.scrollY,
+	}, nil
+}
+
+// manageTabs opens, switches to and closes tabs
+func (b *Browser) manageTabs(params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	switch action {
+	case "open":
+		url, _ := params["url"].(string)
+		if url == "" {
+			return nil, fmt.Errorf("url is required for open action")
+		}
+		page, err := b.page(url)
+		if err != nil {
+			return nil, err
+		}
+
+		t := b.openTabLocked(page)
+		if background, _ := params["background"].(bool); !background {
+			b.activeTab = t.id
+		}
+		return map[string]interface{}{
+			"success":    true,
+			"message":    fmt.Sprintf("Opened tab %d with URL: %s", t.id, url),
+			"new_tab_id": strconv.Itoa(t.id),
+		}, nil
+
+	case "switch", "close":
+		raw, _ := params["tab_id"].(string)
+		if raw == "" {
+			return nil, fmt.Errorf("tab_id is required")
+		}
+		tabID, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tab_id: %q", raw)
+		}
+		t, ok := b.tabs[tabID]
+		if !ok {
+			return nil, fmt.Errorf("tab %d not found", tabID)
+		}
+
+		if action == "switch" {
+			b.activeTab = t.id
+			return map[string]interface{}{"success": true, "message": fmt.Sprintf("Switched to tab %d", t.id)}, nil
+		}
+		b.closeTabLocked(t)
+		return map[string]interface{}{"success": true, "message": fmt.Sprintf("Closed tab %d", t.id)}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+// takeScreenshot returns a placeholder image of a tab
+func (b *Browser) takeScreenshot(params map[string]interface{}) (interface{}, error) {
+	mode, _ := params["mode"].(string)
+	if mode == "" {
+		mode = "viewport"
+	}
+
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case "viewport", "full_page":
+	case "element":
+		if _, _, _, err := b.targetElementLocked(params); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported screenshot mode: %q", mode)
+	}
+
+	return map[string]interface{}{
+		"data":     screenshotPNG,
+		"mimeType": "image/png",
+		"mode":     mode,
+		"url":      t.page.URL,
+		"title":    t.page.Title,
+	}, nil
+}
+
+// targetElementLocked returns the tab of a request and the element named by
+// its element_index param; b.mutex must be held
+func (b *Browser) targetElementLocked(params map[string]interface{}) (*tab, int, *Element, error) {
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	raw, ok := params["element_index"].(float64)
+	if !ok || raw < 0 || raw != float64(int(raw)) {
+		return nil, 0, nil, fmt.Errorf("element_index must be a non-negative integer")
+	}
+	index := int(raw)
+	if index >= len(t.page.Elements) {
+		return nil, 0, nil, fmt.Errorf("element with index %d not found in DOM state", index)
+	}
+	return t, index, &t.page.Elements[index], nil
+}
+
+// followLocked carries out an outcome in a tab; b.mutex must be held
+func (b *Browser) followLocked(t *tab, outcome *Outcome) error {
+	if outcome == nil {
+		return nil
+	}
+	if outcome.Error != "" {
+		return fmt.Errorf("%s", outcome.Error)
+	}
+
+	// Outcome URLs were checked when the script was validated
+	if outcome.Navigate != "" {
+		target, _ := resolveURL(t.page.URL, outcome.Navigate)
+		b.loadLocked(t, b.pages[target])
+	}
+	if outcome.OpenTab != "" {
+		target, _ := resolveURL(t.page.URL, outcome.OpenTab)
+		b.activeTab = b.openTabLocked(b.pages[target]).id
+	}
+	return nil
+}
+
+// attributes returns the attributes of an element with its current value
+func (t *tab) attributes(index int) map[string]string {
+	element := t.page.Elements[index]
+	attributes := make(map[string]string, len(element.Attributes)+1)
+	for name, value := range element.Attributes {
+		attributes[name] = value
+	}
+
+	switch value := t.values[index].(type) {
+	case string:
+		attributes["value"] = value
+	case bool:
+		if value {
+			attributes["checked"] = "true"
+		} else {
+			delete(attributes, "checked")
+		}
+	}
+	return attributes
+}
+
+// formatDom renders the elements of the page like the extension's formattedDom
+func (t *tab) formatDom() string {
+	if len(t.page.Elements) == 0 {
+		return "empty page"
+	}
+
+	var builder strings.Builder
+	if t.scrollY > 0 {
+		fmt.Fprintf(&builder, "... %d pixels above - scroll up to see more ...\n", t.scrollY)
+	} else {
+		builder.WriteString("[Start of page]\n")
+	}
+
+	for index, element := range t.page.Elements {
+		attributes := t.attributes(index)
+		fmt.Fprintf(&builder, "[%d]<%s", index, element.Tag)
+		for _, name := range formattedAttributes {
+			if value, ok := attributes[name]; ok {
+				fmt.Fprintf(&builder, " %s=%q", name, value)
+			}
+		}
+		fmt.Fprintf(&builder, ">%s />\n", element.Text)
+	}
+
+	if pixelsBelow := t.page.height() - viewportHeight - t.scrollY; pixelsBelow > 0 {
+		fmt.Fprintf(&builder, "... %d pixels below - scroll down to see more ...", pixelsBelow)
+	} else {
+		builder.WriteString("[End of page]\n")
+	}
+	return builder.String()
+}
+
+// height returns the document height of a page
+func (p *Page) height() int {
+	return max(p.Height, viewportHeight)
+}
+
+// elementType returns the element type reported by type_value, as the extension reports it
+func elementType(element *Element) string {
+	switch element.Tag {
+	case "input":
+		switch kind := strings.ToLower(element.Attributes["type"]); kind {
+		case "checkbox", "radio":
+			return kind
+		}
+		return "input"
+	case "select", "textarea":
+		return element.Tag
+	}
+	if _, ok := element.Attributes["contenteditable"]; ok {
+		return "contenteditable"
+	}
+	return element.Tag
+}
+
+// describe returns the element_info of click_element and type_value results
+func describe(element *Element) map[string]interface{} {
+	return map[string]interface{}{
+		"tag_name": element.Tag,
+		"text":     element.Text,
+		"type":     elementType(element),
+	}
+}
+
+// isKey reports whether a name in braces is a key or a combination like Ctrl+A.
+// Other braces are typed as text.
+func isKey(name string) bool {
+	parts := strings.Split(name, "+")
+	for _, modifier := range parts[:len(parts)-1] {
+		if !slices.Contains(modifierKeys, modifier) {
+			return false
+		}
+	}
+	key := parts[len(parts)-1]
+	if len(parts) > 1 && len(key) == 1 {
+		return true
+	}
+	return slices.Contains(specialKeys, key)
+}
+
+// keyOperation describes a typed key like the extension's operations_performed
+func keyOperation(key string) map[string]interface{} {
+	parts := strings.Split(key, "+")
+	if len(parts) == 1 {
+		return map[string]interface{}{"type": "specialKey", "key": key}
+	}
+	return map[string]interface{}{"type": "modifierCombination", "modifiers": parts[:len(parts)-1], "key": parts[len(parts)-1]}
+}
+
+// valueText returns the text typed for a type_value value
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
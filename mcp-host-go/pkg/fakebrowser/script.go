@@ -0,0 +1,141 @@
+package fakebrowser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Script describes the pages a fake browser serves and how they link to each
+// other. Scripts are written in YAML; JSON scripts work too, as JSON is YAML.
+type Script struct {
+	StartURL string `yaml:"start_url"` // Page of the first tab, defaults to the first page
+	Pages    []Page `yaml:"pages"`
+}
+
+// Page is a scripted page, found by its URL
+type Page struct {
+	URL      string    `yaml:"url"`
+	Title    string    `yaml:"title"`
+	Height   int       `yaml:"height"` // Document height in pixels, defaults to the viewport height
+	Elements []Element `yaml:"elements"`
+}
+
+// Element is an interactive element of a page. Its index in get_dom_state is
+// its position in Page.Elements.
+type Element struct {
+	Tag        string            `yaml:"tag"`
+	Text       string            `yaml:"text"`
+	Attributes map[string]string `yaml:"attributes"`
+	Options    []string          `yaml:"options"` // Values a select accepts; any value if empty
+	Y          int               `yaml:"y"`       // Offset from the top of the page in pixels
+	OnClick    *Outcome          `yaml:"on_click"`
+	OnSubmit   *Outcome          `yaml:"on_submit"` // When Enter is typed into the element
+}
+
+// Outcome is what happens when an element is clicked or submitted. URLs may
+// be relative to the page and must name scripted pages.
+type Outcome struct {
+	Navigate string `yaml:"navigate"` // Load a page in the same tab
+	OpenTab  string `yaml:"open_tab"` // Open a page in a new active tab
+	Error    string `yaml:"error"`    // Fail the action with this message
+}
+
+// LoadScript reads a script from a YAML or JSON file
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake browser script: %w", err)
+	}
+
+	script, err := ParseScript(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fake browser script %s: %w", path, err)
+	}
+	return script, nil
+}
+
+// ParseScript parses and validates a YAML or JSON script. Unknown fields are
+// errors, so that typos do not silently change what the browser does.
+func ParseScript(data []byte) (*Script, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var script Script
+	if err := decoder.Decode(&script); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := script.Validate(); err != nil {
+		return nil, err
+	}
+	return &script, nil
+}
+
+// Validate checks that every page has a unique URL and that the start page and
+// every outcome lead to scripted pages
+func (s *Script) Validate() error {
+	if len(s.Pages) == 0 {
+		return fmt.Errorf("script has no pages")
+	}
+
+	urls := make(map[string]bool, len(s.Pages))
+	for _, page := range s.Pages {
+		if page.URL == "" {
+			return fmt.Errorf("page without url")
+		}
+		if urls[page.URL] {
+			return fmt.Errorf("page %s is scripted twice", page.URL)
+		}
+		urls[page.URL] = true
+	}
+
+	if s.StartURL != "" && !urls[s.StartURL] {
+		return fmt.Errorf("start_url %s is not a scripted page", s.StartURL)
+	}
+
+	for _, page := range s.Pages {
+		for index, element := range page.Elements {
+			if element.Tag == "" {
+				return fmt.Errorf("element %d of %s has no tag", index, page.URL)
+			}
+			for _, outcome := range []*Outcome{element.OnClick, element.OnSubmit} {
+				if outcome == nil {
+					continue
+				}
+				for _, target := range []string{outcome.Navigate, outcome.OpenTab} {
+					if target == "" {
+						continue
+					}
+					resolved, err := resolveURL(page.URL, target)
+					if err != nil {
+						return fmt.Errorf("element %d of %s: %w", index, page.URL, err)
+					}
+					if !urls[resolved] {
+						return fmt.Errorf("element %d of %s leads to %s, which is not a scripted page", index, page.URL, resolved)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveURL resolves a URL relative to the URL of a page
+func resolveURL(base string, target string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", base, err)
+	}
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", target, err)
+	}
+	return baseURL.ResolveReference(targetURL).String(), nil
+}
@@ -1,36 +1,60 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
 
-// InitHandler handles initialization requests from the browser extension
+// Init response statuses
+const (
+	InitStatusInitialized  = "initialized"
+	InitStatusIncompatible = "incompatible" // The protocol versions of host and extension do not overlap
+)
+
+// hostRpcMethods are the RPC methods the host serves to the extension, registered in main
+var hostRpcMethods = []string{"init", "shutdown", "status"}
+
+// InitHandler handles initialization requests from the browser extension. The
+// init request is a handshake: the extension announces its protocol version and
+// RPC methods, and the host answers with its own.
 type InitHandler struct {
-	logger logger.Logger
-	// Future fields for resources that need initialization:
-	// - database connections
-	// - cache systems
-	// - temporary directories
-	// - other MCP tool dependencies
+	logger       logger.Logger
+	capabilities *types.ExtensionCapabilities
 }
 
 // InitHandlerConfig contains configuration for the InitHandler
 type InitHandlerConfig struct {
-	Logger logger.Logger
+	Logger       logger.Logger
+	Capabilities *types.ExtensionCapabilities // Optional, receives what the extension announced
+}
+
+// InitParams are the parameters of init requests. Extensions speaking protocol
+// version 1 only send the run options.
+type InitParams struct {
+	RunMode            string   `json:"runMode,omitempty"`
+	ExtensionVersion   string   `json:"extensionVersion,omitempty"`
+	ProtocolVersion    int      `json:"protocolVersion,omitempty"`    // Newest version the extension speaks, 1 if missing
+	MinProtocolVersion int      `json:"minProtocolVersion,omitempty"` // Oldest version the extension speaks, ProtocolVersion if missing
+	Methods            []string `json:"methods,omitempty"`            // RPC methods the extension implements
+
+	// Tools the extension offers to MCP clients, each served by the RPC method of its name
+	Tools []types.ExtensionToolDefinition `json:"tools,omitempty"`
 }
 
 // InitResponse represents the response structure for init requests
 type InitResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-	// Future fields for initialization results:
-	// - resource handles
-	// - connection status
-	// - initialization metadata
+	Status             string   `json:"status"`
+	Message            string   `json:"message"`
+	HostVersion        string   `json:"hostVersion"`
+	ProtocolVersion    int      `json:"protocolVersion"`    // Version used from now on, or the newest the host speaks if incompatible
+	MinProtocolVersion int      `json:"minProtocolVersion"` // Oldest version the host speaks
+	Methods            []string `json:"methods"`            // RPC methods the host implements
 }
 
 // NewInitHandler creates a new InitHandler instance
@@ -40,7 +64,8 @@ func NewInitHandler(config InitHandlerConfig) (*InitHandler, error) {
 	}
 
 	return &InitHandler{
-		logger: config.Logger,
+		logger:       config.Logger,
+		capabilities: config.Capabilities,
 	}, nil
 }
 
@@ -48,20 +73,34 @@ func NewInitHandler(config InitHandlerConfig) (*InitHandler, error) {
 func (ih *InitHandler) HandleInit(request types.RpcRequest) (types.RpcResponse, error) {
 	ih.logger.Info("Initializing MCP host resources", zap.String("id", request.ID))
 
-	// TODO: Future initialization logic will be added here:
-	// 1. Initialize database connections
-	// 2. Set up cache systems
-	// 3. Create temporary directories
-	// 4. Initialize MCP tool dependencies
-	// 5. Validate configuration
-	// 6. Prepare shared resources
+	params, err := parseInitParams(request.Params)
+	if err != nil {
+		return types.RpcResponse{}, err
+	}
 
-	// For now, this is a placeholder implementation
-	ih.logger.Info("MCP host resources initialized successfully")
+	info, protocolVersion := negotiateProtocol(params)
+	if ih.capabilities != nil {
+		ih.capabilities.Set(info)
+	}
 
 	response := InitResponse{
-		Status:  "initialized",
-		Message: "MCP host initialized successfully",
+		Status:             InitStatusInitialized,
+		Message:            "MCP host initialized successfully",
+		HostVersion:        Version,
+		ProtocolVersion:    protocolVersion,
+		MinProtocolVersion: types.MinProtocolVersion,
+		Methods:            hostRpcMethods,
+	}
+	if !info.Compatible {
+		response.Status = InitStatusIncompatible
+		response.Message = info.Message
+		ih.logger.Error("Browser extension is not compatible", zap.String("reason", info.Message))
+	} else {
+		ih.logger.Info("MCP host resources initialized successfully",
+			zap.String("extensionVersion", info.Version),
+			zap.Int("protocolVersion", protocolVersion),
+			zap.Strings("methods", info.Methods),
+			zap.Int("tools", len(info.Tools)))
 	}
 
 	ih.logger.Debug("Init response prepared",
@@ -73,3 +112,76 @@ func (ih *InitHandler) HandleInit(request types.RpcRequest) (types.RpcResponse,
 		Result: response,
 	}, nil
 }
+
+// parseInitParams converts the generic request params into InitParams
+func parseInitParams(data interface{}) (InitParams, error) {
+	var params InitParams
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return params, fmt.Errorf("failed to marshal init params: %w", err)
+	}
+
+	if err := json.Unmarshal(dataBytes, &params); err != nil {
+		return params, fmt.Errorf("invalid init params: %w", err)
+	}
+
+	return params, nil
+}
+
+// negotiateProtocol picks the newest protocol version both sides speak and
+// describes the extension. If there is none, the extension is marked
+// incompatible with a message saying which side to update.
+func negotiateProtocol(params InitParams) (types.ExtensionInfo, int) {
+	extensionMax := params.ProtocolVersion
+	if extensionMax <= 0 {
+		extensionMax = 1
+	}
+	extensionMin := params.MinProtocolVersion
+	if extensionMin <= 0 || extensionMin > extensionMax {
+		extensionMin = extensionMax
+	}
+
+	// Advertised tools are served by the RPC method of the same name
+	methods := append([]string{}, params.Methods...)
+	for _, tool := range params.Tools {
+		if !slices.Contains(methods, tool.Name) {
+			methods = append(methods, tool.Name)
+		}
+	}
+	sort.Strings(methods)
+	info := types.ExtensionInfo{
+		Version:         params.ExtensionVersion,
+		ProtocolVersion: extensionMax,
+		Methods:         methods,
+		Compatible:      true,
+		Tools:           params.Tools,
+	}
+
+	protocolVersion := min(extensionMax, types.ProtocolVersion)
+	if protocolVersion >= max(extensionMin, types.MinProtocolVersion) {
+		return info, protocolVersion
+	}
+
+	extension := "The browser extension"
+	if params.ExtensionVersion != "" {
+		extension += " " + params.ExtensionVersion
+	}
+	update := "browser extension"
+	if extensionMin > types.ProtocolVersion {
+		update = "MCP host"
+	}
+
+	info.Compatible = false
+	info.Message = fmt.Sprintf("%s speaks native messaging protocol %s, but MCP host %s speaks %s. Update the %s.",
+		extension, versionRange(extensionMin, extensionMax), Version, versionRange(types.MinProtocolVersion, types.ProtocolVersion), update)
+	return info, types.ProtocolVersion
+}
+
+// versionRange formats a range of protocol versions such as "v2" or "v2-v3"
+func versionRange(minVersion int, maxVersion int) string {
+	if minVersion == maxVersion {
+		return fmt.Sprintf("v%d", maxVersion)
+	}
+	return fmt.Sprintf("v%d-v%d", minVersion, maxVersion)
+}
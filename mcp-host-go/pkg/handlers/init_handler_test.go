@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
@@ -56,6 +57,12 @@ func TestInitHandler_HandleInit(t *testing.T) {
 	})
 	require.NoError(t, err)
 
+	params := map[string]interface{}{
+		"runMode":         "production",
+		"protocolVersion": types.ProtocolVersion,
+		"methods":         []string{"navigate_to", "get_dom_state"},
+	}
+
 	tests := []struct {
 		name     string
 		request  types.RpcRequest
@@ -66,12 +73,17 @@ func TestInitHandler_HandleInit(t *testing.T) {
 			request: types.RpcRequest{
 				ID:     "test-123",
 				Method: "init",
+				Params: params,
 			},
 			expected: types.RpcResponse{
 				ID: "test-123",
 				Result: InitResponse{
-					Status:  "initialized",
-					Message: "MCP host initialized successfully",
+					Status:             "initialized",
+					Message:            "MCP host initialized successfully",
+					HostVersion:        Version,
+					ProtocolVersion:    types.ProtocolVersion,
+					MinProtocolVersion: types.MinProtocolVersion,
+					Methods:            []string{"init", "shutdown", "status"},
 				},
 			},
 		},
@@ -79,11 +91,16 @@ func TestInitHandler_HandleInit(t *testing.T) {
 			name: "init without id",
 			request: types.RpcRequest{
 				Method: "init",
+				Params: params,
 			},
 			expected: types.RpcResponse{
 				Result: InitResponse{
-					Status:  "initialized",
-					Message: "MCP host initialized successfully",
+					Status:             "initialized",
+					Message:            "MCP host initialized successfully",
+					HostVersion:        Version,
+					ProtocolVersion:    types.ProtocolVersion,
+					MinProtocolVersion: types.MinProtocolVersion,
+					Methods:            []string{"init", "shutdown", "status"},
 				},
 			},
 		},
@@ -100,3 +117,121 @@ func TestInitHandler_HandleInit(t *testing.T) {
 		})
 	}
 }
+
+func TestInitHandler_HandleInit_NegotiatesProtocol(t *testing.T) {
+	tests := []struct {
+		name            string
+		params          interface{}
+		status          string
+		protocolVersion int
+		message         string
+	}{
+		{
+			name:            "same version",
+			params:          map[string]interface{}{"protocolVersion": types.ProtocolVersion, "methods": []string{"click_element"}},
+			status:          InitStatusInitialized,
+			protocolVersion: types.ProtocolVersion,
+		},
+		{
+			name:            "newer extension that still speaks this version",
+			params:          map[string]interface{}{"protocolVersion": types.ProtocolVersion + 1, "minProtocolVersion": types.MinProtocolVersion},
+			status:          InitStatusInitialized,
+			protocolVersion: types.ProtocolVersion,
+		},
+		{
+			name:            "extension without a protocol version",
+			params:          map[string]interface{}{"runMode": "production"},
+			status:          InitStatusIncompatible,
+			protocolVersion: types.ProtocolVersion,
+			message:         "speaks native messaging protocol v1, but MCP host dev speaks v2. Update the browser extension.",
+		},
+		{
+			name:            "extension that dropped this version",
+			params:          map[string]interface{}{"extensionVersion": "9.0.0", "protocolVersion": 4, "minProtocolVersion": 3},
+			status:          InitStatusIncompatible,
+			protocolVersion: types.ProtocolVersion,
+			message:         "The browser extension 9.0.0 speaks native messaging protocol v3-v4, but MCP host dev speaks v2. Update the MCP host.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capabilities := &types.ExtensionCapabilities{}
+			handler, err := NewInitHandler(InitHandlerConfig{
+				Logger:       &mockLogger{},
+				Capabilities: capabilities,
+			})
+			require.NoError(t, err)
+
+			response, err := handler.HandleInit(types.RpcRequest{ID: "init-1", Method: "init", Params: tt.params})
+			require.NoError(t, err)
+
+			result := response.Result.(InitResponse)
+			assert.Equal(t, tt.status, result.Status)
+			assert.Equal(t, tt.protocolVersion, result.ProtocolVersion)
+			if tt.message != "" {
+				assert.Contains(t, result.Message, tt.message)
+			}
+
+			info, known := capabilities.Get()
+			require.True(t, known)
+			assert.Equal(t, tt.status == InitStatusInitialized, info.Compatible)
+			assert.Equal(t, info.Message != "", !info.Compatible)
+		})
+	}
+}
+
+func TestInitHandler_HandleInit_AdvertisedTools(t *testing.T) {
+	capabilities := &types.ExtensionCapabilities{}
+	handler, err := NewInitHandler(InitHandlerConfig{
+		Logger:       &mockLogger{},
+		Capabilities: capabilities,
+	})
+	require.NoError(t, err)
+
+	_, err = handler.HandleInit(types.RpcRequest{ID: "init-1", Method: "init", Params: map[string]interface{}{
+		"protocolVersion": types.ProtocolVersion,
+		"methods":         []string{"click_element"},
+		"tools": []map[string]interface{}{
+			{
+				"name":        "read_article",
+				"description": "Extract the main article of the page",
+				"inputSchema": map[string]interface{}{"type": "object"},
+				"readOnly":    true,
+			},
+		},
+	}})
+	require.NoError(t, err)
+
+	info, known := capabilities.Get()
+	require.True(t, known)
+	require.Len(t, info.Tools, 1)
+	assert.Equal(t, "read_article", info.Tools[0].Name)
+	assert.True(t, info.Tools[0].ReadOnly)
+
+	// An advertised tool implies the RPC method serving it
+	assert.Equal(t, []string{"click_element", "read_article"}, info.Methods)
+	assert.NoError(t, capabilities.Check("read_article"))
+}
+
+func TestExtensionCapabilities_Check(t *testing.T) {
+	capabilities := &types.ExtensionCapabilities{}
+
+	// Before the handshake every method is allowed
+	assert.NoError(t, capabilities.Check("click_element"))
+
+	changes := make(chan types.ExtensionInfo, 1)
+	capabilities.OnChange(func(info types.ExtensionInfo) { changes <- info })
+	capabilities.Set(types.ExtensionInfo{Version: "1.2.0", ProtocolVersion: 2, Methods: []string{"click_element"}, Compatible: true})
+	assert.Equal(t, "1.2.0", (<-changes).Version)
+
+	assert.NoError(t, capabilities.Check("click_element"))
+	err := capabilities.Check("wait_for")
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "wait_for (extension 1.2.0)")
+
+	capabilities.Set(types.ExtensionInfo{ProtocolVersion: 1, Message: "Update the browser extension."})
+	err = capabilities.Check("click_element")
+	assert.True(t, errors.Is(err, types.ErrExtensionIncompatible), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "Update the browser extension.")
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// StateChangeMessageType is the native message the extension sends when browser state changes
+const StateChangeMessageType = "browser_state_changed"
+
+// Browser state change events reported by the extension
+const (
+	StateChangeTabCreated   = "tab_created"
+	StateChangeTabRemoved   = "tab_removed"
+	StateChangeTabActivated = "tab_activated"
+	StateChangeTabUpdated   = "tab_updated"
+	StateChangeDomChanged   = "dom_changed"
+)
+
+// StateChangeHandler turns browser state change messages from the extension
+// into resource change notifications for MCP clients
+type StateChangeHandler struct {
+	logger                logger.Logger
+	currentStateResources []types.Resource
+	domStateResources     []types.Resource
+	onTabRemoved          func(tabID int)
+}
+
+// StateChangeHandlerConfig contains configuration for the StateChangeHandler
+type StateChangeHandlerConfig struct {
+	Logger          logger.Logger
+	CurrentStateRes types.Resource // Notified when tabs change
+	DomStateRes     types.Resource // Notified when the active page's DOM changes
+
+	// Optional JSON variants, notified together with the resources above
+	CurrentStateJSONRes types.Resource
+	DomStateJSONRes     types.Resource
+
+	OnTabRemoved func(tabID int) // Optional, called with the ID of every closed tab
+}
+
+// StateChangeEvent represents a browser_state_changed message payload
+type StateChangeEvent struct {
+	Event string `json:"event"`
+	TabID int    `json:"tabId,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// NewStateChangeHandler creates a new StateChangeHandler instance
+func NewStateChangeHandler(config StateChangeHandlerConfig) (*StateChangeHandler, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.CurrentStateRes == nil {
+		return nil, fmt.Errorf("current state resource is required")
+	}
+
+	if config.DomStateRes == nil {
+		return nil, fmt.Errorf("DOM state resource is required")
+	}
+
+	handler := &StateChangeHandler{
+		logger:                config.Logger,
+		currentStateResources: []types.Resource{config.CurrentStateRes},
+		domStateResources:     []types.Resource{config.DomStateRes},
+		onTabRemoved:          config.OnTabRemoved,
+	}
+	if config.CurrentStateJSONRes != nil {
+		handler.currentStateResources = append(handler.currentStateResources, config.CurrentStateJSONRes)
+	}
+	if config.DomStateJSONRes != nil {
+		handler.domStateResources = append(handler.domStateResources, config.DomStateJSONRes)
+	}
+	return handler, nil
+}
+
+// HandleStateChange handles a browser_state_changed message from the extension
+func (h *StateChangeHandler) HandleStateChange(data interface{}) error {
+	event, err := parseStateChangeEvent(data)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Debug("Browser state changed",
+		zap.String("event", event.Event),
+		zap.Int("tabId", event.TabID),
+		zap.String("url", event.URL))
+
+	switch event.Event {
+	case StateChangeTabCreated:
+		notifyAll(h.currentStateResources, event)
+	case StateChangeTabRemoved:
+		if h.onTabRemoved != nil && event.TabID != 0 {
+			h.onTabRemoved(event.TabID)
+		}
+		notifyAll(h.currentStateResources, event)
+	case StateChangeTabActivated, StateChangeTabUpdated:
+		// The DOM resources describe the active tab, which is now a different page
+		notifyAll(h.currentStateResources, event)
+		notifyAll(h.domStateResources, event)
+	case StateChangeDomChanged:
+		notifyAll(h.domStateResources, event)
+	default:
+		h.logger.Warn("Unknown browser state change event", zap.String("event", event.Event))
+	}
+
+	return nil
+}
+
+// notifyAll notifies every resource of a state change
+func notifyAll(resources []types.Resource, event StateChangeEvent) {
+	for _, resource := range resources {
+		resource.NotifyStateChange(event)
+	}
+}
+
+// parseStateChangeEvent converts the generic message data into a StateChangeEvent
+func parseStateChangeEvent(data interface{}) (StateChangeEvent, error) {
+	var event StateChangeEvent
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return event, fmt.Errorf("failed to marshal state change data: %w", err)
+	}
+
+	if err := json.Unmarshal(dataBytes, &event); err != nil {
+		return event, fmt.Errorf("invalid state change data: %w", err)
+	}
+
+	if event.Event == "" {
+		return event, fmt.Errorf("state change event is required")
+	}
+
+	return event, nil
+}
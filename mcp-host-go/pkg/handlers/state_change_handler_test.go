@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockResource records NotifyStateChange calls
+type mockResource struct {
+	uri           string
+	notifications int
+}
+
+func (m *mockResource) GetURI() string         { return m.uri }
+func (m *mockResource) GetName() string        { return m.uri }
+func (m *mockResource) GetMimeType() string    { return "text/markdown" }
+func (m *mockResource) GetDescription() string { return "" }
+func (m *mockResource) Read(ctx context.Context) (types.ResourceContent, error) {
+	return types.ResourceContent{}, nil
+}
+func (m *mockResource) ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (types.ResourceContent, error) {
+	return types.ResourceContent{}, nil
+}
+func (m *mockResource) NotifyStateChange(state interface{}) { m.notifications++ }
+
+func TestNewStateChangeHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   StateChangeHandlerConfig
+		errorMsg string
+	}{
+		{
+			name: "valid config",
+			config: StateChangeHandlerConfig{
+				Logger:          &mockLogger{},
+				CurrentStateRes: &mockResource{},
+				DomStateRes:     &mockResource{},
+			},
+		},
+		{
+			name: "missing logger",
+			config: StateChangeHandlerConfig{
+				CurrentStateRes: &mockResource{},
+				DomStateRes:     &mockResource{},
+			},
+			errorMsg: "logger is required",
+		},
+		{
+			name: "missing DOM state resource",
+			config: StateChangeHandlerConfig{
+				Logger:          &mockLogger{},
+				CurrentStateRes: &mockResource{},
+			},
+			errorMsg: "DOM state resource is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := NewStateChangeHandler(tt.config)
+			if tt.errorMsg != "" {
+				assert.ErrorContains(t, err, tt.errorMsg)
+				assert.Nil(t, handler)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, handler)
+			}
+		})
+	}
+}
+
+func TestStateChangeHandler_HandleStateChange(t *testing.T) {
+	tests := []struct {
+		event               string
+		expectCurrentState  int
+		expectDomStateCount int
+	}{
+		{StateChangeTabCreated, 1, 0},
+		{StateChangeTabRemoved, 1, 0},
+		{StateChangeTabActivated, 1, 1},
+		{StateChangeTabUpdated, 1, 1},
+		{StateChangeDomChanged, 0, 1},
+		{"unknown_event", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			currentState := &mockResource{uri: "browser://current/state"}
+			domState := &mockResource{uri: "browser://dom/state"}
+			currentStateJSON := &mockResource{uri: "browser://current/state.json"}
+			domStateJSON := &mockResource{uri: "browser://dom/state.json"}
+			var removedTabs []int
+
+			handler, err := NewStateChangeHandler(StateChangeHandlerConfig{
+				Logger:              &mockLogger{},
+				CurrentStateRes:     currentState,
+				DomStateRes:         domState,
+				CurrentStateJSONRes: currentStateJSON,
+				DomStateJSONRes:     domStateJSON,
+				OnTabRemoved:        func(tabID int) { removedTabs = append(removedTabs, tabID) },
+			})
+			require.NoError(t, err)
+
+			err = handler.HandleStateChange(map[string]interface{}{
+				"event": tt.event,
+				"tabId": 42,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectCurrentState, currentState.notifications)
+			assert.Equal(t, tt.expectDomStateCount, domState.notifications)
+			assert.Equal(t, tt.expectCurrentState, currentStateJSON.notifications)
+			assert.Equal(t, tt.expectDomStateCount, domStateJSON.notifications)
+			if tt.event == StateChangeTabRemoved {
+				assert.Equal(t, []int{42}, removedTabs)
+			} else {
+				assert.Empty(t, removedTabs)
+			}
+		})
+	}
+}
+
+func TestStateChangeHandler_InvalidData(t *testing.T) {
+	handler, err := NewStateChangeHandler(StateChangeHandlerConfig{
+		Logger:          &mockLogger{},
+		CurrentStateRes: &mockResource{},
+		DomStateRes:     &mockResource{},
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, handler.HandleStateChange(map[string]interface{}{}))
+	assert.Error(t, handler.HandleStateChange("not an object"))
+}
@@ -20,32 +20,43 @@ var (
 
 // StatusHandler handles status requests from the browser extension
 type StatusHandler struct {
-	logger      logger.Logger
-	startTime   time.Time
-	ssePort     string
-	sseBaseURL  string
-	sseBasePath string
+	logger            logger.Logger
+	startTime         time.Time
+	ssePort           string
+	sseBaseURL        string
+	sseBasePath       string
+	streamableHTTPURL string
+	authToken         string
+	messaging         types.Messaging
 }
 
 // StatusHandlerConfig contains configuration for the StatusHandler
 type StatusHandlerConfig struct {
-	Logger      logger.Logger
-	StartTime   time.Time
-	SSEPort     string
-	SSEBaseURL  string
-	SSEBasePath string
+	Logger            logger.Logger
+	StartTime         time.Time
+	SSEPort           string
+	SSEBaseURL        string
+	SSEBasePath       string
+	StreamableHTTPURL string          // Empty when the Streamable HTTP transport is disabled
+	AuthToken         string          // Bearer token MCP clients must send, empty when auth is disabled
+	Messaging         types.Messaging // Optional, reports the extension connection state
 }
 
 // StatusResponse represents the response structure for status requests
 type StatusResponse struct {
-	Version     string    `json:"version"`
-	SSEPort     string    `json:"sse_port"`
-	SSEBaseURL  string    `json:"sse_base_url"`
-	SSEBasePath string    `json:"sse_base_path"`
-	StartTime   time.Time `json:"start_time"`
-	CurrentTime time.Time `json:"current_time"`
-	Uptime      string    `json:"uptime"`
-	BuildInfo   BuildInfo `json:"build_info"`
+	Version           string                   `json:"version"`
+	SSEPort           string                   `json:"sse_port"`
+	SSEBaseURL        string                   `json:"sse_base_url"`
+	SSEBasePath       string                   `json:"sse_base_path"`
+	StreamableHTTPURL string                   `json:"streamable_http_url,omitempty"`
+	AuthToken         string                   `json:"auth_token,omitempty"`
+	IsConnected       bool                     `json:"is_connected"`
+	LastPing          int64                    `json:"last_ping,omitempty"` // Unix milliseconds of the last message from the extension
+	Queue             *types.MessageQueueStats `json:"queue,omitempty"`     // Inbound message queue, if messaging has one
+	StartTime         time.Time                `json:"start_time"`
+	CurrentTime       time.Time                `json:"current_time"`
+	Uptime            string                   `json:"uptime"`
+	BuildInfo         BuildInfo                `json:"build_info"`
 }
 
 // BuildInfo contains build-time information
@@ -62,11 +73,14 @@ func NewStatusHandler(config StatusHandlerConfig) (*StatusHandler, error) {
 	}
 
 	return &StatusHandler{
-		logger:      config.Logger,
-		startTime:   config.StartTime,
-		ssePort:     config.SSEPort,
-		sseBaseURL:  config.SSEBaseURL,
-		sseBasePath: config.SSEBasePath,
+		logger:            config.Logger,
+		startTime:         config.StartTime,
+		ssePort:           config.SSEPort,
+		sseBaseURL:        config.SSEBaseURL,
+		sseBasePath:       config.SSEBasePath,
+		streamableHTTPURL: config.StreamableHTTPURL,
+		authToken:         config.AuthToken,
+		messaging:         config.Messaging,
 	}, nil
 }
 
@@ -80,13 +94,15 @@ func (sh *StatusHandler) HandleStatus(request types.RpcRequest) (types.RpcRespon
 
 	// Create status response
 	status := StatusResponse{
-		Version:     Version,
-		SSEPort:     sh.ssePort,
-		SSEBaseURL:  sh.sseBaseURL,
-		SSEBasePath: sh.sseBasePath,
-		StartTime:   sh.startTime,
-		CurrentTime: time.Now(),
-		Uptime:      uptimeStr,
+		Version:           Version,
+		SSEPort:           sh.ssePort,
+		SSEBaseURL:        sh.sseBaseURL,
+		SSEBasePath:       sh.sseBasePath,
+		StreamableHTTPURL: sh.streamableHTTPURL,
+		AuthToken:         sh.authToken,
+		StartTime:         sh.startTime,
+		CurrentTime:       time.Now(),
+		Uptime:            uptimeStr,
 		BuildInfo: BuildInfo{
 			GoVersion: GoVersion,
 			BuildTime: BuildTime,
@@ -94,11 +110,24 @@ func (sh *StatusHandler) HandleStatus(request types.RpcRequest) (types.RpcRespon
 		},
 	}
 
+	// The status request itself arrived over the connection, which may not have been recorded yet
+	status.IsConnected = true
+	if sh.messaging != nil {
+		connection := sh.messaging.ConnectionStatus()
+		status.IsConnected = connection.IsConnected
+		status.LastPing = connection.LastPing
+	}
+	if reporter, ok := sh.messaging.(types.MessageQueueReporter); ok {
+		queue := reporter.QueueStats()
+		status.Queue = &queue
+	}
+
 	sh.logger.Debug("Status response prepared",
 		zap.String("version", status.Version),
 		zap.String("uptime", status.Uptime),
 		zap.Time("start_time", status.StartTime),
-		zap.Time("current_time", status.CurrentTime))
+		zap.Time("current_time", status.CurrentTime),
+		zap.Bool("is_connected", status.IsConnected))
 
 	return types.RpcResponse{
 		ID:     request.ID,
@@ -59,11 +59,13 @@ func TestStatusHandler_HandleStatus(t *testing.T) {
 	startTime := time.Now().Add(-5 * time.Minute) // 5 minutes ago
 
 	handler, err := NewStatusHandler(StatusHandlerConfig{
-		Logger:      &mockLogger{},
-		StartTime:   startTime,
-		SSEPort:     ":9090",
-		SSEBaseURL:  "http://test:9090",
-		SSEBasePath: "/test",
+		Logger:            &mockLogger{},
+		StartTime:         startTime,
+		SSEPort:           ":9090",
+		SSEBaseURL:        "http://test:9090",
+		SSEBasePath:       "/test",
+		StreamableHTTPURL: "http://test:9090/mcp",
+		AuthToken:         "test-token",
 	})
 	require.NoError(t, err)
 
@@ -91,6 +93,8 @@ func TestStatusHandler_HandleStatus(t *testing.T) {
 	assert.Equal(t, ":9090", status.SSEPort)
 	assert.Equal(t, "http://test:9090", status.SSEBaseURL)
 	assert.Equal(t, "/test", status.SSEBasePath)
+	assert.Equal(t, "http://test:9090/mcp", status.StreamableHTTPURL)
+	assert.Equal(t, "test-token", status.AuthToken)
 	assert.NotEmpty(t, status.Version)
 	assert.NotEmpty(t, status.Uptime)
 	assert.NotZero(t, status.CurrentTime)
@@ -101,6 +105,51 @@ func TestStatusHandler_HandleStatus(t *testing.T) {
 	assert.NotEmpty(t, status.BuildInfo.GitCommit)
 }
 
+func TestStatusHandler_HandleStatus_ConnectionState(t *testing.T) {
+	messaging := &mockMessaging{status: types.ConnectionStatus{IsConnected: false, LastPing: 1700000000000}}
+
+	handler, err := NewStatusHandler(StatusHandlerConfig{
+		Logger:    &mockLogger{},
+		StartTime: time.Now(),
+		Messaging: messaging,
+	})
+	require.NoError(t, err)
+
+	response, err := handler.HandleStatus(types.RpcRequest{ID: "test-456", Method: "status"})
+	require.NoError(t, err)
+
+	status, ok := response.Result.(StatusResponse)
+	require.True(t, ok)
+	assert.False(t, status.IsConnected)
+	assert.Equal(t, int64(1700000000000), status.LastPing)
+
+	messaging.status = types.ConnectionStatus{IsConnected: true, LastPing: 1700000005000}
+	response, err = handler.HandleStatus(types.RpcRequest{ID: "test-789", Method: "status"})
+	require.NoError(t, err)
+
+	status = response.Result.(StatusResponse)
+	assert.True(t, status.IsConnected)
+	assert.Equal(t, int64(1700000005000), status.LastPing)
+}
+
+func TestStatusHandler_HandleStatus_QueueStats(t *testing.T) {
+	queue := types.MessageQueueStats{Workers: 8, Depth: 3, Capacity: 256, Processed: 40, Dropped: 2, Rejected: 1}
+
+	handler, err := NewStatusHandler(StatusHandlerConfig{
+		Logger:    &mockLogger{},
+		StartTime: time.Now(),
+		Messaging: &mockQueueMessaging{mockMessaging: mockMessaging{status: types.ConnectionStatus{IsConnected: true}}, queue: queue},
+	})
+	require.NoError(t, err)
+
+	response, err := handler.HandleStatus(types.RpcRequest{ID: "test-queue", Method: "status"})
+	require.NoError(t, err)
+
+	status := response.Result.(StatusResponse)
+	require.NotNil(t, status.Queue)
+	assert.Equal(t, queue, *status.Queue)
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -134,3 +183,19 @@ func (m *mockLogger) Error(msg string, fields ...zap.Field)  {}
 func (m *mockLogger) Named(name string) logger.Logger        { return &mockLogger{} }
 func (m *mockLogger) With(fields ...zap.Field) logger.Logger { return &mockLogger{} }
 func (m *mockLogger) Sync() error                            { return nil }
+
+// mockMessaging implements the connection state of types.Messaging for testing
+type mockMessaging struct {
+	types.Messaging
+	status types.ConnectionStatus
+}
+
+func (m *mockMessaging) ConnectionStatus() types.ConnectionStatus { return m.status }
+
+// mockQueueMessaging also reports an inbound message queue
+type mockQueueMessaging struct {
+	mockMessaging
+	queue types.MessageQueueStats
+}
+
+func (m *mockQueueMessaging) QueueStats() types.MessageQueueStats { return m.queue }
@@ -0,0 +1,440 @@
+package htmlbrowser
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// reportedAttributes are the attributes listed in interactiveElements, as the extension lists them
+var reportedAttributes = []string{"id", "name", "type", "role", "aria-label", "placeholder", "href", "value", "title", "alt", "checked", "selected"}
+
+// formattedAttributes are the attributes shown in the formatted DOM, as the extension shows them
+var formattedAttributes = []string{"role", "aria-label", "placeholder", "name", "type", "href", "value"}
+
+// interactiveRoles are the ARIA roles of elements that are interactive without being controls
+var interactiveRoles = map[string]bool{
+	"button": true, "link": true, "checkbox": true, "radio": true, "tab": true, "menuitem": true,
+	"option": true, "switch": true, "textbox": true, "combobox": true,
+}
+
+// skippedElements are never rendered. noscript is rendered: pages are shown without scripts.
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Template: true,
+}
+
+// blockElements start a new line of text in the formatted DOM
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Details: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true, atom.Td: true, atom.Th: true,
+	atom.Tr: true, atom.Ul: true,
+}
+
+// document is a parsed page. Interactive elements are numbered in document
+// order, the way the extension numbers them.
+type document struct {
+	url      *url.URL
+	base     *url.URL // Base for relative URLs, from <base href> or the page URL
+	title    string
+	root     *html.Node
+	elements []*html.Node
+}
+
+// blankDocument is the page of a new tab
+func blankDocument() *document {
+	blank := &url.URL{Scheme: "about", Opaque: "blank"}
+	return &document{url: blank, base: blank, root: &html.Node{Type: html.DocumentNode}}
+}
+
+// parseDocument parses a page fetched from pageURL
+func parseDocument(pageURL *url.URL, body io.Reader) (*document, error) {
+	// Parse as a browser with scripts disabled, so noscript content is markup
+	root, err := html.ParseWithOptions(body, html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	doc := &document{url: pageURL, base: pageURL, root: root}
+	if title := findElement(root, atom.Title); title != nil {
+		doc.title = collapseSpace(textContent(title))
+	}
+	if base := findElement(root, atom.Base); base != nil {
+		if href, ok := attr(base, "href"); ok {
+			if resolved, err := pageURL.Parse(href); err == nil {
+				doc.base = resolved
+			}
+		}
+	}
+	doc.index()
+	return doc, nil
+}
+
+// index numbers the visible interactive elements
+func (d *document) index() {
+	d.elements = nil
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if !isRendered(n) {
+				return
+			}
+			if isInteractive(n) {
+				d.elements = append(d.elements, n)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(d.root)
+}
+
+// element returns the interactive element at an index
+func (d *document) element(index int) (*html.Node, error) {
+	if index < 0 || index >= len(d.elements) {
+		return nil, fmt.Errorf("element with index %d not found in DOM state", index)
+	}
+	return d.elements[index], nil
+}
+
+// resolve resolves a URL found in the page
+func (d *document) resolve(ref string) (*url.URL, error) {
+	resolved, err := d.base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", ref, err)
+	}
+	return resolved, nil
+}
+
+// interactiveElements describes the interactive elements like the extension's get_dom_state
+func (d *document) interactiveElements() []map[string]interface{} {
+	elements := make([]map[string]interface{}, 0, len(d.elements))
+	for index, n := range d.elements {
+		elements = append(elements, map[string]interface{}{
+			"index":        index,
+			"tagName":      n.Data,
+			"text":         elementText(n),
+			"attributes":   reportedAttributeValues(n),
+			"isInViewport": true, // Nothing is laid out, so the whole page is in view
+			"selector":     selector(n),
+		})
+	}
+	return elements
+}
+
+// formattedDom renders the text of the page with its interactive elements
+// numbered, like the extension's formattedDom
+func (d *document) formattedDom() string {
+	if len(d.elements) == 0 && d.root.FirstChild == nil {
+		return "empty page"
+	}
+
+	indexes := make(map[*html.Node]int, len(d.elements))
+	for index, n := range d.elements {
+		indexes[n] = index
+	}
+
+	var builder strings.Builder
+	builder.WriteString("[Start of page]\n")
+
+	var line []string
+	flush := func() {
+		if text := collapseSpace(strings.Join(line, " ")); text != "" {
+			builder.WriteString(text)
+			builder.WriteByte('\n')
+		}
+		line = nil
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			line = append(line, n.Data)
+			return
+		case html.ElementNode:
+			if !isRendered(n) {
+				return
+			}
+			if index, ok := indexes[n]; ok {
+				flush()
+				values := reportedAttributeValues(n)
+				fmt.Fprintf(&builder, "[%d]<%s", index, n.Data)
+				for _, name := range formattedAttributes {
+					if value, ok := values[name]; ok {
+						fmt.Fprintf(&builder, " %s=%q", name, value)
+					}
+				}
+				fmt.Fprintf(&builder, ">%s />\n", elementText(n))
+				return
+			}
+			if blockElements[n.DataAtom] {
+				flush()
+				defer flush()
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(d.root)
+	flush()
+
+	builder.WriteString("[End of page]\n")
+	return builder.String()
+}
+
+// isRendered reports whether an element would be shown. Only the hidden
+// attribute and inline styles are considered, as there is no CSS engine.
+func isRendered(n *html.Node) bool {
+	if skippedElements[n.DataAtom] {
+		return false
+	}
+	if _, hidden := attr(n, "hidden"); hidden {
+		return false
+	}
+	if n.DataAtom == atom.Input && strings.EqualFold(attrValue(n, "type"), "hidden") {
+		return false
+	}
+	style := strings.ToLower(strings.ReplaceAll(attrValue(n, "style"), " ", ""))
+	return !strings.Contains(style, "display:none") && !strings.Contains(style, "visibility:hidden")
+}
+
+// isInteractive reports whether an element is numbered in the DOM state
+func isInteractive(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.A:
+		if _, ok := attr(n, "href"); ok {
+			return true
+		}
+	case atom.Button, atom.Input, atom.Select, atom.Textarea, atom.Summary:
+		return true
+	}
+
+	if interactiveRoles[strings.ToLower(attrValue(n, "role"))] {
+		return true
+	}
+	if _, ok := attr(n, "onclick"); ok {
+		return true
+	}
+	if editable, ok := attr(n, "contenteditable"); ok && (editable == "" || strings.EqualFold(editable, "true")) {
+		return true
+	}
+	if tabindex, ok := attr(n, "tabindex"); ok && strings.TrimSpace(tabindex) != "-1" {
+		return true
+	}
+	return false
+}
+
+// elementType returns the element type reported by type_value, as the extension reports it
+func elementType(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.Input:
+		switch kind := strings.ToLower(attrValue(n, "type")); kind {
+		case "checkbox", "radio":
+			return kind
+		}
+		return "input"
+	case atom.Select, atom.Textarea:
+		return n.Data
+	}
+	if editable, ok := attr(n, "contenteditable"); ok && (editable == "" || strings.EqualFold(editable, "true")) {
+		return "contenteditable"
+	}
+	return n.Data
+}
+
+// describe returns the element_info of click_element and type_value results
+func describe(n *html.Node) map[string]interface{} {
+	return map[string]interface{}{
+		"tag_name": n.Data,
+		"text":     elementText(n),
+		"type":     elementType(n),
+	}
+}
+
+// elementText returns the text an element is known by: its content, value or label
+func elementText(n *html.Node) string {
+	text := collapseSpace(textContent(n))
+	if n.DataAtom == atom.Select {
+		// Options are often written without whitespace between them
+		var labels []string
+		for _, option := range options(n) {
+			labels = append(labels, collapseSpace(textContent(option)))
+		}
+		text = strings.Join(labels, " ")
+	}
+	if text == "" && n.DataAtom == atom.Input {
+		text = attrValue(n, "value")
+	}
+	if text == "" {
+		text = attrValue(n, "aria-label")
+	}
+	if text == "" {
+		if img := findElement(n, atom.Img); img != nil {
+			text = attrValue(img, "alt")
+		}
+	}
+	if text == "" {
+		text = attrValue(n, "title")
+	}
+
+	text = collapseSpace(text)
+	if runes := []rune(text); len(runes) > 100 {
+		text = string(runes[:100])
+	}
+	return text
+}
+
+// reportedAttributeValues returns the attributes listed for an element,
+// with the current value of form controls
+func reportedAttributeValues(n *html.Node) map[string]string {
+	values := make(map[string]string)
+	for _, name := range reportedAttributes {
+		if value, ok := attr(n, name); ok && (value != "" || name == "checked" || name == "selected") {
+			values[name] = value
+		}
+	}
+
+	switch n.DataAtom {
+	case atom.Select:
+		if selected := selectedValues(n); len(selected) > 0 {
+			values["value"] = strings.Join(selected, ",")
+		}
+	case atom.Textarea:
+		if value := textContent(n); value != "" {
+			values["value"] = value
+		}
+	}
+	if _, checked := values["checked"]; checked {
+		values["checked"] = "true"
+	}
+	return values
+}
+
+// selector returns a CSS selector of an element, anchored at the nearest ancestor with an ID
+func selector(n *html.Node) string {
+	var parts []string
+	for current := n; current != nil && current.Type == html.ElementNode; current = current.Parent {
+		if id := attrValue(current, "id"); id != "" {
+			parts = append([]string{"#" + id}, parts...)
+			break
+		}
+
+		part := current.Data
+		position, count := 0, 0
+		if current.Parent != nil {
+			for sibling := current.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == html.ElementNode && sibling.Data == current.Data {
+					count++
+					if sibling == current {
+						position = count
+					}
+				}
+			}
+		}
+		if count > 1 {
+			part += fmt.Sprintf(":nth-of-type(%d)", position)
+		}
+		parts = append([]string{part}, parts...)
+	}
+	return strings.Join(parts, " > ")
+}
+
+// findElement returns the first element of a kind in a subtree
+func findElement(n *html.Node, kind atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == kind {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, kind); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// ancestor returns the nearest ancestor of a kind
+func ancestor(n *html.Node, kind atom.Atom) *html.Node {
+	for current := n.Parent; current != nil; current = current.Parent {
+		if current.Type == html.ElementNode && current.DataAtom == kind {
+			return current
+		}
+	}
+	return nil
+}
+
+// textContent returns the text of a subtree
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var builder strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && !isRendered(child) {
+			continue
+		}
+		builder.WriteString(textContent(child))
+	}
+	return builder.String()
+}
+
+// setTextContent replaces the children of an element with text
+func setTextContent(n *html.Node, text string) {
+	for n.FirstChild != nil {
+		n.RemoveChild(n.FirstChild)
+	}
+	if text != "" {
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	}
+}
+
+// attr returns an attribute of an element and whether it is set
+func attr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// attrValue returns an attribute of an element, or "" if it is not set
+func attrValue(n *html.Node, name string) string {
+	value, _ := attr(n, name)
+	return value
+}
+
+// setAttr sets an attribute of an element
+func setAttr(n *html.Node, name string, value string) {
+	for i, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: name, Val: value})
+}
+
+// removeAttr removes an attribute of an element
+func removeAttr(n *html.Node, name string) {
+	for i, a := range n.Attr {
+		if a.Namespace == "" && a.Key == name {
+			n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+			return
+		}
+	}
+}
+
+// collapseSpace trims text and collapses runs of whitespace, as rendering does
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,319 @@
+package htmlbrowser
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// options returns the options of a select element
+func options(selectNode *html.Node) []*html.Node {
+	var found []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.DataAtom == atom.Option {
+				found = append(found, child)
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(selectNode)
+	return found
+}
+
+// optionValue returns the value an option submits
+func optionValue(option *html.Node) string {
+	if value, ok := attr(option, "value"); ok {
+		return value
+	}
+	return collapseSpace(textContent(option))
+}
+
+// selectedValues returns the values of the selected options of a select
+// element. Without a selected option, a single select shows its first one.
+func selectedValues(selectNode *html.Node) []string {
+	var values []string
+	all := options(selectNode)
+	for _, option := range all {
+		if _, selected := attr(option, "selected"); selected {
+			values = append(values, optionValue(option))
+		}
+	}
+	if len(values) == 0 && len(all) > 0 {
+		if _, multiple := attr(selectNode, "multiple"); !multiple {
+			values = append(values, optionValue(all[0]))
+		}
+	}
+	return values
+}
+
+// selectOptions selects the options matching values by value or text
+func selectOptions(selectNode *html.Node, values []string) error {
+	_, multiple := attr(selectNode, "multiple")
+	if len(values) > 1 && !multiple {
+		return fmt.Errorf("select element does not allow multiple selections")
+	}
+
+	all := options(selectNode)
+	matched := make([]*html.Node, 0, len(values))
+	for _, value := range values {
+		var match *html.Node
+		for _, option := range all {
+			if optionValue(option) == value || collapseSpace(textContent(option)) == value {
+				match = option
+				break
+			}
+		}
+		if match == nil {
+			available := make([]string, 0, len(all))
+			for _, option := range all {
+				available = append(available, optionValue(option))
+			}
+			return fmt.Errorf("option %q not found in select element, available options: %s", value, strings.Join(available, ", "))
+		}
+		matched = append(matched, match)
+	}
+
+	for _, option := range all {
+		removeAttr(option, "selected")
+	}
+	for _, option := range matched {
+		setAttr(option, "selected", "")
+	}
+	return nil
+}
+
+// setChecked checks or unchecks a checkbox or radio button. Checking a radio
+// button unchecks the others of its group.
+func setChecked(input *html.Node, checked bool) {
+	if !checked {
+		removeAttr(input, "checked")
+		return
+	}
+
+	if strings.EqualFold(attrValue(input, "type"), "radio") {
+		if name := attrValue(input, "name"); name != "" {
+			scope := ancestor(input, atom.Form)
+			if scope == nil {
+				scope = root(input)
+			}
+			for _, other := range controls(scope) {
+				if other != input && other.DataAtom == atom.Input && strings.EqualFold(attrValue(other, "type"), "radio") && attrValue(other, "name") == name {
+					removeAttr(other, "checked")
+				}
+			}
+		}
+	}
+	setAttr(input, "checked", "")
+}
+
+// isChecked reports whether a checkbox or radio button is checked
+func isChecked(input *html.Node) bool {
+	_, checked := attr(input, "checked")
+	return checked
+}
+
+// isDisabled reports whether a control is disabled, itself or by a disabled fieldset
+func isDisabled(n *html.Node) bool {
+	if _, disabled := attr(n, "disabled"); disabled {
+		return true
+	}
+	for fieldset := ancestor(n, atom.Fieldset); fieldset != nil; fieldset = ancestor(fieldset, atom.Fieldset) {
+		if _, disabled := attr(fieldset, "disabled"); disabled {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubmitButton reports whether clicking an element submits its form
+func isSubmitButton(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Button:
+		kind := strings.ToLower(attrValue(n, "type"))
+		return kind == "" || kind == "submit"
+	case atom.Input:
+		kind := strings.ToLower(attrValue(n, "type"))
+		return kind == "submit" || kind == "image"
+	}
+	return false
+}
+
+// isTextInput reports whether typing Enter into an input submits its form
+func isTextInput(n *html.Node) bool {
+	if n.DataAtom != atom.Input {
+		return false
+	}
+	switch strings.ToLower(attrValue(n, "type")) {
+	case "checkbox", "radio", "submit", "image", "button", "reset", "file", "hidden":
+		return false
+	}
+	return true
+}
+
+// formOf returns the form a control belongs to
+func formOf(n *html.Node) *html.Node {
+	if id := attrValue(n, "form"); id != "" {
+		var found *html.Node
+		var walk func(current *html.Node)
+		walk = func(current *html.Node) {
+			for child := current.FirstChild; child != nil && found == nil; child = child.NextSibling {
+				if child.Type == html.ElementNode && child.DataAtom == atom.Form && attrValue(child, "id") == id {
+					found = child
+					return
+				}
+				walk(child)
+			}
+		}
+		walk(root(n))
+		return found
+	}
+	return ancestor(n, atom.Form)
+}
+
+// controls returns the form controls in a subtree in document order
+func controls(n *html.Node) []*html.Node {
+	var found []*html.Node
+	var walk func(current *html.Node)
+	walk = func(current *html.Node) {
+		for child := current.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Input, atom.Button, atom.Select, atom.Textarea:
+				found = append(found, child)
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// root returns the document node of a node
+func root(n *html.Node) *html.Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// formPair is a name and value submitted by a form
+type formPair struct {
+	name  string
+	value string
+}
+
+// formPairs returns the name-value pairs a form submits in document order.
+// submitter is the button that submitted the form, if any.
+func formPairs(form *html.Node, submitter *html.Node) []formPair {
+	members := controls(form)
+	if id := attrValue(form, "id"); id != "" {
+		for _, control := range controls(root(form)) {
+			if attrValue(control, "form") == id && ancestor(control, atom.Form) != form {
+				members = append(members, control)
+			}
+		}
+	}
+
+	var pairs []formPair
+	for _, control := range members {
+		name := attrValue(control, "name")
+		if name == "" || isDisabled(control) || formOf(control) != form {
+			continue
+		}
+
+		switch control.DataAtom {
+		case atom.Input:
+			switch strings.ToLower(attrValue(control, "type")) {
+			case "checkbox", "radio":
+				if isChecked(control) {
+					value, ok := attr(control, "value")
+					if !ok {
+						value = "on"
+					}
+					pairs = append(pairs, formPair{name, value})
+				}
+			case "submit", "image", "button":
+				if control == submitter {
+					pairs = append(pairs, formPair{name, attrValue(control, "value")})
+				}
+			case "reset", "file":
+			default:
+				pairs = append(pairs, formPair{name, attrValue(control, "value")})
+			}
+		case atom.Button:
+			if control == submitter {
+				pairs = append(pairs, formPair{name, attrValue(control, "value")})
+			}
+		case atom.Select:
+			for _, value := range selectedValues(control) {
+				pairs = append(pairs, formPair{name, value})
+			}
+		case atom.Textarea:
+			pairs = append(pairs, formPair{name, textContent(control)})
+		}
+	}
+	return pairs
+}
+
+// encodeForm encodes the data of a form as application/x-www-form-urlencoded
+// in document order, as browsers do; url.Values would sort it by name
+func encodeForm(form *html.Node, submitter *html.Node) string {
+	var parts []string
+	for _, pair := range formPairs(form, submitter) {
+		parts = append(parts, url.QueryEscape(pair.name)+"="+url.QueryEscape(pair.value))
+	}
+	return strings.Join(parts, "&")
+}
+
+// submission builds the request that submits a form
+func (d *document) submission(form *html.Node, submitter *html.Node) (*http.Request, error) {
+	action := attrValue(form, "action")
+	method := strings.ToUpper(attrValue(form, "method"))
+	if submitter != nil {
+		if value, ok := attr(submitter, "formaction"); ok {
+			action = value
+		}
+		if value, ok := attr(submitter, "formmethod"); ok {
+			method = strings.ToUpper(value)
+		}
+	}
+
+	target := d.url
+	if strings.TrimSpace(action) != "" {
+		resolved, err := d.resolve(action)
+		if err != nil {
+			return nil, err
+		}
+		target = resolved
+	}
+
+	body := encodeForm(form, submitter)
+	switch method {
+	case "", http.MethodGet:
+		withQuery := *target
+		withQuery.RawQuery = body
+		withQuery.Fragment = ""
+		return http.NewRequest(http.MethodGet, withQuery.String(), nil)
+	case http.MethodPost:
+		request, err := http.NewRequest(http.MethodPost, target.String(), strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return request, nil
+	default:
+		return nil, fmt.Errorf("unsupported form method %q", method)
+	}
+}
@@ -0,0 +1,284 @@
+package htmlbrowser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/messaging"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// Static HTML backend defaults
+const (
+	DefaultMaxPageSize       = 10 * 1024 * 1024 // Largest page fetched, in bytes
+	DefaultNavigationTimeout = 30 * time.Second // How long navigate_to waits in auto mode
+)
+
+// maxHistoryLength is the number of pages a tab keeps for going back, as in Chrome
+const maxHistoryLength = 50
+
+// Version is reported as the extension version by the static HTML backend
+const Version = "htmlbrowser"
+
+// method serves one RPC method of the extension protocol
+type method func(ctx context.Context, params map[string]interface{}) (interface{}, error)
+
+// tab is an open tab showing a fetched page
+type tab struct {
+	id      int
+	doc     *document
+	history []*document // Pages visited, kept as they were left for going back and forward
+	current int         // Index of doc in history
+}
+
+// Browser implements the types.Messaging interface without a browser engine:
+// it fetches pages over HTTP, parses them and serves the extension's RPC
+// methods from the parsed HTML. Scripts are not run, so it suits JS-free
+// pages such as documentation sites and local fixture servers. Links and form
+// submissions are followed, typed values are kept in the parsed page, which
+// going back shows again, and cookies are kept for the life of the browser. Nothing is laid out, so
+// scrolling and screenshots are not offered.
+type Browser struct {
+	*messaging.LocalBackend
+	logger      logger.Logger
+	client      *http.Client
+	startURL    string
+	maxPageSize int64
+	methods     map[string]method
+
+	mutex     sync.Mutex
+	started   bool
+	tabs      map[int]*tab
+	nextTabID int
+	activeTab int
+	changes   []map[string]interface{} // browser_state_changed messages not yet delivered
+}
+
+// Config contains configuration for Browser
+type Config struct {
+	Logger       logger.Logger
+	StartURL     string       // Page of the first tab, about:blank if empty
+	Client       *http.Client // Fetches pages, defaults to a client with a cookie jar
+	MaxPageSize  int64        // Largest page fetched, defaults to DefaultMaxPageSize
+	Capabilities *types.ExtensionCapabilities
+}
+
+// NewBrowser creates a new static HTML browser
+func NewBrowser(config Config) (*Browser, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	client := config.Client
+	if client == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+		}
+		client = &http.Client{Jar: jar}
+	}
+
+	maxPageSize := config.MaxPageSize
+	if maxPageSize <= 0 {
+		maxPageSize = DefaultMaxPageSize
+	}
+
+	b := &Browser{
+		LocalBackend: messaging.NewLocalBackend(messaging.LocalBackendConfig{
+			Logger:       config.Logger,
+			Name:         "static HTML browser",
+			Capabilities: config.Capabilities,
+		}),
+		logger:      config.Logger,
+		client:      client,
+		startURL:    config.StartURL,
+		maxPageSize: maxPageSize,
+		tabs:        make(map[int]*tab),
+		nextTabID:   1,
+	}
+
+	b.methods = map[string]method{
+		"get_browser_state": b.getBrowserState,
+		"get_dom_state":     b.getDomState,
+		"navigate_to":       b.navigateTo,
+		"navigate_history":  b.navigateHistory,
+		"click_element":     b.clickElement,
+		"type_value":        b.typeValue,
+		"manage_tabs":       b.manageTabs,
+	}
+
+	return b, nil
+}
+
+// Start opens the first tab, loads the start page into it and reports the
+// browser as connected. A start page that fails to load leaves the tab blank.
+func (b *Browser) Start() error {
+	b.mutex.Lock()
+	if b.started {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.started = true
+	first := b.openTabLocked()
+	b.activeTab = first.id
+	b.changes = nil
+	b.mutex.Unlock()
+
+	b.logger.Info("Starting static HTML browser", zap.String("startURL", b.startURL))
+
+	if b.startURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultNavigationTimeout)
+		request, err := http.NewRequest(http.MethodGet, b.startURL, nil)
+		if err == nil {
+			_, err = b.load(ctx, first.id, request)
+		}
+		cancel()
+		if err != nil {
+			b.logger.Warn("Failed to load start page", zap.String("url", b.startURL), zap.Error(err))
+		}
+		b.mutex.Lock()
+		b.changes = nil
+		b.mutex.Unlock()
+	}
+
+	b.SetConnected(Version, messaging.MethodNames(b.methods))
+	return nil
+}
+
+// RpcRequest serves an RPC method of the extension protocol from fetched pages
+func (b *Browser) RpcRequest(ctx context.Context, request types.RpcRequest, options types.RpcOptions) (types.RpcResponse, error) {
+	method, ok := b.methods[request.Method]
+	if !ok {
+		return types.RpcResponse{}, b.UnsupportedMethod(request.Method)
+	}
+
+	return b.Serve(ctx, request, options, func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+		result, err := method(ctx, params)
+
+		b.mutex.Lock()
+		changes := b.changes
+		b.changes = nil
+		b.mutex.Unlock()
+
+		b.DeliverStateChanges(changes...)
+		return result, err
+	})
+}
+
+// load fetches a page and shows it in a tab. The tab is not locked while the
+// page is fetched; if it was closed meanwhile, the page is dropped.
+func (b *Browser) load(ctx context.Context, tabID int, request *http.Request) (*document, error) {
+	doc, err := b.fetch(ctx, tabID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	t, ok := b.tabs[tabID]
+	if !ok {
+		return nil, fmt.Errorf("tab %d was closed while loading %s", tabID, request.URL)
+	}
+	t.visit(doc)
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_updated", "tabId": t.id, "url": doc.url.String()})
+	return doc, nil
+}
+
+// fetch fetches and parses a page for a tab
+func (b *Browser) fetch(ctx context.Context, tabID int, request *http.Request) (*document, error) {
+	if request.URL.Scheme != "http" && request.URL.Scheme != "https" {
+		return nil, fmt.Errorf("cannot load %s: only http and https URLs are supported", request.URL)
+	}
+
+	b.logger.Debug("Loading page", zap.String("method", request.Method), zap.String("url", request.URL.String()), zap.Int("tabId", tabID))
+
+	response, err := b.client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", request.URL, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, b.maxPageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", request.URL, err)
+	}
+	if int64(len(body)) > b.maxPageSize {
+		return nil, fmt.Errorf("page %s is larger than %d bytes", request.URL, b.maxPageSize)
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("%s is not an HTML page (content type %q)", request.URL, contentType)
+	}
+
+	// The final URL, after redirects, is the address of the page
+	doc, err := parseDocument(response.Request.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= 400 {
+		b.logger.Debug("Page loaded with an error status", zap.String("url", doc.url.String()), zap.Int("status", response.StatusCode))
+	}
+	return doc, nil
+}
+
+// openTabLocked opens a blank tab; b.mutex must be held
+func (b *Browser) openTabLocked() *tab {
+	blank := blankDocument()
+	t := &tab{id: b.nextTabID, doc: blank, history: []*document{blank}}
+	b.nextTabID++
+	b.tabs[t.id] = t
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_created", "tabId": t.id, "url": t.doc.url.String()})
+	return t
+}
+
+// visit shows a newly loaded page, dropping the pages the tab could go forward
+// to. The blank page of a new tab is replaced, as browsers replace it.
+func (t *tab) visit(doc *document) {
+	if len(t.history) == 1 && t.doc.url.Scheme == "about" {
+		t.history = t.history[:0]
+	} else {
+		t.history = t.history[:t.current+1]
+	}
+	t.history = append(t.history, doc)
+	if len(t.history) > maxHistoryLength {
+		t.history = t.history[len(t.history)-maxHistoryLength:]
+	}
+	t.current = len(t.history) - 1
+	t.doc = doc
+}
+
+// closeTabLocked closes a tab, activating the lowest remaining one if it was active; b.mutex must be held
+func (b *Browser) closeTabLocked(t *tab) {
+	delete(b.tabs, t.id)
+	if b.activeTab == t.id {
+		b.activeTab = 0
+		for id := range b.tabs {
+			if b.activeTab == 0 || id < b.activeTab {
+				b.activeTab = id
+			}
+		}
+	}
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_removed", "tabId": t.id})
+}
+
+// targetTabLocked returns the tab named by a numeric tab_id param, or the active tab; b.mutex must be held
+func (b *Browser) targetTabLocked(params map[string]interface{}) (*tab, error) {
+	_, t, err := messaging.TargetTab(params, b.tabs, b.activeTab)
+	return t, err
+}
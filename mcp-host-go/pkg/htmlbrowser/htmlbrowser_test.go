@@ -0,0 +1,292 @@
+package htmlbrowser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const homePage = `<!DOCTYPE html>
+<html>
+<head><title>Home</title><script>document.write("<a href='/script'>Script</a>")</script></head>
+<body>
+  <h1>Welcome</h1>
+  <p>Read the <a href="/docs">docs</a> or <a href="#top">go up</a>.</p>
+  <a href="/hidden" hidden>Hidden</a>
+  <a href="javascript:void(0)">Menu</a>
+  <noscript><a href="/basic">Basic site</a></noscript>
+  <form action="/search">
+    <input type="text" name="q" placeholder="Search">
+    <button>Go</button>
+  </form>
+  <form action="/login" method="post">
+    <input name="user" aria-label="User">
+    <input type="checkbox" name="remember" value="yes">
+    <select name="lang"><option value="en">English</option><option value="fr">French</option></select>
+    <textarea name="note"></textarea>
+    <input type="submit" name="action" value="Log in">
+  </form>
+  <a href="/docs" target="_blank">Docs in a new tab</a>
+</body>
+</html>`
+
+// newTestServer serves a small site with links, forms and a cookie-protected page
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, homePage)
+	})
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Docs</title></head><body><a href="/">Home</a></body></html>`)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><title>Search</title></head><body><p>Results for %s</p></body></html>`, r.URL.Query().Get("q"))
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		form, err := url.ParseQuery(string(body))
+		require.NoError(t, err)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: form.Get("user")})
+		http.Redirect(w, r, "/account?"+string(body), http.StatusSeeOther)
+	})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `<html><head><title>Log in</title></head><body><a href="/">Log in first</a></body></html>`)
+			return
+		}
+		fmt.Fprintf(w, `<html><head><title>Account</title></head><body><p>Hello %s, %s</p></body></html>`, cookie.Value, r.URL.RawQuery)
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestBrowser creates and starts a static HTML browser on the home page of a test server
+func newTestBrowser(t *testing.T, server *httptest.Server) (*Browser, *types.ExtensionCapabilities) {
+	capabilities := &types.ExtensionCapabilities{}
+	browser, err := NewBrowser(Config{
+		Logger:       logger.NewLoggerFromZap(zap.NewNop()),
+		StartURL:     server.URL + "/",
+		Capabilities: capabilities,
+	})
+	require.NoError(t, err)
+	require.NoError(t, browser.Start())
+	return browser, capabilities
+}
+
+// request makes an RPC request and returns its result, failing on errors
+func request(t *testing.T, browser *Browser, method string, params map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	response, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: method, Params: params}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.Nil(t, response.Error, "%s failed: %+v", method, response.Error)
+	return response.Result.(map[string]interface{})
+}
+
+// requestError makes an RPC request that must fail and returns the error message
+func requestError(t *testing.T, browser *Browser, method string, params map[string]interface{}) string {
+	t.Helper()
+	response, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: method, Params: params}, types.RpcOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, response.Error, "%s did not fail", method)
+	return response.Error.Message
+}
+
+// formattedDom returns the formattedDom of the active tab
+func formattedDom(t *testing.T, browser *Browser) string {
+	t.Helper()
+	return request(t, browser, "get_dom_state", nil)["formattedDom"].(string)
+}
+
+func TestBrowser_DomState(t *testing.T) {
+	server := newTestServer(t)
+	browser, capabilities := newTestBrowser(t, server)
+
+	info, ok := capabilities.Get()
+	require.True(t, ok)
+	assert.Equal(t, []string{"click_element", "get_browser_state", "get_dom_state", "manage_tabs", "navigate_history", "navigate_to", "type_value"}, info.Methods)
+
+	_, err := browser.RpcRequest(context.Background(), types.RpcRequest{Method: "scroll_page"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+
+	dom := request(t, browser, "get_dom_state", nil)
+	assert.Equal(t, "Home", dom["meta"].(map[string]interface{})["title"])
+	assert.Equal(t, []string{
+		"[Start of page]",
+		"Welcome",
+		"Read the",
+		`[0]<a href="/docs">docs />`,
+		"or",
+		`[1]<a href="#top">go up />`,
+		".",
+		`[2]<a href="javascript:void(0)">Menu />`,
+		`[3]<a href="/basic">Basic site />`,
+		`[4]<input placeholder="Search" name="q" type="text"> />`,
+		`[5]<button>Go />`,
+		`[6]<input aria-label="User" name="user">User />`,
+		`[7]<input name="remember" type="checkbox" value="yes">yes />`,
+		`[8]<select name="lang" value="en">English French />`,
+		`[9]<textarea name="note"> />`,
+		`[10]<input name="action" type="submit" value="Log in">Log in />`,
+		`[11]<a href="/docs">Docs in a new tab />`,
+		"[End of page]",
+	}, strings.Split(strings.TrimSuffix(dom["formattedDom"].(string), "\n"), "\n"))
+
+	elements := dom["interactiveElements"].([]interface{})
+	require.Len(t, elements, 12)
+	first := elements[0].(map[string]interface{})
+	assert.Equal(t, "a", first["tagName"])
+	assert.Equal(t, "docs", first["text"])
+	assert.Equal(t, true, first["isInViewport"])
+}
+
+func TestBrowser_LinksAndForms(t *testing.T) {
+	server := newTestServer(t)
+	browser, _ := newTestBrowser(t, server)
+
+	var changes []map[string]interface{}
+	browser.RegisterHandler("browser_state_changed", func(data interface{}) error {
+		changes = append(changes, data.(map[string]interface{}))
+		return nil
+	})
+
+	// Links within the page and script links stay on the page
+	for _, index := range []int{1, 2} {
+		result := request(t, browser, "click_element", map[string]interface{}{"element_index": index})
+		assert.Equal(t, false, result["page_changed"])
+	}
+	assert.Empty(t, changes)
+
+	result := request(t, browser, "click_element", map[string]interface{}{"element_index": 0})
+	assert.Equal(t, true, result["page_changed"])
+	assert.Equal(t, server.URL+"/docs", result["after_url"])
+	require.Len(t, changes, 1)
+	assert.Equal(t, "tab_updated", changes[0]["event"])
+
+	request(t, browser, "click_element", map[string]interface{}{"element_index": 0})
+
+	// Typing Enter into a text input submits its form
+	result = request(t, browser, "type_value", map[string]interface{}{"element_index": 4, "value": "lamps{Backspace}{Enter}"})
+	assert.Equal(t, "lamp", result["actual_value"])
+	assert.Len(t, result["operations_performed"], 3)
+	assert.Contains(t, formattedDom(t, browser), "Results for lamp")
+
+	request(t, browser, "navigate_to", map[string]interface{}{"url": server.URL + "/"})
+	request(t, browser, "type_value", map[string]interface{}{"element_index": 6, "value": "ada"})
+	request(t, browser, "type_value", map[string]interface{}{"element_index": 7, "value": true})
+	result = request(t, browser, "type_value", map[string]interface{}{"element_index": 8, "value": "French"})
+	assert.Equal(t, "fr", result["actual_value"])
+	request(t, browser, "type_value", map[string]interface{}{"element_index": 9, "value": "hi there"})
+	assert.Contains(t, formattedDom(t, browser), `[8]<select name="lang" value="fr">`)
+
+	assert.Contains(t, requestError(t, browser, "type_value", map[string]interface{}{"element_index": 8, "value": "German"}), "available options: en, fr")
+	assert.Contains(t, requestError(t, browser, "type_value", map[string]interface{}{"element_index": 10, "value": "x"}), "cannot be typed into")
+
+	// The login form posts its fields in document order and the session cookie is kept
+	result = request(t, browser, "click_element", map[string]interface{}{"element_index": 10})
+	assert.Equal(t, server.URL+"/account?user=ada&remember=yes&lang=fr&note=hi+there&action=Log+in", result["after_url"])
+	assert.Contains(t, formattedDom(t, browser), "Hello ada")
+}
+
+func TestBrowser_NavigateHistory(t *testing.T) {
+	server := newTestServer(t)
+	var cacheControl []string
+	server.Config.Handler = func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cacheControl = append(cacheControl, r.Header.Get("Cache-Control"))
+			next.ServeHTTP(w, r)
+		})
+	}(server.Config.Handler)
+	browser, _ := newTestBrowser(t, server)
+
+	// The start page replaced the blank page of the first tab
+	assert.Contains(t, requestError(t, browser, "navigate_history", map[string]interface{}{"action": "back"}), "cannot go back")
+
+	request(t, browser, "type_value", map[string]interface{}{"element_index": 6, "value": "ada"})
+	request(t, browser, "click_element", map[string]interface{}{"element_index": 0})
+
+	// Going back shows the page as it was left, without fetching it
+	fetches := len(cacheControl)
+	result := request(t, browser, "navigate_history", map[string]interface{}{"action": "back"})
+	assert.Equal(t, server.URL+"/", result["url"])
+	assert.Equal(t, "Home", result["title"])
+	assert.Contains(t, formattedDom(t, browser), `value="ada"`)
+	assert.Len(t, cacheControl, fetches)
+
+	result = request(t, browser, "navigate_history", map[string]interface{}{"action": "forward"})
+	assert.Equal(t, server.URL+"/docs", result["url"])
+	assert.Contains(t, requestError(t, browser, "navigate_history", map[string]interface{}{"action": "forward"}), "cannot go forward")
+
+	// Reloading fetches the page again, hard reloads bypass caches
+	request(t, browser, "navigate_history", map[string]interface{}{"action": "reload"})
+	request(t, browser, "navigate_history", map[string]interface{}{"action": "hard_reload"})
+	assert.Equal(t, []string{"", "no-cache"}, cacheControl[fetches:])
+
+	// A page reloaded from the history keeps its place
+	request(t, browser, "navigate_history", map[string]interface{}{"action": "back"})
+	request(t, browser, "navigate_history", map[string]interface{}{"action": "reload"})
+	assert.NotContains(t, formattedDom(t, browser), `value="ada"`)
+	result = request(t, browser, "navigate_history", map[string]interface{}{"action": "forward"})
+	assert.Equal(t, server.URL+"/docs", result["url"])
+}
+
+func TestBrowser_Tabs(t *testing.T) {
+	server := newTestServer(t)
+	browser, _ := newTestBrowser(t, server)
+
+	// target=_blank links open a new active tab and leave the page unchanged
+	result := request(t, browser, "click_element", map[string]interface{}{"element_index": 11})
+	assert.Equal(t, false, result["page_changed"])
+	state := request(t, browser, "get_browser_state", nil)
+	assert.Len(t, state["tabs"], 2)
+	assert.Equal(t, "Docs", state["activeTab"].(map[string]interface{})["title"])
+
+	result = request(t, browser, "manage_tabs", map[string]interface{}{"action": "open", "url": server.URL + "/search?q=x", "background": true})
+	assert.Equal(t, "3", result["new_tab_id"])
+	dom := request(t, browser, "get_dom_state", map[string]interface{}{"tab_id": 3})
+	assert.Contains(t, dom["formattedDom"], "Results for x")
+
+	request(t, browser, "manage_tabs", map[string]interface{}{"action": "close", "tab_id": "2"})
+	state = request(t, browser, "get_browser_state", nil)
+	assert.Equal(t, "Home", state["activeTab"].(map[string]interface{})["title"])
+
+	// Pages that fail to load leave no tab behind
+	assert.Contains(t, requestError(t, browser, "manage_tabs", map[string]interface{}{"action": "open", "url": server.URL + "/data.json"}), "is not an HTML page")
+	assert.Len(t, request(t, browser, "get_browser_state", nil)["tabs"], 2)
+}
+
+func TestBrowser_NavigationErrors(t *testing.T) {
+	server := newTestServer(t)
+	browser, _ := newTestBrowser(t, server)
+
+	assert.Contains(t, requestError(t, browser, "navigate_to", map[string]interface{}{"url": "file:///etc/passwd"}), "only http and https")
+	assert.Contains(t, requestError(t, browser, "navigate_to", map[string]interface{}{"url": server.URL + "/", "timeout": "soon"}), "timeout must be")
+	assert.Contains(t, requestError(t, browser, "click_element", map[string]interface{}{"element_index": 99}), "element with index 99 not found")
+
+	// Error pages are shown like a browser shows them
+	result := request(t, browser, "navigate_to", map[string]interface{}{"url": server.URL + "/account"})
+	assert.Equal(t, "Log in", result["title"])
+
+	server.Close()
+	assert.Contains(t, requestError(t, browser, "navigate_to", map[string]interface{}{"url": server.URL + "/"}), "failed to load")
+}
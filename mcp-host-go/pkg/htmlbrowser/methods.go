@@ -0,0 +1,581 @@
+package htmlbrowser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// keyPattern matches special keys like {Enter} and combinations like {Ctrl+A}
+var keyPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// specialKeys and modifierKeys are the key names type_value understands in braces
+var (
+	specialKeys = []string{
+		"Enter", "Tab", "Backspace", "Delete", "Escape", "Space", "Home", "End",
+		"PageUp", "PageDown", "ArrowUp", "ArrowDown", "ArrowLeft", "ArrowRight",
+	}
+	modifierKeys = []string{"Ctrl", "Control", "Alt", "Shift", "Meta", "Cmd"}
+)
+
+// getBrowserState lists the open tabs
+func (b *Browser) getBrowserState(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	tabs := make([]map[string]interface{}, 0, len(b.tabs))
+	var active map[string]interface{}
+	for id := 1; id < b.nextTabID; id++ {
+		t, ok := b.tabs[id]
+		if !ok {
+			continue
+		}
+		info := map[string]interface{}{
+			"id":     t.id,
+			"url":    t.doc.url.String(),
+			"title":  t.doc.title,
+			"active": t.id == b.activeTab,
+		}
+		if t.id == b.activeTab {
+			active = info
+		}
+		tabs = append(tabs, info)
+	}
+
+	return map[string]interface{}{
+		"activeTab": active,
+		"tabs":      tabs,
+	}, nil
+}
+
+// getDomState describes the page of a tab in the format of the extension
+func (b *Browser) getDomState(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"formattedDom":        t.doc.formattedDom(),
+		"interactiveElements": t.doc.interactiveElements(),
+		"meta": map[string]interface{}{
+			"url":         t.doc.url.String(),
+			"title":       t.doc.title,
+			"tabId":       t.id,
+			"pixelsAbove": 0,
+			"pixelsBelow": 0,
+		},
+	}, nil
+}
+
+// navigateTo fetches a page into a tab
+func (b *Browser) navigateTo(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	rawURL, _ := params["url"].(string)
+	if rawURL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	timeout, err := navigationTimeout(params)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	t, err := b.targetTabLocked(params)
+	b.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	doc, err := b.load(ctx, t.id, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Successfully navigated to %s", rawURL),
+		"url":     doc.url.String(),
+		"title":   doc.title,
+		"tabId":   t.id,
+	}, nil
+}
+
+// navigateHistory goes back or forward in the history of a tab, showing the
+// page as it was left, or fetches its page again
+func (b *Browser) navigateHistory(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	timeout, err := navigationTimeout(params)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		b.mutex.Unlock()
+		return nil, err
+	}
+
+	switch action {
+	case "back", "forward":
+		defer b.mutex.Unlock()
+		index := t.current + 1
+		if action == "back" {
+			index = t.current - 1
+		}
+		if index < 0 || index >= len(t.history) {
+			return nil, fmt.Errorf("cannot go %s: no page in the tab's history", action)
+		}
+		t.current = index
+		t.doc = t.history[index]
+		b.changes = append(b.changes, map[string]interface{}{"event": "tab_updated", "tabId": t.id, "url": t.doc.url.String()})
+		return historyResult(action, t.id, t.doc), nil
+	case "reload", "hard_reload":
+	default:
+		b.mutex.Unlock()
+		return nil, fmt.Errorf("action must be one of back, forward, reload or hard_reload")
+	}
+
+	reloaded := t.doc
+	b.mutex.Unlock()
+	if reloaded.url.Scheme == "about" {
+		return historyResult(action, t.id, reloaded), nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, reloaded.url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if action == "hard_reload" {
+		// Headers browsers send to bypass caches on the way
+		request.Header.Set("Cache-Control", "no-cache")
+		request.Header.Set("Pragma", "no-cache")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	doc, err := b.fetch(ctx, t.id, request)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.tabs[t.id]; !ok {
+		return nil, fmt.Errorf("tab %d was closed while loading %s", t.id, request.URL)
+	}
+	// The page fetched again takes the place of the old one in the history,
+	// unless the tab moved on meanwhile
+	if t.doc == reloaded {
+		t.history[t.current] = doc
+		t.doc = doc
+	} else {
+		t.visit(doc)
+	}
+	b.changes = append(b.changes, map[string]interface{}{"event": "tab_updated", "tabId": t.id, "url": doc.url.String()})
+	return historyResult(action, t.id, doc), nil
+}
+
+// historyResult is the navigate_history result for a page shown in a tab
+func historyResult(action string, tabID int, doc *document) map[string]interface{} {
+	return map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("History action %s completed on %s", action, doc.url),
+		"action":  action,
+		"url":     doc.url.String(),
+		"title":   doc.title,
+		"tabId":   tabID,
+	}
+}
+
+// clickElement follows a link, submits a form, or toggles a checkbox or radio
+// button. Other elements have no effect, as their scripts are not run.
+func (b *Browser) clickElement(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	t, index, element, err := b.targetElementLocked(params)
+	if err != nil {
+		b.mutex.Unlock()
+		return nil, err
+	}
+	if isDisabled(element) {
+		b.mutex.Unlock()
+		return nil, fmt.Errorf("element with index %d is disabled", index)
+	}
+
+	doc := t.doc
+	before := doc.url.String()
+	targetTab := t.id
+	var request *http.Request
+	switch {
+	case element.DataAtom == atom.Input && elementType(element) == "checkbox":
+		setChecked(element, !isChecked(element))
+	case element.DataAtom == atom.Input && elementType(element) == "radio":
+		setChecked(element, true)
+	case element.DataAtom == atom.A:
+		request, err = b.linkRequest(doc, element)
+		if request != nil && strings.EqualFold(attrValue(element, "target"), "_blank") {
+			opened := b.openTabLocked()
+			b.activeTab = opened.id
+			targetTab = opened.id
+		}
+	case isSubmitButton(element):
+		if form := formOf(element); form != nil {
+			request, err = doc.submission(form, element)
+		}
+	}
+	elementInfo := describe(element)
+	b.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	after := before
+	if request != nil {
+		loaded, err := b.load(ctx, targetTab, request)
+		if err != nil {
+			return nil, err
+		}
+		if targetTab == t.id {
+			after = loaded.url.String()
+		}
+	}
+
+	return map[string]interface{}{
+		"success":       true,
+		"message":       fmt.Sprintf("Successfully clicked element at index %d", index),
+		"element_index": index,
+		"page_changed":  request != nil && targetTab == t.id,
+		"element_info":  elementInfo,
+		"before_url":    before,
+		"after_url":     after,
+	}, nil
+}
+
+// typeValue sets the value of a form control. Typing {Enter} into a text
+// input, or the submit option, submits its form.
+func (b *Browser) typeValue(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	value, ok := params["value"]
+	if !ok {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	options, _ := params["options"].(map[string]interface{})
+	clearFirst := true
+	if clear, ok := options["clear_first"].(bool); ok {
+		clearFirst = clear
+	}
+	submit, _ := options["submit"].(bool)
+
+	b.mutex.Lock()
+	t, index, element, err := b.targetElementLocked(params)
+	if err != nil {
+		b.mutex.Unlock()
+		return nil, err
+	}
+	if isDisabled(element) {
+		b.mutex.Unlock()
+		return nil, fmt.Errorf("element with index %d is disabled", index)
+	}
+
+	var operations []map[string]interface{}
+	var actualValue interface{}
+	inputMethod := "keyboard"
+	kind := elementType(element)
+	switch kind {
+	case "select":
+		inputMethod = "text"
+		var choices []string
+		if list, ok := value.([]interface{}); ok {
+			for _, item := range list {
+				choices = append(choices, valueText(item))
+			}
+		} else {
+			choices = []string{valueText(value)}
+		}
+		err = selectOptions(element, choices)
+		operations = append(operations, map[string]interface{}{"type": "select", "value": value})
+		actualValue = strings.Join(selectedValues(element), ",")
+	case "checkbox", "radio":
+		inputMethod = "text"
+		checked, ok := value.(bool)
+		if !ok {
+			checked, _ = strconv.ParseBool(valueText(value))
+		}
+		setChecked(element, checked)
+		operations = append(operations, map[string]interface{}{"type": "select", "value": checked})
+		actualValue = isChecked(element)
+	case "input", "textarea", "contenteditable":
+		if element.DataAtom == atom.Input && !isTextInput(element) {
+			err = fmt.Errorf("element with index %d is a %s input and cannot be typed into", index, strings.ToLower(attrValue(element, "type")))
+			break
+		}
+		text := ""
+		if !clearFirst {
+			text = currentText(element)
+		}
+		var pressedEnter bool
+		text, operations, pressedEnter = typeKeys(text, valueText(value))
+		submit = submit || (pressedEnter && isTextInput(element))
+		if element.DataAtom == atom.Input {
+			setAttr(element, "value", text)
+		} else {
+			setTextContent(element, text)
+		}
+		actualValue = text
+	default:
+		err = fmt.Errorf("element with index %d is a %s and cannot be typed into", index, kind)
+	}
+
+	var request *http.Request
+	if err == nil && submit {
+		if form := formOf(element); form != nil {
+			request, err = t.doc.submission(form, nil)
+		}
+	}
+	elementInfo := describe(element)
+	b.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if request != nil {
+		if _, err := b.load(ctx, t.id, request); err != nil {
+			return nil, err
+		}
+	}
+
+	return map[string]interface{}{
+		"success":              true,
+		"message":              fmt.Sprintf("Successfully set %s at index %d", kind, index),
+		"element_index":        index,
+		"element_type":         kind,
+		"input_method":         inputMethod,
+		"actual_value":         actualValue,
+		"operations_performed": operations,
+		"element_info":         elementInfo,
+	}, nil
+}
+
+// manageTabs opens, switches to and closes tabs
+func (b *Browser) manageTabs(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	action, _ := params["action"].(string)
+	switch action {
+	case "open":
+		rawURL, _ := params["url"].(string)
+		if rawURL == "" {
+			return nil, fmt.Errorf("url is required for open action")
+		}
+		request, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url %q: %w", rawURL, err)
+		}
+
+		b.mutex.Lock()
+		t := b.openTabLocked()
+		if background, _ := params["background"].(bool); !background {
+			b.activeTab = t.id
+		}
+		b.mutex.Unlock()
+
+		if _, err := b.load(ctx, t.id, request); err != nil {
+			b.mutex.Lock()
+			b.closeTabLocked(t)
+			b.mutex.Unlock()
+			return nil, err
+		}
+		return map[string]interface{}{
+			"success":    true,
+			"message":    fmt.Sprintf("Opened tab %d with URL: %s", t.id, rawURL),
+			"new_tab_id": strconv.Itoa(t.id),
+		}, nil
+
+	case "switch", "close":
+		raw, _ := params["tab_id"].(string)
+		if raw == "" {
+			return nil, fmt.Errorf("tab_id is required")
+		}
+		tabID, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tab_id: %q", raw)
+		}
+
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		t, ok := b.tabs[tabID]
+		if !ok {
+			return nil, fmt.Errorf("tab %d not found", tabID)
+		}
+		if action == "switch" {
+			b.activeTab = t.id
+			return map[string]interface{}{"success": true, "message": fmt.Sprintf("Switched to tab %d", t.id)}, nil
+		}
+		b.closeTabLocked(t)
+		return map[string]interface{}{"success": true, "message": fmt.Sprintf("Closed tab %d", t.id)}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+// targetElementLocked returns the tab of a request and the element named by
+// its element_index param; b.mutex must be held
+func (b *Browser) targetElementLocked(params map[string]interface{}) (*tab, int, *html.Node, error) {
+	t, err := b.targetTabLocked(params)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	raw, ok := params["element_index"].(float64)
+	if !ok || raw < 0 || raw != float64(int(raw)) {
+		return nil, 0, nil, fmt.Errorf("element_index must be a non-negative integer")
+	}
+	index := int(raw)
+	element, err := t.doc.element(index)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return t, index, element, nil
+}
+
+// linkRequest returns the request that following a link makes, or nil for
+// links that do not load a page: script links and links within the page
+func (b *Browser) linkRequest(doc *document, link *html.Node) (*http.Request, error) {
+	href := strings.TrimSpace(attrValue(link, "href"))
+	if strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return nil, nil
+	}
+
+	target, err := doc.resolve(href)
+	if err != nil {
+		return nil, err
+	}
+	if withoutFragment(target) == withoutFragment(doc.url) && target.Fragment != "" {
+		return nil, nil
+	}
+	return http.NewRequest(http.MethodGet, target.String(), nil)
+}
+
+// navigationTimeout reads the timeout param of navigations: 'auto' or a
+// timeout in milliseconds
+func navigationTimeout(params map[string]interface{}) (time.Duration, error) {
+	raw, _ := params["timeout"].(string)
+	if raw == "" || raw == "auto" {
+		return DefaultNavigationTimeout, nil
+	}
+	milliseconds, err := strconv.Atoi(raw)
+	if err != nil || milliseconds <= 0 {
+		return 0, fmt.Errorf("timeout must be 'auto' or a timeout in milliseconds")
+	}
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+// withoutFragment returns a URL without its fragment
+func withoutFragment(u *url.URL) string {
+	copied := *u
+	copied.Fragment = ""
+	copied.RawFragment = ""
+	return copied.String()
+}
+
+// currentText returns the text of an input, textarea or editable element
+func currentText(n *html.Node) string {
+	if n.DataAtom == atom.Input {
+		return attrValue(n, "value")
+	}
+	return textContent(n)
+}
+
+// typeKeys types a type_value value after text. Braces that name a key are
+// pressed: Backspace deletes and Enter is reported; other keys only appear in
+// the operations.
+func typeKeys(text string, input string) (string, []map[string]interface{}, bool) {
+	var operations []map[string]interface{}
+	pressedEnter := false
+	last := 0
+	for _, match := range keyPattern.FindAllStringSubmatchIndex(input, -1) {
+		key := input[match[2]:match[3]]
+		if !isKey(key) {
+			continue
+		}
+		if match[0] > last {
+			text += input[last:match[0]]
+			operations = append(operations, map[string]interface{}{"type": "text", "content": input[last:match[0]]})
+		}
+		last = match[1]
+
+		switch key {
+		case "Enter":
+			pressedEnter = true
+		case "Backspace":
+			if runes := []rune(text); len(runes) > 0 {
+				text = string(runes[:len(runes)-1])
+			}
+		}
+		operations = append(operations, keyOperation(key))
+	}
+	if last < len(input) {
+		text += input[last:]
+		operations = append(operations, map[string]interface{}{"type": "text", "content": input[last:]})
+	}
+	return text, operations, pressedEnter
+}
+
+// isKey reports whether a name in braces is a key or a combination like Ctrl+A.
+// Other braces are typed as text.
+func isKey(name string) bool {
+	parts := strings.Split(name, "+")
+	for _, modifier := range parts[:len(parts)-1] {
+		if !slices.Contains(modifierKeys, modifier) {
+			return false
+		}
+	}
+	key := parts[len(parts)-1]
+	if len(parts) > 1 && len(key) == 1 {
+		return true
+	}
+	return slices.Contains(specialKeys, key)
+}
+
+// keyOperation describes a typed key like the extension's operations_performed
+func keyOperation(key string) map[string]interface{} {
+	parts := strings.Split(key, "+")
+	if len(parts) == 1 {
+		return map[string]interface{}{"type": "specialKey", "key": key}
+	}
+	return map[string]interface{}{"type": "modifierCombination", "modifiers": parts[:len(parts)-1], "key": parts[len(parts)-1]}
+}
+
+// valueText returns the text typed for a type_value value
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
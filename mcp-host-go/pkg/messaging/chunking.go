@@ -0,0 +1,165 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/google/uuid"
+)
+
+// Size limits of native messages
+const (
+	// MaxOutgoingMessageSize is Chrome's limit on a single message from the host.
+	// Larger messages are sent in chunks.
+	MaxOutgoingMessageSize = 1024 * 1024
+
+	// DefaultMaxMessageSize is the default limit on a whole message in either
+	// direction, after chunks are joined. It matches Chrome's limit on a single
+	// message from the extension.
+	DefaultMaxMessageSize = 64 * 1024 * 1024
+)
+
+// chunkMessageType is the type of messages carrying a types.MessageChunk
+const chunkMessageType = "chunk"
+
+// chunkPayloadSize is the number of message bytes per chunk. Base64 grows it to
+// about 700 KB, leaving room for the envelope under MaxOutgoingMessageSize.
+const chunkPayloadSize = 512 * 1024
+
+// chunkAssemblyTimeout is how long a partly received message is kept
+const chunkAssemblyTimeout = time.Minute
+
+// ErrMessageTooLarge is returned for messages over the size limit
+var ErrMessageTooLarge = errors.New("native message too large")
+
+// splitMessage splits message JSON into chunk messages of at most chunkSize bytes of payload each
+func splitMessage(messageJSON []byte, chunkSize int) ([][]byte, error) {
+	chunkID := uuid.New().String()
+	total := (len(messageJSON) + chunkSize - 1) / chunkSize
+
+	frames := make([][]byte, 0, total)
+	for seq := 0; seq < total; seq++ {
+		end := min((seq+1)*chunkSize, len(messageJSON))
+		frame, err := json.Marshal(types.Message{
+			Type: chunkMessageType,
+			Data: types.MessageChunk{
+				ChunkID: chunkID,
+				Seq:     seq,
+				Total:   total,
+				Payload: base64.StdEncoding.EncodeToString(messageJSON[seq*chunkSize : end]),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling chunk: %w", err)
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+// chunkedMessage is a message whose chunks are still arriving
+type chunkedMessage struct {
+	total   int
+	parts   map[int][]byte
+	size    int
+	started time.Time
+}
+
+// chunkAssembler joins chunks from the extension back into messages. Chunks
+// are added by the stdin reader goroutine only, so it needs no locking.
+type chunkAssembler struct {
+	maxSize int
+	timeout time.Duration
+	pending map[string]*chunkedMessage
+	size    int // Payload bytes held for all pending messages
+}
+
+// newChunkAssembler creates a chunkAssembler that holds at most maxSize bytes of pending messages
+func newChunkAssembler(maxSize int, timeout time.Duration) *chunkAssembler {
+	return &chunkAssembler{
+		maxSize: maxSize,
+		timeout: timeout,
+		pending: make(map[string]*chunkedMessage),
+	}
+}
+
+// add stores a chunk and returns the joined message JSON once all of its chunks
+// have arrived, or nil while chunks are missing. An invalid chunk discards the
+// whole message.
+func (a *chunkAssembler) add(chunk types.MessageChunk) ([]byte, error) {
+	now := time.Now()
+	a.expire(now)
+
+	if chunk.ChunkID == "" || chunk.Total <= 0 || chunk.Seq < 0 || chunk.Seq >= chunk.Total {
+		a.discard(chunk.ChunkID)
+		return nil, fmt.Errorf("invalid chunk %q: seq %d of %d", chunk.ChunkID, chunk.Seq, chunk.Total)
+	}
+
+	part, err := base64.StdEncoding.DecodeString(chunk.Payload)
+	if err != nil {
+		a.discard(chunk.ChunkID)
+		return nil, fmt.Errorf("invalid payload in chunk %q: %w", chunk.ChunkID, err)
+	}
+
+	message, exists := a.pending[chunk.ChunkID]
+	if !exists {
+		message = &chunkedMessage{
+			total:   chunk.Total,
+			parts:   make(map[int][]byte),
+			started: now,
+		}
+		a.pending[chunk.ChunkID] = message
+	}
+
+	if message.total != chunk.Total {
+		a.discard(chunk.ChunkID)
+		return nil, fmt.Errorf("chunk %q changed its total from %d to %d", chunk.ChunkID, message.total, chunk.Total)
+	}
+	if _, duplicate := message.parts[chunk.Seq]; duplicate {
+		a.discard(chunk.ChunkID)
+		return nil, fmt.Errorf("duplicate chunk %q: seq %d", chunk.ChunkID, chunk.Seq)
+	}
+	if a.size+len(part) > a.maxSize {
+		a.discard(chunk.ChunkID)
+		return nil, fmt.Errorf("chunked message %q exceeds the limit of %d bytes: %w", chunk.ChunkID, a.maxSize, ErrMessageTooLarge)
+	}
+
+	message.parts[chunk.Seq] = part
+	message.size += len(part)
+	a.size += len(part)
+
+	if len(message.parts) < message.total {
+		return nil, nil
+	}
+
+	a.discard(chunk.ChunkID)
+	var joined bytes.Buffer
+	joined.Grow(message.size)
+	for seq := 0; seq < message.total; seq++ {
+		joined.Write(message.parts[seq])
+	}
+	return joined.Bytes(), nil
+}
+
+// discard drops a pending message
+func (a *chunkAssembler) discard(chunkID string) {
+	if message, exists := a.pending[chunkID]; exists {
+		a.size -= message.size
+		delete(a.pending, chunkID)
+	}
+}
+
+// expire drops messages whose chunks stopped arriving
+func (a *chunkAssembler) expire(now time.Time) {
+	for chunkID, message := range a.pending {
+		if now.Sub(message.started) > a.timeout {
+			a.discard(chunkID)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package messaging
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkAssembler_RejectsInvalidChunks(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		chunks []types.MessageChunk
+	}{
+		{
+			name:   "missing chunk ID",
+			chunks: []types.MessageChunk{{Seq: 0, Total: 1, Payload: encode("{}")}},
+		},
+		{
+			name:   "sequence out of range",
+			chunks: []types.MessageChunk{{ChunkID: "a", Seq: 2, Total: 2, Payload: encode("{}")}},
+		},
+		{
+			name:   "invalid base64",
+			chunks: []types.MessageChunk{{ChunkID: "a", Seq: 0, Total: 2, Payload: "not base64!"}},
+		},
+		{
+			name: "duplicate chunk",
+			chunks: []types.MessageChunk{
+				{ChunkID: "a", Seq: 0, Total: 3, Payload: encode("{")},
+				{ChunkID: "a", Seq: 0, Total: 3, Payload: encode("{")},
+			},
+		},
+		{
+			name: "total changed",
+			chunks: []types.MessageChunk{
+				{ChunkID: "a", Seq: 0, Total: 3, Payload: encode("{")},
+				{ChunkID: "a", Seq: 1, Total: 2, Payload: encode("}")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assembler := newChunkAssembler(1024, time.Minute)
+
+			var err error
+			for _, chunk := range tt.chunks {
+				if _, err = assembler.add(chunk); err != nil {
+					break
+				}
+			}
+			assert.Error(t, err)
+			assert.Empty(t, assembler.pending)
+			assert.Zero(t, assembler.size)
+		})
+	}
+}
+
+func TestChunkAssembler_SizeLimit(t *testing.T) {
+	assembler := newChunkAssembler(10, time.Minute)
+
+	_, err := assembler.add(types.MessageChunk{ChunkID: "a", Seq: 0, Total: 3, Payload: base64.StdEncoding.EncodeToString([]byte("123456"))})
+	require.NoError(t, err)
+
+	// Pending messages count together towards the limit
+	_, err = assembler.add(types.MessageChunk{ChunkID: "b", Seq: 0, Total: 2, Payload: base64.StdEncoding.EncodeToString([]byte("123456"))})
+	assert.True(t, errors.Is(err, ErrMessageTooLarge), "unexpected error: %v", err)
+	assert.Equal(t, 6, assembler.size)
+}
+
+func TestChunkAssembler_ExpiresIncompleteMessages(t *testing.T) {
+	assembler := newChunkAssembler(1024, 10*time.Millisecond)
+
+	_, err := assembler.add(types.MessageChunk{ChunkID: "a", Seq: 0, Total: 2, Payload: base64.StdEncoding.EncodeToString([]byte("{"))})
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	// The rest of the expired message starts a new one that never completes
+	joined, err := assembler.add(types.MessageChunk{ChunkID: "a", Seq: 1, Total: 2, Payload: base64.StdEncoding.EncodeToString([]byte("}"))})
+	require.NoError(t, err)
+	assert.Nil(t, joined)
+	assert.Equal(t, 1, assembler.size)
+}
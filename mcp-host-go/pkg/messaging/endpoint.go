@@ -0,0 +1,659 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// endpoint implements the message protocol shared by the extension transports:
+// message handlers, RPC requests in both directions, heartbeats, chunking and
+// the inbound message queue. A transport reads messages from the extension
+// into processMessage and provides write to send them.
+type endpoint struct {
+	logger             logger.Logger
+	write              func(frame []byte) error // Sends one message to the extension; called with mutex held
+	maxMessageSize     int
+	chunks             *chunkAssembler // Used by the transport's reader goroutine only
+	workers            *workerPool
+	capabilities       *types.ExtensionCapabilities
+	messageHandlers    map[string]types.MessageHandler
+	rpcHandlers        map[string]types.RpcHandler
+	pendingRequests    map[string]*pendingRequest
+	mutex              sync.Mutex
+	heartbeatInterval  time.Duration
+	heartbeatTimeout   time.Duration
+	connected          bool      // Guarded by mutex
+	closed             bool      // The extension cannot come back; guarded by mutex
+	startTime          time.Time // Guarded by mutex
+	lastMessage        time.Time // Last message from the extension; guarded by mutex
+	connectionMutex    sync.Mutex
+	connectionHandlers []func(status types.ConnectionStatus)
+}
+
+// endpointConfig contains the transport-independent settings of an endpoint,
+// with zero values meaning the defaults
+type endpointConfig struct {
+	logger            logger.Logger
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	maxMessageSize    int
+	workers           int
+	queueSize         int
+	capabilities      *types.ExtensionCapabilities
+	connected         bool // Whether the extension is there from the start
+}
+
+// newEndpoint creates an endpoint that sends messages with write
+func newEndpoint(config endpointConfig, write func(frame []byte) error) *endpoint {
+	heartbeatInterval := config.heartbeatInterval
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	heartbeatTimeout := config.heartbeatTimeout
+	if heartbeatTimeout <= 0 {
+		heartbeatTimeout = DefaultHeartbeatTimeout
+	}
+
+	maxMessageSize := config.maxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultMaxMessageSize
+	}
+
+	workers := config.workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	queueSize := config.queueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	e := &endpoint{
+		logger:            config.logger,
+		write:             write,
+		maxMessageSize:    maxMessageSize,
+		capabilities:      config.capabilities,
+		chunks:            newChunkAssembler(maxMessageSize, chunkAssemblyTimeout),
+		messageHandlers:   make(map[string]types.MessageHandler),
+		rpcHandlers:       make(map[string]types.RpcHandler),
+		pendingRequests:   make(map[string]*pendingRequest),
+		heartbeatInterval: heartbeatInterval,
+		heartbeatTimeout:  heartbeatTimeout,
+		connected:         config.connected,
+		startTime:         time.Now(),
+	}
+
+	e.workers = newWorkerPool(workers, queueSize, func(message types.Message) {
+		if err := e.handleMessage(message); err != nil {
+			e.logger.Error("Error handling message", zap.Error(err), zap.Any("message", message))
+		}
+	})
+
+	e.registerRpcResponseHandler()
+	e.registerRpcProgressHandler()
+	e.RegisterHandler(pongMessageType, func(data interface{}) error {
+		return nil // Liveness is recorded for every message by recordMessage
+	})
+
+	return e
+}
+
+// start launches the workers and the heartbeat
+func (e *endpoint) start() {
+	e.workers.start()
+
+	e.mutex.Lock()
+	e.startTime = time.Now()
+	e.mutex.Unlock()
+
+	go e.runHeartbeat()
+}
+
+// Message types of the extension protocol
+const (
+	rpcProgressMessageType = "rpc_progress" // Sent by the extension to report progress of a running RPC request
+	pingMessageType        = "ping"         // Heartbeat sent by the host
+	pongMessageType        = "pong"         // Heartbeat reply sent by the extension
+)
+
+// Heartbeat defaults
+const (
+	DefaultHeartbeatInterval = 10 * time.Second
+	DefaultHeartbeatTimeout  = 30 * time.Second
+)
+
+// pendingRequest represents a pending RPC request
+type pendingRequest struct {
+	done       chan struct{}
+	response   types.RpcResponse
+	err        error
+	timer      *time.Timer
+	onProgress types.ProgressFunc
+}
+
+// runHeartbeat pings the extension until the connection closes and marks it disconnected
+// when nothing has been received from it for the heartbeat timeout
+func (e *endpoint) runHeartbeat() {
+	ticker := time.NewTicker(e.heartbeatInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		e.mutex.Lock()
+		closed := e.closed
+		connected := e.connected
+		lastSeen := e.lastMessage
+		if lastSeen.IsZero() {
+			lastSeen = e.startTime
+		}
+		e.mutex.Unlock()
+
+		if closed {
+			return
+		}
+
+		if connected && time.Since(lastSeen) > e.heartbeatTimeout {
+			e.logger.Warn("No message from the extension within the heartbeat timeout",
+				zap.Duration("timeout", e.heartbeatTimeout),
+				zap.Time("lastSeen", lastSeen))
+			e.setDisconnected("heartbeat timeout", false)
+		}
+
+		if err := e.SendMessage(types.Message{
+			Type: pingMessageType,
+			ID:   uuid.New().String(),
+		}); err != nil && !errors.Is(err, types.ErrExtensionDisconnected) {
+			e.logger.Warn("Failed to send heartbeat", zap.Error(err))
+		}
+	}
+}
+
+// recordMessage marks the extension as alive after it sent a message
+func (e *endpoint) recordMessage() {
+	e.mutex.Lock()
+	e.lastMessage = time.Now()
+	reconnected := !e.connected && !e.closed
+	if reconnected {
+		e.connected = true
+	}
+	status := e.connectionStatusLocked()
+	e.mutex.Unlock()
+
+	if reconnected {
+		e.logger.Info("Extension connection restored")
+		e.notifyConnectionChange(status)
+	}
+}
+
+// setDisconnected marks the extension as disconnected and fails every pending
+// request with types.ErrExtensionDisconnected. A closed connection stays
+// disconnected; after a heartbeat timeout the next message reconnects it.
+func (e *endpoint) setDisconnected(reason string, closed bool) {
+	e.mutex.Lock()
+	wasConnected := e.connected
+	e.connected = false
+	e.closed = e.closed || closed
+	pending := e.pendingRequests
+	e.pendingRequests = make(map[string]*pendingRequest)
+	status := e.connectionStatusLocked()
+	e.mutex.Unlock()
+
+	for id, request := range pending {
+		request.timer.Stop()
+		request.err = fmt.Errorf("RPC request failed (id: %s), %s: %w", id, reason, types.ErrExtensionDisconnected)
+		close(request.done)
+	}
+
+	if wasConnected {
+		e.logger.Warn("Extension disconnected",
+			zap.String("reason", reason),
+			zap.Int("failedRequests", len(pending)))
+		e.notifyConnectionChange(status)
+	}
+}
+
+// ConnectionStatus returns whether the extension is connected and when it was last heard from
+func (e *endpoint) ConnectionStatus() types.ConnectionStatus {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.connectionStatusLocked()
+}
+
+// connectionStatusLocked returns the connection status; e.mutex must be held
+func (e *endpoint) connectionStatusLocked() types.ConnectionStatus {
+	status := types.ConnectionStatus{IsConnected: e.connected}
+	if !e.lastMessage.IsZero() {
+		status.LastPing = e.lastMessage.UnixMilli()
+	}
+	return status
+}
+
+// OnConnectionChange registers a handler called whenever the extension connects or disconnects
+func (e *endpoint) OnConnectionChange(handler func(status types.ConnectionStatus)) {
+	e.connectionMutex.Lock()
+	defer e.connectionMutex.Unlock()
+
+	e.connectionHandlers = append(e.connectionHandlers, handler)
+}
+
+// notifyConnectionChange calls the connection change handlers
+func (e *endpoint) notifyConnectionChange(status types.ConnectionStatus) {
+	e.connectionMutex.Lock()
+	handlers := append([]func(status types.ConnectionStatus){}, e.connectionHandlers...)
+	e.connectionMutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(status)
+	}
+}
+
+// processMessage parses a message and handles it, joining chunked messages first
+func (e *endpoint) processMessage(messageJSON []byte) {
+	var message types.Message
+	if err := json.Unmarshal(messageJSON, &message); err != nil {
+		e.logger.Error("Error parsing message JSON", zap.Error(err), zap.String("json", string(messageJSON)))
+		return
+	}
+
+	if message.Type == chunkMessageType {
+		var envelope struct {
+			Data types.MessageChunk `json:"data"`
+		}
+		if err := json.Unmarshal(messageJSON, &envelope); err != nil {
+			e.logger.Error("Error parsing message chunk", zap.Error(err))
+			return
+		}
+
+		joined, err := e.chunks.add(envelope.Data)
+		if err != nil {
+			e.logger.Error("Dropping chunked message", zap.Error(err))
+			return
+		}
+		if joined != nil {
+			e.processMessage(joined)
+		}
+		return
+	}
+
+	// Responses only complete a pending request, and workers may be waiting
+	// for them, so they never queue behind other messages
+	if message.Type == "rpc_response" {
+		if err := e.handleMessage(message); err != nil {
+			e.logger.Error("Error handling message", zap.Error(err), zap.Any("message", message))
+		}
+		return
+	}
+
+	if !e.workers.submit(message) {
+		e.refuseMessage(message)
+	}
+}
+
+// refuseMessage handles a message that did not fit in the queue. RPC requests
+// are answered with an error so the extension does not wait for them to time
+// out; other messages are dropped.
+func (e *endpoint) refuseMessage(message types.Message) {
+	rejected := message.Type == "rpc_request"
+	e.workers.recordRefused(rejected)
+
+	if !rejected {
+		e.logger.Warn("Message queue full, dropping message", zap.String("type", message.Type))
+		return
+	}
+
+	e.logger.Warn("Message queue full, rejecting RPC request", zap.String("method", message.Method), zap.String("id", message.ID))
+	if err := e.SendMessage(types.Message{
+		Type: "rpc_response",
+		ID:   message.ID,
+		Error: &types.ErrorInfo{
+			Code:    -32000, // Server error (JSON-RPC spec)
+			Message: "Server busy: message queue is full",
+		},
+	}); err != nil {
+		e.logger.Warn("Failed to reject RPC request", zap.Error(err), zap.String("id", message.ID))
+	}
+}
+
+// QueueStats reports the queue of messages from the extension
+func (e *endpoint) QueueStats() types.MessageQueueStats {
+	return e.workers.stats()
+}
+
+// handleMessage processes a received message
+func (e *endpoint) handleMessage(message types.Message) error {
+	e.logger.Info("Received message", zap.Any("message", message))
+
+	handler, ok := e.messageHandlers[message.Type]
+	if !ok {
+		e.logger.Warn("No handler registered for message type", zap.String("type", message.Type))
+		return e.SendMessage(types.Message{
+			Type:  "error",
+			Error: &types.ErrorInfo{Message: fmt.Sprintf("Unknown message type: %s", message.Type)},
+		})
+	}
+
+	var data interface{}
+	switch message.Type {
+	case "rpc_request":
+		data = types.RpcRequest{
+			ID:     message.ID,
+			Method: message.Method,
+			Params: message.Params,
+		}
+	case "rpc_response":
+		data = types.RpcResponse{
+			ID:     message.ID,
+			Result: message.Result,
+			Error:  message.Error,
+		}
+	case rpcProgressMessageType:
+		// Progress updates are routed by request ID, so keep the whole message
+		data = message
+	default:
+		data = message.Data
+	}
+
+	return handler(data)
+}
+
+// RegisterHandler registers a handler for a specific message type
+func (e *endpoint) RegisterHandler(messageType string, handler types.MessageHandler) {
+	e.logger.Debug("Registering handler for message type", zap.String("type", messageType))
+	e.messageHandlers[messageType] = handler
+}
+
+// RegisterRpcMethod registers a handler for an RPC method
+func (e *endpoint) RegisterRpcMethod(method string, handler types.RpcHandler) {
+	e.logger.Debug("Registering RPC handler for method", zap.String("method", method))
+	e.rpcHandlers[method] = handler
+
+	// Register the RPC request handler if not already registered
+	if _, exists := e.messageHandlers["rpc_request"]; !exists {
+		e.registerRpcRequestHandler()
+	}
+}
+
+// SendMessage sends a message to the extension. Messages over MaxOutgoingMessageSize
+// are split into chunks, which the extension joins again.
+func (e *endpoint) SendMessage(message types.Message) error {
+	e.logger.Debug("Sending message", zap.Any("message", message))
+
+	// Convert message to JSON
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshaling message: %w", err)
+	}
+
+	if len(messageJSON) > e.maxMessageSize {
+		return fmt.Errorf("%s message of %d bytes exceeds the limit of %d bytes: %w", message.Type, len(messageJSON), e.maxMessageSize, ErrMessageTooLarge)
+	}
+
+	frames := [][]byte{messageJSON}
+	if len(messageJSON) > MaxOutgoingMessageSize {
+		frames, err = splitMessage(messageJSON, chunkPayloadSize)
+		if err != nil {
+			return err
+		}
+		e.logger.Debug("Sending message in chunks", zap.String("type", message.Type), zap.Int("size", len(messageJSON)), zap.Int("chunks", len(frames)))
+	}
+
+	// Keep the chunks of a message together
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for _, frame := range frames {
+		if err := e.write(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RpcRequest sends an RPC request and waits for the response. If ctx is
+// cancelled or its deadline passes first, the extension is sent an rpc_cancel
+// message so it can abort the in-flight operation.
+func (e *endpoint) RpcRequest(ctx context.Context, request types.RpcRequest, options types.RpcOptions) (types.RpcResponse, error) {
+	id := request.ID
+	if id == "" {
+		id = uuid.New().String()
+		request.ID = id
+	}
+
+	if err := ctx.Err(); err != nil {
+		return types.RpcResponse{}, fmt.Errorf("RPC request cancelled: %s (id: %s): %w", request.Method, id, err)
+	}
+
+	// Fail with a clear reason instead of a "Method not found" from an older extension
+	if e.capabilities != nil {
+		if err := e.capabilities.Check(request.Method); err != nil {
+			e.logger.Warn("RPC request not supported by the extension", zap.String("method", request.Method), zap.Error(err))
+			return types.RpcResponse{}, fmt.Errorf("RPC request %s (id: %s) not sent: %w", request.Method, id, err)
+		}
+	}
+
+	e.logger.Info("Sending RPC request", zap.String("method", request.Method), zap.String("id", id))
+
+	timeout := 5000 // Default 5 seconds
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+
+	// Create pending request
+	pending := &pendingRequest{
+		done:       make(chan struct{}),
+		onProgress: options.OnProgress,
+	}
+	if pending.onProgress == nil {
+		pending.onProgress = types.ProgressFromContext(ctx)
+	}
+
+	// Set timeout
+	pending.timer = time.AfterFunc(time.Duration(timeout)*time.Millisecond, func() {
+		if e.removePending(id) {
+			pending.err = fmt.Errorf("RPC request timeout: %s (id: %s)", request.Method, id)
+			close(pending.done)
+			e.sendRpcCancel(id, "timeout")
+		}
+	})
+
+	// Register pending request, unless the extension is gone
+	e.mutex.Lock()
+	if !e.connected {
+		e.mutex.Unlock()
+		pending.timer.Stop()
+		e.logger.Warn("RPC request while extension is disconnected", zap.String("method", request.Method), zap.String("id", id))
+		return types.RpcResponse{}, fmt.Errorf("RPC request %s (id: %s) not sent: %w", request.Method, id, types.ErrExtensionDisconnected)
+	}
+	e.pendingRequests[id] = pending
+	e.mutex.Unlock()
+
+	// Send the request
+	if err := e.SendMessage(types.Message{
+		Type:   "rpc_request",
+		ID:     id,
+		Method: request.Method,
+		Params: request.Params,
+	}); err != nil {
+		e.removePending(id)
+		pending.timer.Stop()
+		return types.RpcResponse{}, err
+	}
+
+	// Wait for response, timeout or cancellation
+	select {
+	case <-pending.done:
+	case <-ctx.Done():
+		if e.removePending(id) {
+			pending.timer.Stop()
+			e.logger.Info("RPC request cancelled", zap.String("method", request.Method), zap.String("id", id), zap.Error(ctx.Err()))
+			e.sendRpcCancel(id, "cancelled")
+			return types.RpcResponse{}, fmt.Errorf("RPC request cancelled: %s (id: %s): %w", request.Method, id, ctx.Err())
+		}
+		// The response or timeout won the race
+		<-pending.done
+	}
+
+	e.logger.Info("RPC request Done", zap.Any("response", pending.response), zap.Any("err", pending.err))
+
+	return pending.response, pending.err
+}
+
+// removePending removes a pending request, reporting whether it was still pending
+func (e *endpoint) removePending(id string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, exists := e.pendingRequests[id]; !exists {
+		return false
+	}
+	delete(e.pendingRequests, id)
+	return true
+}
+
+// sendRpcCancel tells the extension to abort the operation for an RPC request
+// that the host is no longer waiting for
+func (e *endpoint) sendRpcCancel(id string, reason string) {
+	if err := e.SendMessage(types.Message{
+		Type: "rpc_cancel",
+		ID:   id,
+		Data: map[string]interface{}{
+			"reason": reason,
+		},
+	}); err != nil {
+		e.logger.Warn("Failed to send rpc_cancel", zap.Error(err), zap.String("id", id))
+	}
+}
+
+// registerRpcResponseHandler registers a handler for RPC responses
+func (e *endpoint) registerRpcResponseHandler() {
+	e.RegisterHandler("rpc_response", func(data interface{}) error {
+		response, ok := data.(types.RpcResponse)
+		if !ok {
+			return fmt.Errorf("invalid RPC response format")
+		}
+
+		id := response.ID
+		e.logger.Debug("Received RPC response for ID", zap.String("id", id))
+
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+
+		pending, exists := e.pendingRequests[id]
+		if !exists {
+			e.logger.Warn("No pending request found for RPC response ID", zap.String("id", id))
+			return nil
+		}
+
+		// Stop the timer
+		pending.timer.Stop()
+
+		// Set the response
+		pending.response = response
+
+		// Signal completion
+		close(pending.done)
+
+		// Remove from pending requests
+		delete(e.pendingRequests, id)
+
+		return nil
+	})
+}
+
+// registerRpcProgressHandler registers a handler that relays progress updates
+// from the extension to the pending request they belong to
+func (e *endpoint) registerRpcProgressHandler() {
+	e.RegisterHandler(rpcProgressMessageType, func(data interface{}) error {
+		message, ok := data.(types.Message)
+		if !ok {
+			return fmt.Errorf("invalid RPC progress format")
+		}
+
+		var progress types.RpcProgress
+		dataBytes, err := json.Marshal(message.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal RPC progress: %w", err)
+		}
+		if err := json.Unmarshal(dataBytes, &progress); err != nil {
+			return fmt.Errorf("invalid RPC progress data: %w", err)
+		}
+
+		e.mutex.Lock()
+		pending, exists := e.pendingRequests[message.ID]
+		e.mutex.Unlock()
+
+		if !exists {
+			// The request already finished, was cancelled or timed out
+			e.logger.Debug("No pending request found for RPC progress ID", zap.String("id", message.ID))
+			return nil
+		}
+		if pending.onProgress == nil {
+			return nil
+		}
+
+		pending.onProgress(progress)
+		return nil
+	})
+}
+
+// registerRpcRequestHandler registers a handler for RPC requests
+func (e *endpoint) registerRpcRequestHandler() {
+	e.RegisterHandler("rpc_request", func(data interface{}) error {
+		request, ok := data.(types.RpcRequest)
+		if !ok {
+			return fmt.Errorf("invalid RPC request format")
+		}
+
+		method := request.Method
+		e.logger.Debug("Handling RPC request", zap.String("method", method))
+
+		handler, exists := e.rpcHandlers[method]
+		if !exists {
+			e.logger.Warn("No handler registered for RPC method", zap.String("method", method))
+			return e.SendMessage(types.Message{
+				Type: "rpc_response",
+				ID:   request.ID,
+				Error: &types.ErrorInfo{
+					Code:    -32601, // Method not found (JSON-RPC spec)
+					Message: fmt.Sprintf("Method not found: %s", method),
+				},
+			})
+		}
+
+		// Handle the request
+		response, err := handler(request)
+		if err != nil {
+			e.logger.Error("Error in RPC handler", zap.Error(err))
+			return e.SendMessage(types.Message{
+				Type: "rpc_response",
+				ID:   request.ID,
+				Error: &types.ErrorInfo{
+					Code:    -32000, // Server error (JSON-RPC spec)
+					Message: fmt.Sprintf("Server error: %s", err.Error()),
+				},
+			})
+		}
+
+		// Ensure ID is set correctly
+		response.ID = request.ID
+
+		// Send response
+		return e.SendMessage(types.Message{
+			Type:   "rpc_response",
+			ID:     response.ID,
+			Result: response.Result,
+			Error:  response.Error,
+		})
+	})
+}
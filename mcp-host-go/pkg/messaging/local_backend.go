@@ -0,0 +1,249 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// BrowserStateChangedMessageType is the message the extension sends when tabs
+// change; local backends deliver it to handlers for their own tab changes
+const BrowserStateChangedMessageType = "browser_state_changed"
+
+// defaultLocalRpcTimeout bounds requests without a timeout, like requests to the extension
+const defaultLocalRpcTimeout = 5000
+
+// LocalBackend is embedded by backends that serve the extension's RPC methods
+// in the host instead of forwarding them to an extension, such as the CDP
+// backend, the fake browser and the static HTML backend. It provides the parts
+// of types.Messaging they share: connection state and its handlers, message
+// handlers, and serving requests in their JSON form. There is no extension to
+// send messages to or to receive RPC requests from.
+type LocalBackend struct {
+	logger       logger.Logger
+	name         string
+	capabilities *types.ExtensionCapabilities
+	lastPing     func() int64
+
+	mutex              sync.Mutex
+	connected          bool
+	messageHandlers    map[string]types.MessageHandler
+	connectionHandlers []func(status types.ConnectionStatus)
+}
+
+// LocalBackendConfig contains configuration for LocalBackend
+type LocalBackendConfig struct {
+	Logger       logger.Logger
+	Name         string // Name of the backend in errors, e.g. "fake browser"
+	Capabilities *types.ExtensionCapabilities
+	LastPing     func() int64 // Unix milliseconds the browser was last heard from, defaults to now
+}
+
+// NewLocalBackend creates a LocalBackend that is not connected yet
+func NewLocalBackend(config LocalBackendConfig) *LocalBackend {
+	lastPing := config.LastPing
+	if lastPing == nil {
+		lastPing = func() int64 { return time.Now().UnixMilli() }
+	}
+
+	return &LocalBackend{
+		logger:          config.Logger,
+		name:            config.Name,
+		capabilities:    config.Capabilities,
+		lastPing:        lastPing,
+		messageHandlers: make(map[string]types.MessageHandler),
+	}
+}
+
+// SetConnected reports the backend as connected, announcing the version and
+// RPC methods it serves, and calls the connection change handlers
+func (l *LocalBackend) SetConnected(version string, methods []string) {
+	if l.capabilities != nil {
+		l.capabilities.Set(types.ExtensionInfo{
+			Version:         version,
+			ProtocolVersion: types.ProtocolVersion,
+			Methods:         methods,
+			Compatible:      true,
+		})
+	}
+
+	l.mutex.Lock()
+	l.connected = true
+	l.mutex.Unlock()
+
+	l.notifyConnectionChange()
+}
+
+// SetDisconnected reports the backend as disconnected and calls the connection change handlers
+func (l *LocalBackend) SetDisconnected() {
+	l.mutex.Lock()
+	l.connected = false
+	l.mutex.Unlock()
+
+	l.notifyConnectionChange()
+}
+
+// ConnectionStatus returns whether the backend is connected and when the browser was last heard from
+func (l *LocalBackend) ConnectionStatus() types.ConnectionStatus {
+	l.mutex.Lock()
+	connected := l.connected
+	l.mutex.Unlock()
+
+	if !connected {
+		return types.ConnectionStatus{}
+	}
+	return types.ConnectionStatus{IsConnected: true, LastPing: l.lastPing()}
+}
+
+// OnConnectionChange registers a handler called whenever the backend connects or disconnects
+func (l *LocalBackend) OnConnectionChange(handler func(status types.ConnectionStatus)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.connectionHandlers = append(l.connectionHandlers, handler)
+}
+
+// notifyConnectionChange calls the connection change handlers with the current status
+func (l *LocalBackend) notifyConnectionChange() {
+	status := l.ConnectionStatus()
+
+	l.mutex.Lock()
+	handlers := append([]func(status types.ConnectionStatus){}, l.connectionHandlers...)
+	l.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(status)
+	}
+}
+
+// RegisterHandler registers a handler for messages of a type. Local backends
+// produce browser_state_changed messages only.
+func (l *LocalBackend) RegisterHandler(messageType string, handler types.MessageHandler) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.messageHandlers[messageType] = handler
+}
+
+// DeliverStateChanges hands browser_state_changed messages to their handler, if any
+func (l *LocalBackend) DeliverStateChanges(changes ...map[string]interface{}) {
+	l.mutex.Lock()
+	handler := l.messageHandlers[BrowserStateChangedMessageType]
+	l.mutex.Unlock()
+
+	if handler == nil {
+		return
+	}
+	for _, change := range changes {
+		if err := handler(change); err != nil {
+			l.logger.Warn("Error handling browser state change", zap.String("backend", l.name), zap.Error(err))
+		}
+	}
+}
+
+// RegisterRpcMethod accepts handlers for RPC requests from the extension.
+// There is no extension, so they are never called.
+func (l *LocalBackend) RegisterRpcMethod(method string, handler types.RpcHandler) {}
+
+// SendMessage fails: there is no extension to send messages to
+func (l *LocalBackend) SendMessage(message types.Message) error {
+	return fmt.Errorf("cannot send %s message to the %s: %w", message.Type, l.name, types.ErrMethodUnsupported)
+}
+
+// UnsupportedMethod returns the error of a request for a method the backend does not serve
+func (l *LocalBackend) UnsupportedMethod(method string) error {
+	return fmt.Errorf("RPC method %s is not available in the %s: %w", method, l.name, types.ErrMethodUnsupported)
+}
+
+// Serve answers an RPC request with serve, working on the JSON form of its
+// params and result as if the request had been sent to the extension. The
+// call is bounded by the request timeout. Errors of serve become RPC error
+// responses, unless the backend went away or the request timed out.
+func (l *LocalBackend) Serve(ctx context.Context, request types.RpcRequest, options types.RpcOptions, serve func(ctx context.Context, params map[string]interface{}) (interface{}, error)) (types.RpcResponse, error) {
+	if !l.ConnectionStatus().IsConnected {
+		return types.RpcResponse{}, fmt.Errorf("RPC request %s not sent, %s is not connected: %w", request.Method, l.name, types.ErrExtensionDisconnected)
+	}
+
+	timeout := defaultLocalRpcTimeout
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+
+	var params map[string]interface{}
+	if err := roundTripJSON(request.Params, &params); err != nil {
+		return types.RpcResponse{}, fmt.Errorf("invalid %s params: %w", request.Method, err)
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+
+	result, err := serve(ctx, params)
+	if err != nil {
+		if errors.Is(err, types.ErrExtensionDisconnected) {
+			return types.RpcResponse{}, err
+		}
+		if ctx.Err() != nil {
+			return types.RpcResponse{}, fmt.Errorf("RPC request timeout: %s: %w", request.Method, err)
+		}
+		l.logger.Debug("Request failed", zap.String("backend", l.name), zap.String("method", request.Method), zap.Error(err))
+		return types.RpcResponse{ID: request.ID, Error: &types.ErrorInfo{Code: -32000, Message: err.Error()}}, nil
+	}
+
+	var response interface{}
+	if err := roundTripJSON(result, &response); err != nil {
+		return types.RpcResponse{}, fmt.Errorf("invalid %s result: %w", request.Method, err)
+	}
+	return types.RpcResponse{ID: request.ID, Result: response}, nil
+}
+
+// MethodNames returns the sorted names of the RPC methods in a method table
+func MethodNames[M any](methods map[string]M) []string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TargetTab returns the tab named by a numeric tab_id param, or the active tab
+func TargetTab[T any](params map[string]interface{}, tabs map[int]T, activeTab int) (int, T, error) {
+	var none T
+
+	tabID := activeTab
+	if raw, ok := params["tab_id"]; ok && raw != nil {
+		id, ok := types.ParseTabID(raw)
+		if !ok {
+			return 0, none, fmt.Errorf("invalid tab_id: must be an integer")
+		}
+		tabID = id
+	}
+
+	tab, ok := tabs[tabID]
+	if !ok {
+		if tabID == 0 {
+			return 0, none, fmt.Errorf("no tab is open")
+		}
+		return 0, none, fmt.Errorf("tab %d not found", tabID)
+	}
+	return tabID, tab, nil
+}
+
+// roundTripJSON converts a value to target through its JSON encoding
+func roundTripJSON(value interface{}, target interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
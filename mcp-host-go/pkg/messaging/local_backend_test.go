@@ -0,0 +1,179 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestLocalBackend creates a LocalBackend that is not connected yet
+func newTestLocalBackend(capabilities *types.ExtensionCapabilities) *LocalBackend {
+	return NewLocalBackend(LocalBackendConfig{
+		Logger:       logger.NewLoggerFromZap(zap.NewNop()),
+		Name:         "test browser",
+		Capabilities: capabilities,
+		LastPing:     func() int64 { return 42 },
+	})
+}
+
+func TestLocalBackend_ReportsConnectionChanges(t *testing.T) {
+	capabilities := &types.ExtensionCapabilities{}
+	backend := newTestLocalBackend(capabilities)
+
+	var statuses []types.ConnectionStatus
+	backend.OnConnectionChange(func(status types.ConnectionStatus) {
+		statuses = append(statuses, status)
+	})
+	assert.False(t, backend.ConnectionStatus().IsConnected)
+
+	backend.SetConnected("1.0.0", []string{"get_dom_state"})
+	backend.SetDisconnected()
+
+	assert.Equal(t, []types.ConnectionStatus{{IsConnected: true, LastPing: 42}, {}}, statuses)
+	info, ok := capabilities.Get()
+	require.True(t, ok)
+	assert.Equal(t, "1.0.0", info.Version)
+	assert.Equal(t, []string{"get_dom_state"}, info.Methods)
+	assert.True(t, info.Compatible)
+}
+
+func TestLocalBackend_Serve(t *testing.T) {
+	backend := newTestLocalBackend(nil)
+
+	echo := func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+		return params, nil
+	}
+
+	t.Run("not connected", func(t *testing.T) {
+		_, err := backend.Serve(context.Background(), types.RpcRequest{Method: "echo"}, types.RpcOptions{}, echo)
+		assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+	})
+
+	backend.SetConnected("1.0.0", []string{"echo"})
+
+	t.Run("params and result in JSON form", func(t *testing.T) {
+		resp, err := backend.Serve(context.Background(), types.RpcRequest{
+			ID:     "1",
+			Method: "echo",
+			Params: struct {
+				TabID int `json:"tab_id"`
+			}{TabID: 2},
+		}, types.RpcOptions{}, echo)
+		require.NoError(t, err)
+		assert.Equal(t, "1", resp.ID)
+		assert.Equal(t, map[string]interface{}{"tab_id": float64(2)}, resp.Result)
+	})
+
+	t.Run("failures become RPC errors", func(t *testing.T) {
+		resp, err := backend.Serve(context.Background(), types.RpcRequest{Method: "fail"}, types.RpcOptions{},
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				return nil, fmt.Errorf("no such element")
+			})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, "no such element", resp.Error.Message)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := backend.Serve(context.Background(), types.RpcRequest{Method: "wait"}, types.RpcOptions{Timeout: 10},
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "RPC request timeout")
+	})
+
+	t.Run("disconnected while serving", func(t *testing.T) {
+		_, err := backend.Serve(context.Background(), types.RpcRequest{Method: "echo"}, types.RpcOptions{},
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				return nil, fmt.Errorf("browser went away: %w", types.ErrExtensionDisconnected)
+			})
+		assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+	})
+}
+
+func TestLocalBackend_DeliversStateChanges(t *testing.T) {
+	backend := newTestLocalBackend(nil)
+	backend.DeliverStateChanges(map[string]interface{}{"event": "tab_created"})
+
+	received := make(chan interface{}, 2)
+	backend.RegisterHandler(BrowserStateChangedMessageType, func(data interface{}) error {
+		received <- data
+		return nil
+	})
+	backend.DeliverStateChanges(
+		map[string]interface{}{"event": "tab_created", "tabId": 2},
+		map[string]interface{}{"event": "tab_removed", "tabId": 1},
+	)
+
+	for _, event := range []string{"tab_created", "tab_removed"} {
+		select {
+		case data := <-received:
+			assert.Equal(t, event, data.(map[string]interface{})["event"])
+		case <-time.After(time.Second):
+			t.Fatal("state change was not delivered")
+		}
+	}
+}
+
+func TestLocalBackend_RefusesExtensionOnlyOperations(t *testing.T) {
+	backend := newTestLocalBackend(nil)
+
+	err := backend.SendMessage(types.Message{Type: "ping"})
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "test browser")
+
+	err = backend.UnsupportedMethod("wait_for")
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+}
+
+func TestTargetTab(t *testing.T) {
+	tabs := map[int]string{1: "first", 2: "second"}
+
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		tabs    map[int]string
+		tabID   int
+		tab     string
+		wantErr string
+	}{
+		{name: "active tab", params: map[string]interface{}{}, tabs: tabs, tabID: 1, tab: "first"},
+		{name: "named tab", params: map[string]interface{}{"tab_id": float64(2)}, tabs: tabs, tabID: 2, tab: "second"},
+		{name: "not an integer", params: map[string]interface{}{"tab_id": 1.5}, tabs: tabs, wantErr: "invalid tab_id"},
+		{name: "unknown tab", params: map[string]interface{}{"tab_id": float64(42)}, tabs: tabs, wantErr: "tab 42 not found"},
+		{name: "no tabs", params: map[string]interface{}{}, tabs: map[int]string{}, wantErr: "no tab is open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activeTab := 0
+			if len(tt.tabs) > 0 {
+				activeTab = 1
+			}
+			tabID, tab, err := TargetTab(tt.params, tt.tabs, activeTab)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.tabID, tabID)
+			assert.Equal(t, tt.tab, tab)
+		})
+	}
+}
+
+func TestMethodNames(t *testing.T) {
+	methods := map[string]int{"wait_for": 1, "click_element": 2, "get_dom_state": 3}
+	assert.Equal(t, []string{"click_element", "get_dom_state", "wait_for"}, MethodNames(methods))
+}
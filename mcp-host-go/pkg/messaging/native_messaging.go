@@ -2,44 +2,36 @@ package messaging
 
 import (
 	"encoding/binary"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"sync"
 	"time"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 // NativeMessaging implements the types.Messaging interface for Chrome native messaging
 type NativeMessaging struct {
-	logger          logger.Logger
-	stdin           io.Reader
-	stdout          io.Writer
-	buffer          []byte
-	messageHandlers map[string]types.MessageHandler
-	rpcHandlers     map[string]types.RpcHandler
-	pendingRequests map[string]*pendingRequest
-	mutex           sync.Mutex
-}
-
-// pendingRequest represents a pending RPC request
-type pendingRequest struct {
-	done     chan struct{}
-	response types.RpcResponse
-	err      error
-	timer    *time.Timer
+	*endpoint
+	stdin  io.Reader
+	stdout io.Writer
+	buffer []byte
 }
 
 // NativeMessagingConfig contains configuration for NativeMessaging
 type NativeMessagingConfig struct {
-	Logger logger.Logger
-	Stdin  io.Reader
-	Stdout io.Writer
+	Logger            logger.Logger
+	Stdin             io.Reader
+	Stdout            io.Writer
+	HeartbeatInterval time.Duration // How often to ping the extension, defaults to DefaultHeartbeatInterval
+	HeartbeatTimeout  time.Duration // Silence after which the extension counts as disconnected, defaults to DefaultHeartbeatTimeout
+	MaxMessageSize    int           // Largest message accepted from or sent to the extension, defaults to DefaultMaxMessageSize
+	Workers           int           // Messages from the extension handled at the same time, defaults to DefaultWorkers
+	QueueSize         int           // Messages that may wait for a worker before new ones are refused, defaults to DefaultQueueSize
+	// Capabilities, if set, stops RPC requests the extension announced it cannot serve
+	Capabilities *types.ExtensionCapabilities
 }
 
 // NewNativeMessaging creates a new NativeMessaging instance
@@ -59,23 +51,35 @@ func NewNativeMessaging(config NativeMessagingConfig) (*NativeMessaging, error)
 	}
 
 	nm := &NativeMessaging{
-		logger:          config.Logger,
-		stdin:           stdin,
-		stdout:          stdout,
-		buffer:          make([]byte, 0),
-		messageHandlers: make(map[string]types.MessageHandler),
-		rpcHandlers:     make(map[string]types.RpcHandler),
-		pendingRequests: make(map[string]*pendingRequest),
+		stdin:  stdin,
+		stdout: stdout,
+		buffer: make([]byte, 0),
 	}
 
-	nm.registerRpcResponseHandler()
+	// Chrome starts the host with the extension on the other end of stdin, so it is connected from the start
+	nm.endpoint = newEndpoint(endpointConfig{
+		logger:            config.Logger,
+		heartbeatInterval: config.HeartbeatInterval,
+		heartbeatTimeout:  config.HeartbeatTimeout,
+		maxMessageSize:    config.MaxMessageSize,
+		workers:           config.Workers,
+		queueSize:         config.QueueSize,
+		capabilities:      config.Capabilities,
+		connected:         true,
+	}, nm.writeFrame)
 
 	return nm, nil
 }
 
-// Start begins processing messages from stdin
+// Start begins processing messages from stdin and sending heartbeats
 func (nm *NativeMessaging) Start() error {
-	nm.logger.Info("Starting native messaging processing")
+	nm.logger.Info("Starting native messaging processing",
+		zap.Duration("heartbeatInterval", nm.heartbeatInterval),
+		zap.Duration("heartbeatTimeout", nm.heartbeatTimeout),
+		zap.Int("workers", nm.workers.workers),
+		zap.Int("queueSize", nm.workers.queueSize))
+
+	nm.start()
 
 	go func() {
 		buffer := make([]byte, 4096)
@@ -84,15 +88,22 @@ func (nm *NativeMessaging) Start() error {
 			if err != nil {
 				if err == io.EOF {
 					nm.logger.Info("Native messaging: stdin closed")
-					return
+				} else {
+					nm.logger.Error("Error reading from stdin", zap.Error(err))
 				}
-				nm.logger.Error("Error reading from stdin", zap.Error(err))
+				nm.setDisconnected("stdin closed", true)
 				return
 			}
 
 			if n > 0 {
 				nm.buffer = append(nm.buffer, buffer[:n]...)
-				nm.processBuffer()
+				if err := nm.processBuffer(); err != nil {
+					// The message boundaries are lost, so nothing more can be read
+					nm.logger.Error("Invalid native message framing, closing the connection", zap.Error(err))
+					nm.buffer = nil
+					nm.setDisconnected("invalid message framing", true)
+					return
+				}
 			}
 		}
 	}()
@@ -100,101 +111,42 @@ func (nm *NativeMessaging) Start() error {
 	return nil
 }
 
-// processBuffer processes the buffer for messages
-func (nm *NativeMessaging) processBuffer() {
+// processBuffer processes the buffer for messages. It fails when a length
+// prefix is over the size limit, which means the stream is corrupt.
+func (nm *NativeMessaging) processBuffer() error {
 	// Need at least 4 bytes for the message length
 	for len(nm.buffer) >= 4 {
 		// Read message length (first 4 bytes, little-endian uint32)
 		messageLength := binary.LittleEndian.Uint32(nm.buffer[:4])
 
+		// Never wait for more data than a message may hold
+		if int64(messageLength) > int64(nm.maxMessageSize) {
+			return fmt.Errorf("message length %d exceeds the limit of %d bytes: %w", messageLength, nm.maxMessageSize, ErrMessageTooLarge)
+		}
+		frameLength := 4 + int(messageLength)
+
 		// Check if we have the complete message
-		if uint32(len(nm.buffer)) < messageLength+4 {
-			return // Need more data
+		if len(nm.buffer) < frameLength {
+			return nil // Need more data
 		}
 
 		// Extract message JSON
-		messageJSON := nm.buffer[4 : messageLength+4]
+		messageJSON := nm.buffer[4:frameLength]
 
 		// Remove processed message from buffer
-		nm.buffer = nm.buffer[messageLength+4:]
+		nm.buffer = nm.buffer[frameLength:]
 
-		// Process the message
-		var message types.Message
-		if err := json.Unmarshal(messageJSON, &message); err != nil {
-			nm.logger.Error("Error parsing message JSON", zap.Error(err), zap.String("json", string(messageJSON)))
-			continue
-		}
+		// Any message, even a malformed one, shows the extension is alive
+		nm.recordMessage()
 
-		// Handle the message asynchronously
-		go func(msg types.Message) {
-			if err := nm.handleMessage(msg); err != nil {
-				nm.logger.Error("Error handling message", zap.Error(err), zap.Any("message", msg))
-			}
-		}(message)
-	}
-}
-
-// handleMessage processes a received message
-func (nm *NativeMessaging) handleMessage(message types.Message) error {
-	nm.logger.Info("Received message", zap.Any("message", message))
-
-	handler, ok := nm.messageHandlers[message.Type]
-	if !ok {
-		nm.logger.Warn("No handler registered for message type", zap.String("type", message.Type))
-		return nm.SendMessage(types.Message{
-			Type:  "error",
-			Error: &types.ErrorInfo{Message: fmt.Sprintf("Unknown message type: %s", message.Type)},
-		})
+		nm.processMessage(messageJSON)
 	}
 
-	var data interface{}
-	switch message.Type {
-	case "rpc_request":
-		data = types.RpcRequest{
-			ID:     message.ID,
-			Method: message.Method,
-			Params: message.Params,
-		}
-	case "rpc_response":
-		data = types.RpcResponse{
-			ID:     message.ID,
-			Result: message.Result,
-			Error:  message.Error,
-		}
-	default:
-		data = message.Data
-	}
-
-	return handler(data)
-}
-
-// RegisterHandler registers a handler for a specific message type
-func (nm *NativeMessaging) RegisterHandler(messageType string, handler types.MessageHandler) {
-	nm.logger.Debug("Registering handler for message type", zap.String("type", messageType))
-	nm.messageHandlers[messageType] = handler
-}
-
-// RegisterRpcMethod registers a handler for an RPC method
-func (nm *NativeMessaging) RegisterRpcMethod(method string, handler types.RpcHandler) {
-	nm.logger.Debug("Registering RPC handler for method", zap.String("method", method))
-	nm.rpcHandlers[method] = handler
-
-	// Register the RPC request handler if not already registered
-	if _, exists := nm.messageHandlers["rpc_request"]; !exists {
-		nm.registerRpcRequestHandler()
-	}
+	return nil
 }
 
-// SendMessage sends a message to stdout
-func (nm *NativeMessaging) SendMessage(message types.Message) error {
-	nm.logger.Debug("Sending message", zap.Any("message", message))
-
-	// Convert message to JSON
-	messageJSON, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("error marshaling message: %w", err)
-	}
-
+// writeFrame writes one length-prefixed message to stdout; nm.mutex must be held
+func (nm *NativeMessaging) writeFrame(messageJSON []byte) error {
 	// Get message length
 	messageLength := uint32(len(messageJSON))
 
@@ -203,158 +155,9 @@ func (nm *NativeMessaging) SendMessage(message types.Message) error {
 	binary.LittleEndian.PutUint32(buffer, messageLength)
 	copy(buffer[4:], messageJSON)
 
-	// Write to stdout
-	nm.mutex.Lock()
-	defer nm.mutex.Unlock()
-	_, err = nm.stdout.Write(buffer)
-	if err != nil {
+	if _, err := nm.stdout.Write(buffer); err != nil {
 		return fmt.Errorf("error writing message: %w", err)
 	}
 
 	return nil
 }
-
-// RpcRequest sends an RPC request and waits for the response
-func (nm *NativeMessaging) RpcRequest(request types.RpcRequest, options types.RpcOptions) (types.RpcResponse, error) {
-	id := request.ID
-	if id == "" {
-		id = uuid.New().String()
-		request.ID = id
-	}
-
-	nm.logger.Info("Sending RPC request", zap.String("method", request.Method), zap.String("id", id))
-
-	timeout := 5000 // Default 5 seconds
-	if options.Timeout > 0 {
-		timeout = options.Timeout
-	}
-
-	// Create pending request
-	pending := &pendingRequest{
-		done: make(chan struct{}),
-	}
-
-	// Set timeout
-	pending.timer = time.AfterFunc(time.Duration(timeout)*time.Millisecond, func() {
-		nm.mutex.Lock()
-		defer nm.mutex.Unlock()
-		if _, exists := nm.pendingRequests[id]; exists {
-			pending.err = fmt.Errorf("RPC request timeout: %s (id: %s)", request.Method, id)
-			close(pending.done)
-			delete(nm.pendingRequests, id)
-		}
-	})
-
-	// Register pending request
-	nm.mutex.Lock()
-	nm.pendingRequests[id] = pending
-	nm.mutex.Unlock()
-
-	// Send the request
-	if err := nm.SendMessage(types.Message{
-		Type:   "rpc_request",
-		ID:     id,
-		Method: request.Method,
-		Params: request.Params,
-	}); err != nil {
-		nm.mutex.Lock()
-		delete(nm.pendingRequests, id)
-		nm.mutex.Unlock()
-		pending.timer.Stop()
-		return types.RpcResponse{}, err
-	}
-
-	// Wait for response or timeout
-	<-pending.done
-
-	nm.logger.Info("RPC request Done", zap.Any("response", pending.response), zap.Any("err", pending.err))
-
-	return pending.response, pending.err
-}
-
-// registerRpcResponseHandler registers a handler for RPC responses
-func (nm *NativeMessaging) registerRpcResponseHandler() {
-	nm.RegisterHandler("rpc_response", func(data interface{}) error {
-		response, ok := data.(types.RpcResponse)
-		if !ok {
-			return fmt.Errorf("invalid RPC response format")
-		}
-
-		id := response.ID
-		nm.logger.Debug("Received RPC response for ID", zap.String("id", id))
-
-		nm.mutex.Lock()
-		defer nm.mutex.Unlock()
-
-		pending, exists := nm.pendingRequests[id]
-		if !exists {
-			nm.logger.Warn("No pending request found for RPC response ID", zap.String("id", id))
-			return nil
-		}
-
-		// Stop the timer
-		pending.timer.Stop()
-
-		// Set the response
-		pending.response = response
-
-		// Signal completion
-		close(pending.done)
-
-		// Remove from pending requests
-		delete(nm.pendingRequests, id)
-
-		return nil
-	})
-}
-
-// registerRpcRequestHandler registers a handler for RPC requests
-func (nm *NativeMessaging) registerRpcRequestHandler() {
-	nm.RegisterHandler("rpc_request", func(data interface{}) error {
-		request, ok := data.(types.RpcRequest)
-		if !ok {
-			return fmt.Errorf("invalid RPC request format")
-		}
-
-		method := request.Method
-		nm.logger.Debug("Handling RPC request", zap.String("method", method))
-
-		handler, exists := nm.rpcHandlers[method]
-		if !exists {
-			nm.logger.Warn("No handler registered for RPC method", zap.String("method", method))
-			return nm.SendMessage(types.Message{
-				Type: "rpc_response",
-				ID:   request.ID,
-				Error: &types.ErrorInfo{
-					Code:    -32601, // Method not found (JSON-RPC spec)
-					Message: fmt.Sprintf("Method not found: %s", method),
-				},
-			})
-		}
-
-		// Handle the request
-		response, err := handler(request)
-		if err != nil {
-			nm.logger.Error("Error in RPC handler", zap.Error(err))
-			return nm.SendMessage(types.Message{
-				Type: "rpc_response",
-				ID:   request.ID,
-				Error: &types.ErrorInfo{
-					Code:    -32000, // Server error (JSON-RPC spec)
-					Message: fmt.Sprintf("Server error: %s", err.Error()),
-				},
-			})
-		}
-
-		// Ensure ID is set correctly
-		response.ID = request.ID
-
-		// Send response
-		return nm.SendMessage(types.Message{
-			Type:   "rpc_response",
-			ID:     response.ID,
-			Result: response.Result,
-			Error:  response.Error,
-		})
-	})
-}
@@ -0,0 +1,556 @@
+package messaging
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// readFrames reads the length-prefixed native messages written by the host into a channel
+func readFrames(r io.Reader) <-chan []byte {
+	frames := make(chan []byte, 10)
+	go func() {
+		defer close(frames)
+		for {
+			var length uint32
+			if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+				return
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			frames <- data
+		}
+	}()
+	return frames
+}
+
+// readMessages decodes native messages written by the host into a channel
+func readMessages(t *testing.T, r io.Reader) <-chan types.Message {
+	messages := make(chan types.Message, 10)
+	go func() {
+		defer close(messages)
+		for data := range readFrames(r) {
+			var message types.Message
+			if err := json.Unmarshal(data, &message); err != nil {
+				t.Errorf("invalid message JSON: %v", err)
+				return
+			}
+			messages <- message
+		}
+	}()
+	return messages
+}
+
+func newTestMessaging(t *testing.T) (*NativeMessaging, <-chan types.Message) {
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() { stdoutWriter.Close() })
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger: logger.NewLoggerFromZap(zap.NewNop()),
+		Stdout: stdoutWriter,
+	})
+	require.NoError(t, err)
+
+	return nm, readMessages(t, stdoutReader)
+}
+
+func TestRpcRequest_CancelSendsRpcCancel(t *testing.T) {
+	nm, messages := newTestMessaging(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := nm.RpcRequest(ctx, types.RpcRequest{Method: "type_value"}, types.RpcOptions{Timeout: 60000})
+		errCh <- err
+	}()
+
+	request := <-messages
+	assert.Equal(t, "rpc_request", request.Type)
+	require.NotEmpty(t, request.ID)
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("RpcRequest did not return after cancellation")
+	}
+
+	cancelMessage := <-messages
+	assert.Equal(t, "rpc_cancel", cancelMessage.Type)
+	assert.Equal(t, request.ID, cancelMessage.ID)
+	assert.Equal(t, "cancelled", cancelMessage.Data.(map[string]interface{})["reason"])
+}
+
+func TestRpcRequest_DeadlineShorterThanTimeout(t *testing.T) {
+	nm, messages := newTestMessaging(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := nm.RpcRequest(ctx, types.RpcRequest{Method: "navigate_to"}, types.RpcOptions{Timeout: 60000})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	assert.Equal(t, "rpc_request", (<-messages).Type)
+	assert.Equal(t, "rpc_cancel", (<-messages).Type)
+}
+
+func TestRpcRequest_AlreadyCancelled(t *testing.T) {
+	nm, _ := newTestMessaging(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := nm.RpcRequest(ctx, types.RpcRequest{Method: "click_element"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+}
+
+// writeMessage encodes a native message from the extension
+func writeMessage(t *testing.T, w io.Writer, message types.Message) {
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+	require.NoError(t, binary.Write(w, binary.LittleEndian, uint32(len(data))))
+	_, err = w.Write(data)
+	require.NoError(t, err)
+}
+
+// newStartedTestMessaging creates a started NativeMessaging whose stdin is fed by the returned writer
+func newStartedTestMessaging(t *testing.T) (*NativeMessaging, io.Writer, <-chan types.Message) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() {
+		stdinWriter.Close()
+		stdoutWriter.Close()
+	})
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger: logger.NewLoggerFromZap(zap.NewNop()),
+		Stdin:  stdinReader,
+		Stdout: stdoutWriter,
+	})
+	require.NoError(t, err)
+	require.NoError(t, nm.Start())
+
+	return nm, stdinWriter, readMessages(t, stdoutReader)
+}
+
+func TestRpcRequest_ProgressRoutedToCaller(t *testing.T) {
+	tests := []struct {
+		name        string
+		withContext bool
+	}{
+		{name: "options", withContext: false},
+		{name: "context", withContext: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nm, stdin, messages := newStartedTestMessaging(t)
+
+			progressCh := make(chan types.RpcProgress, 10)
+			onProgress := func(progress types.RpcProgress) { progressCh <- progress }
+
+			ctx := context.Background()
+			options := types.RpcOptions{Timeout: 5000}
+			if tt.withContext {
+				ctx = types.WithProgress(ctx, onProgress)
+			} else {
+				options.OnProgress = onProgress
+			}
+
+			respCh := make(chan types.RpcResponse, 1)
+			go func() {
+				resp, err := nm.RpcRequest(ctx, types.RpcRequest{Method: "type_value"}, options)
+				assert.NoError(t, err)
+				respCh <- resp
+			}()
+
+			request := <-messages
+			require.Equal(t, "rpc_request", request.Type)
+
+			// Progress of other requests is ignored
+			writeMessage(t, stdin, types.Message{
+				Type: "rpc_progress",
+				ID:   "other",
+				Data: map[string]interface{}{"progress": 99},
+			})
+			writeMessage(t, stdin, types.Message{
+				Type: "rpc_progress",
+				ID:   request.ID,
+				Data: map[string]interface{}{"progress": 120, "total": 480, "message": "Typed 120 of 480 characters"},
+			})
+
+			select {
+			case progress := <-progressCh:
+				assert.Equal(t, types.RpcProgress{Progress: 120, Total: 480, Message: "Typed 120 of 480 characters"}, progress)
+			case <-time.After(time.Second):
+				t.Fatal("progress was not relayed")
+			}
+
+			writeMessage(t, stdin, types.Message{
+				Type:   "rpc_response",
+				ID:     request.ID,
+				Result: map[string]interface{}{"success": true},
+			})
+			select {
+			case resp := <-respCh:
+				assert.Equal(t, request.ID, resp.ID)
+			case <-time.After(time.Second):
+				t.Fatal("RpcRequest did not return")
+			}
+			assert.Empty(t, progressCh)
+		})
+	}
+}
+
+func TestRpcRequest_FailsWhenStdinCloses(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() { stdoutWriter.Close() })
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger: logger.NewLoggerFromZap(zap.NewNop()),
+		Stdin:  stdinReader,
+		Stdout: stdoutWriter,
+	})
+	require.NoError(t, err)
+	messages := readMessages(t, stdoutReader)
+
+	changes := make(chan types.ConnectionStatus, 10)
+	nm.OnConnectionChange(func(status types.ConnectionStatus) { changes <- status })
+	require.NoError(t, nm.Start())
+	assert.True(t, nm.ConnectionStatus().IsConnected)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := nm.RpcRequest(context.Background(), types.RpcRequest{Method: "navigate_to"}, types.RpcOptions{Timeout: 60000})
+		errCh <- err
+	}()
+	request := <-messages
+	require.Equal(t, "rpc_request", request.Type)
+
+	// The pending request fails right away instead of waiting for its timeout
+	require.NoError(t, stdinWriter.Close())
+	select {
+	case err := <-errCh:
+		assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+		assert.Contains(t, err.Error(), types.ErrorCodeExtensionDisconnected)
+	case <-time.After(time.Second):
+		t.Fatal("pending request did not fail when stdin closed")
+	}
+
+	select {
+	case status := <-changes:
+		assert.False(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("connection change was not reported")
+	}
+	assert.False(t, nm.ConnectionStatus().IsConnected)
+
+	// Later requests are not sent at all
+	_, err = nm.RpcRequest(context.Background(), types.RpcRequest{Method: "click_element"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+}
+
+func TestHeartbeat_TimeoutAndReconnect(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() {
+		stdinWriter.Close()
+		stdoutWriter.Close()
+	})
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger:            logger.NewLoggerFromZap(zap.NewNop()),
+		Stdin:             stdinReader,
+		Stdout:            stdoutWriter,
+		HeartbeatInterval: 20 * time.Millisecond,
+		HeartbeatTimeout:  100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	messages := readMessages(t, stdoutReader)
+
+	changes := make(chan types.ConnectionStatus, 10)
+	nm.OnConnectionChange(func(status types.ConnectionStatus) { changes <- status })
+	require.NoError(t, nm.Start())
+
+	// The extension answers pings, so it stays connected
+	var ping types.Message
+	for i := 0; i < 10; i++ {
+		ping = <-messages
+		require.Equal(t, "ping", ping.Type)
+		writeMessage(t, stdinWriter, types.Message{Type: "pong", ID: ping.ID})
+	}
+	assert.Empty(t, changes)
+	status := nm.ConnectionStatus()
+	assert.True(t, status.IsConnected)
+	assert.NotZero(t, status.LastPing)
+
+	// Unanswered pings end in a disconnect
+	go func() {
+		for range messages {
+		}
+	}()
+	select {
+	case status := <-changes:
+		assert.False(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat timeout was not detected")
+	}
+
+	_, err = nm.RpcRequest(context.Background(), types.RpcRequest{Method: "click_element"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+
+	// The next message from the extension restores the connection
+	writeMessage(t, stdinWriter, types.Message{Type: "pong", ID: ping.ID})
+	select {
+	case status := <-changes:
+		assert.True(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("reconnect was not reported")
+	}
+}
+
+func TestSendMessage_ChunksLargeMessages(t *testing.T) {
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() { stdoutWriter.Close() })
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger: logger.NewLoggerFromZap(zap.NewNop()),
+		Stdout: stdoutWriter,
+	})
+	require.NoError(t, err)
+	frames := readFrames(stdoutReader)
+
+	// A small message goes out as it is
+	require.NoError(t, nm.SendMessage(types.Message{Type: "status", Data: "ok"}))
+	var message types.Message
+	require.NoError(t, json.Unmarshal(<-frames, &message))
+	assert.Equal(t, "status", message.Type)
+
+	// A large one is split into chunks that each fit Chrome's limit
+	payload := strings.Repeat("0123456789", 250*1024)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- nm.SendMessage(types.Message{Type: "rpc_request", ID: "big", Method: "type_value", Params: payload})
+	}()
+
+	assembler := newChunkAssembler(DefaultMaxMessageSize, time.Minute)
+	var joined []byte
+	for joined == nil {
+		frame := <-frames
+		require.LessOrEqual(t, len(frame), MaxOutgoingMessageSize)
+
+		var envelope struct {
+			Type string             `json:"type"`
+			Data types.MessageChunk `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(frame, &envelope))
+		require.Equal(t, "chunk", envelope.Type)
+		assert.Equal(t, 5, envelope.Data.Total)
+
+		joined, err = assembler.add(envelope.Data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, <-errCh)
+
+	require.NoError(t, json.Unmarshal(joined, &message))
+	assert.Equal(t, "rpc_request", message.Type)
+	assert.Equal(t, "big", message.ID)
+	assert.Equal(t, payload, message.Params)
+}
+
+func TestSendMessage_RejectsMessagesOverLimit(t *testing.T) {
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger:         logger.NewLoggerFromZap(zap.NewNop()),
+		Stdout:         io.Discard,
+		MaxMessageSize: 1024,
+	})
+	require.NoError(t, err)
+
+	err = nm.SendMessage(types.Message{Type: "rpc_request", Params: strings.Repeat("x", 2048)})
+	assert.True(t, errors.Is(err, ErrMessageTooLarge), "unexpected error: %v", err)
+}
+
+func TestProcessBuffer_JoinsChunkedMessages(t *testing.T) {
+	nm, stdin, messages := newStartedTestMessaging(t)
+
+	received := make(chan interface{}, 2)
+	nm.RegisterHandler("browser_state_changed", func(data interface{}) error {
+		received <- data
+		return nil
+	})
+
+	// The chunks of two messages may arrive interleaved
+	large := strings.Repeat("dom ", 100*1024)
+	first, err := json.Marshal(types.Message{Type: "browser_state_changed", Data: large})
+	require.NoError(t, err)
+	firstChunks, err := splitMessage(first, 64*1024)
+	require.NoError(t, err)
+	second, err := json.Marshal(types.Message{Type: "browser_state_changed", Data: "small"})
+	require.NoError(t, err)
+	secondChunks, err := splitMessage(second, 16)
+	require.NoError(t, err)
+	require.Greater(t, len(firstChunks), len(secondChunks))
+
+	for i, chunk := range firstChunks {
+		writeFrame(t, stdin, chunk)
+		if i < len(secondChunks) {
+			writeFrame(t, stdin, secondChunks[i])
+		}
+	}
+
+	// Messages are handled concurrently, so they may complete in any order
+	var handled []string
+	for range 2 {
+		select {
+		case data := <-received:
+			handled = append(handled, data.(string))
+		case <-time.After(time.Second):
+			t.Fatal("chunked message was not handled")
+		}
+	}
+	sort.Slice(handled, func(i, j int) bool { return len(handled[i]) < len(handled[j]) })
+	assert.Equal(t, "small", handled[0])
+	assert.True(t, handled[1] == large, "large message was not joined correctly")
+	assert.Empty(t, messages)
+}
+
+func TestProcessBuffer_RejectsLengthOverLimit(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	t.Cleanup(func() { stdinWriter.Close() })
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger:         logger.NewLoggerFromZap(zap.NewNop()),
+		Stdin:          stdinReader,
+		Stdout:         io.Discard,
+		MaxMessageSize: 1024,
+	})
+	require.NoError(t, err)
+
+	changes := make(chan types.ConnectionStatus, 10)
+	nm.OnConnectionChange(func(status types.ConnectionStatus) { changes <- status })
+	require.NoError(t, nm.Start())
+
+	// A corrupt length prefix closes the connection instead of waiting for 4 GB
+	require.NoError(t, binary.Write(stdinWriter, binary.LittleEndian, uint32(0xFFFFFFF0)))
+	select {
+	case status := <-changes:
+		assert.False(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("invalid length was not detected")
+	}
+
+	_, err = nm.RpcRequest(context.Background(), types.RpcRequest{Method: "click_element"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+}
+
+// writeFrame writes message JSON from the extension with its length prefix
+func writeFrame(t *testing.T, w io.Writer, data []byte) {
+	require.NoError(t, binary.Write(w, binary.LittleEndian, uint32(len(data))))
+	_, err := w.Write(data)
+	require.NoError(t, err)
+}
+
+func TestProcessMessage_RefusesMessagesWhenQueueFull(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	t.Cleanup(func() {
+		stdinWriter.Close()
+		stdoutWriter.Close()
+	})
+
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger:    logger.NewLoggerFromZap(zap.NewNop()),
+		Stdin:     stdinReader,
+		Stdout:    stdoutWriter,
+		Workers:   1,
+		QueueSize: 1,
+	})
+	require.NoError(t, err)
+	messages := readMessages(t, stdoutReader)
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	nm.RegisterHandler("browser_state_changed", func(data interface{}) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+	require.NoError(t, nm.Start())
+
+	// The only worker is busy and the queue holds one more event
+	writeMessage(t, stdinWriter, types.Message{Type: "browser_state_changed"})
+	<-started
+	writeMessage(t, stdinWriter, types.Message{Type: "browser_state_changed"})
+
+	// Events beyond that are dropped and requests are answered as busy
+	writeMessage(t, stdinWriter, types.Message{Type: "browser_state_changed"})
+	writeMessage(t, stdinWriter, types.Message{Type: "rpc_request", ID: "status-1", Method: "status"})
+
+	select {
+	case response := <-messages:
+		assert.Equal(t, "rpc_response", response.Type)
+		assert.Equal(t, "status-1", response.ID)
+		require.NotNil(t, response.Error)
+		assert.Contains(t, response.Error.Message, "busy")
+	case <-time.After(time.Second):
+		t.Fatal("refused RPC request was not answered")
+	}
+
+	stats := nm.QueueStats()
+	assert.Equal(t, 1, stats.Depth)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, uint64(1), stats.Rejected)
+
+	// Responses never wait in the queue
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := nm.RpcRequest(context.Background(), types.RpcRequest{ID: "req-1", Method: "get_dom_state"}, types.RpcOptions{Timeout: 60000})
+		errCh <- err
+	}()
+	require.Equal(t, "rpc_request", (<-messages).Type)
+	writeMessage(t, stdinWriter, types.Message{Type: "rpc_response", ID: "req-1", Result: map[string]interface{}{}})
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("RPC response was held up by the full queue")
+	}
+}
+
+func TestRpcRequest_RefusesMethodsTheExtensionLacks(t *testing.T) {
+	capabilities := &types.ExtensionCapabilities{}
+	nm, err := NewNativeMessaging(NativeMessagingConfig{
+		Logger:       logger.NewLoggerFromZap(zap.NewNop()),
+		Stdout:       io.Discard,
+		Capabilities: capabilities,
+	})
+	require.NoError(t, err)
+
+	capabilities.Set(types.ExtensionInfo{Version: "1.0.0", ProtocolVersion: 2, Methods: []string{"get_dom_state"}, Compatible: true})
+	_, err = nm.RpcRequest(context.Background(), types.RpcRequest{Method: "wait_for"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrMethodUnsupported), "unexpected error: %v", err)
+
+	capabilities.Set(types.ExtensionInfo{ProtocolVersion: 1, Message: "Update the browser extension."})
+	_, err = nm.RpcRequest(context.Background(), types.RpcRequest{Method: "get_dom_state"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionIncompatible), "unexpected error: %v", err)
+}
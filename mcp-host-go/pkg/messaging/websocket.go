@@ -0,0 +1,75 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// websocketWriteTimeout bounds writes so a stalled peer cannot block senders forever
+const websocketWriteTimeout = 10 * time.Second
+
+// errWebSocketClosed is returned by readMessage after the other end closed the connection
+var errWebSocketClosed = errors.New("websocket closed")
+
+// websocketConn is the host end of the extension's WebSocket, exchanging
+// whole messages. Pings and close handshakes are answered while a reader is
+// waiting in readMessage.
+type websocketConn struct {
+	conn *websocket.Conn
+}
+
+// upgradeWebSocket answers a WebSocket handshake and takes over the
+// connection. Invalid handshakes are answered with 400 Bad Request. The
+// caller decides which origins may connect.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, maxMessageSize int) (*websocketConn, error) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(int64(maxMessageSize))
+	return &websocketConn{conn: conn}, nil
+}
+
+// readMessage returns the next text or binary message. It returns
+// errWebSocketClosed once the other end closes the connection.
+func (c *websocketConn) readMessage() ([]byte, error) {
+	_, message, err := c.conn.Read(context.Background())
+	switch {
+	case err == nil:
+		return message, nil
+	case errors.Is(err, websocket.ErrMessageTooBig):
+		return nil, fmt.Errorf("%w: %w", ErrMessageTooLarge, err)
+	case websocket.CloseStatus(err) != -1:
+		return nil, errWebSocketClosed
+	default:
+		return nil, err
+	}
+}
+
+// writeMessage sends one text message
+func (c *websocketConn) writeMessage(message []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), websocketWriteTimeout)
+	defer cancel()
+
+	if err := c.conn.Write(ctx, websocket.MessageText, message); err != nil {
+		return fmt.Errorf("error writing WebSocket message: %w", err)
+	}
+	return nil
+}
+
+// close ends the connection normally
+func (c *websocketConn) close() {
+	c.conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// closeNow drops the connection without waiting for the other end, e.g. one that stopped answering
+func (c *websocketConn) closeNow() {
+	c.conn.CloseNow()
+}
@@ -0,0 +1,277 @@
+package messaging
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/auth"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// WebSocket transport defaults
+const (
+	DefaultWebSocketAddr = "127.0.0.1:9334"
+	DefaultWebSocketPath = "/extension"
+)
+
+// WebSocketMessaging implements the types.Messaging interface over a WebSocket
+// the extension connects to, for setups where native messaging is not
+// available. Every WebSocket message carries one message of the native
+// messaging protocol, with the same size limits and chunking. The extension
+// pairs by presenting the token, and one extension is connected at a time.
+type WebSocketMessaging struct {
+	*endpoint
+	addr        string
+	path        string
+	token       string
+	server      *http.Server
+	listener    net.Listener
+	acceptMutex sync.Mutex     // Serializes connection attempts
+	conn        *websocketConn // The connected extension; guarded by mutex
+	readerDone  chan struct{}  // Closed when the reader of conn stops; guarded by mutex
+}
+
+// WebSocketMessagingConfig contains configuration for WebSocketMessaging
+type WebSocketMessagingConfig struct {
+	Logger            logger.Logger
+	Addr              string // Address to listen on, defaults to DefaultWebSocketAddr
+	Path              string // Endpoint path, defaults to DefaultWebSocketPath
+	Token             string // Pairing token the extension must present
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+	MaxMessageSize    int
+	Workers           int
+	QueueSize         int
+	Capabilities      *types.ExtensionCapabilities
+}
+
+// NewWebSocketMessaging creates a new WebSocketMessaging instance
+func NewWebSocketMessaging(config WebSocketMessagingConfig) (*WebSocketMessaging, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Token == "" {
+		return nil, fmt.Errorf("pairing token is required")
+	}
+
+	addr := config.Addr
+	if addr == "" {
+		addr = DefaultWebSocketAddr
+	}
+
+	path := config.Path
+	if path == "" {
+		path = DefaultWebSocketPath
+	}
+
+	ws := &WebSocketMessaging{
+		addr:  addr,
+		path:  path,
+		token: config.Token,
+	}
+
+	// The extension is not there until it connects
+	ws.endpoint = newEndpoint(endpointConfig{
+		logger:            config.Logger,
+		heartbeatInterval: config.HeartbeatInterval,
+		heartbeatTimeout:  config.HeartbeatTimeout,
+		maxMessageSize:    config.MaxMessageSize,
+		workers:           config.Workers,
+		queueSize:         config.QueueSize,
+		capabilities:      config.Capabilities,
+	}, ws.writeFrame)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, ws.handleConnection)
+	ws.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return ws, nil
+}
+
+// Start listens for the extension and begins sending heartbeats
+func (ws *WebSocketMessaging) Start() error {
+	listener, err := net.Listen("tcp", ws.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for the extension on %s: %w", ws.addr, err)
+	}
+
+	ws.listener = listener
+
+	ws.logger.Info("Starting WebSocket messaging",
+		zap.String("url", ws.URL()),
+		zap.Duration("heartbeatInterval", ws.heartbeatInterval),
+		zap.Duration("heartbeatTimeout", ws.heartbeatTimeout),
+		zap.Int("workers", ws.workers.workers),
+		zap.Int("queueSize", ws.workers.queueSize))
+
+	ws.start()
+
+	go func() {
+		if err := ws.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ws.logger.Error("WebSocket messaging server error", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+// URL returns the WebSocket URL the extension connects to
+func (ws *WebSocketMessaging) URL() string {
+	addr := ws.addr
+	if ws.listener != nil {
+		addr = ws.listener.Addr().String()
+	}
+	return "ws://" + addr + ws.path
+}
+
+// Close stops listening and disconnects the extension
+func (ws *WebSocketMessaging) Close() error {
+	err := ws.server.Close()
+
+	ws.mutex.Lock()
+	conn := ws.conn
+	ws.mutex.Unlock()
+	if conn != nil {
+		conn.close()
+	}
+
+	ws.setDisconnected("host shutting down", true)
+	return err
+}
+
+// handleConnection pairs an extension and reads its messages until it disconnects
+func (ws *WebSocketMessaging) handleConnection(w http.ResponseWriter, r *http.Request) {
+	// Only extensions and local tools may connect, never web pages
+	if origin := r.Header.Get("Origin"); isWebOrigin(origin) {
+		ws.logger.Warn("Rejected WebSocket connection from a web page", zap.String("origin", origin))
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if !ws.checkToken(r) {
+		ws.logger.Warn("Rejected WebSocket connection without a valid pairing token", zap.String("remoteAddr", r.RemoteAddr))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, done, err := ws.accept(w, r)
+	if err != nil {
+		ws.logger.Warn("Rejected WebSocket connection", zap.Error(err), zap.String("remoteAddr", r.RemoteAddr))
+		return
+	}
+
+	ws.logger.Info("Extension connected over WebSocket", zap.String("remoteAddr", r.RemoteAddr))
+	ws.recordMessage()
+
+	err = ws.readMessages(conn)
+
+	ws.mutex.Lock()
+	if ws.conn == conn {
+		ws.conn = nil
+		ws.readerDone = nil
+	}
+	ws.mutex.Unlock()
+	close(done)
+	conn.close()
+
+	reason := "websocket closed"
+	if err != nil && !errors.Is(err, errWebSocketClosed) && !errors.Is(err, io.EOF) {
+		ws.logger.Warn("WebSocket connection failed", zap.Error(err))
+		reason = "websocket error"
+	}
+	ws.setDisconnected(reason, false)
+}
+
+// accept upgrades the request unless a live extension is already connected. An
+// extension that stopped answering heartbeats is replaced.
+func (ws *WebSocketMessaging) accept(w http.ResponseWriter, r *http.Request) (*websocketConn, chan struct{}, error) {
+	ws.acceptMutex.Lock()
+	defer ws.acceptMutex.Unlock()
+
+	ws.mutex.Lock()
+	current, currentDone, connected := ws.conn, ws.readerDone, ws.connected
+	ws.mutex.Unlock()
+
+	if current != nil {
+		if connected {
+			http.Error(w, "Another extension is connected", http.StatusConflict)
+			return nil, nil, fmt.Errorf("another extension is connected")
+		}
+		ws.logger.Info("Replacing unresponsive extension connection")
+		current.closeNow()
+		<-currentDone
+	}
+
+	conn, err := upgradeWebSocket(w, r, ws.maxMessageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The previous reader has stopped, so the new one gets a fresh chunk assembler
+	done := make(chan struct{})
+	ws.mutex.Lock()
+	ws.conn = conn
+	ws.readerDone = done
+	ws.chunks = newChunkAssembler(ws.maxMessageSize, chunkAssemblyTimeout)
+	ws.mutex.Unlock()
+
+	return conn, done, nil
+}
+
+// readMessages handles messages from the extension until the connection ends
+func (ws *WebSocketMessaging) readMessages(conn *websocketConn) error {
+	for {
+		messageJSON, err := conn.readMessage()
+		if err != nil {
+			return err
+		}
+
+		// Any message, even a malformed one, shows the extension is alive
+		ws.recordMessage()
+
+		ws.processMessage(messageJSON)
+	}
+}
+
+// checkToken reports whether the request presents the pairing token, as the
+// token query parameter or as a bearer token
+func (ws *WebSocketMessaging) checkToken(r *http.Request) bool {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(ws.token)) == 1
+	}
+	return auth.CheckBearerToken(r, ws.token)
+}
+
+// writeFrame sends one message to the connected extension; ws.mutex must be held
+func (ws *WebSocketMessaging) writeFrame(messageJSON []byte) error {
+	if ws.conn == nil {
+		return fmt.Errorf("no WebSocket connection: %w", types.ErrExtensionDisconnected)
+	}
+	return ws.conn.writeMessage(messageJSON)
+}
+
+// isWebOrigin reports whether an Origin header belongs to a web page. An
+// Origin that is present but cannot be parsed is treated as one, so it is
+// rejected rather than let through.
+func isWebOrigin(origin string) bool {
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return true
+	}
+	scheme := strings.ToLower(originURL.Scheme)
+	return scheme == "http" || scheme == "https"
+}
@@ -0,0 +1,217 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testPairingToken = "test-pairing-token"
+
+// testWebSocketClient is a WebSocket client playing the extension
+type testWebSocketClient struct {
+	conn *websocket.Conn
+}
+
+// dialWebSocket opens a WebSocket to the host, returning the HTTP status of a refused handshake
+func dialWebSocket(t *testing.T, wsURL string, header http.Header) (*testWebSocketClient, int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, response, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{HTTPHeader: header})
+	if err != nil {
+		require.NotNil(t, response, "handshake failed: %v", err)
+		return nil, response.StatusCode
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return &testWebSocketClient{conn: conn}, response.StatusCode
+}
+
+// send writes a message as one text frame, or as one frame per part
+func (c *testWebSocketClient) send(t *testing.T, parts ...[]byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	writer, err := c.conn.Writer(ctx, websocket.MessageText)
+	require.NoError(t, err)
+	for _, part := range parts {
+		_, err := writer.Write(part)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+}
+
+// sendMessage writes a message as one text frame
+func (c *testWebSocketClient) sendMessage(t *testing.T, message types.Message) {
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+	c.send(t, data)
+}
+
+// read returns the next message from the host, or the error that ended the connection
+func (c *testWebSocketClient) read() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, data, err := c.conn.Read(ctx)
+	return data, err
+}
+
+// readMessage reads the next message from the host, skipping heartbeats
+func (c *testWebSocketClient) readMessage(t *testing.T) types.Message {
+	for {
+		data, err := c.read()
+		require.NoError(t, err)
+		var message types.Message
+		require.NoError(t, json.Unmarshal(data, &message))
+		if message.Type != pingMessageType {
+			return message
+		}
+	}
+}
+
+func newTestWebSocketMessaging(t *testing.T) *WebSocketMessaging {
+	ws, err := NewWebSocketMessaging(WebSocketMessagingConfig{
+		Logger: logger.NewLoggerFromZap(zap.NewNop()),
+		Addr:   "127.0.0.1:0",
+		Token:  testPairingToken,
+	})
+	require.NoError(t, err)
+	require.NoError(t, ws.Start())
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func TestWebSocketMessaging_RequiresPairingToken(t *testing.T) {
+	ws := newTestWebSocketMessaging(t)
+
+	_, status := dialWebSocket(t, ws.URL(), nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	_, status = dialWebSocket(t, ws.URL()+"?token=wrong", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// Web pages cannot connect even with the token
+	_, status = dialWebSocket(t, ws.URL()+"?token="+testPairingToken, http.Header{"Origin": {"https://example.com"}})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// Nor can requests whose Origin is not a valid URL
+	_, status = dialWebSocket(t, ws.URL()+"?token="+testPairingToken, http.Header{"Origin": {"http://[::1"}})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	_, status = dialWebSocket(t, ws.URL(), http.Header{
+		"Authorization": {"Bearer " + testPairingToken},
+		"Origin":        {"chrome-extension://abcdefghijklmnop"},
+	})
+	assert.Equal(t, http.StatusSwitchingProtocols, status)
+}
+
+func TestWebSocketMessaging_RpcRoundTrip(t *testing.T) {
+	ws := newTestWebSocketMessaging(t)
+	changes := make(chan types.ConnectionStatus, 10)
+	ws.OnConnectionChange(func(status types.ConnectionStatus) { changes <- status })
+
+	// Until the extension connects, requests fail fast
+	assert.False(t, ws.ConnectionStatus().IsConnected)
+	_, err := ws.RpcRequest(context.Background(), types.RpcRequest{Method: "navigate_to"}, types.RpcOptions{})
+	assert.True(t, errors.Is(err, types.ErrExtensionDisconnected), "unexpected error: %v", err)
+
+	client, status := dialWebSocket(t, ws.URL()+"?token="+testPairingToken, nil)
+	require.Equal(t, http.StatusSwitchingProtocols, status)
+	select {
+	case status := <-changes:
+		assert.True(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("connection was not reported")
+	}
+
+	// A second extension is refused while the first one is connected
+	_, status = dialWebSocket(t, ws.URL()+"?token="+testPairingToken, nil)
+	assert.Equal(t, http.StatusConflict, status)
+
+	// Host to extension
+	type result struct {
+		response types.RpcResponse
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		response, err := ws.RpcRequest(context.Background(), types.RpcRequest{Method: "navigate_to", Params: map[string]interface{}{"url": "https://example.com"}}, types.RpcOptions{})
+		results <- result{response, err}
+	}()
+
+	request := client.readMessage(t)
+	require.Equal(t, "rpc_request", request.Type)
+	assert.Equal(t, "navigate_to", request.Method)
+	client.sendMessage(t, types.Message{Type: "rpc_response", ID: request.ID, Result: "done"})
+
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		assert.Equal(t, "done", r.response.Result)
+	case <-time.After(time.Second):
+		t.Fatal("RPC response was not delivered")
+	}
+
+	// Extension to host, in a fragmented message
+	ws.RegisterRpcMethod("status", func(request types.RpcRequest) (types.RpcResponse, error) {
+		return types.RpcResponse{Result: "ok"}, nil
+	})
+	data, err := json.Marshal(types.Message{Type: "rpc_request", ID: "ext-1", Method: "status"})
+	require.NoError(t, err)
+	client.send(t, data[:10], data[10:])
+
+	response := client.readMessage(t)
+	assert.Equal(t, "rpc_response", response.Type)
+	assert.Equal(t, "ext-1", response.ID)
+	assert.Equal(t, "ok", response.Result)
+
+	// Closing the socket disconnects the extension until it connects again
+	require.NoError(t, client.conn.Close(websocket.StatusNormalClosure, ""))
+	select {
+	case status := <-changes:
+		assert.False(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("disconnect was not reported")
+	}
+
+	_, status = dialWebSocket(t, ws.URL()+"?token="+testPairingToken, nil)
+	assert.Equal(t, http.StatusSwitchingProtocols, status)
+	select {
+	case status := <-changes:
+		assert.True(t, status.IsConnected)
+	case <-time.After(time.Second):
+		t.Fatal("reconnection was not reported")
+	}
+}
+
+func TestWebSocketMessaging_RejectsMessagesOverLimit(t *testing.T) {
+	ws, err := NewWebSocketMessaging(WebSocketMessagingConfig{
+		Logger:         logger.NewLoggerFromZap(zap.NewNop()),
+		Addr:           "127.0.0.1:0",
+		Token:          testPairingToken,
+		MaxMessageSize: 1024,
+	})
+	require.NoError(t, err)
+	require.NoError(t, ws.Start())
+	t.Cleanup(func() { ws.Close() })
+
+	client, status := dialWebSocket(t, ws.URL()+"?token="+testPairingToken, nil)
+	require.Equal(t, http.StatusSwitchingProtocols, status)
+
+	client.send(t, []byte(strings.Repeat("x", 2048)))
+
+	_, err = client.read()
+	assert.Equal(t, websocket.StatusMessageTooBig, websocket.CloseStatus(err), "unexpected error: %v", err)
+}
@@ -0,0 +1,148 @@
+package messaging
+
+import (
+	"sync"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// Worker pool defaults
+const (
+	DefaultWorkers   = 8
+	DefaultQueueSize = 256
+)
+
+// messageLane holds the queued messages of one message type. A lane is
+// handled by one worker at a time, so messages of a type keep their order.
+type messageLane struct {
+	messageType string
+	queue       []types.Message
+	scheduled   bool // Waiting in the ready list or being handled by a worker
+}
+
+// workerPool handles inbound messages with a fixed number of workers. At most
+// queueSize messages wait for a worker; submit refuses more instead of blocking
+// the stdin reader, which must keep reading RPC responses.
+type workerPool struct {
+	handle    func(message types.Message)
+	workers   int
+	queueSize int
+
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	lanes     map[string]*messageLane
+	ready     []*messageLane // Lanes with queued messages and no worker, oldest first
+	depth     int
+	processed uint64
+	dropped   uint64
+	rejected  uint64
+	started   bool
+}
+
+// newWorkerPool creates a workerPool that calls handle for every submitted message
+func newWorkerPool(workers int, queueSize int, handle func(message types.Message)) *workerPool {
+	pool := &workerPool{
+		handle:    handle,
+		workers:   workers,
+		queueSize: queueSize,
+		lanes:     make(map[string]*messageLane),
+	}
+	pool.cond = sync.NewCond(&pool.mutex)
+	return pool
+}
+
+// start launches the workers; later calls do nothing
+func (p *workerPool) start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.started {
+		return
+	}
+	p.started = true
+	for i := 0; i < p.workers; i++ {
+		go p.work()
+	}
+}
+
+// submit queues a message, reporting false when the queue is full
+func (p *workerPool) submit(message types.Message) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.depth >= p.queueSize {
+		return false
+	}
+
+	lane, exists := p.lanes[message.Type]
+	if !exists {
+		lane = &messageLane{messageType: message.Type}
+		p.lanes[message.Type] = lane
+	}
+	lane.queue = append(lane.queue, message)
+	p.depth++
+
+	if !lane.scheduled {
+		lane.scheduled = true
+		p.ready = append(p.ready, lane)
+		p.cond.Signal()
+	}
+	return true
+}
+
+// work handles the next message of the oldest ready lane, forever
+func (p *workerPool) work() {
+	for {
+		p.mutex.Lock()
+		for len(p.ready) == 0 {
+			p.cond.Wait()
+		}
+		lane := p.ready[0]
+		p.ready = p.ready[1:]
+		message := lane.queue[0]
+		lane.queue = lane.queue[1:]
+		p.depth--
+		p.mutex.Unlock()
+
+		p.handle(message)
+
+		p.mutex.Lock()
+		p.processed++
+		if len(lane.queue) > 0 {
+			// Go to the back so one busy type does not starve the others
+			p.ready = append(p.ready, lane)
+			p.cond.Signal()
+		} else {
+			lane.scheduled = false
+			delete(p.lanes, lane.messageType)
+		}
+		p.mutex.Unlock()
+	}
+}
+
+// recordRefused counts a message refused because the queue was full
+func (p *workerPool) recordRefused(rejected bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if rejected {
+		p.rejected++
+	} else {
+		p.dropped++
+	}
+}
+
+// stats returns the queue metrics
+func (p *workerPool) stats() types.MessageQueueStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return types.MessageQueueStats{
+		Workers:   p.workers,
+		Depth:     p.depth,
+		Capacity:  p.queueSize,
+		Processed: p.processed,
+		Dropped:   p.dropped,
+		Rejected:  p.rejected,
+	}
+}
@@ -0,0 +1,81 @@
+package messaging
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool_KeepsOrderPerMessageType(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		handled = map[string][]string{}
+		wg      sync.WaitGroup
+	)
+	pool := newWorkerPool(4, 1000, func(message types.Message) {
+		defer wg.Done()
+		time.Sleep(time.Millisecond)
+		mutex.Lock()
+		defer mutex.Unlock()
+		handled[message.Type] = append(handled[message.Type], message.ID)
+	})
+	pool.start()
+
+	var want []string
+	for i := 0; i < 50; i++ {
+		want = append(want, fmt.Sprint(i))
+		for _, messageType := range []string{"browser_state_changed", "rpc_request", "rpc_progress"} {
+			wg.Add(1)
+			require.True(t, pool.submit(types.Message{Type: messageType, ID: fmt.Sprint(i)}))
+		}
+	}
+	wg.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, messageType := range []string{"browser_state_changed", "rpc_request", "rpc_progress"} {
+		assert.Equal(t, want, handled[messageType], "order of %s messages", messageType)
+	}
+	assert.Equal(t, uint64(150), pool.stats().Processed)
+}
+
+func TestWorkerPool_BoundsWorkersAndQueue(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	pool := newWorkerPool(2, 3, func(message types.Message) {
+		started <- message.Type
+		<-release
+	})
+	pool.start()
+
+	// Two types run at once, one per worker
+	require.True(t, pool.submit(types.Message{Type: "a"}))
+	require.True(t, pool.submit(types.Message{Type: "b"}))
+	<-started
+	<-started
+
+	// Further messages wait, up to the queue size
+	for _, messageType := range []string{"c", "a", "b"} {
+		require.True(t, pool.submit(types.Message{Type: messageType}))
+	}
+	assert.False(t, pool.submit(types.Message{Type: "d"}))
+	select {
+	case messageType := <-started:
+		t.Fatalf("%s started while both workers were busy", messageType)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stats := pool.stats()
+	assert.Equal(t, 2, stats.Workers)
+	assert.Equal(t, 3, stats.Depth)
+	assert.Equal(t, 3, stats.Capacity)
+
+	close(release)
+	require.Eventually(t, func() bool { return pool.stats().Processed == 5 }, time.Second, 10*time.Millisecond)
+	assert.Zero(t, pool.stats().Depth)
+}
@@ -1,12 +1,13 @@
 package resources
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -17,14 +18,18 @@ type CurrentStateResource struct {
 	name        string
 	mimeType    string
 	description string
+	format      string
 	logger      logger.Logger
 	messaging   types.Messaging
+	notifier    types.ResourceNotifier
 }
 
 // CurrentStateConfig contains configuration for CurrentStateResource
 type CurrentStateConfig struct {
 	Logger    logger.Logger
 	Messaging types.Messaging
+	Notifier  types.ResourceNotifier // Optional, notifies subscribed MCP clients of changes
+	Format    string                 // Optional, types.OutputFormatJSON serves the browser://current/state.json variant
 }
 
 // BrowserStateData represents the browser state data from Chrome extension
@@ -51,14 +56,30 @@ func NewCurrentStateResource(config CurrentStateConfig) (*CurrentStateResource,
 		return nil, fmt.Errorf("messaging is required")
 	}
 
-	return &CurrentStateResource{
+	format, err := validateFormat(config.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &CurrentStateResource{
 		uri:         "browser://current/state",
 		name:        "Current Browser State",
 		mimeType:    "text/markdown",
 		description: "Complete state of the current active page and all tabs in AI-friendly Markdown format",
+		format:      format,
 		logger:      config.Logger,
 		messaging:   config.Messaging,
-	}, nil
+		notifier:    config.Notifier,
+	}
+
+	if format == types.OutputFormatJSON {
+		resource.uri += jsonURISuffix
+		resource.name = "Current Browser State (JSON)"
+		resource.mimeType = types.MimeTypeJSON
+		resource.description = "The browser state of browser://current/state as a typed JSON object, described by browser://schema/current_state."
+	}
+
+	return resource, nil
 }
 
 // GetURI returns the resource URI
@@ -81,17 +102,23 @@ func (r *CurrentStateResource) GetDescription() string {
 	return r.description
 }
 
+// GetOutputSchema returns the schema of the JSON format
+func (r *CurrentStateResource) GetOutputSchema() interface{} {
+	return schema.FromType(BrowserStateData{})
+}
+
 // Read reads the current browser state
-func (r *CurrentStateResource) Read() (types.ResourceContent, error) {
-	return r.ReadWithArguments(r.uri, nil)
+func (r *CurrentStateResource) Read(ctx context.Context) (types.ResourceContent, error) {
+	return r.ReadWithArguments(ctx, r.uri, nil)
 }
 
-// ReadWithArguments reads the current browser state (ignores arguments for compatibility)
-func (r *CurrentStateResource) ReadWithArguments(uri string, arguments map[string]any) (types.ResourceContent, error) {
+// ReadWithArguments reads the current browser state. A "format" argument of "json"
+// selects the JSON format regardless of the resource's own format.
+func (r *CurrentStateResource) ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (types.ResourceContent, error) {
 	r.logger.Info("Reading current browser state")
 
 	// Request browser state from the extension
-	resp, err := r.messaging.RpcRequest(types.RpcRequest{
+	resp, err := r.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "get_browser_state",
 	}, types.RpcOptions{Timeout: 5000})
 
@@ -114,6 +141,14 @@ func (r *CurrentStateResource) ReadWithArguments(uri string, arguments map[strin
 		return types.ResourceContent{}, fmt.Errorf("failed to parse browser state data: %w", err)
 	}
 
+	if requestedFormat(r.format, arguments) == types.OutputFormatJSON {
+		item, err := jsonResourceItem(uri, browserStateData)
+		if err != nil {
+			return types.ResourceContent{}, err
+		}
+		return types.ResourceContent{Contents: []types.ResourceItem{item}}, nil
+	}
+
 	// Convert to Markdown format
 	markdownContent := r.convertToMarkdown(browserStateData)
 
@@ -133,22 +168,14 @@ func (r *CurrentStateResource) ReadWithArguments(uri string, arguments map[strin
 	}, nil
 }
 
-// NotifyStateChange notifies that the state has changed
+// NotifyStateChange notifies subscribed MCP clients that the state has changed
 func (r *CurrentStateResource) NotifyStateChange(state interface{}) {
-	r.logger.Debug("Notifying state change")
-
-	// Send resource_updated message
-	err := r.messaging.SendMessage(types.Message{
-		Type: "resource_updated",
-		Data: map[string]interface{}{
-			"uri":       r.uri,
-			"timestamp": getCurrentTimestamp(),
-		},
-	})
+	r.logger.Debug("Notifying state change", zap.String("uri", r.uri))
 
-	if err != nil {
-		r.logger.Error("Error sending resource_updated message", zap.Error(err))
+	if r.notifier == nil {
+		return
 	}
+	r.notifier.NotifyResourceUpdated(r.uri)
 }
 
 // parseResponseToStruct converts response result to a struct
@@ -291,13 +318,3 @@ func (r *CurrentStateResource) formatTabID(id interface{}) string {
 		return str
 	}
 }
-
-// getCurrentTimestamp returns the current timestamp in milliseconds
-func getCurrentTimestamp() int64 {
-	return timeNow().UnixNano() / int64(1e6)
-}
-
-// timeNow is a variable to allow testing with a mock time
-var timeNow = func() time.Time {
-	return time.Now()
-}
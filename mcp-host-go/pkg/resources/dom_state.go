@@ -1,11 +1,13 @@
 package resources
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -16,14 +18,18 @@ type DomStateResource struct {
 	name        string
 	mimeType    string
 	description string
+	format      string
 	logger      logger.Logger
 	messaging   types.Messaging
+	notifier    types.ResourceNotifier
 }
 
 // DomStateConfig contains configuration for DomStateResource
 type DomStateConfig struct {
 	Logger    logger.Logger
 	Messaging types.Messaging
+	Notifier  types.ResourceNotifier // Optional, notifies subscribed MCP clients of changes
+	Format    string                 // Optional, types.OutputFormatJSON serves the browser://dom/state.json variant
 }
 
 // NewDomStateResource creates a new DomStateResource
@@ -36,7 +42,12 @@ func NewDomStateResource(config DomStateConfig) (*DomStateResource, error) {
 		return nil, fmt.Errorf("messaging is required")
 	}
 
-	return &DomStateResource{
+	format, err := validateFormat(config.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &DomStateResource{
 		uri:      "browser://dom/state",
 		name:     "DOM State",
 		mimeType: "text/markdown",
@@ -49,10 +60,23 @@ The overview includes:
 • First 20 interactive elements (buttons, inputs, links, etc.)
 • Total count of all interactive elements
 • Simplified DOM structure
-• Clear indication when more elements are available`,
+• Clear indication when more elements are available
+
+A "tab_id" read argument inspects that tab instead of the current one, without switching to it.`,
+		format:    format,
 		logger:    config.Logger,
 		messaging: config.Messaging,
-	}, nil
+		notifier:  config.Notifier,
+	}
+
+	if format == types.OutputFormatJSON {
+		resource.uri += jsonURISuffix
+		resource.name = "DOM State (JSON)"
+		resource.mimeType = types.MimeTypeJSON
+		resource.description = "The DOM state overview of browser://dom/state as a typed JSON object, described by browser://schema/dom_state."
+	}
+
+	return resource, nil
 }
 
 // GetURI returns the resource URI
@@ -75,19 +99,43 @@ func (r *DomStateResource) GetDescription() string {
 	return r.description
 }
 
+// GetOutputSchema returns the schema of the JSON format
+func (r *DomStateResource) GetOutputSchema() interface{} {
+	return schema.FromType(DomStateOverview{})
+}
+
 // Read reads the current DOM state overview
-func (r *DomStateResource) Read() (types.ResourceContent, error) {
-	return r.ReadWithArguments(r.uri, nil)
+func (r *DomStateResource) Read(ctx context.Context) (types.ResourceContent, error) {
+	return r.ReadWithArguments(ctx, r.uri, nil)
 }
 
-// ReadWithArguments reads the DOM state overview (arguments are ignored for overview mode)
-func (r *DomStateResource) ReadWithArguments(uri string, arguments map[string]any) (types.ResourceContent, error) {
-	r.logger.Debug("Reading DOM state overview", zap.String("uri", uri))
+// ReadWithArguments reads the DOM state overview of the tab named by a "tab_id"
+// argument, else of the session's tab, or of the active tab if the session has
+// none. A "format" argument of "json" selects the JSON format regardless of the
+// resource's own format.
+func (r *DomStateResource) ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (types.ResourceContent, error) {
+	if raw, exists := arguments["tab_id"]; exists {
+		tabID, ok := types.ParseTabID(raw)
+		if !ok {
+			return types.ResourceContent{}, fmt.Errorf("tab_id must be a non-negative integer, got %v", raw)
+		}
+		ctx = types.WithTargetTab(ctx, tabID)
+	}
+
+	return r.readOverview(ctx, uri, types.AddTargetTab(ctx, nil), requestedFormat(r.format, arguments))
+}
+
+// readOverview requests the DOM state from the extension and renders the overview in format.
+// params are passed to get_dom_state, e.g. tab_id to inspect a specific tab.
+func (r *DomStateResource) readOverview(ctx context.Context, uri string, params map[string]interface{}, format string) (types.ResourceContent, error) {
+	r.logger.Debug("Reading DOM state overview", zap.String("uri", uri), zap.Any("params", params))
 
 	// Request DOM state from the extension
-	resp, err := r.messaging.RpcRequest(types.RpcRequest{
-		Method: "get_dom_state",
-	}, types.RpcOptions{Timeout: 5000})
+	request := types.RpcRequest{Method: "get_dom_state"}
+	if params != nil {
+		request.Params = params
+	}
+	resp, err := r.messaging.RpcRequest(ctx, request, types.RpcOptions{Timeout: 5000})
 
 	if err != nil {
 		r.logger.Error("Error requesting DOM state", zap.Error(err))
@@ -109,6 +157,14 @@ func (r *DomStateResource) ReadWithArguments(uri string, arguments map[string]an
 	// Create overview with max 20 elements
 	overview := r.createOverview(domStateData)
 
+	if format == types.OutputFormatJSON {
+		item, err := jsonResourceItem(uri, overview)
+		if err != nil {
+			return types.ResourceContent{}, err
+		}
+		return types.ResourceContent{Contents: []types.ResourceItem{item}}, nil
+	}
+
 	// Convert to Markdown format
 	markdownContent := r.convertToMarkdown(overview)
 
@@ -129,22 +185,14 @@ func (r *DomStateResource) ReadWithArguments(uri string, arguments map[string]an
 	}, nil
 }
 
-// NotifyStateChange notifies that the DOM state has changed
+// NotifyStateChange notifies subscribed MCP clients that the DOM state has changed
 func (r *DomStateResource) NotifyStateChange(state interface{}) {
-	r.logger.Debug("Notifying DOM state change")
-
-	// Send resource_updated message
-	err := r.messaging.SendMessage(types.Message{
-		Type: "resource_updated",
-		Data: map[string]interface{}{
-			"uri":       r.uri,
-			"timestamp": getCurrentTimestamp(),
-		},
-	})
+	r.logger.Debug("Notifying DOM state change", zap.String("uri", r.uri))
 
-	if err != nil {
-		r.logger.Error("Error sending resource_updated message for DOM state", zap.Error(err))
+	if r.notifier == nil {
+		return
 	}
+	r.notifier.NotifyResourceUpdated(r.uri)
 }
 
 // DomStateData represents the raw DOM state data from Chrome extension
@@ -0,0 +1,45 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// jsonURISuffix is appended to the URI of a resource to name its JSON variant
+const jsonURISuffix = ".json"
+
+// validateFormat checks the configured output format of a resource, defaulting to Markdown
+func validateFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return types.OutputFormatMarkdown, nil
+	case types.OutputFormatMarkdown, types.OutputFormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// requestedFormat returns the format named by a "format" read argument, or the resource's own format
+func requestedFormat(format string, arguments map[string]any) string {
+	if requested, ok := arguments["format"].(string); ok && requested == types.OutputFormatJSON {
+		return types.OutputFormatJSON
+	}
+	return format
+}
+
+// jsonResourceItem returns a typed value as JSON resource content
+func jsonResourceItem(uri string, value interface{}) (types.ResourceItem, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return types.ResourceItem{}, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return types.ResourceItem{
+		URI:      uri,
+		MimeType: types.MimeTypeJSON,
+		Text:     string(data),
+	}, nil
+}
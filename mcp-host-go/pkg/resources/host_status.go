@@ -0,0 +1,130 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// HostStatusResource implements the host status resource, which reports
+// whether the browser extension is connected
+type HostStatusResource struct {
+	uri          string
+	name         string
+	mimeType     string
+	description  string
+	logger       logger.Logger
+	messaging    types.Messaging
+	capabilities *types.ExtensionCapabilities
+	notifier     types.ResourceNotifier
+	hostInfo     types.HostInfo
+	startTime    time.Time
+}
+
+// HostStatusConfig contains configuration for HostStatusResource
+type HostStatusConfig struct {
+	Logger       logger.Logger
+	Messaging    types.Messaging
+	Capabilities *types.ExtensionCapabilities // Optional, reports the extension that completed the init handshake
+	Notifier     types.ResourceNotifier       // Optional, notifies subscribed MCP clients of connection changes
+	HostInfo     types.HostInfo
+	StartTime    time.Time
+}
+
+// NewHostStatusResource creates a new HostStatusResource
+func NewHostStatusResource(config HostStatusConfig) (*HostStatusResource, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	return &HostStatusResource{
+		uri:      "browser://host/status",
+		name:     "Host Status",
+		mimeType: types.MimeTypeJSON,
+		description: `Status of the MCP host and its connection to the browser extension, described by browser://schema/host_status.
+
+While isConnected is false, every tool call and browser resource read fails with an EXTENSION_DISCONNECTED error. The extension object describes the extension after its init handshake; if compatible is false, its message says whether the extension or the host needs an update. Subscribe to this resource to be notified when the extension disconnects or reconnects.`,
+		logger:       config.Logger,
+		messaging:    config.Messaging,
+		capabilities: config.Capabilities,
+		notifier:     config.Notifier,
+		hostInfo:     config.HostInfo,
+		startTime:    config.StartTime,
+	}, nil
+}
+
+// GetURI returns the resource URI
+func (r *HostStatusResource) GetURI() string {
+	return r.uri
+}
+
+// GetName returns the resource name
+func (r *HostStatusResource) GetName() string {
+	return r.name
+}
+
+// GetMimeType returns the resource MIME type
+func (r *HostStatusResource) GetMimeType() string {
+	return r.mimeType
+}
+
+// GetDescription returns the resource description
+func (r *HostStatusResource) GetDescription() string {
+	return r.description
+}
+
+// GetOutputSchema returns the schema of the status
+func (r *HostStatusResource) GetOutputSchema() interface{} {
+	return schema.FromType(types.HostStatus{})
+}
+
+// Read reads the host status
+func (r *HostStatusResource) Read(ctx context.Context) (types.ResourceContent, error) {
+	return r.ReadWithArguments(ctx, r.uri, nil)
+}
+
+// ReadWithArguments reads the host status. It is answered by the host alone,
+// so it works while the extension is disconnected.
+func (r *HostStatusResource) ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (types.ResourceContent, error) {
+	connection := r.messaging.ConnectionStatus()
+	status := types.HostStatus{
+		HostInfo:    r.hostInfo,
+		IsConnected: connection.IsConnected,
+		StartTime:   r.startTime.UnixMilli(),
+		LastPing:    connection.LastPing,
+	}
+	if r.capabilities != nil {
+		if info, known := r.capabilities.Get(); known {
+			status.Extension = &info
+		}
+	}
+	if reporter, ok := r.messaging.(types.MessageQueueReporter); ok {
+		queue := reporter.QueueStats()
+		status.Queue = &queue
+	}
+
+	item, err := jsonResourceItem(uri, status)
+	if err != nil {
+		return types.ResourceContent{}, err
+	}
+	return types.ResourceContent{Contents: []types.ResourceItem{item}}, nil
+}
+
+// NotifyStateChange notifies subscribed MCP clients that the connection state has changed
+func (r *HostStatusResource) NotifyStateChange(state interface{}) {
+	r.logger.Debug("Notifying host status change", zap.String("uri", r.uri), zap.Any("state", state))
+
+	if r.notifier == nil {
+		return
+	}
+	r.notifier.NotifyResourceUpdated(r.uri)
+}
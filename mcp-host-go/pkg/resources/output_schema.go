@@ -0,0 +1,125 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// OutputSchemaResource publishes the JSON Schemas of the typed JSON outputs of tools and resources
+type OutputSchemaResource struct {
+	uriTemplate string
+	name        string
+	mimeType    string
+	description string
+	logger      logger.Logger
+	mutex       sync.RWMutex
+	schemas     map[string]interface{}
+}
+
+// OutputSchemaConfig contains configuration for OutputSchemaResource
+type OutputSchemaConfig struct {
+	Logger logger.Logger
+}
+
+// NewOutputSchemaResource creates a new OutputSchemaResource
+func NewOutputSchemaResource(config OutputSchemaConfig) (*OutputSchemaResource, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	return &OutputSchemaResource{
+		uriTemplate: "browser://schema/{name}",
+		name:        "Output Schema",
+		mimeType:    "application/schema+json",
+		description: `JSON Schema of a typed JSON output, for tools called with format "json" and for the *.json resource variants.
+
+The name is a tool name (e.g. click_element) or a resource name: current_state for browser://current/state.json, dom_state for browser://dom/state.json and browser://tab/{tabId}/dom/state.json, host_status for browser://host/status.`,
+		logger:  config.Logger,
+		schemas: make(map[string]interface{}),
+	}, nil
+}
+
+// AddSchema publishes the output schema of a tool or resource under name
+func (r *OutputSchemaResource) AddSchema(name string, schema interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.schemas[name] = schema
+}
+
+// GetURITemplate returns the resource URI template
+func (r *OutputSchemaResource) GetURITemplate() string {
+	return r.uriTemplate
+}
+
+// GetName returns the resource name
+func (r *OutputSchemaResource) GetName() string {
+	return r.name
+}
+
+// GetMimeType returns the resource MIME type
+func (r *OutputSchemaResource) GetMimeType() string {
+	return r.mimeType
+}
+
+// GetDescription returns the resource description
+func (r *OutputSchemaResource) GetDescription() string {
+	return r.description
+}
+
+// GetVariables returns the typed variables of the URI template
+func (r *OutputSchemaResource) GetVariables() []types.ResourceTemplateVariable {
+	return []types.ResourceTemplateVariable{
+		{Name: "name", Type: "string"},
+	}
+}
+
+// ReadWithArguments returns the output schema named by the name argument
+func (r *OutputSchemaResource) ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (types.ResourceContent, error) {
+	name, ok := arguments["name"].(string)
+	if !ok {
+		return types.ResourceContent{}, fmt.Errorf("name is required")
+	}
+
+	r.mutex.RLock()
+	schema, exists := r.schemas[name]
+	r.mutex.RUnlock()
+	if !exists {
+		return types.ResourceContent{}, fmt.Errorf("unknown output schema %q, available: %s", name, strings.Join(r.names(), ", "))
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return types.ResourceContent{}, fmt.Errorf("failed to encode output schema: %w", err)
+	}
+
+	return types.ResourceContent{
+		Contents: []types.ResourceItem{
+			{
+				URI:      uri,
+				MimeType: r.mimeType,
+				Text:     string(data),
+			},
+		},
+	}, nil
+}
+
+// names returns the sorted names of all published schemas
+func (r *OutputSchemaResource) names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.schemas))
+	for name := range r.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
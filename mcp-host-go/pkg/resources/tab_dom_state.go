@@ -0,0 +1,116 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// TabDomStateResource implements the DOM state resource template for a specific tab
+type TabDomStateResource struct {
+	uriTemplate string
+	name        string
+	mimeType    string
+	description string
+	format      string
+	logger      logger.Logger
+	domState    *DomStateResource
+}
+
+// TabDomStateConfig contains configuration for TabDomStateResource
+type TabDomStateConfig struct {
+	Logger    logger.Logger
+	Messaging types.Messaging
+	Format    string // Optional, types.OutputFormatJSON serves the browser://tab/{tabId}/dom/state.json variant
+}
+
+// NewTabDomStateResource creates a new TabDomStateResource
+func NewTabDomStateResource(config TabDomStateConfig) (*TabDomStateResource, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	format, err := validateFormat(config.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	domState, err := NewDomStateResource(DomStateConfig{
+		Logger:    config.Logger,
+		Messaging: config.Messaging,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &TabDomStateResource{
+		uriTemplate: "browser://tab/{tabId}/dom/state",
+		name:        "Tab DOM State",
+		mimeType:    "text/markdown",
+		description: `DOM state overview of a specific tab, in the same format as browser://dom/state.
+
+The tab is inspected without switching to it, so any open tab can be read. Tab IDs are listed in browser://current/state.`,
+		format:   format,
+		logger:   config.Logger,
+		domState: domState,
+	}
+
+	if format == types.OutputFormatJSON {
+		resource.uriTemplate += jsonURISuffix
+		resource.name = "Tab DOM State (JSON)"
+		resource.mimeType = types.MimeTypeJSON
+		resource.description = "The DOM state overview of a specific tab as a typed JSON object, in the same format as browser://dom/state.json."
+	}
+
+	return resource, nil
+}
+
+// GetURITemplate returns the resource URI template
+func (r *TabDomStateResource) GetURITemplate() string {
+	return r.uriTemplate
+}
+
+// GetName returns the resource name
+func (r *TabDomStateResource) GetName() string {
+	return r.name
+}
+
+// GetMimeType returns the resource MIME type
+func (r *TabDomStateResource) GetMimeType() string {
+	return r.mimeType
+}
+
+// GetDescription returns the resource description
+func (r *TabDomStateResource) GetDescription() string {
+	return r.description
+}
+
+// GetOutputSchema returns the schema of the JSON format
+func (r *TabDomStateResource) GetOutputSchema() interface{} {
+	return r.domState.GetOutputSchema()
+}
+
+// GetVariables returns the typed variables of the URI template
+func (r *TabDomStateResource) GetVariables() []types.ResourceTemplateVariable {
+	return []types.ResourceTemplateVariable{
+		{Name: "tabId", Type: "integer"},
+	}
+}
+
+// ReadWithArguments reads the DOM state overview of the tab named by the tabId argument
+func (r *TabDomStateResource) ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (types.ResourceContent, error) {
+	tabID, ok := arguments["tabId"].(int)
+	if !ok {
+		return types.ResourceContent{}, fmt.Errorf("tabId is required")
+	}
+
+	return r.domState.readOverview(ctx, uri, map[string]interface{}{
+		"tab_id": tabID,
+	}, requestedFormat(r.format, arguments))
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// FromType generates a JSON Schema describing the JSON encoding of v's type.
+// Struct fields are named by their json tags; fields without omitempty are
+// required, and a description tag becomes the property description. Required
+// pointer, slice and map fields also accept null, which is how encoding/json
+// writes their nil value. Untyped
+// values (interface{}, json.RawMessage) accept any JSON value. Recursive types
+// are not supported.
+func FromType(v interface{}) map[string]interface{} {
+	return fromType(reflect.TypeOf(v))
+}
+
+// fromType generates the schema of a single Go type
+func fromType(t reflect.Type) map[string]interface{} {
+	if t == nil || t == rawMessageType {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return fromType(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": fromType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": fromType(t.Elem())}
+	case reflect.Struct:
+		return fromStruct(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// fromStruct generates an object schema from the exported fields of a struct
+func fromStruct(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	addStructFields(t, properties, &required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// addStructFields adds the properties of a struct, flattening embedded structs as encoding/json does
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := fromType(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if !strings.Contains(","+options+",", ",omitempty,") {
+			*required = append(*required, name)
+			switch field.Type.Kind() {
+			case reflect.Ptr, reflect.Slice, reflect.Map:
+				property = nullable(property)
+			}
+		}
+		properties[name] = property
+	}
+}
+
+// nullable extends the type of a schema to also accept null
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typeName, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typeName, "null"}
+	}
+	return schema
+}
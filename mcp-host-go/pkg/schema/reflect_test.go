@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reflectBase struct {
+	ID int `json:"id"`
+}
+
+type reflectItem struct {
+	Name string `json:"name"`
+}
+
+type reflectResult struct {
+	reflectBase
+	Success  bool              `json:"success" description:"Whether it worked"`
+	Ratio    float64           `json:"ratio"`
+	Message  string            `json:"message,omitempty"`
+	Items    []reflectItem     `json:"items"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Detail   *reflectItem      `json:"detail"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Extra    interface{}       `json:"extra"`
+	Data     []byte            `json:"data,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+	Attrs    map[string]interface{} `json:"attrs,omitempty"`
+}
+
+func TestFromType(t *testing.T) {
+	s := FromType(reflectResult{})
+
+	assert.Equal(t, "object", s["type"])
+	assert.Equal(t, false, s["additionalProperties"])
+	assert.ElementsMatch(t, []string{"id", "success", "ratio", "items", "detail", "extra"}, s["required"])
+
+	properties := s["properties"].(map[string]interface{})
+	assert.Len(t, properties, 11)
+	assert.NotContains(t, properties, "Ignored")
+	assert.NotContains(t, properties, "internal")
+
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["id"])
+	assert.Equal(t, map[string]interface{}{"type": "boolean", "description": "Whether it worked"}, properties["success"])
+	assert.Equal(t, map[string]interface{}{"type": "number"}, properties["ratio"])
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["message"])
+	assert.Equal(t, map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}}, properties["labels"])
+	assert.Equal(t, map[string]interface{}{}, properties["raw"])
+	assert.Equal(t, map[string]interface{}{}, properties["extra"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "contentEncoding": "base64"}, properties["data"])
+
+	items := properties["items"].(map[string]interface{})
+	assert.Equal(t, []string{"array", "null"}, items["type"])
+	assert.Equal(t, []string{"name"}, items["items"].(map[string]interface{})["required"])
+
+	detail := properties["detail"].(map[string]interface{})
+	assert.Equal(t, []string{"object", "null"}, detail["type"])
+}
+
+func TestFromType_ValidatesEncodedValues(t *testing.T) {
+	validator, err := NewValidator(FromType(&reflectResult{}))
+	require.NoError(t, err)
+
+	values := []reflectResult{
+		{},
+		{
+			reflectBase: reflectBase{ID: 7},
+			Success:     true,
+			Ratio:       0.5,
+			Message:     "done",
+			Items:       []reflectItem{{Name: "a"}},
+			Labels:      map[string]string{"k": "v"},
+			Detail:      &reflectItem{Name: "b"},
+			Raw:         json.RawMessage(`{"any":[1,2]}`),
+			Extra:       "anything",
+			Data:        []byte{1, 2, 3},
+			Attrs:       map[string]interface{}{"n": 1},
+		},
+	}
+
+	for _, value := range values {
+		encoded, err := json.Marshal(value)
+		require.NoError(t, err)
+
+		var decoded interface{}
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.NoError(t, validator.Validate(decoded), string(encoded))
+	}
+}
+
+func TestFromType_Scalars(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{"type": "string"}, FromType(""))
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}}, FromType([]int{}))
+	assert.Equal(t, map[string]interface{}{}, FromType(nil))
+}
@@ -0,0 +1,392 @@
+// Package schema validates tool arguments against JSON Schemas and generates
+// output schemas from Go result types.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes a single value that does not match the schema
+type ValidationError struct {
+	Path    string // Dotted path of the value, e.g. options.wait_after; empty for the root
+	Message string
+}
+
+// Error formats the error as "path: message"
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors lists every schema violation found in a value
+type ValidationErrors []ValidationError
+
+// Error joins all violations with "; "
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validator checks values against a JSON Schema. It supports the keywords used
+// by tool input schemas: type, properties, required, additionalProperties,
+// items, enum, const, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, minItems, maxItems, allOf, anyOf and oneOf.
+// Other keywords are passed through to clients but not checked.
+type Validator struct {
+	raw      json.RawMessage
+	schema   map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// NewValidator creates a Validator for a schema given as JSON-compatible Go values
+func NewValidator(schema interface{}) (*Validator, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	// Round-trip through JSON so that Go slices and numbers take their JSON-decoded form
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("schema must be a JSON object: %w", err)
+	}
+	if decoded == nil {
+		return nil, fmt.Errorf("schema must be a JSON object")
+	}
+
+	v := &Validator{
+		raw:      raw,
+		schema:   decoded,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := v.compilePatterns(decoded); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// RawSchema returns the schema as JSON, exactly as it will be sent to clients
+func (v *Validator) RawSchema() json.RawMessage {
+	return v.raw
+}
+
+// Validate checks value against the schema, returning ValidationErrors on failure
+func (v *Validator) Validate(value interface{}) error {
+	var errs ValidationErrors
+	v.validate(v.schema, value, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// compilePatterns precompiles every pattern keyword in the schema
+func (v *Validator) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if pattern, ok := n["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			v.patterns[pattern] = re
+		}
+		for _, child := range n {
+			if err := v.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := v.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate appends the violations of value against schema to errs
+func (v *Validator) validate(schema map[string]interface{}, value interface{}, path string, errs *ValidationErrors) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// A type mismatch makes the remaining keywords meaningless
+	if typ, ok := schema["type"]; ok {
+		if message := checkType(typ, value); message != "" {
+			fail("%s", message)
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		if !containsValue(enum, value) {
+			fail("must be one of: %s", formatValues(enum))
+		}
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		fail("must be %s", formatValue(constant))
+	}
+
+	switch val := value.(type) {
+	case float64:
+		v.validateNumber(schema, val, fail)
+	case string:
+		v.validateString(schema, val, fail)
+	case []interface{}:
+		v.validateArray(schema, val, path, errs, fail)
+	case map[string]interface{}:
+		v.validateObject(schema, val, path, errs)
+	}
+
+	v.validateCombinators(schema, value, path, errs, fail)
+}
+
+// validateNumber checks the numeric range keywords
+func (v *Validator) validateNumber(schema map[string]interface{}, value float64, fail func(string, ...interface{})) {
+	if minimum, ok := schema["minimum"].(float64); ok && value < minimum {
+		fail("must be >= %s", formatNumber(minimum))
+	}
+	if maximum, ok := schema["maximum"].(float64); ok && value > maximum {
+		fail("must be <= %s", formatNumber(maximum))
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok && value <= minimum {
+		fail("must be > %s", formatNumber(minimum))
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok && value >= maximum {
+		fail("must be < %s", formatNumber(maximum))
+	}
+}
+
+// validateString checks the string length and pattern keywords
+func (v *Validator) validateString(schema map[string]interface{}, value string, fail func(string, ...interface{})) {
+	length := float64(len([]rune(value)))
+	if minLength, ok := schema["minLength"].(float64); ok && length < minLength {
+		fail("must be at least %s characters long", formatNumber(minLength))
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok && length > maxLength {
+		fail("must be at most %s characters long", formatNumber(maxLength))
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !v.patterns[pattern].MatchString(value) {
+		fail("must match pattern %q", pattern)
+	}
+}
+
+// validateArray checks the array size keywords and every item
+func (v *Validator) validateArray(schema map[string]interface{}, value []interface{}, path string, errs *ValidationErrors, fail func(string, ...interface{})) {
+	count := float64(len(value))
+	if minItems, ok := schema["minItems"].(float64); ok && count < minItems {
+		fail("must have at least %s items", formatNumber(minItems))
+	}
+	if maxItems, ok := schema["maxItems"].(float64); ok && count > maxItems {
+		fail("must have at most %s items", formatNumber(maxItems))
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range value {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// validateObject checks required, properties and additionalProperties
+func (v *Validator) validateObject(schema map[string]interface{}, value map[string]interface{}, path string, errs *ValidationErrors) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, exists := value[name]; !exists {
+					*errs = append(*errs, ValidationError{Path: joinPath(path, name), Message: "is required"})
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	// Visit keys in order so errors are reported deterministically
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+			v.validate(propertySchema, value[key], joinPath(path, key), errs)
+			continue
+		}
+		if _, declared := properties[key]; declared {
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, ValidationError{Path: joinPath(path, key), Message: "is not allowed"})
+			}
+		case map[string]interface{}:
+			v.validate(additional, value[key], joinPath(path, key), errs)
+		}
+	}
+}
+
+// validateCombinators checks allOf, anyOf and oneOf
+func (v *Validator) validateCombinators(schema map[string]interface{}, value interface{}, path string, errs *ValidationErrors, fail func(string, ...interface{})) {
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if subSchema, ok := sub.(map[string]interface{}); ok {
+				v.validate(subSchema, value, path, errs)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if v.countMatches(anyOf, value, path) == 0 {
+			fail("must match at least one of the allowed schemas")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if v.countMatches(oneOf, value, path) != 1 {
+			fail("must match exactly one of the allowed schemas")
+		}
+	}
+}
+
+// countMatches returns how many of the schemas value satisfies
+func (v *Validator) countMatches(schemas []interface{}, value interface{}, path string) int {
+	matches := 0
+	for _, sub := range schemas {
+		subSchema, ok := sub.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var subErrs ValidationErrors
+		v.validate(subSchema, value, path, &subErrs)
+		if len(subErrs) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// checkType returns a message when value does not have one of the types in typ
+func checkType(typ interface{}, value interface{}) string {
+	var allowed []string
+	switch t := typ.(type) {
+	case string:
+		allowed = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok {
+				allowed = append(allowed, name)
+			}
+		}
+	default:
+		return ""
+	}
+
+	for _, name := range allowed {
+		if hasType(name, value) {
+			return ""
+		}
+	}
+
+	if len(allowed) == 1 {
+		return "must be " + typeWithArticle(allowed[0])
+	}
+	return "must be one of types: " + strings.Join(allowed, ", ")
+}
+
+// hasType reports whether value is of the named JSON Schema type
+func hasType(name string, value interface{}) bool {
+	switch name {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	// Unknown types are not enforced
+	return true
+}
+
+// typeWithArticle returns the type name with its indefinite article
+func typeWithArticle(name string) string {
+	switch name {
+	case "integer", "array", "object":
+		return "an " + name
+	case "null":
+		return name
+	}
+	return "a " + name
+}
+
+// containsValue reports whether values contains value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValues formats enum values for error messages
+func formatValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = formatValue(value)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatValue formats a single value for error messages
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return formatNumber(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// formatNumber formats a number without exponent or trailing zeros
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// joinPath appends a property name to a dotted path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
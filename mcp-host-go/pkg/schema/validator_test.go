@@ -0,0 +1,267 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// typeValueSchema mirrors the nested input schema of the type_value tool
+var typeValueSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"element_index": map[string]interface{}{
+			"type":    "number",
+			"minimum": 0,
+		},
+		"value": map[string]interface{}{},
+		"options": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"submit": map[string]interface{}{
+					"type": "boolean",
+				},
+				"wait_after": map[string]interface{}{
+					"type":    "number",
+					"minimum": 0,
+					"maximum": 30,
+				},
+			},
+			"additionalProperties": false,
+		},
+	},
+	"required":             []string{"element_index", "value"},
+	"additionalProperties": false,
+}
+
+func TestNewValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   interface{}
+		errorMsg string
+	}{
+		{
+			name:   "valid schema",
+			schema: typeValueSchema,
+		},
+		{
+			name:     "not an object",
+			schema:   []string{"type"},
+			errorMsg: "schema must be a JSON object",
+		},
+		{
+			name:     "nil schema",
+			schema:   nil,
+			errorMsg: "schema must be a JSON object",
+		},
+		{
+			name: "invalid pattern",
+			schema: map[string]interface{}{
+				"type":    "string",
+				"pattern": "[",
+			},
+			errorMsg: "invalid pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewValidator(tt.schema)
+			if tt.errorMsg != "" {
+				assert.ErrorContains(t, err, tt.errorMsg)
+				assert.Nil(t, validator)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, validator)
+			}
+		})
+	}
+}
+
+func TestValidator_RawSchemaKeepsAllKeywords(t *testing.T) {
+	validator, err := NewValidator(typeValueSchema)
+	require.NoError(t, err)
+
+	raw := string(validator.RawSchema())
+	assert.Contains(t, raw, `"additionalProperties":false`)
+	assert.Contains(t, raw, `"maximum":30`)
+	assert.Contains(t, raw, `"required":["element_index","value"]`)
+}
+
+func TestValidator_Validate(t *testing.T) {
+	validator, err := NewValidator(typeValueSchema)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		errorMsg string
+	}{
+		{
+			name: "valid arguments",
+			value: map[string]interface{}{
+				"element_index": 3.0,
+				"value":         "hello",
+				"options":       map[string]interface{}{"wait_after": 2.0},
+			},
+		},
+		{
+			name:     "missing required",
+			value:    map[string]interface{}{"value": "hello"},
+			errorMsg: "element_index: is required",
+		},
+		{
+			name: "nested maximum",
+			value: map[string]interface{}{
+				"element_index": 1.0,
+				"value":         "hello",
+				"options":       map[string]interface{}{"wait_after": 31.0},
+			},
+			errorMsg: "options.wait_after: must be <= 30",
+		},
+		{
+			name: "nested type",
+			value: map[string]interface{}{
+				"element_index": 1.0,
+				"value":         "hello",
+				"options":       map[string]interface{}{"submit": "yes"},
+			},
+			errorMsg: "options.submit: must be a boolean",
+		},
+		{
+			name: "additional property",
+			value: map[string]interface{}{
+				"element_index": 1.0,
+				"value":         "hello",
+				"options":       map[string]interface{}{"retry": true},
+			},
+			errorMsg: "options.retry: is not allowed",
+		},
+		{
+			name: "minimum",
+			value: map[string]interface{}{
+				"element_index": -1.0,
+				"value":         "hello",
+			},
+			errorMsg: "element_index: must be >= 0",
+		},
+		{
+			name:     "root type",
+			value:    "not an object",
+			errorMsg: "must be an object",
+		},
+		{
+			name:     "multiple errors",
+			value:    map[string]interface{}{"element_index": "one", "extra": 1.0},
+			errorMsg: "value: is required; element_index: must be a number; extra: is not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(tt.value)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errorMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidator_Keywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   map[string]interface{}
+		value    interface{}
+		errorMsg string
+	}{
+		{
+			name:     "enum",
+			schema:   map[string]interface{}{"type": "string", "enum": []string{"up", "down"}},
+			value:    "left",
+			errorMsg: "must be one of: up, down",
+		},
+		{
+			name:     "integer rejects fractions",
+			schema:   map[string]interface{}{"type": "integer"},
+			value:    1.5,
+			errorMsg: "must be an integer",
+		},
+		{
+			name:   "integer accepts whole numbers",
+			schema: map[string]interface{}{"type": "integer"},
+			value:  2.0,
+		},
+		{
+			name:     "type list",
+			schema:   map[string]interface{}{"type": []string{"string", "null"}},
+			value:    true,
+			errorMsg: "must be one of types: string, null",
+		},
+		{
+			name:     "pattern",
+			schema:   map[string]interface{}{"type": "string", "pattern": "^[0-9]+$"},
+			value:    "10s",
+			errorMsg: `must match pattern "^[0-9]+$"`,
+		},
+		{
+			name:     "max length",
+			schema:   map[string]interface{}{"type": "string", "maxLength": 3},
+			value:    "four",
+			errorMsg: "must be at most 3 characters long",
+		},
+		{
+			name:     "array items",
+			schema:   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			value:    []interface{}{"a", 2.0},
+			errorMsg: "[1]: must be a string",
+		},
+		{
+			name:     "min items",
+			schema:   map[string]interface{}{"type": "array", "minItems": 1},
+			value:    []interface{}{},
+			errorMsg: "must have at least 1 items",
+		},
+		{
+			name: "one of",
+			schema: map[string]interface{}{"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "number"},
+			}},
+			value:    true,
+			errorMsg: "must match exactly one of the allowed schemas",
+		},
+		{
+			name: "any of",
+			schema: map[string]interface{}{"anyOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "number"},
+			}},
+			value: 5.0,
+		},
+		{
+			name:     "exclusive minimum",
+			schema:   map[string]interface{}{"type": "number", "exclusiveMinimum": 0},
+			value:    0.0,
+			errorMsg: "must be > 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := NewValidator(tt.schema)
+			require.NoError(t, err)
+
+			err = validator.Validate(tt.value)
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.errorMsg, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// MethodNotificationCancelled is sent by clients to cancel an in-flight request
+const MethodNotificationCancelled = "notifications/cancelled"
+
+// requestScopeKey is the context key for the per-message requestScope
+type requestScopeKey struct{}
+
+// requestScope carries the JSON-RPC request ID from the server hooks to the
+// tool handler, which mcp-go only hands the decoded request without its ID
+type requestScope struct {
+	id any
+}
+
+// withRequestScope attaches an empty requestScope to the context of one incoming message
+func withRequestScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestScopeKey{}, &requestScope{})
+}
+
+// requestScopeFromContext returns the requestScope of the message, if any
+func requestScopeFromContext(ctx context.Context) *requestScope {
+	scope, _ := ctx.Value(requestScopeKey{}).(*requestScope)
+	return scope
+}
+
+// withRequestScopeHandler gives every HTTP request its own requestScope
+func withRequestScopeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withRequestScope(r.Context())))
+	})
+}
+
+// inFlightCalls tracks cancel functions of running tool calls by session and request ID
+type inFlightCalls struct {
+	mutex   sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// newInFlightCalls creates an empty inFlightCalls registry
+func newInFlightCalls() *inFlightCalls {
+	return &inFlightCalls{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// callKey builds the registry key; IDs are compared in their JSON-decoded form
+func callKey(sessionID string, id any) string {
+	return fmt.Sprintf("%s/%v", sessionID, id)
+}
+
+// track derives a cancellable context for a tool call and registers it under
+// its session and request ID. The returned function must be called when the
+// call finishes.
+func (c *inFlightCalls) track(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	scope := requestScopeFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if scope == nil || scope.id == nil || session == nil {
+		return ctx, cancel
+	}
+
+	key := callKey(session.SessionID(), scope.id)
+	c.mutex.Lock()
+	c.cancels[key] = cancel
+	c.mutex.Unlock()
+
+	return ctx, func() {
+		c.mutex.Lock()
+		delete(c.cancels, key)
+		c.mutex.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels the tool call with the given session and request ID, reporting whether it was running
+func (c *inFlightCalls) cancel(sessionID string, id any) bool {
+	c.mutex.Lock()
+	cancel, exists := c.cancels[callKey(sessionID, id)]
+	c.mutex.Unlock()
+
+	if exists {
+		cancel()
+	}
+	return exists
+}
+
+// newCancellationHooks records the request ID of each tool call in its requestScope
+func newCancellationHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest) {
+		if scope := requestScopeFromContext(ctx); scope != nil {
+			scope.id = id
+		}
+	})
+	return hooks
+}
+
+// handleCancelledNotification cancels the tool call named by a notifications/cancelled message
+func (s *SSEServer) handleCancelledNotification(ctx context.Context, notification mcp.JSONRPCNotification) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return
+	}
+
+	requestID, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		s.logger.Warn("Cancellation notification without requestId", zap.String("sessionId", session.SessionID()))
+		return
+	}
+
+	reason, _ := notification.Params.AdditionalFields["reason"].(string)
+	if s.inFlight.cancel(session.SessionID(), requestID) {
+		s.logger.Info("Cancelled tool call at client request",
+			zap.String("sessionId", session.SessionID()),
+			zap.Any("requestId", requestID),
+			zap.String("reason", reason))
+	} else {
+		s.logger.Debug("Cancellation for unknown or finished request",
+			zap.String("sessionId", session.SessionID()),
+			zap.Any("requestId", requestID))
+	}
+}
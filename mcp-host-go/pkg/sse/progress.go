@@ -0,0 +1,54 @@
+package sse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// MethodNotificationProgress reports the progress of a long-running request to the client
+const MethodNotificationProgress = "notifications/progress"
+
+// progressRelay returns a ProgressFunc that sends notifications/progress with
+// the client's progress token to the session of ctx. MCP requires progress to
+// increase with every notification, so updates that arrive out of order are dropped.
+func (s *SSEServer) progressRelay(ctx context.Context, token mcp.ProgressToken) types.ProgressFunc {
+	var (
+		mutex sync.Mutex
+		sent  bool
+		last  float64
+	)
+
+	return func(progress types.RpcProgress) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if sent && progress.Progress <= last {
+			s.logger.Debug("Dropping out-of-order progress update",
+				zap.Any("progressToken", token),
+				zap.Float64("progress", progress.Progress),
+				zap.Float64("last", last))
+			return
+		}
+		sent = true
+		last = progress.Progress
+
+		params := map[string]any{
+			"progressToken": token,
+			"progress":      progress.Progress,
+		}
+		if progress.Total > 0 {
+			params["total"] = progress.Total
+		}
+		if progress.Message != "" {
+			params["message"] = progress.Message
+		}
+
+		if err := s.mcpServer.SendNotificationToClient(ctx, MethodNotificationProgress, params); err != nil {
+			s.logger.Warn("Failed to send progress notification", zap.Error(err), zap.Any("progressToken", token))
+		}
+	}
+}
@@ -2,39 +2,84 @@ package sse
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/yosida95/uritemplate/v3"
 	"go.uber.org/zap"
 
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/auth"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 )
 
-// SSEServer represents an SSE MCP server that forwards requests to Chrome extension
+// DefaultStreamableHTTPPath is the endpoint path of the Streamable HTTP transport
+const DefaultStreamableHTTPPath = "/mcp"
+
+// errorMetaKey is the _meta key under which failed tool results report a typed error code
+const errorMetaKey = "algonius/error"
+
+// DefaultAllowedHosts are the host names accepted in Host and Origin headers
+var DefaultAllowedHosts = []string{"localhost", "127.0.0.1"}
+
+// SSEServer represents an MCP server that forwards requests to Chrome extension.
+// It serves the legacy SSE transport and the Streamable HTTP transport from a
+// single HTTP server, both backed by the same set of tools and resources.
 type SSEServer struct {
-	logger    logger.Logger
-	messaging types.Messaging
-	mcpServer *server.MCPServer
-	sseServer *server.SSEServer
-	port      string
-	baseURL   string
-	hostInfo  types.HostInfo
-	tools     map[string]types.Tool
-	resources map[string]types.Resource
+	logger               logger.Logger
+	messaging            types.Messaging
+	mcpServer            *server.MCPServer
+	sseServer            *server.SSEServer
+	streamableServer     *server.StreamableHTTPServer
+	httpServer           *http.Server
+	baseCtx              context.Context
+	cancelBaseCtx        context.CancelFunc
+	port                 string
+	baseURL              string
+	streamableHTTPPath   string
+	socketPath           string
+	socketListener       net.Listener
+	authToken            string
+	allowedHosts         map[string]bool
+	inFlight             *inFlightCalls
+	subscriptions        *subscriptionRegistry
+	sessionTabs          *sessionTabRegistry
+	tabScheduler         *tabScheduler
+	enableSSE            bool
+	enableStreamableHTTP bool
+	hostInfo             types.HostInfo
+	capabilities         *types.ExtensionCapabilities
+	toolsMutex           sync.RWMutex // Tools are added and removed while clients list them
+	tools                map[string]types.Tool
+	resources            map[string]types.Resource
+	resourceTemplates    map[string]types.ResourceTemplate
 }
 
 // SSEServerConfig contains configuration for SSE server
 type SSEServerConfig struct {
-	Logger    logger.Logger
-	Messaging types.Messaging
-	Port      string
-	BaseURL   string
-	HostInfo  types.HostInfo
+	Logger               logger.Logger
+	Messaging            types.Messaging
+	Port                 string
+	BaseURL              string
+	StreamableHTTPPath   string   // Defaults to DefaultStreamableHTTPPath
+	SocketPath           string   // Unix socket for the stdio bridge, empty to disable
+	AuthToken            string   // Bearer token required on HTTP requests, empty to disable
+	AllowedHosts         []string // Host names accepted in Host/Origin headers, defaults to DefaultAllowedHosts
+	EnableSSE            bool
+	EnableStreamableHTTP bool
+	HostInfo             types.HostInfo
+	// Capabilities, if set, hides tools the connected extension does not support
+	Capabilities *types.ExtensionCapabilities
 }
 
 // NewSSEServer creates a new SSE MCP server
@@ -45,26 +90,105 @@ func NewSSEServer(config SSEServerConfig) (*SSEServer, error) {
 	if config.Messaging == nil {
 		return nil, fmt.Errorf("messaging is required")
 	}
+	if !config.EnableSSE && !config.EnableStreamableHTTP {
+		return nil, fmt.Errorf("at least one MCP transport must be enabled")
+	}
+
+	streamableHTTPPath := config.StreamableHTTPPath
+	if streamableHTTPPath == "" {
+		streamableHTTPPath = DefaultStreamableHTTPPath
+	}
 
-	// Create the MCP server
+	allowedHostList := config.AllowedHosts
+	if len(allowedHostList) == 0 {
+		allowedHostList = DefaultAllowedHosts
+	}
+	allowedHosts := make(map[string]bool, len(allowedHostList))
+	for _, host := range allowedHostList {
+		allowedHosts[strings.ToLower(host)] = true
+	}
+
+	// Drop the subscriptions and tabs of sessions that disconnect
+	subscriptions := newSubscriptionRegistry()
+	sessionTabs := newSessionTabRegistry()
+	hooks := newCancellationHooks()
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptions.removeSession(session.SessionID())
+		sessionTabs.removeSession(session.SessionID())
+	})
+
+	// Create the MCP server. The tool filter needs the registered tools, so it
+	// is bound to the server once it exists.
+	var s *SSEServer
 	mcpServer := server.NewMCPServer(
 		config.HostInfo.Name,
 		config.HostInfo.Version,
+		server.WithHooks(hooks),
+		server.WithRecovery(),
+		server.WithResourceCapabilities(true, false),
+		server.WithToolCapabilities(true),
+		server.WithToolFilter(func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+			return s.supportedTools(tools)
+		}),
 	)
 
+	// Both transports are mounted on one HTTP server. Handlers run on a base
+	// context that is cancelled on shutdown so long-lived streams are released.
+	baseCtx, cancelBaseCtx := context.WithCancel(context.Background())
+	httpServer := &http.Server{
+		Addr: config.Port,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
+	}
+
 	// Create SSE server with proper configuration
-	sseServer := server.NewSSEServer(mcpServer)
+	sseServer := server.NewSSEServer(mcpServer, server.WithHTTPServer(httpServer))
 
-	s := &SSEServer{
-		logger:    config.Logger,
-		messaging: config.Messaging,
-		mcpServer: mcpServer,
-		sseServer: sseServer,
-		port:      config.Port,
-		baseURL:   config.BaseURL,
-		hostInfo:  config.HostInfo,
-		tools:     make(map[string]types.Tool),
-		resources: make(map[string]types.Resource),
+	// Create Streamable HTTP server sharing the same MCP server
+	streamableServer := server.NewStreamableHTTPServer(mcpServer,
+		server.WithEndpointPath(streamableHTTPPath),
+		server.WithLogger(&mcpLogger{logger: config.Logger}),
+	)
+
+	s = &SSEServer{
+		logger:               config.Logger,
+		messaging:            config.Messaging,
+		mcpServer:            mcpServer,
+		sseServer:            sseServer,
+		streamableServer:     streamableServer,
+		httpServer:           httpServer,
+		baseCtx:              baseCtx,
+		cancelBaseCtx:        cancelBaseCtx,
+		port:                 config.Port,
+		baseURL:              config.BaseURL,
+		streamableHTTPPath:   streamableHTTPPath,
+		socketPath:           config.SocketPath,
+		authToken:            config.AuthToken,
+		allowedHosts:         allowedHosts,
+		inFlight:             newInFlightCalls(),
+		subscriptions:        subscriptions,
+		sessionTabs:          sessionTabs,
+		tabScheduler:         newTabScheduler(),
+		enableSSE:            config.EnableSSE,
+		enableStreamableHTTP: config.EnableStreamableHTTP,
+		hostInfo:             config.HostInfo,
+		capabilities:         config.Capabilities,
+		tools:                make(map[string]types.Tool),
+		resources:            make(map[string]types.Resource),
+		resourceTemplates:    make(map[string]types.ResourceTemplate),
+	}
+
+	mcpServer.AddNotificationHandler(MethodNotificationCancelled, s.handleCancelledNotification)
+
+	// The set of supported tools changes when an extension completes the init handshake
+	if s.capabilities != nil {
+		s.capabilities.OnChange(func(info types.ExtensionInfo) {
+			s.logger.Info("Extension capabilities changed, notifying clients of the tool list",
+				zap.String("extensionVersion", info.Version),
+				zap.Bool("compatible", info.Compatible))
+			s.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+		})
 	}
 
 	return s, nil
@@ -75,51 +199,43 @@ func (s *SSEServer) RegisterTool(tool types.Tool) error {
 	name := tool.GetName()
 	s.logger.Debug("Registering tool for SSE server", zap.String("name", name))
 
-	s.tools[name] = tool
-
-	// Get the schema and convert to the proper format
-	schema := tool.GetInputSchema()
-
-	// Create a minimal schema if none is provided
-	toolSchema := mcp.ToolInputSchema{
-		Type:       "object",
-		Properties: make(map[string]any),
-	}
-
-	// Try to convert the schema if it's provided in the right format
-	if schemaMap, ok := schema.(map[string]interface{}); ok {
-		if typeVal, ok := schemaMap["type"].(string); ok {
-			toolSchema.Type = typeVal
-		}
-		if props, ok := schemaMap["properties"].(map[string]interface{}); ok {
-			toolSchema.Properties = props
-		}
-		if required, ok := schemaMap["required"].([]string); ok {
-			toolSchema.Required = required
-		} else if requiredAny, ok := schemaMap["required"].([]interface{}); ok {
-			// Convert []interface{} to []string if needed
-			for _, r := range requiredAny {
-				if s, ok := r.(string); ok {
-					toolSchema.Required = append(toolSchema.Required, s)
-				}
-			}
+	// Pass the whole input schema through to clients and validate calls against it
+	inputSchema := tool.GetInputSchema()
+	if inputSchema == nil {
+		inputSchema = map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
 		}
-	} else {
-		s.logger.Warn("Invalid input schema format for tool", zap.String("tool", name))
 	}
 
-	// Register with MCP server
-	serverTool := mcp.Tool{
-		Name:        name,
-		Description: tool.GetDescription(),
-		InputSchema: toolSchema,
+	validator, err := schema.NewValidator(inputSchema)
+	if err != nil {
+		return fmt.Errorf("invalid input schema for tool %s: %w", name, err)
 	}
 
-	s.mcpServer.AddTool(serverTool, s.createToolHandlerForTool(tool))
+	s.toolsMutex.Lock()
+	s.tools[name] = tool
+	s.toolsMutex.Unlock()
+
+	// Register with MCP server, which notifies clients once it runs
+	serverTool := mcp.NewToolWithRawSchema(name, tool.GetDescription(), validator.RawSchema())
+
+	s.mcpServer.AddTool(serverTool, s.createToolHandlerForTool(tool, validator))
 
 	return nil
 }
 
+// UnregisterTool removes a tool from the SSE server and notifies clients of the changed tool list
+func (s *SSEServer) UnregisterTool(name string) {
+	s.logger.Debug("Unregistering tool from SSE server", zap.String("name", name))
+
+	s.toolsMutex.Lock()
+	delete(s.tools, name)
+	s.toolsMutex.Unlock()
+
+	s.mcpServer.DeleteTools(name)
+}
+
 // RegisterResource registers a resource with the SSE server
 func (s *SSEServer) RegisterResource(resource types.Resource) error {
 	uri := resource.GetURI()
@@ -140,43 +256,68 @@ func (s *SSEServer) RegisterResource(resource types.Resource) error {
 	return nil
 }
 
-// findMatchingResource finds a resource that matches the requested URI
-func (s *SSEServer) findMatchingResource(requestedURI string) types.Resource {
-	// First try exact match
-	if resource, exists := s.resources[requestedURI]; exists {
-		return resource
+// RegisterResourceTemplate registers a resource template with the SSE server
+func (s *SSEServer) RegisterResourceTemplate(template types.ResourceTemplate) error {
+	uriTemplate := template.GetURITemplate()
+	s.logger.Debug("Registering resource template for SSE server", zap.String("uriTemplate", uriTemplate))
+
+	parsed, err := uritemplate.New(uriTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid URI template %q: %w", uriTemplate, err)
 	}
 
-	// Then try pattern matching for path-based parameters
-	for baseURI, resource := range s.resources {
-		if s.matchesURIPattern(requestedURI, baseURI) {
-			return resource
+	// Every declared variable must appear in the template and vice versa
+	declared := make(map[string]bool)
+	for _, variable := range template.GetVariables() {
+		if variable.Type != "string" && variable.Type != "integer" {
+			return fmt.Errorf("unsupported type %q for variable %s in URI template %q", variable.Type, variable.Name, uriTemplate)
+		}
+		declared[variable.Name] = true
+	}
+	for _, name := range parsed.Varnames() {
+		if !declared[name] {
+			return fmt.Errorf("variable %s in URI template %q is not declared", name, uriTemplate)
 		}
 	}
 
-	return nil
-}
+	s.resourceTemplates[uriTemplate] = template
 
-// matchesURIPattern checks if a requested URI matches a base URI pattern
-func (s *SSEServer) matchesURIPattern(requestedURI, baseURI string) bool {
-	// Simple pattern matching for DOM state resource
-	// This specifically handles browser://dom/state/* patterns
-	if baseURI == "browser://dom/state" {
-		return strings.HasPrefix(requestedURI, "browser://dom/state")
+	// Register with MCP server
+	mcpTemplate := mcp.ResourceTemplate{
+		URITemplate: &mcp.URITemplate{Template: parsed},
+		Name:        template.GetName(),
+		Description: template.GetDescription(),
+		MIMEType:    template.GetMimeType(),
 	}
 
-	return false
+	s.mcpServer.AddResourceTemplate(mcpTemplate, s.createResourceHandlerForTemplate(template))
+
+	return nil
 }
 
-// Start starts the SSE MCP server
+// Start starts the MCP server with every enabled transport
 func (s *SSEServer) Start() error {
 	s.logger.Info("Starting SSE MCP server",
 		zap.String("port", s.port),
-		zap.String("baseURL", s.baseURL))
+		zap.String("baseURL", s.baseURL),
+		zap.Bool("sseEnabled", s.enableSSE),
+		zap.Bool("streamableHTTPEnabled", s.enableStreamableHTTP),
+		zap.String("streamableHTTPPath", s.streamableHTTPPath),
+		zap.Bool("authEnabled", s.authToken != ""))
+
+	// Reject DNS-rebinding and cross-site requests before any transport sees them
+	s.httpServer.Handler = s.validateHostAndOrigin(withRequestScopeHandler(s.buildHandler()))
+
+	// Serve the stdio bridge socket when configured
+	if s.socketPath != "" {
+		if err := s.startSocketListener(); err != nil {
+			return err
+		}
+	}
 
 	// Start the server in a goroutine
 	go func() {
-		if err := s.sseServer.Start(s.port); err != nil && err != http.ErrServerClosed {
+		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			s.logger.Error("SSE server error", zap.Error(err))
 		}
 	}()
@@ -185,6 +326,96 @@ func (s *SSEServer) Start() error {
 	return nil
 }
 
+// buildHandler routes the enabled transports on a single mux
+func (s *SSEServer) buildHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	if s.enableSSE {
+		mux.Handle(s.sseServer.CompleteSsePath(), s.sseServer.SSEHandler())
+		mux.Handle(s.sseServer.CompleteMessagePath(), s.interceptSubscriptionsHandler(
+			s.sseServer.MessageHandler(),
+			func(r *http.Request) string { return r.URL.Query().Get("sessionId") },
+			s.replyOnEventStream,
+		))
+	}
+
+	if s.enableStreamableHTTP {
+		mux.Handle(s.streamableHTTPPath, s.interceptSubscriptionsHandler(
+			s.streamableServer,
+			func(r *http.Request) string { return r.Header.Get("Mcp-Session-Id") },
+			replyInBody,
+		))
+	}
+
+	if s.authToken == "" {
+		return mux
+	}
+	return s.requireBearerToken(mux)
+}
+
+// validateHostAndOrigin rejects requests whose Host or Origin header names a host outside the allowlist
+func (s *SSEServer) validateHostAndOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAllowedHost(r.Host) {
+			s.logger.Warn("Rejected request with disallowed Host header",
+				zap.String("host", r.Host),
+				zap.String("remoteAddr", r.RemoteAddr),
+				zap.String("path", r.URL.Path))
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		// Non-browser clients do not send Origin; browsers always do on cross-origin requests
+		if origin := r.Header.Get("Origin"); origin != "" && !s.isAllowedOrigin(origin) {
+			s.logger.Warn("Rejected request with disallowed Origin header",
+				zap.String("origin", origin),
+				zap.String("remoteAddr", r.RemoteAddr),
+				zap.String("path", r.URL.Path))
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAllowedHost checks a Host header value, with or without port, against the allowlist
+func (s *SSEServer) isAllowedHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	return s.allowedHosts[strings.ToLower(host)]
+}
+
+// isAllowedOrigin checks that an Origin header is an http(s) URL on an allowed host
+func (s *SSEServer) isAllowedOrigin(origin string) bool {
+	originURL, err := url.Parse(origin)
+	if err != nil || (originURL.Scheme != "http" && originURL.Scheme != "https") {
+		return false
+	}
+
+	return s.allowedHosts[strings.ToLower(originURL.Hostname())]
+}
+
+// requireBearerToken rejects HTTP requests that do not carry the configured bearer token
+func (s *SSEServer) requireBearerToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.CheckBearerToken(r, s.authToken) {
+			s.logger.Warn("Rejected unauthorized MCP request",
+				zap.String("remoteAddr", r.RemoteAddr),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-host"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // Shutdown shuts down the SSE MCP server
 func (s *SSEServer) Shutdown() error {
 	s.logger.Info("Shutting down SSE MCP server")
@@ -192,9 +423,19 @@ func (s *SSEServer) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Release long-lived SSE and Streamable HTTP streams and socket sessions
+	s.cancelBaseCtx()
+	s.stopSocketListener()
+
+	// The SSE server owns the shared HTTP server and closes its sessions first
 	return s.sseServer.Shutdown(ctx)
 }
 
+// GetStreamableHTTPPath returns the endpoint path of the Streamable HTTP transport
+func (s *SSEServer) GetStreamableHTTPPath() string {
+	return s.streamableHTTPPath
+}
+
 // IsRunning returns whether the SSE server is running
 func (s *SSEServer) IsRunning() bool {
 	// For now just return true if the server is initialized
@@ -203,22 +444,118 @@ func (s *SSEServer) IsRunning() bool {
 }
 
 // createToolHandlerForTool creates a tool handler function for the provided tool
-func (s *SSEServer) createToolHandlerForTool(tool types.Tool) server.ToolHandlerFunc {
+func (s *SSEServer) createToolHandlerForTool(tool types.Tool, validator *schema.Validator) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		s.logger.Debug("Executing tool via SSE", zap.String("tool", tool.GetName()))
 
-		// Convert arguments to map[string]interface{}
-		args, ok := request.Params.Arguments.(map[string]interface{})
+		// Allow notifications/cancelled to abort this call
+		ctx, done := s.inFlight.track(ctx)
+		defer done()
+
+		// Act on the tab of the calling session
+		ctx = s.withSessionTab(ctx)
+
+		// Relay progress reported by the extension if the client asked for it
+		if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+			ctx = types.WithProgress(ctx, s.progressRelay(ctx, request.Params.Meta.ProgressToken))
+		}
+
+		// A call without arguments is checked as an empty object
+		var rawArgs interface{} = request.Params.Arguments
+		if rawArgs == nil {
+			rawArgs = map[string]interface{}{}
+		}
+
+		// Reject arguments that do not match the input schema before the tool sees them
+		if err := validator.Validate(rawArgs); err != nil {
+			s.logger.Warn("Invalid tool arguments", zap.Error(err), zap.String("tool", tool.GetName()))
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Invalid arguments: %s", err.Error()),
+					},
+				},
+			}, nil
+		}
+		args, ok := rawArgs.(map[string]interface{})
 		if !ok {
-			args = make(map[string]interface{})
-			s.logger.Warn("Invalid arguments format", zap.String("tool", tool.GetName()))
+			s.logger.Warn("Tool arguments are not an object", zap.String("tool", tool.GetName()))
+			return mcp.NewToolResultError("arguments must be an object"), nil
+		}
+
+		// A tab_id argument targets that tab instead of the session's tab
+		if targeting, ok := tool.(types.TabTargetingTool); ok {
+			if tabID, ok := targeting.TargetTab(args); ok {
+				ctx = types.WithTargetTab(ctx, tabID)
+			}
+		}
+
+		// Fail fast instead of queueing calls for an extension that is not there
+		if !s.messaging.ConnectionStatus().IsConnected {
+			s.logger.Warn("Tool call while extension is disconnected", zap.String("tool", tool.GetName()))
+			return &mcp.CallToolResult{
+				Result:  mcp.Result{Meta: withErrorCode(nil, types.ErrExtensionDisconnected)},
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Tool execution failed: %s", types.ErrExtensionDisconnected.Error()),
+					},
+				},
+			}, nil
+		}
+
+		// Explain why a hidden tool or an incompatible extension cannot serve the call
+		if err := s.checkToolSupported(tool); err != nil {
+			s.logger.Warn("Tool call not supported by the extension", zap.Error(err), zap.String("tool", tool.GetName()))
+			return &mcp.CallToolResult{
+				Result:  mcp.Result{Meta: withErrorCode(nil, err)},
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Tool execution failed: %s", err.Error()),
+					},
+				},
+			}, nil
+		}
+
+		// Mutating calls wait for earlier calls on the same tab to finish
+		var meta map[string]any
+		if !isReadOnlyTool(tool) {
+			release, stats, err := s.tabScheduler.acquire(ctx, tabQueueKey(ctx))
+			if err != nil {
+				s.logger.Warn("Tool call abandoned while queued", zap.Error(err),
+					zap.String("tool", tool.GetName()),
+					zap.String("tab", stats.Tab))
+				return &mcp.CallToolResult{
+					IsError: true,
+					Content: []mcp.Content{
+						&mcp.TextContent{
+							Type: "text",
+							Text: fmt.Sprintf("Tool call abandoned while waiting for tab %s: %s", stats.Tab, err.Error()),
+						},
+					},
+				}, nil
+			}
+			defer release()
+
+			s.logger.Debug("Tool call acquired tab",
+				zap.String("tool", tool.GetName()),
+				zap.String("tab", stats.Tab),
+				zap.Int("queueDepth", stats.Depth),
+				zap.Int64("waitMs", stats.WaitMs))
+			meta = map[string]any{queueMetaKey: stats}
 		}
 
 		// Execute the tool
-		result, err := tool.Execute(args)
+		result, err := tool.Execute(ctx, args)
 		if err != nil {
 			s.logger.Error("Tool execution failed", zap.Error(err), zap.String("tool", tool.GetName()))
 			return &mcp.CallToolResult{
+				Result:  mcp.Result{Meta: withErrorCode(meta, err)},
 				IsError: true,
 				Content: []mcp.Content{
 					&mcp.TextContent{
@@ -232,19 +569,88 @@ func (s *SSEServer) createToolHandlerForTool(tool types.Tool) server.ToolHandler
 		// Convert result to MCP format
 		var content []mcp.Content
 		for _, item := range result.Content {
-			content = append(content, &mcp.TextContent{
-				Type: item.Type,
-				Text: item.Text,
-			})
+			content = append(content, toMCPContent(item))
 		}
 
 		return &mcp.CallToolResult{
+			Result:  mcp.Result{Meta: meta},
 			IsError: false,
 			Content: content,
 		}, nil
 	}
 }
 
+// errorCodes are the typed errors reported in the _meta of failed tool results, with their codes
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{types.ErrExtensionDisconnected, types.ErrorCodeExtensionDisconnected},
+	{types.ErrExtensionIncompatible, types.ErrorCodeExtensionIncompatible},
+	{types.ErrMethodUnsupported, types.ErrorCodeMethodUnsupported},
+}
+
+// withErrorCode adds the code of a typed error to the _meta of a failed tool result
+func withErrorCode(meta map[string]any, err error) map[string]any {
+	for _, errorCode := range errorCodes {
+		if !errors.Is(err, errorCode.err) {
+			continue
+		}
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+		meta[errorMetaKey] = map[string]any{"code": errorCode.code}
+		break
+	}
+	return meta
+}
+
+// toolRpcMethods returns the extension RPC methods a tool calls
+func toolRpcMethods(tool types.Tool) []string {
+	if provider, ok := tool.(types.RpcMethodsTool); ok {
+		return provider.RpcMethods()
+	}
+	return []string{tool.GetName()}
+}
+
+// checkToolSupported returns an error if the connected extension cannot serve a tool
+func (s *SSEServer) checkToolSupported(tool types.Tool) error {
+	if s.capabilities == nil {
+		return nil
+	}
+	for _, method := range toolRpcMethods(tool) {
+		if err := s.capabilities.Check(method); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// supportedTools hides the tools the connected extension does not implement.
+// An incompatible extension keeps every tool listed, so calls can explain the
+// version mismatch instead of the tools silently disappearing.
+func (s *SSEServer) supportedTools(tools []mcp.Tool) []mcp.Tool {
+	if s.capabilities == nil {
+		return tools
+	}
+	if info, known := s.capabilities.Get(); !known || !info.Compatible {
+		return tools
+	}
+
+	s.toolsMutex.RLock()
+	defer s.toolsMutex.RUnlock()
+
+	supported := make([]mcp.Tool, 0, len(tools))
+	for _, listed := range tools {
+		tool, ok := s.tools[listed.Name]
+		if ok && s.checkToolSupported(tool) != nil {
+			continue
+		}
+		supported = append(supported, listed)
+	}
+	return supported
+}
+
 // createResourceHandlerForResource creates a resource handler function for the provided resource
 func (s *SSEServer) createResourceHandlerForResource(resource types.Resource) server.ResourceHandlerFunc {
 	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
@@ -253,39 +659,145 @@ func (s *SSEServer) createResourceHandlerForResource(resource types.Resource) se
 			zap.String("requestedURI", request.Params.URI),
 			zap.Any("arguments", request.Params.Arguments))
 
-		// Check if this is a pattern match request
-		var targetResource types.Resource = resource
-		if request.Params.URI != resource.GetURI() {
-			// Look for a matching resource pattern
-			matchedResource := s.findMatchingResource(request.Params.URI)
-			if matchedResource != nil {
-				targetResource = matchedResource
-				s.logger.Debug("Matched URI pattern",
-					zap.String("requestedURI", request.Params.URI),
-					zap.String("baseURI", targetResource.GetURI()))
-			}
+		// Read the resource with arguments if provided
+		content, err := resource.ReadWithArguments(s.withSessionTab(ctx), request.Params.URI, request.Params.Arguments)
+		if err != nil {
+			s.logger.Error("Failed to read resource", zap.Error(err),
+				zap.String("uri", resource.GetURI()),
+				zap.String("requestedURI", request.Params.URI))
+			return nil, fmt.Errorf("failed to read resource: %w", err)
 		}
 
-		// Read the resource with arguments if provided
-		content, err := targetResource.ReadWithArguments(request.Params.URI, request.Params.Arguments)
+		return toMCPResourceContents(content), nil
+	}
+}
+
+// createResourceHandlerForTemplate creates a resource handler function for the provided resource template
+func (s *SSEServer) createResourceHandlerForTemplate(template types.ResourceTemplate) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		s.logger.Debug("Reading resource template via SSE",
+			zap.String("uriTemplate", template.GetURITemplate()),
+			zap.String("requestedURI", request.Params.URI),
+			zap.Any("variables", request.Params.Arguments))
+
+		arguments, err := typeTemplateArguments(template.GetVariables(), request.Params.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource URI %s: %w", request.Params.URI, err)
+		}
+
+		content, err := template.ReadWithArguments(ctx, request.Params.URI, arguments)
 		if err != nil {
 			s.logger.Error("Failed to read resource", zap.Error(err),
-				zap.String("uri", targetResource.GetURI()),
+				zap.String("uriTemplate", template.GetURITemplate()),
 				zap.String("requestedURI", request.Params.URI))
 			return nil, fmt.Errorf("failed to read resource: %w", err)
 		}
 
-		// Convert to MCP format
-		var result []mcp.ResourceContents
+		return toMCPResourceContents(content), nil
+	}
+}
 
-		for _, item := range content.Contents {
-			result = append(result, &mcp.TextResourceContents{
-				URI:      item.URI,
-				MIMEType: item.MimeType,
-				Text:     item.Text,
-			})
+// typeTemplateArguments converts the raw variables matched from a URI template
+// into arguments of their declared types
+func typeTemplateArguments(variables []types.ResourceTemplateVariable, matched map[string]any) (map[string]any, error) {
+	arguments := make(map[string]any, len(variables))
+	for _, variable := range variables {
+		raw, ok := matched[variable.Name]
+		if !ok {
+			continue
 		}
 
-		return result, nil
+		// mcp-go passes each matched variable as the list of its values
+		var value string
+		switch v := raw.(type) {
+		case []string:
+			if len(v) != 1 {
+				return nil, fmt.Errorf("%s must be a single value", variable.Name)
+			}
+			value = v[0]
+		case string:
+			value = v
+		default:
+			return nil, fmt.Errorf("%s has unexpected type %T", variable.Name, raw)
+		}
+
+		if value == "" {
+			return nil, fmt.Errorf("%s must not be empty", variable.Name)
+		}
+
+		switch variable.Type {
+		case "integer":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer, got %q", variable.Name, value)
+			}
+			arguments[variable.Name] = n
+		default:
+			arguments[variable.Name] = value
+		}
+	}
+	return arguments, nil
+}
+
+// toMCPResourceContents converts resource content to the MCP format
+func toMCPResourceContents(content types.ResourceContent) []mcp.ResourceContents {
+	var result []mcp.ResourceContents
+	for _, item := range content.Contents {
+		result = append(result, toMCPResourceItem(item))
+	}
+	return result
+}
+
+// toMCPResourceItem converts a resource item to text or blob contents
+func toMCPResourceItem(item types.ResourceItem) mcp.ResourceContents {
+	if item.Blob != "" {
+		return &mcp.BlobResourceContents{
+			URI:      item.URI,
+			MIMEType: item.MimeType,
+			Blob:     item.Blob,
+		}
+	}
+	return &mcp.TextResourceContents{
+		URI:      item.URI,
+		MIMEType: item.MimeType,
+		Text:     item.Text,
+	}
+}
+
+// toMCPContent converts a tool result item to the matching MCP content type
+func toMCPContent(item types.ToolResultItem) mcp.Content {
+	switch item.Type {
+	case types.ToolResultTypeImage:
+		return &mcp.ImageContent{
+			Type:     types.ToolResultTypeImage,
+			Data:     item.Data,
+			MIMEType: item.MimeType,
+		}
+	case types.ToolResultTypeResource:
+		if item.Resource != nil {
+			return &mcp.EmbeddedResource{
+				Type:     types.ToolResultTypeResource,
+				Resource: toMCPResourceItem(*item.Resource),
+			}
+		}
+	}
+	return &mcp.TextContent{
+		Type: types.ToolResultTypeText,
+		Text: item.Text,
 	}
 }
+
+// mcpLogger adapts logger.Logger to the logger interface used by mcp-go transports
+type mcpLogger struct {
+	logger logger.Logger
+}
+
+// Infof logs an info message from the transport
+func (l *mcpLogger) Infof(format string, v ...any) {
+	l.logger.Info(fmt.Sprintf(format, v...))
+}
+
+// Errorf logs an error message from the transport
+func (l *mcpLogger) Errorf(format string, v ...any) {
+	l.logger.Error(fmt.Sprintf(format, v...))
+}
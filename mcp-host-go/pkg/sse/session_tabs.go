@@ -0,0 +1,71 @@
+package sse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// sessionTabRegistry keeps the current tab of every MCP session, so concurrent
+// clients each work in their own tab instead of the globally active one
+type sessionTabRegistry struct {
+	mutex     sync.Mutex
+	bySession map[string]*types.SessionTab
+}
+
+// newSessionTabRegistry creates an empty sessionTabRegistry
+func newSessionTabRegistry() *sessionTabRegistry {
+	return &sessionTabRegistry{
+		bySession: make(map[string]*types.SessionTab),
+	}
+}
+
+// get returns the SessionTab of a session, creating it on first use
+func (r *sessionTabRegistry) get(sessionID string) *types.SessionTab {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tab, exists := r.bySession[sessionID]
+	if !exists {
+		tab = &types.SessionTab{}
+		r.bySession[sessionID] = tab
+	}
+	return tab
+}
+
+// removeSession drops the tab of a session that went away
+func (r *sessionTabRegistry) removeSession(sessionID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.bySession, sessionID)
+}
+
+// forgetTab clears a closed tab from every session that worked in it
+func (r *sessionTabRegistry) forgetTab(tabID int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, tab := range r.bySession {
+		tab.Forget(tabID)
+	}
+}
+
+// withSessionTab attaches the SessionTab of the calling session to ctx.
+// Calls without a session keep acting on the active tab.
+func (s *SSEServer) withSessionTab(ctx context.Context) context.Context {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return ctx
+	}
+	return types.WithSessionTab(ctx, s.sessionTabs.get(session.SessionID()))
+}
+
+// ForgetTab clears a tab that was closed in the browser from every session that worked in it
+func (s *SSEServer) ForgetTab(tabID int) {
+	s.logger.Debug("Forgetting closed tab", zap.Int("tabId", tabID))
+	s.sessionTabs.forgetTab(tabID)
+}
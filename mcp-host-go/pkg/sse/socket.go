@@ -0,0 +1,195 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+// socketSession represents one MCP client connected over the local socket
+type socketSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+}
+
+// SessionID returns the unique session identifier
+func (s *socketSession) SessionID() string {
+	return s.id
+}
+
+// NotificationChannel returns the channel used to push notifications to the client
+func (s *socketSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// Initialize marks the session as ready for notifications
+func (s *socketSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+// Initialized returns whether the session has been initialized
+func (s *socketSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+var _ server.ClientSession = (*socketSession)(nil)
+
+// startSocketListener starts serving newline-delimited MCP JSON-RPC on a Unix socket.
+// It is used by the `mcp-host stdio` bridge, whose own stdin/stdout belong to the MCP client.
+func (s *SSEServer) startSocketListener() error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Remove a stale socket left behind by a previous host process
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on socket %s: %w", s.socketPath, err)
+	}
+
+	// Only the current user may talk to the browser through the socket
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	s.socketListener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if s.baseCtx.Err() == nil {
+					s.logger.Error("Socket accept error", zap.Error(err))
+				}
+				return
+			}
+			go s.serveSocketConn(conn)
+		}
+	}()
+
+	s.logger.Info("MCP socket listener started", zap.String("socketPath", s.socketPath))
+	return nil
+}
+
+// stopSocketListener closes the socket listener and removes the socket file
+func (s *SSEServer) stopSocketListener() {
+	if s.socketListener == nil {
+		return
+	}
+
+	if err := s.socketListener.Close(); err != nil {
+		s.logger.Warn("Error closing socket listener", zap.Error(err))
+	}
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Error removing socket file", zap.Error(err))
+	}
+}
+
+// serveSocketConn relays MCP messages between one socket connection and the MCP server
+func (s *SSEServer) serveSocketConn(conn net.Conn) {
+	defer conn.Close()
+
+	session := &socketSession{
+		id:            uuid.New().String(),
+		notifications: make(chan mcp.JSONRPCNotification, 100),
+	}
+
+	ctx, cancel := context.WithCancel(s.baseCtx)
+	defer cancel()
+
+	if err := s.mcpServer.RegisterSession(ctx, session); err != nil {
+		s.logger.Error("Failed to register socket session", zap.Error(err))
+		return
+	}
+	defer s.mcpServer.UnregisterSession(ctx, session.id)
+	ctx = s.mcpServer.WithContext(ctx, session)
+
+	s.logger.Info("MCP socket client connected", zap.String("sessionId", session.id))
+
+	// Close the connection on shutdown to unblock the reader
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	var writeMutex sync.Mutex
+	write := func(message interface{}) {
+		data, err := json.Marshal(message)
+		if err != nil {
+			s.logger.Error("Error marshaling socket message", zap.Error(err))
+			return
+		}
+
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			s.logger.Warn("Error writing to socket", zap.Error(err), zap.String("sessionId", session.id))
+		}
+	}
+
+	// Forward server notifications to the client
+	go func() {
+		for {
+			select {
+			case notification := <-session.notifications:
+				write(notification)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var inFlight sync.WaitGroup
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		message := make(json.RawMessage, len(line))
+		copy(message, line)
+		if response, ok := s.answerSubscriptions(session.id, message); ok {
+			if response != nil {
+				write(response)
+			}
+			continue
+		}
+
+		// Handle requests concurrently so a long tool call does not block the session
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			if response := s.mcpServer.HandleMessage(withRequestScope(ctx), message); response != nil {
+				write(response)
+			}
+		}()
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		s.logger.Warn("Error reading from socket", zap.Error(err), zap.String("sessionId", session.id))
+	}
+
+	// The client may half-close after its last request, so deliver pending responses
+	inFlight.Wait()
+
+	s.logger.Info("MCP socket client disconnected", zap.String("sessionId", session.id))
+}
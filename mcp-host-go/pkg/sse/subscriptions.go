@@ -0,0 +1,276 @@
+package sse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yosida95/uritemplate/v3"
+	"go.uber.org/zap"
+)
+
+// MCP methods for resource subscriptions
+const (
+	MethodResourcesSubscribe           = "resources/subscribe"
+	MethodResourcesUnsubscribe         = "resources/unsubscribe"
+	MethodNotificationResourcesUpdated = "notifications/resources/updated"
+)
+
+// maxInterceptedBodySize is the largest HTTP request body accepted by the MCP endpoints
+const maxInterceptedBodySize = 16 * 1024 * 1024
+
+// subscriptionRegistry tracks which sessions are subscribed to which resource URIs
+type subscriptionRegistry struct {
+	mutex     sync.RWMutex
+	bySession map[string]map[string]bool
+}
+
+// newSubscriptionRegistry creates an empty subscriptionRegistry
+func newSubscriptionRegistry() *subscriptionRegistry {
+	return &subscriptionRegistry{
+		bySession: make(map[string]map[string]bool),
+	}
+}
+
+// subscribe records that a session wants updates for a URI
+func (r *subscriptionRegistry) subscribe(sessionID, uri string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.bySession[sessionID] == nil {
+		r.bySession[sessionID] = make(map[string]bool)
+	}
+	r.bySession[sessionID][uri] = true
+}
+
+// unsubscribe removes a session's subscription to a URI
+func (r *subscriptionRegistry) unsubscribe(sessionID, uri string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.bySession[sessionID], uri)
+	if len(r.bySession[sessionID]) == 0 {
+		delete(r.bySession, sessionID)
+	}
+}
+
+// removeSession drops every subscription of a session that went away
+func (r *subscriptionRegistry) removeSession(sessionID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.bySession, sessionID)
+}
+
+// subscribers returns the sessions subscribed to a URI
+func (r *subscriptionRegistry) subscribers(uri string) []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var sessionIDs []string
+	for sessionID, uris := range r.bySession {
+		if uris[uri] {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	return sessionIDs
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated to every session subscribed to uri
+func (s *SSEServer) NotifyResourceUpdated(uri string) {
+	sessionIDs := s.subscriptions.subscribers(uri)
+	if len(sessionIDs) == 0 {
+		return
+	}
+
+	s.logger.Debug("Notifying subscribers of resource update",
+		zap.String("uri", uri),
+		zap.Int("subscribers", len(sessionIDs)))
+
+	for _, sessionID := range sessionIDs {
+		err := s.mcpServer.SendNotificationToSpecificClient(sessionID, MethodNotificationResourcesUpdated, map[string]any{
+			"uri": uri,
+		})
+		if err != nil {
+			s.logger.Warn("Failed to send resource update notification",
+				zap.Error(err),
+				zap.String("uri", uri),
+				zap.String("sessionId", sessionID))
+		}
+	}
+}
+
+// subscriptionRequest is a JSON-RPC message, checked for resources/subscribe and resources/unsubscribe
+type subscriptionRequest struct {
+	ID     *mcp.RequestId  `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// isSubscription reports whether the message is a subscription request
+func (r subscriptionRequest) isSubscription() bool {
+	return r.Method == MethodResourcesSubscribe || r.Method == MethodResourcesUnsubscribe
+}
+
+// answerSubscriptions answers resources/subscribe and resources/unsubscribe,
+// which mcp-go does not implement. It returns the JSON-RPC response, nil for a
+// notification, and whether the message was answered; other messages are left
+// to mcp-go. mcp-go does not accept batches either, so a batch containing a
+// subscription request is answered as a whole and its other requests refused.
+func (s *SSEServer) answerSubscriptions(sessionID string, message []byte) (interface{}, bool) {
+	// Requests outside a session are left to the transport, which rejects them
+	if sessionID == "" {
+		return nil, false
+	}
+
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		var request subscriptionRequest
+		if err := json.Unmarshal(trimmed, &request); err != nil || !request.isSubscription() {
+			return nil, false
+		}
+		return s.answerSubscription(sessionID, request), true
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		return nil, false
+	}
+	requests := make([]subscriptionRequest, len(batch))
+	subscriptions := 0
+	for i, raw := range batch {
+		if err := json.Unmarshal(raw, &requests[i]); err == nil && requests[i].isSubscription() {
+			subscriptions++
+		}
+	}
+	if subscriptions == 0 {
+		return nil, false
+	}
+
+	var responses []interface{}
+	for _, request := range requests {
+		var response interface{}
+		if request.isSubscription() {
+			response = s.answerSubscription(sessionID, request)
+		} else if request.ID != nil && !request.ID.IsNil() {
+			response = mcp.NewJSONRPCError(*request.ID, mcp.INVALID_REQUEST,
+				"Only resources/subscribe and resources/unsubscribe requests may be batched", nil)
+		} else {
+			s.logger.Warn("Ignoring message batched with a subscription request", zap.String("method", request.Method))
+		}
+		if response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil, true
+	}
+	return responses, true
+}
+
+// answerSubscription records or drops a subscription of the session and
+// returns the response, or nil if the request is a notification
+func (s *SSEServer) answerSubscription(sessionID string, request subscriptionRequest) interface{} {
+	if request.ID == nil || request.ID.IsNil() {
+		s.logger.Warn("Ignoring subscription request without an ID", zap.String("method", request.Method))
+		return nil
+	}
+
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(request.Params, &params); err != nil || params.URI == "" {
+		return mcp.NewJSONRPCError(*request.ID, mcp.INVALID_PARAMS, "Invalid params: uri is required", nil)
+	}
+	if !s.isKnownResource(params.URI) {
+		return mcp.NewJSONRPCError(*request.ID, mcp.RESOURCE_NOT_FOUND,
+			fmt.Sprintf("Resource not found: %s", params.URI), map[string]any{"uri": params.URI})
+	}
+
+	if request.Method == MethodResourcesSubscribe {
+		s.subscriptions.subscribe(sessionID, params.URI)
+		s.logger.Info("Resource subscribed", zap.String("uri", params.URI), zap.String("sessionId", sessionID))
+	} else {
+		s.subscriptions.unsubscribe(sessionID, params.URI)
+		s.logger.Info("Resource unsubscribed", zap.String("uri", params.URI), zap.String("sessionId", sessionID))
+	}
+	return mcp.NewJSONRPCResponse(*request.ID, mcp.Result{})
+}
+
+// isKnownResource reports whether a URI names a registered resource or matches a resource template
+func (s *SSEServer) isKnownResource(uri string) bool {
+	if _, ok := s.resources[uri]; ok {
+		return true
+	}
+	for uriTemplate := range s.resourceTemplates {
+		parsed, err := uritemplate.New(uriTemplate)
+		if err == nil && parsed.Match(uri) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// interceptSubscriptionsHandler answers subscription requests in HTTP POST
+// bodies with reply and passes every other request on to next. sessionID
+// extracts the MCP session of the request for the wrapped transport.
+func (s *SSEServer) interceptSubscriptionsHandler(
+	next http.Handler,
+	sessionID func(r *http.Request) string,
+	reply func(w http.ResponseWriter, r *http.Request, response interface{}),
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInterceptedBodySize))
+		r.Body.Close()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		if response, ok := s.answerSubscriptions(sessionID(r), body); ok {
+			reply(w, r, response)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// replyInBody sends a response as the body of the HTTP response, as the Streamable HTTP transport does
+func replyInBody(w http.ResponseWriter, r *http.Request, response interface{}) {
+	if response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// replyOnEventStream sends a response over the SSE stream of the session, as the SSE transport does
+func (s *SSEServer) replyOnEventStream(w http.ResponseWriter, r *http.Request, response interface{}) {
+	if response != nil {
+		if err := s.sseServer.SendEventToSession(r.URL.Query().Get("sessionId"), response); err != nil {
+			s.logger.Warn("Failed to send subscription response", zap.Error(err))
+			http.Error(w, "Failed to send response: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,159 @@
+package sse
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// activeTabQueueKey is the queue of calls that act on whatever tab is active
+const activeTabQueueKey = "active"
+
+// queueMetaKey is the _meta key under which tool results report their queueing
+const queueMetaKey = "algonius/queue"
+
+// tabCall is a mutating call waiting for its tab
+type tabCall struct {
+	key  string
+	turn chan struct{}
+}
+
+// tabScheduler serializes mutating tool calls per tab, so that e.g. a
+// scroll_page cannot land in the middle of a type_value on the same tab.
+// The host does not know which tab is active, so a call on the active tab
+// is serialized against calls on every tab, including one naming the active
+// tab by its ID. Calls start in arrival order among those that conflict.
+// Read-only tools and resources do not go through it and run in parallel.
+type tabScheduler struct {
+	mutex   sync.Mutex
+	running map[string]bool
+	waiting []*tabCall
+}
+
+// queueStats describes how a call was queued before it ran
+type queueStats struct {
+	Tab    string `json:"tab"`    // Tab ID, or "active" for the active tab
+	Depth  int    `json:"depth"`  // Conflicting calls running or waiting when the call arrived
+	WaitMs int64  `json:"waitMs"` // Time spent waiting for the tab
+}
+
+// newTabScheduler creates a tabScheduler without queued calls
+func newTabScheduler() *tabScheduler {
+	return &tabScheduler{
+		running: make(map[string]bool),
+	}
+}
+
+// tabQueueKey returns the queue of calls on the tab the call acts on
+func tabQueueKey(ctx context.Context) string {
+	if tabID, ok := types.TargetTabID(ctx); ok {
+		return strconv.Itoa(tabID)
+	}
+	return activeTabQueueKey
+}
+
+// tabQueuesConflict returns whether calls queued under a and b may act on the same tab
+func tabQueuesConflict(a, b string) bool {
+	return a == b || a == activeTabQueueKey || b == activeTabQueueKey
+}
+
+// acquire waits until no earlier call on the tab of key is running or
+// waiting. The returned release function must be called once the call is
+// done. If ctx ends while waiting, the call leaves the queue and ctx's error
+// is returned.
+func (s *tabScheduler) acquire(ctx context.Context, key string) (func(), queueStats, error) {
+	start := time.Now()
+
+	s.mutex.Lock()
+	stats := queueStats{Tab: key}
+	for running := range s.running {
+		if tabQueuesConflict(key, running) {
+			stats.Depth++
+		}
+	}
+	for _, waiter := range s.waiting {
+		if tabQueuesConflict(key, waiter.key) {
+			stats.Depth++
+		}
+	}
+
+	if stats.Depth == 0 {
+		s.running[key] = true
+		s.mutex.Unlock()
+		return func() { s.release(key) }, stats, nil
+	}
+
+	call := &tabCall{key: key, turn: make(chan struct{})}
+	s.waiting = append(s.waiting, call)
+	s.mutex.Unlock()
+
+	select {
+	case <-call.turn:
+		stats.WaitMs = time.Since(start).Milliseconds()
+		return func() { s.release(key) }, stats, nil
+	case <-ctx.Done():
+		s.mutex.Lock()
+		for i, waiter := range s.waiting {
+			if waiter == call {
+				s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+				// Calls queued behind this one may be free to start now
+				s.dispatchLocked()
+				s.mutex.Unlock()
+				return nil, stats, ctx.Err()
+			}
+		}
+		s.mutex.Unlock()
+
+		// The turn was handed over concurrently, pass it on to the next call
+		s.release(key)
+		return nil, stats, ctx.Err()
+	}
+}
+
+// release frees the tab of key and starts the calls waiting for it
+func (s *tabScheduler) release(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.running, key)
+	s.dispatchLocked()
+}
+
+// dispatchLocked starts, in arrival order, every waiting call that conflicts
+// neither with a running call nor with an earlier call still waiting.
+// s.mutex must be held.
+func (s *tabScheduler) dispatchLocked() {
+	var blocked []*tabCall
+	for _, call := range s.waiting {
+		free := true
+		for running := range s.running {
+			if tabQueuesConflict(call.key, running) {
+				free = false
+				break
+			}
+		}
+		for _, earlier := range blocked {
+			if !free {
+				break
+			}
+			free = !tabQueuesConflict(call.key, earlier.key)
+		}
+
+		if free {
+			s.running[call.key] = true
+			close(call.turn)
+		} else {
+			blocked = append(blocked, call)
+		}
+	}
+	s.waiting = blocked
+}
+
+// isReadOnlyTool returns whether a tool only inspects the page and may run in parallel
+func isReadOnlyTool(tool types.Tool) bool {
+	readOnly, ok := tool.(types.ReadOnlyTool)
+	return ok && readOnly.IsReadOnly()
+}
@@ -1,10 +1,12 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -25,6 +27,16 @@ type ClickElementConfig struct {
 	DomStateRes types.Resource
 }
 
+// ClickResult is the typed result of click_element in JSON format
+type ClickResult struct {
+	Message       string                 `json:"message"`
+	ElementIndex  int                    `json:"elementIndex"`
+	PageChanged   bool                   `json:"pageChanged"`
+	ExecutionTime float64                `json:"executionTime"` // Seconds
+	ElementInfo   map[string]interface{} `json:"elementInfo,omitempty"`
+	DomStateSnapshot
+}
+
 // NewClickElementTool creates a new ClickElementTool
 func NewClickElementTool(config ClickElementConfig) (*ClickElementTool, error) {
 	if config.Logger == nil {
@@ -80,60 +92,44 @@ func (t *ClickElementTool) GetInputSchema() interface{} {
 				"description": "Whether to return DOM state content after successful click",
 				"default":     false,
 			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
 		},
 		"required":             []string{"element_index"},
 		"additionalProperties": false,
 	}
 }
 
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *ClickElementTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *ClickElementTool) GetOutputSchema() interface{} {
+	return schema.FromType(ClickResult{})
+}
+
 // Execute executes the click_element tool
-func (t *ClickElementTool) Execute(args map[string]interface{}) (types.ToolResult, error) {
+func (t *ClickElementTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	startTime := time.Now()
 	t.logger.Info("Executing click_element tool", zap.Any("args", args))
 
-	// Extract and validate element_index
-	elementIndexArg, exists := args["element_index"]
-	if !exists {
+	// Arguments were validated against the input schema before Execute
+	elementIndexArg, ok := args["element_index"].(float64)
+	if !ok {
 		return types.ToolResult{}, fmt.Errorf("element_index is required")
 	}
+	elementIndex := int(elementIndexArg)
 
-	var elementIndex int
-	switch v := elementIndexArg.(type) {
-	case float64:
-		if v < 0 {
-			return types.ToolResult{}, fmt.Errorf("element_index must be non-negative, got: %v", v)
-		}
-		elementIndex = int(v)
-	case int:
-		if v < 0 {
-			return types.ToolResult{}, fmt.Errorf("element_index must be non-negative, got: %v", v)
-		}
-		elementIndex = v
-	default:
-		return types.ToolResult{}, fmt.Errorf("element_index must be a number, got: %T", elementIndexArg)
-	}
-
-	// Extract and validate wait_after
 	waitAfter := 1000.0 // default value
-	if waitAfterArg, exists := args["wait_after"]; exists {
-		if waitVal, ok := waitAfterArg.(float64); ok {
-			if waitVal < 0 || waitVal > 30000 {
-				return types.ToolResult{}, fmt.Errorf("wait_after must be between 0 and 30000 milliseconds, got: %v", int(waitVal))
-			}
-			waitAfter = waitVal
-		} else {
-			return types.ToolResult{}, fmt.Errorf("wait_after must be a number, got: %T", waitAfterArg)
-		}
+	if waitVal, ok := args["wait_after"].(float64); ok {
+		waitAfter = waitVal
 	}
 
-	// Extract and validate return_dom_state
 	returnDomState := false // default value
-	if returnDomStateArg, exists := args["return_dom_state"]; exists {
-		if returnVal, ok := returnDomStateArg.(bool); ok {
-			returnDomState = returnVal
-		} else {
-			return types.ToolResult{}, fmt.Errorf("return_dom_state must be a boolean, got: %T", returnDomStateArg)
-		}
+	if returnVal, ok := args["return_dom_state"].(bool); ok {
+		returnDomState = returnVal
 	}
 
 	// Prepare RPC parameters
@@ -141,6 +137,7 @@ func (t *ClickElementTool) Execute(args map[string]interface{}) (types.ToolResul
 		"element_index": elementIndex,
 		"wait_after":    waitAfter,
 	}
+	rpcParams = types.AddTargetTab(ctx, rpcParams)
 
 	t.logger.Debug("Sending click_element RPC request",
 		zap.Int("element_index", elementIndex),
@@ -148,7 +145,7 @@ func (t *ClickElementTool) Execute(args map[string]interface{}) (types.ToolResul
 		zap.String("wait_after_unit", "milliseconds"))
 
 	// Send RPC request to the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "click_element",
 		Params: rpcParams,
 	}, types.RpcOptions{Timeout: 15000}) // 15 second timeout
@@ -239,6 +236,22 @@ func (t *ClickElementTool) Execute(args map[string]interface{}) (types.ToolResul
 		zap.Any("element_info", elementInfo),
 		zap.Float64("execution_time", executionTime))
 
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := ClickResult{
+			Message:       message,
+			ElementIndex:  elementIndex,
+			PageChanged:   pageChanged,
+			ExecutionTime: executionTime,
+			ElementInfo:   elementInfo,
+		}
+		if returnDomState {
+			if err := result.readDomState(ctx, t.domStateRes); err != nil {
+				t.logger.Warn("Failed to get DOM state after click", zap.Error(err))
+			}
+		}
+		return jsonResult(result)
+	}
+
 	// Create detailed success response
 	responseText := fmt.Sprintf(`Click Element Result:
 - Status: Success
@@ -267,7 +280,7 @@ func (t *ClickElementTool) Execute(args map[string]interface{}) (types.ToolResul
 	// If return_dom_state is true, fetch and append DOM state
 	if returnDomState {
 		t.logger.Debug("Fetching DOM state after successful click")
-		domContent, err := t.domStateRes.Read()
+		domContent, err := t.domStateRes.Read(ctx)
 		if err != nil {
 			t.logger.Warn("Failed to get DOM state after click", zap.Error(err))
 			// Don't fail the entire operation, just add a note
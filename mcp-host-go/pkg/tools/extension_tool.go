@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// extensionToolTimeout is how long an extension tool may run, in milliseconds
+const extensionToolTimeout = 30000
+
+// ExtensionTool implements a tool advertised by the browser extension. Calls
+// are forwarded to the extension RPC method named like the tool.
+type ExtensionTool struct {
+	definition types.ExtensionToolDefinition
+	logger     logger.Logger
+	messaging  types.Messaging
+}
+
+// ExtensionToolConfig contains configuration for ExtensionTool
+type ExtensionToolConfig struct {
+	Logger     logger.Logger
+	Messaging  types.Messaging
+	Definition types.ExtensionToolDefinition
+}
+
+// NewExtensionTool creates a new ExtensionTool
+func NewExtensionTool(config ExtensionToolConfig) (*ExtensionTool, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	if config.Definition.Name == "" {
+		return nil, fmt.Errorf("tool name is required")
+	}
+
+	return &ExtensionTool{
+		definition: config.Definition,
+		logger:     config.Logger,
+		messaging:  config.Messaging,
+	}, nil
+}
+
+// GetName returns the tool name
+func (t *ExtensionTool) GetName() string {
+	return t.definition.Name
+}
+
+// GetDescription returns the tool description
+func (t *ExtensionTool) GetDescription() string {
+	return t.definition.Description
+}
+
+// GetInputSchema returns the input schema advertised by the extension
+func (t *ExtensionTool) GetInputSchema() interface{} {
+	if t.definition.InputSchema == nil {
+		return nil
+	}
+	return t.definition.InputSchema
+}
+
+// IsReadOnly reports whether the extension declared that the tool only inspects the page
+func (t *ExtensionTool) IsReadOnly() bool {
+	return t.definition.ReadOnly
+}
+
+// TargetTab returns the tab named by the tab_id argument, if the tool takes one
+func (t *ExtensionTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// Definition returns the definition the extension advertised
+func (t *ExtensionTool) Definition() types.ExtensionToolDefinition {
+	return t.definition
+}
+
+// Execute forwards the call to the extension
+func (t *ExtensionTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
+	t.logger.Debug("Executing extension tool", zap.String("tool", t.definition.Name), zap.Any("args", args))
+
+	params := make(map[string]interface{}, len(args)+1)
+	for key, value := range args {
+		params[key] = value
+	}
+
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
+		Method: t.definition.Name,
+		Params: types.AddTargetTab(ctx, params),
+	}, types.RpcOptions{Timeout: extensionToolTimeout})
+
+	if err != nil {
+		t.logger.Error("Error calling extension tool", zap.String("tool", t.definition.Name), zap.Error(err))
+		return types.ToolResult{}, fmt.Errorf("%s RPC failed: %w", t.definition.Name, err)
+	}
+
+	if resp.Error != nil {
+		t.logger.Error("RPC error in extension tool", zap.String("tool", t.definition.Name), zap.Any("respError", resp.Error))
+		return types.ToolResult{}, fmt.Errorf("RPC error: %s", resp.Error.Message)
+	}
+
+	return extensionToolResult(resp.Result)
+}
+
+// extensionToolResult converts the RPC result of an extension tool. A result
+// shaped like a tool result is passed through, a string becomes text and
+// anything else is returned as JSON text.
+func extensionToolResult(result interface{}) (types.ToolResult, error) {
+	if text, ok := result.(string); ok {
+		return types.ToolResult{
+			Content: []types.ToolResultItem{
+				{
+					Type: types.ToolResultTypeText,
+					Text: text,
+				},
+			},
+		}, nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return types.ToolResult{}, fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	var toolResult types.ToolResult
+	if err := json.Unmarshal(data, &toolResult); err == nil && len(toolResult.Content) > 0 {
+		return toolResult, nil
+	}
+
+	return jsonResult(result)
+}
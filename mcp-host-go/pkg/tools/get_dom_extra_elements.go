@@ -1,11 +1,13 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -86,27 +88,46 @@ func (t *GetDomExtraElementsTool) GetInputSchema() interface{} {
 				"description": "Optional: Start from specific element index (1-based, overrides page parameter)",
 				"minimum":     1,
 			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.GetName()),
 		},
 		"additionalProperties": false,
 	}
 }
 
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *GetDomExtraElementsTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *GetDomExtraElementsTool) GetOutputSchema() interface{} {
+	return schema.FromType(ExtraElementsResult{})
+}
+
+// IsReadOnly reports that the tool only inspects the page and may run alongside other calls
+func (t *GetDomExtraElementsTool) IsReadOnly() bool {
+	return true
+}
+
+// RpcMethods returns the extension RPC method the tool calls
+func (t *GetDomExtraElementsTool) RpcMethods() []string {
+	return []string{"get_dom_state"}
+}
+
 // Execute executes the get_dom_extra_elements tool
-func (t *GetDomExtraElementsTool) Execute(arguments map[string]interface{}) (types.ToolResult, error) {
+func (t *GetDomExtraElementsTool) Execute(ctx context.Context, arguments map[string]interface{}) (types.ToolResult, error) {
 	t.logger.Debug("Executing get_dom_extra_elements tool", zap.Any("arguments", arguments))
 
-	// Parse and validate parameters
-	params, err := t.parseArguments(arguments)
-	if err != nil {
-		t.logger.Error("Invalid arguments for get_dom_extra_elements", zap.Error(err))
-		return types.ToolResult{}, fmt.Errorf("invalid arguments: %w", err)
-	}
+	// Arguments were validated against the input schema before Execute
+	params := t.parseArguments(arguments)
 
 	t.logger.Debug("Parsed parameters", zap.Any("params", params))
 
 	// Request DOM state from the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "get_dom_state",
+		Params: types.AddTargetTab(ctx, nil),
 	}, types.RpcOptions{Timeout: 5000})
 
 	if err != nil {
@@ -134,6 +155,10 @@ func (t *GetDomExtraElementsTool) Execute(arguments map[string]interface{}) (typ
 		zap.Int("returnedElements", len(result.Elements)),
 		zap.Int("currentPage", result.Pagination.CurrentPage))
 
+	if outputFormat(arguments) == types.OutputFormatJSON {
+		return jsonResult(result)
+	}
+
 	// Generate markdown format
 	markdownText := t.generateMarkdown(result)
 
@@ -186,81 +211,28 @@ type FilterInfo struct {
 	ElementType string `json:"elementType"`
 }
 
-// parseArguments parses and validates the tool arguments
-func (t *GetDomExtraElementsTool) parseArguments(arguments map[string]interface{}) (ExtraElementsParams, error) {
+// parseArguments reads the tool arguments, applying defaults for missing ones
+func (t *GetDomExtraElementsTool) parseArguments(arguments map[string]interface{}) ExtraElementsParams {
 	params := ExtraElementsParams{
 		Page:        1,     // Default to page 1
 		PageSize:    20,    // Default page size
 		ElementType: "all", // Default to all elements
 	}
 
-	if arguments == nil {
-		return params, nil
+	if page, ok := arguments["page"].(float64); ok {
+		params.Page = int(page)
 	}
-
-	// Parse page parameter
-	if pageVal, exists := arguments["page"]; exists && pageVal != nil {
-		if pageFloat, ok := pageVal.(float64); ok {
-			page := int(pageFloat)
-			if page < 1 {
-				return params, fmt.Errorf("page must be >= 1, got %d", page)
-			}
-			params.Page = page
-		} else {
-			return params, fmt.Errorf("page must be an integer, got %T", pageVal)
-		}
+	if pageSize, ok := arguments["pageSize"].(float64); ok {
+		params.PageSize = int(pageSize)
 	}
-
-	// Parse pageSize parameter
-	if pageSizeVal, exists := arguments["pageSize"]; exists && pageSizeVal != nil {
-		if pageSizeFloat, ok := pageSizeVal.(float64); ok {
-			pageSize := int(pageSizeFloat)
-			if pageSize < 1 || pageSize > 100 {
-				return params, fmt.Errorf("pageSize must be between 1 and 100, got %d", pageSize)
-			}
-			params.PageSize = pageSize
-		} else {
-			return params, fmt.Errorf("pageSize must be an integer, got %T", pageSizeVal)
-		}
-	}
-
-	// Parse elementType parameter
-	if elementTypeVal, exists := arguments["elementType"]; exists && elementTypeVal != nil {
-		if elementTypeStr, ok := elementTypeVal.(string); ok {
-			if !t.isValidElementType(elementTypeStr) {
-				return params, fmt.Errorf("invalid elementType: %s, must be one of: button, input, link, select, textarea, all", elementTypeStr)
-			}
-			params.ElementType = elementTypeStr
-		} else {
-			return params, fmt.Errorf("elementType must be a string, got %T", elementTypeVal)
-		}
+	if elementType, ok := arguments["elementType"].(string); ok {
+		params.ElementType = elementType
 	}
-
-	// Parse startIndex parameter (optional)
-	if startIndexVal, exists := arguments["startIndex"]; exists && startIndexVal != nil {
-		if startIndexFloat, ok := startIndexVal.(float64); ok {
-			startIndex := int(startIndexFloat)
-			if startIndex < 1 {
-				return params, fmt.Errorf("startIndex must be >= 1, got %d", startIndex)
-			}
-			params.StartIndex = startIndex
-		} else {
-			return params, fmt.Errorf("startIndex must be an integer, got %T", startIndexVal)
-		}
+	if startIndex, ok := arguments["startIndex"].(float64); ok {
+		params.StartIndex = int(startIndex)
 	}
 
-	return params, nil
-}
-
-// isValidElementType checks if element type is valid
-func (t *GetDomExtraElementsTool) isValidElementType(elementType string) bool {
-	validTypes := []string{"button", "input", "link", "select", "textarea", "all"}
-	for _, validType := range validTypes {
-		if validType == elementType {
-			return true
-		}
-	}
-	return false
+	return params
 }
 
 // parseResponseToStruct converts response result to a struct
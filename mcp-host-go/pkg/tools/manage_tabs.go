@@ -1,9 +1,12 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -22,6 +25,15 @@ type ManageTabsConfig struct {
 	Messaging types.Messaging
 }
 
+// ManageTabsResult is the typed result of manage_tabs in JSON format
+type ManageTabsResult struct {
+	Message    string `json:"message"`
+	Action     string `json:"action"`
+	TabID      string `json:"tabId"` // Tab switched to, opened or closed
+	URL        string `json:"url,omitempty"`
+	Background bool   `json:"background,omitempty"`
+}
+
 // NewManageTabsTool creates a new ManageTabsTool
 func NewManageTabsTool(config ManageTabsConfig) (*ManageTabsTool, error) {
 	if config.Logger == nil {
@@ -73,43 +85,43 @@ func (t *ManageTabsTool) GetInputSchema() interface{} {
 				"default":     false,
 				"description": "Open tab in background without switching focus (for open action)",
 			},
+			"format": outputFormatProperty(t.name),
 		},
 		"required":             []string{"action"},
 		"additionalProperties": false,
 	}
 }
 
+// GetOutputSchema returns the schema of the JSON output format
+func (t *ManageTabsTool) GetOutputSchema() interface{} {
+	return schema.FromType(ManageTabsResult{})
+}
+
 // Execute executes the manage_tabs tool
-func (t *ManageTabsTool) Execute(args map[string]interface{}) (types.ToolResult, error) {
+func (t *ManageTabsTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	t.logger.Info("Executing manage_tabs tool with args:", zap.Any("args", args))
 
-	// Extract action from arguments
+	// Arguments were validated against the input schema before Execute
 	action, ok := args["action"].(string)
-	if !ok || action == "" {
-		return types.ToolResult{}, fmt.Errorf("action is required and must be a string")
-	}
-
-	// Validate action
-	validActions := map[string]bool{"switch": true, "open": true, "close": true}
-	if !validActions[action] {
-		return types.ToolResult{}, fmt.Errorf("invalid action: %s. Must be one of: switch, open, close", action)
+	if !ok {
+		return types.ToolResult{}, fmt.Errorf("action is required")
 	}
 
 	// Route to appropriate handler based on action
 	switch action {
 	case "switch":
-		return t.handleSwitchTab(args)
+		return t.handleSwitchTab(ctx, args)
 	case "open":
-		return t.handleOpenTab(args)
+		return t.handleOpenTab(ctx, args)
 	case "close":
-		return t.handleCloseTab(args)
+		return t.handleCloseTab(ctx, args)
 	default:
 		return types.ToolResult{}, fmt.Errorf("unsupported action: %s", action)
 	}
 }
 
 // handleSwitchTab handles switching to a specific tab
-func (t *ManageTabsTool) handleSwitchTab(args map[string]interface{}) (types.ToolResult, error) {
+func (t *ManageTabsTool) handleSwitchTab(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	// Extract tab_id
 	tabID, ok := args["tab_id"].(string)
 	if !ok || tabID == "" {
@@ -119,7 +131,7 @@ func (t *ManageTabsTool) handleSwitchTab(args map[string]interface{}) (types.Too
 	t.logger.Info("Switching to tab", zap.String("tab_id", tabID))
 
 	// Send RPC request to the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "manage_tabs",
 		Params: map[string]interface{}{
 			"action": "switch",
@@ -137,19 +149,17 @@ func (t *ManageTabsTool) handleSwitchTab(args map[string]interface{}) (types.Too
 		return types.ToolResult{}, fmt.Errorf("RPC error: %s", resp.Error.Message)
 	}
 
-	// Return enhanced result
-	return types.ToolResult{
-		Content: []types.ToolResultItem{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("Successfully switched to tab %s", tabID),
-			},
-		},
-	}, nil
+	t.setSessionTab(ctx, tabID)
+
+	return t.toolResult(args, ManageTabsResult{
+		Message: fmt.Sprintf("Successfully switched to tab %s", tabID),
+		Action:  "switch",
+		TabID:   tabID,
+	})
 }
 
 // handleOpenTab handles opening a new tab
-func (t *ManageTabsTool) handleOpenTab(args map[string]interface{}) (types.ToolResult, error) {
+func (t *ManageTabsTool) handleOpenTab(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	// Extract url
 	url, ok := args["url"].(string)
 	if !ok || url == "" {
@@ -167,7 +177,7 @@ func (t *ManageTabsTool) handleOpenTab(args map[string]interface{}) (types.ToolR
 	t.logger.Info("Opening new tab", zap.String("url", url), zap.Bool("background", background))
 
 	// Send RPC request to the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "manage_tabs",
 		Params: map[string]interface{}{
 			"action":     "open",
@@ -200,24 +210,25 @@ func (t *ManageTabsTool) handleOpenTab(args map[string]interface{}) (types.ToolR
 		}
 	}
 
+	// The session works in the tab it opened, even when it stays in the background
+	t.setSessionTab(ctx, newTabID)
+
 	backgroundStr := "foreground"
 	if background {
 		backgroundStr = "background"
 	}
 
-	// Return enhanced result
-	return types.ToolResult{
-		Content: []types.ToolResultItem{
-			{
-				Type: "text",
-				Text: fmt.Sprintf("Successfully opened new tab (%s) with URL: %s (Tab ID: %s)", backgroundStr, url, newTabID),
-			},
-		},
-	}, nil
+	return t.toolResult(args, ManageTabsResult{
+		Message:    fmt.Sprintf("Successfully opened new tab (%s) with URL: %s (Tab ID: %s)", backgroundStr, url, newTabID),
+		Action:     "open",
+		TabID:      newTabID,
+		URL:        url,
+		Background: background,
+	})
 }
 
 // handleCloseTab handles closing a specific tab
-func (t *ManageTabsTool) handleCloseTab(args map[string]interface{}) (types.ToolResult, error) {
+func (t *ManageTabsTool) handleCloseTab(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	// Extract tab_id
 	tabID, ok := args["tab_id"].(string)
 	if !ok || tabID == "" {
@@ -227,7 +238,7 @@ func (t *ManageTabsTool) handleCloseTab(args map[string]interface{}) (types.Tool
 	t.logger.Info("Closing tab", zap.String("tab_id", tabID))
 
 	// Send RPC request to the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "manage_tabs",
 		Params: map[string]interface{}{
 			"action": "close",
@@ -245,12 +256,46 @@ func (t *ManageTabsTool) handleCloseTab(args map[string]interface{}) (types.Tool
 		return types.ToolResult{}, fmt.Errorf("RPC error: %s", resp.Error.Message)
 	}
 
-	// Return enhanced result
+	if id, err := strconv.Atoi(tabID); err == nil {
+		if sessionTab := types.SessionTabFromContext(ctx); sessionTab != nil {
+			sessionTab.Forget(id)
+		}
+	}
+
+	return t.toolResult(args, ManageTabsResult{
+		Message: fmt.Sprintf("Successfully closed tab %s", tabID),
+		Action:  "close",
+		TabID:   tabID,
+	})
+}
+
+// setSessionTab makes tabID the tab that the calling session's page-level tools act on
+func (t *ManageTabsTool) setSessionTab(ctx context.Context, tabID string) {
+	sessionTab := types.SessionTabFromContext(ctx)
+	if sessionTab == nil {
+		return
+	}
+
+	id, err := strconv.Atoi(tabID)
+	if err != nil {
+		t.logger.Warn("Cannot set session tab from invalid tab ID", zap.String("tab_id", tabID))
+		return
+	}
+	sessionTab.Set(id)
+	t.logger.Debug("Session tab set", zap.Int("tab_id", id))
+}
+
+// toolResult returns a manage_tabs result in the requested output format
+func (t *ManageTabsTool) toolResult(args map[string]interface{}, result ManageTabsResult) (types.ToolResult, error) {
+	if outputFormat(args) == types.OutputFormatJSON {
+		return jsonResult(result)
+	}
+
 	return types.ToolResult{
 		Content: []types.ToolResultItem{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("Successfully closed tab %s", tabID),
+				Text: result.Message,
 			},
 		},
 	}, nil
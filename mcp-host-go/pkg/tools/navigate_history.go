@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// navigateHistoryActions are the actions of navigate_history
+var navigateHistoryActions = []string{"back", "forward", "reload", "hard_reload"}
+
+// NavigateHistoryTool implements a tool for moving through the history of a tab and reloading it
+type NavigateHistoryTool struct {
+	name        string
+	description string
+	logger      logger.Logger
+	messaging   types.Messaging
+	domStateRes types.Resource
+}
+
+// NavigateHistoryConfig contains configuration for NavigateHistoryTool
+type NavigateHistoryConfig struct {
+	Logger      logger.Logger
+	Messaging   types.Messaging
+	DomStateRes types.Resource
+}
+
+// NavigateHistoryResult is the typed result of navigate_history in JSON format
+type NavigateHistoryResult struct {
+	Message  string `json:"message"`
+	Action   string `json:"action" description:"back, forward, reload or hard_reload"`
+	URL      string `json:"url" description:"URL of the page shown after the navigation"`
+	Title    string `json:"title,omitempty"`
+	Strategy string `json:"strategy"` // "auto" or the timeout in milliseconds
+	DomStateSnapshot
+}
+
+// NewNavigateHistoryTool creates a new NavigateHistoryTool
+func NewNavigateHistoryTool(config NavigateHistoryConfig) (*NavigateHistoryTool, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	if config.DomStateRes == nil {
+		return nil, fmt.Errorf("domStateRes is required")
+	}
+
+	return &NavigateHistoryTool{
+		name:        "navigate_history",
+		description: "Go back or forward in the tab's history, or reload the page. 'hard_reload' bypasses the browser cache. Unlike navigating to a previous URL again, going back keeps the history and lets the browser restore the page",
+		logger:      config.Logger,
+		messaging:   config.Messaging,
+		domStateRes: config.DomStateRes,
+	}, nil
+}
+
+// GetName returns the tool name
+func (t *NavigateHistoryTool) GetName() string {
+	return t.name
+}
+
+// GetDescription returns the tool description
+func (t *NavigateHistoryTool) GetDescription() string {
+	return t.description
+}
+
+// GetInputSchema returns the tool input schema
+func (t *NavigateHistoryTool) GetInputSchema() interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        navigateHistoryActions,
+				"description": "History action: 'back', 'forward', 'reload', or 'hard_reload' to reload bypassing the cache",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "string",
+				"description": "Navigation timeout: 'auto' for intelligent detection or timeout in milliseconds (e.g. '5000')",
+				"default":     "auto",
+			},
+			"return_dom_state": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether to return DOM state content after successful navigation",
+				"default":     false,
+			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
+		},
+		"required":             []string{"action"},
+		"additionalProperties": false,
+	}
+}
+
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *NavigateHistoryTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *NavigateHistoryTool) GetOutputSchema() interface{} {
+	return schema.FromType(NavigateHistoryResult{})
+}
+
+// Execute executes the navigate_history tool
+func (t *NavigateHistoryTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
+	t.logger.Info("Executing navigate_history tool with args:", zap.Any("args", args))
+
+	// Arguments were validated against the input schema before Execute
+	action, _ := args["action"].(string)
+
+	// Handle timeout parameter
+	timeoutStr, rpcTimeout, err := navigationTimeout(args)
+	if err != nil {
+		return types.ToolResult{}, err
+	}
+
+	// Handle return_dom_state parameter
+	returnDomState := false // default value
+	if returnDomStateArg, ok := args["return_dom_state"].(bool); ok {
+		returnDomState = returnDomStateArg
+	}
+
+	t.logger.Info("Navigate history with timeout", zap.String("action", action), zap.String("timeout", timeoutStr), zap.Int("rpcTimeout", rpcTimeout), zap.Bool("return_dom_state", returnDomState))
+
+	// Send RPC request to the extension
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
+		Method: "navigate_history",
+		Params: types.AddTargetTab(ctx, map[string]interface{}{
+			"action":  action,
+			"timeout": timeoutStr,
+		}),
+	}, types.RpcOptions{Timeout: rpcTimeout + 5000}) // Add 5 seconds buffer for RPC timeout
+
+	if err != nil {
+		t.logger.Error("Error calling navigate_history", zap.Error(err))
+		return types.ToolResult{}, fmt.Errorf("navigate_history RPC failed: %w", err)
+	}
+
+	if resp.Error != nil {
+		t.logger.Error("RPC error in navigate_history", zap.Any("respError", resp.Error))
+		return types.ToolResult{}, fmt.Errorf("RPC error: %s", resp.Error.Message)
+	}
+
+	resultData, _ := resp.Result.(map[string]interface{})
+	url, _ := resultData["url"].(string)
+	title, _ := resultData["title"].(string)
+
+	var successText string
+	switch action {
+	case "back":
+		successText = fmt.Sprintf("Successfully went back to %s (strategy: %s)", url, timeoutStr)
+	case "forward":
+		successText = fmt.Sprintf("Successfully went forward to %s (strategy: %s)", url, timeoutStr)
+	case "hard_reload":
+		successText = fmt.Sprintf("Successfully reloaded %s bypassing the cache (strategy: %s)", url, timeoutStr)
+	default:
+		successText = fmt.Sprintf("Successfully reloaded %s (strategy: %s)", url, timeoutStr)
+	}
+
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := NavigateHistoryResult{
+			Message:  successText,
+			Action:   action,
+			URL:      url,
+			Title:    title,
+			Strategy: timeoutStr,
+		}
+		if returnDomState {
+			if err := result.readDomState(ctx, t.domStateRes); err != nil {
+				t.logger.Error("Failed to get DOM state after navigation", zap.Error(err))
+			}
+		}
+		return jsonResult(result)
+	}
+
+	// If return_dom_state is true, get DOM state content
+	if returnDomState {
+		t.logger.Info("Getting DOM state after navigation", zap.String("action", action), zap.String("url", url))
+
+		domContent, err := t.domStateRes.Read(ctx)
+		if err != nil {
+			t.logger.Error("Failed to get DOM state after navigation", zap.Error(err))
+			// Still return success for navigation, but include error info
+			successText += fmt.Sprintf("\n\nNote: Failed to retrieve DOM state: %s", err.Error())
+		} else if len(domContent.Contents) > 0 {
+			// Return both navigation success and DOM state
+			return types.ToolResult{
+				Content: []types.ToolResultItem{
+					{
+						Type: "text",
+						Text: successText,
+					},
+					{
+						Type: "text",
+						Text: "\n\n--- DOM State ---\n\n" + domContent.Contents[0].Text,
+					},
+				},
+			}, nil
+		}
+	}
+
+	// Return standard result (no DOM state requested or DOM state failed)
+	return types.ToolResult{
+		Content: []types.ToolResultItem{
+			{
+				Type: "text",
+				Text: successText,
+			},
+		},
+	}, nil
+}
@@ -1,10 +1,12 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -25,6 +27,14 @@ type NavigateToConfig struct {
 	DomStateRes types.Resource
 }
 
+// NavigateResult is the typed result of navigate_to in JSON format
+type NavigateResult struct {
+	Message  string `json:"message"`
+	URL      string `json:"url"`
+	Strategy string `json:"strategy"` // "auto" or the timeout in milliseconds
+	DomStateSnapshot
+}
+
 // NewNavigateToTool creates a new NavigateToTool
 func NewNavigateToTool(config NavigateToConfig) (*NavigateToTool, error) {
 	if config.Logger == nil {
@@ -77,14 +87,26 @@ func (t *NavigateToTool) GetInputSchema() interface{} {
 				"description": "Whether to return DOM state content after successful navigation",
 				"default":     false,
 			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
 		},
 		"required":             []string{"url"},
 		"additionalProperties": false,
 	}
 }
 
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *NavigateToTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *NavigateToTool) GetOutputSchema() interface{} {
+	return schema.FromType(NavigateResult{})
+}
+
 // Execute executes the navigate_to tool
-func (t *NavigateToTool) Execute(args map[string]interface{}) (types.ToolResult, error) {
+func (t *NavigateToTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	t.logger.Info("Executing navigate_to tool with args:", zap.Any("args", args))
 
 	// Extract URL from arguments
@@ -94,9 +116,9 @@ func (t *NavigateToTool) Execute(args map[string]interface{}) (types.ToolResult,
 	}
 
 	// Handle timeout parameter
-	timeoutStr := "auto" // default value
-	if timeoutArg, ok := args["timeout"].(string); ok {
-		timeoutStr = timeoutArg
+	timeoutStr, rpcTimeout, err := navigationTimeout(args)
+	if err != nil {
+		return types.ToolResult{}, err
 	}
 
 	// Handle return_dom_state parameter
@@ -105,31 +127,15 @@ func (t *NavigateToTool) Execute(args map[string]interface{}) (types.ToolResult,
 		returnDomState = returnDomStateArg
 	}
 
-	// Parse timeout value
-	var rpcTimeout int
-	if timeoutStr == "auto" {
-		rpcTimeout = 30000 // auto mode uses 30 seconds timeout
-	} else {
-		// Try to parse as number (milliseconds)
-		if parsedTimeout, err := strconv.Atoi(timeoutStr); err == nil {
-			if parsedTimeout < 1000 || parsedTimeout > 120000 {
-				return types.ToolResult{}, fmt.Errorf("timeout must be between 1000 and 120000 milliseconds")
-			}
-			rpcTimeout = parsedTimeout
-		} else {
-			return types.ToolResult{}, fmt.Errorf("timeout must be 'auto' or a timeout in milliseconds")
-		}
-	}
-
 	t.logger.Info("Navigate to URL with timeout", zap.String("url", url), zap.String("timeout", timeoutStr), zap.Int("rpcTimeout", rpcTimeout), zap.Bool("return_dom_state", returnDomState))
 
 	// Send RPC request to the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "navigate_to",
-		Params: map[string]interface{}{
+		Params: types.AddTargetTab(ctx, map[string]interface{}{
 			"url":     url,
 			"timeout": timeoutStr,
-		},
+		}),
 	}, types.RpcOptions{Timeout: rpcTimeout + 5000}) // Add 5 seconds buffer for RPC timeout
 
 	if err != nil {
@@ -145,11 +151,25 @@ func (t *NavigateToTool) Execute(args map[string]interface{}) (types.ToolResult,
 	// Base success message
 	successText := fmt.Sprintf("Successfully navigated to %s (strategy: %s)", url, timeoutStr)
 
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := NavigateResult{
+			Message:  successText,
+			URL:      url,
+			Strategy: timeoutStr,
+		}
+		if returnDomState {
+			if err := result.readDomState(ctx, t.domStateRes); err != nil {
+				t.logger.Error("Failed to get DOM state after navigation", zap.Error(err))
+			}
+		}
+		return jsonResult(result)
+	}
+
 	// If return_dom_state is true, get DOM state content
 	if returnDomState {
 		t.logger.Info("Getting DOM state after navigation", zap.String("url", url))
 
-		domContent, err := t.domStateRes.Read()
+		domContent, err := t.domStateRes.Read(ctx)
 		if err != nil {
 			t.logger.Error("Failed to get DOM state after navigation", zap.Error(err))
 			// Still return success for navigation, but include error info
@@ -181,3 +201,27 @@ func (t *NavigateToTool) Execute(args map[string]interface{}) (types.ToolResult,
 		},
 	}, nil
 }
+
+// navigationTimeout reads the timeout argument of the navigation tools: 'auto'
+// or a timeout in milliseconds. It returns the argument and the time the
+// navigation may take, in milliseconds.
+func navigationTimeout(args map[string]interface{}) (string, int, error) {
+	timeoutStr := "auto" // default value
+	if timeoutArg, ok := args["timeout"].(string); ok {
+		timeoutStr = timeoutArg
+	}
+
+	if timeoutStr == "auto" {
+		return timeoutStr, 30000, nil // auto mode uses 30 seconds timeout
+	}
+
+	// Try to parse as number (milliseconds)
+	parsedTimeout, err := strconv.Atoi(timeoutStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("timeout must be 'auto' or a timeout in milliseconds")
+	}
+	if parsedTimeout < 1000 || parsedTimeout > 120000 {
+		return "", 0, fmt.Errorf("timeout must be between 1000 and 120000 milliseconds")
+	}
+	return timeoutStr, parsedTimeout, nil
+}
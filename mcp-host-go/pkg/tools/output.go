@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+)
+
+// outputFormatProperty returns the input schema property that selects the output format of a tool
+func outputFormatProperty(toolName string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": fmt.Sprintf("Output format: 'markdown' for readable text, or 'json' for a typed result described by browser://schema/%s", toolName),
+		"enum":        []string{types.OutputFormatMarkdown, types.OutputFormatJSON},
+		"default":     types.OutputFormatMarkdown,
+	}
+}
+
+// outputFormat returns the output format requested in the tool arguments
+func outputFormat(args map[string]interface{}) string {
+	if format, ok := args["format"].(string); ok {
+		return format
+	}
+	return types.OutputFormatMarkdown
+}
+
+// jsonResult returns a typed result as the single JSON text item of a tool result
+func jsonResult(result interface{}) (types.ToolResult, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return types.ToolResult{}, fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	return types.ToolResult{
+		Content: []types.ToolResultItem{
+			{
+				Type: types.ToolResultTypeText,
+				Text: string(data),
+			},
+		},
+	}, nil
+}
+
+// readDomStateJSON reads the DOM state overview in JSON format, for embedding in typed results
+func readDomStateJSON(ctx context.Context, domStateRes types.Resource) (json.RawMessage, error) {
+	content, err := domStateRes.ReadWithArguments(ctx, domStateRes.GetURI(), map[string]any{
+		"format": types.OutputFormatJSON,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(content.Contents) == 0 {
+		return nil, fmt.Errorf("DOM state result is empty")
+	}
+
+	data := json.RawMessage(content.Contents[0].Text)
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("DOM state is not valid JSON")
+	}
+	return data, nil
+}
+
+// DomStateSnapshot carries the DOM state of typed results for calls with return_dom_state
+type DomStateSnapshot struct {
+	DomState      json.RawMessage `json:"domState,omitempty" description:"DOM state overview after the action, described by browser://schema/dom_state"`
+	DomStateError string          `json:"domStateError,omitempty" description:"Why the DOM state could not be retrieved"`
+}
+
+// readDomState fills the snapshot from the DOM state resource. A failure is
+// recorded in DomStateError and also returned for logging.
+func (s *DomStateSnapshot) readDomState(ctx context.Context, domStateRes types.Resource) error {
+	domState, err := readDomStateJSON(ctx, domStateRes)
+	if err != nil {
+		s.DomStateError = err.Error()
+		return err
+	}
+	s.DomState = domState
+	return nil
+}
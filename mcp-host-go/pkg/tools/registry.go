@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// toolNamePattern restricts the names of tools advertised by the extension
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Registry keeps the tools offered to MCP clients: the host's own tools, added
+// at startup, and the tools the extension advertises in the init handshake,
+// which come and go with the extension.
+type Registry struct {
+	logger    logger.Logger
+	messaging types.Messaging
+	registrar types.ToolRegistrar
+
+	mutex          sync.Mutex
+	builtins       []types.Tool
+	builtinNames   map[string]bool
+	extensionTools map[string]*ExtensionTool
+}
+
+// RegistryConfig contains configuration for Registry
+type RegistryConfig struct {
+	Logger    logger.Logger
+	Messaging types.Messaging     // Used by extension tools
+	Registrar types.ToolRegistrar // The MCP server
+}
+
+// NewRegistry creates a new Registry
+func NewRegistry(config RegistryConfig) (*Registry, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	if config.Registrar == nil {
+		return nil, fmt.Errorf("registrar is required")
+	}
+
+	return &Registry{
+		logger:         config.Logger,
+		messaging:      config.Messaging,
+		registrar:      config.Registrar,
+		builtinNames:   make(map[string]bool),
+		extensionTools: make(map[string]*ExtensionTool),
+	}, nil
+}
+
+// Add registers one of the host's own tools with the MCP server
+func (r *Registry) Add(tool types.Tool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	name := tool.GetName()
+	if r.builtinNames[name] {
+		return fmt.Errorf("tool %s is already registered", name)
+	}
+
+	if err := r.registrar.RegisterTool(tool); err != nil {
+		return fmt.Errorf("failed to register %s tool: %w", name, err)
+	}
+
+	r.builtins = append(r.builtins, tool)
+	r.builtinNames[name] = true
+	return nil
+}
+
+// Tools returns the host's own tools in the order they were added
+func (r *Registry) Tools() []types.Tool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]types.Tool{}, r.builtins...)
+}
+
+// ExtensionTools returns the names of the registered extension tools
+func (r *Registry) ExtensionTools() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := make([]string, 0, len(r.extensionTools))
+	for name := range r.extensionTools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SyncExtensionTools makes the registered extension tools match the ones an
+// extension announced. It is meant as an ExtensionCapabilities change handler.
+// An incompatible extension offers no tools. Advertised tools that are invalid
+// or named like one of the host's own tools are skipped.
+func (r *Registry) SyncExtensionTools(info types.ExtensionInfo) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	wanted := make(map[string]types.ExtensionToolDefinition)
+	if info.Compatible {
+		for _, definition := range info.Tools {
+			if err := r.checkDefinition(definition, wanted); err != nil {
+				r.logger.Warn("Ignoring tool advertised by the extension", zap.Error(err))
+				continue
+			}
+			wanted[definition.Name] = definition
+		}
+	}
+
+	// Remove tools the extension no longer offers or has changed
+	for name, tool := range r.extensionTools {
+		if definition, ok := wanted[name]; ok && reflect.DeepEqual(definition, tool.Definition()) {
+			delete(wanted, name)
+			continue
+		}
+		r.registrar.UnregisterTool(name)
+		delete(r.extensionTools, name)
+		r.logger.Info("Unregistered extension tool", zap.String("tool", name))
+	}
+
+	names := make([]string, 0, len(wanted))
+	for name := range wanted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		tool, err := NewExtensionTool(ExtensionToolConfig{
+			Logger:     r.logger,
+			Messaging:  r.messaging,
+			Definition: wanted[name],
+		})
+		if err == nil {
+			err = r.registrar.RegisterTool(tool)
+		}
+		if err != nil {
+			r.logger.Warn("Failed to register extension tool", zap.String("tool", name), zap.Error(err))
+			continue
+		}
+		r.extensionTools[name] = tool
+		r.logger.Info("Registered extension tool", zap.String("tool", name))
+	}
+}
+
+// checkDefinition returns an error if an advertised tool cannot be registered
+func (r *Registry) checkDefinition(definition types.ExtensionToolDefinition, seen map[string]types.ExtensionToolDefinition) error {
+	if !toolNamePattern.MatchString(definition.Name) {
+		return fmt.Errorf("invalid tool name %q", definition.Name)
+	}
+	if r.builtinNames[definition.Name] {
+		return fmt.Errorf("tool %s is one of the host's own tools", definition.Name)
+	}
+	if _, duplicate := seen[definition.Name]; duplicate {
+		return fmt.Errorf("tool %s is advertised twice", definition.Name)
+	}
+
+	// A missing schema accepts any object; a given one must describe an object
+	if definition.InputSchema != nil {
+		if definition.InputSchema["type"] != "object" {
+			return fmt.Errorf("input schema of tool %s must have type \"object\"", definition.Name)
+		}
+		if _, err := schema.NewValidator(definition.InputSchema); err != nil {
+			return fmt.Errorf("invalid input schema of tool %s: %w", definition.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubMessaging stands in for the extension; registering tools does not use it
+type stubMessaging struct {
+	types.Messaging
+}
+
+// recordingRegistrar records the tools registered with it
+type recordingRegistrar struct {
+	tools map[string]types.Tool
+}
+
+func (r *recordingRegistrar) RegisterTool(tool types.Tool) error {
+	r.tools[tool.GetName()] = tool
+	return nil
+}
+
+func (r *recordingRegistrar) UnregisterTool(name string) {
+	delete(r.tools, name)
+}
+
+func TestRegistry_SyncExtensionToolsChecksInputSchemas(t *testing.T) {
+	registrar := &recordingRegistrar{tools: make(map[string]types.Tool)}
+	registry, err := NewRegistry(RegistryConfig{
+		Logger:    logger.NewLoggerFromZap(zap.NewNop()),
+		Messaging: stubMessaging{},
+		Registrar: registrar,
+	})
+	require.NoError(t, err)
+
+	registry.SyncExtensionTools(types.ExtensionInfo{
+		Compatible: true,
+		Tools: []types.ExtensionToolDefinition{
+			{Name: "no_schema", Description: "Takes any object"},
+			{Name: "object_schema", Description: "Takes a selector", InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"selector": map[string]interface{}{"type": "string"}},
+			}},
+			{Name: "empty_schema", Description: "Takes anything", InputSchema: map[string]interface{}{}},
+			{Name: "array_schema", Description: "Takes a list", InputSchema: map[string]interface{}{"type": "array"}},
+			{Name: "bad_pattern", Description: "Does not compile", InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"id": map[string]interface{}{"type": "string", "pattern": "("}},
+			}},
+		},
+	})
+
+	assert.Equal(t, []string{"no_schema", "object_schema"}, registry.ExtensionTools())
+	assert.NotContains(t, registrar.tools, "empty_schema")
+	assert.NotContains(t, registrar.tools, "array_schema")
+	assert.NotContains(t, registrar.tools, "bad_pattern")
+}
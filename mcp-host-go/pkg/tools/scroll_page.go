@@ -1,9 +1,11 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -24,6 +26,15 @@ type ScrollPageConfig struct {
 	DomStateRes types.Resource
 }
 
+// ScrollResult is the typed result of scroll_page in JSON format
+type ScrollResult struct {
+	Message      string  `json:"message"`
+	Action       string  `json:"action"`
+	Pixels       float64 `json:"pixels,omitempty"`       // Only for "up" and "down"
+	ElementIndex *int    `json:"elementIndex,omitempty"` // Only for "to_element"
+	DomStateSnapshot
+}
+
 // NewScrollPageTool creates a new ScrollPageTool
 func NewScrollPageTool(config ScrollPageConfig) (*ScrollPageTool, error) {
 	if config.Logger == nil {
@@ -81,42 +92,37 @@ func (t *ScrollPageTool) GetInputSchema() interface{} {
 				"description": "Whether to return DOM state content after successful scroll",
 				"default":     false,
 			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
 		},
 		"required":             []string{"action"},
 		"additionalProperties": false,
 	}
 }
 
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *ScrollPageTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *ScrollPageTool) GetOutputSchema() interface{} {
+	return schema.FromType(ScrollResult{})
+}
+
 // Execute executes the scroll_page tool
-func (t *ScrollPageTool) Execute(args map[string]interface{}) (types.ToolResult, error) {
+func (t *ScrollPageTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	t.logger.Info("Executing scroll_page tool with args:", zap.Any("args", args))
 
-	// Extract action from arguments
+	// Arguments were validated against the input schema before Execute
 	action, ok := args["action"].(string)
-	if !ok || action == "" {
-		return types.ToolResult{}, fmt.Errorf("action is required and must be a string")
+	if !ok {
+		return types.ToolResult{}, fmt.Errorf("action is required")
 	}
 
-	// Extract and validate return_dom_state
 	returnDomState := false // default value
-	if returnDomStateArg, exists := args["return_dom_state"]; exists {
-		if returnVal, ok := returnDomStateArg.(bool); ok {
-			returnDomState = returnVal
-		} else {
-			return types.ToolResult{}, fmt.Errorf("return_dom_state must be a boolean, got: %T", returnDomStateArg)
-		}
-	}
-
-	// Validate action
-	validActions := map[string]bool{
-		"up":         true,
-		"down":       true,
-		"to_element": true,
-		"to_top":     true,
-		"to_bottom":  true,
-	}
-	if !validActions[action] {
-		return types.ToolResult{}, fmt.Errorf("invalid action: %s. Valid actions are: up, down, to_element, to_top, to_bottom", action)
+	if returnVal, ok := args["return_dom_state"].(bool); ok {
+		returnDomState = returnVal
 	}
 
 	// Prepare RPC parameters
@@ -129,33 +135,26 @@ func (t *ScrollPageTool) Execute(args map[string]interface{}) (types.ToolResult,
 	case "up", "down":
 		// Extract pixels parameter with default value
 		pixels := 300.0 // default value
-		if pixelsArg, exists := args["pixels"]; exists {
-			if pixelsVal, ok := pixelsArg.(float64); ok {
-				pixels = pixelsVal
-			} else {
-				return types.ToolResult{}, fmt.Errorf("pixels must be a number")
-			}
+		if pixelsVal, ok := args["pixels"].(float64); ok {
+			pixels = pixelsVal
 		}
 		rpcParams["pixels"] = pixels
 
 	case "to_element":
 		// Extract element_index parameter (required for this action)
-		elementIndex, exists := args["element_index"]
-		if !exists {
+		elementIndex, ok := args["element_index"].(float64)
+		if !ok {
 			return types.ToolResult{}, fmt.Errorf("element_index is required for 'to_element' action")
 		}
-		if elementIndexVal, ok := elementIndex.(float64); ok {
-			rpcParams["element_index"] = int(elementIndexVal)
-		} else {
-			return types.ToolResult{}, fmt.Errorf("element_index must be a number")
-		}
+		rpcParams["element_index"] = int(elementIndex)
 
 	case "to_top", "to_bottom":
 		// No additional parameters needed for these actions
 	}
+	rpcParams = types.AddTargetTab(ctx, rpcParams)
 
 	// Send RPC request to the extension
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "scroll_page",
 		Params: rpcParams,
 	}, types.RpcOptions{Timeout: 10000})
@@ -188,6 +187,25 @@ func (t *ScrollPageTool) Execute(args map[string]interface{}) (types.ToolResult,
 		message = "Scrolled to bottom of page"
 	}
 
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := ScrollResult{
+			Message: message,
+			Action:  action,
+		}
+		if pixels, ok := rpcParams["pixels"].(float64); ok {
+			result.Pixels = pixels
+		}
+		if elementIndex, ok := rpcParams["element_index"].(int); ok {
+			result.ElementIndex = &elementIndex
+		}
+		if returnDomState {
+			if err := result.readDomState(ctx, t.domStateRes); err != nil {
+				t.logger.Warn("Failed to get DOM state after scroll", zap.Error(err))
+			}
+		}
+		return jsonResult(result)
+	}
+
 	// Create result content
 	resultContent := []types.ToolResultItem{
 		{
@@ -199,7 +217,7 @@ func (t *ScrollPageTool) Execute(args map[string]interface{}) (types.ToolResult,
 	// If return_dom_state is true, fetch and append DOM state
 	if returnDomState {
 		t.logger.Debug("Fetching DOM state after successful scroll")
-		domContent, err := t.domStateRes.Read()
+		domContent, err := t.domStateRes.Read(ctx)
 		if err != nil {
 			t.logger.Warn("Failed to get DOM state after scroll", zap.Error(err))
 			// Don't fail the entire operation, just add a note
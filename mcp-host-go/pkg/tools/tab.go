@@ -0,0 +1,10 @@
+package tools
+
+// tabIDProperty returns the input schema property of the optional tab_id argument of page-level tools
+func tabIDProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "integer",
+		"description": "ID of the tab to act on, from browser://current/state. The tab does not need to be active. Defaults to the tab opened or switched to by this session, or the active tab",
+		"minimum":     0,
+	}
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// TakeScreenshotTool implements a tool for capturing screenshots of the current page
+type TakeScreenshotTool struct {
+	name        string
+	description string
+	logger      logger.Logger
+	messaging   types.Messaging
+}
+
+// TakeScreenshotConfig contains configuration for TakeScreenshotTool
+type TakeScreenshotConfig struct {
+	Logger    logger.Logger
+	Messaging types.Messaging
+}
+
+// ScreenshotData represents the screenshot returned by the Chrome extension
+type ScreenshotData struct {
+	Data     string `json:"data"`
+	MimeType string `json:"mimeType"`
+	Mode     string `json:"mode"`
+	URL      string `json:"url"`
+	Title    string `json:"title"`
+}
+
+// ScreenshotResult is the typed result of take_screenshot in JSON format.
+// The image itself follows as a separate image content item.
+type ScreenshotResult struct {
+	Message      string `json:"message"`
+	Mode         string `json:"mode"`
+	ElementIndex *int   `json:"elementIndex,omitempty"` // Only for "element" mode
+	MimeType     string `json:"mimeType"`
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+}
+
+// NewTakeScreenshotTool creates a new TakeScreenshotTool
+func NewTakeScreenshotTool(config TakeScreenshotConfig) (*TakeScreenshotTool, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	return &TakeScreenshotTool{
+		name:        "take_screenshot",
+		description: "Capture a screenshot of the visible viewport, the full page, or a single element (by index from DOM state). Returns the image so that the page layout can be inspected visually.",
+		logger:      config.Logger,
+		messaging:   config.Messaging,
+	}, nil
+}
+
+// GetName returns the tool name
+func (t *TakeScreenshotTool) GetName() string {
+	return t.name
+}
+
+// GetDescription returns the tool description
+func (t *TakeScreenshotTool) GetDescription() string {
+	return t.description
+}
+
+// GetInputSchema returns the tool input schema
+func (t *TakeScreenshotTool) GetInputSchema() interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"mode": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"viewport", "full_page", "element"},
+				"description": "What to capture: the visible viewport, the full scrollable page, or a single element",
+				"default":     "viewport",
+			},
+			"element_index": map[string]interface{}{
+				"type":        "integer",
+				"description": "Index of the element to capture (required for 'element' mode, from DOM state interactive_elements)",
+				"minimum":     0,
+			},
+			"image_format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"png", "jpeg"},
+				"description": "Image format",
+				"default":     "png",
+			},
+			"quality": map[string]interface{}{
+				"type":        "integer",
+				"description": "JPEG quality from 0 to 100 (only for 'jpeg' image_format)",
+				"minimum":     0,
+				"maximum":     100,
+				"default":     80,
+			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
+		},
+		"additionalProperties": false,
+	}
+}
+
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *TakeScreenshotTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *TakeScreenshotTool) GetOutputSchema() interface{} {
+	return schema.FromType(ScreenshotResult{})
+}
+
+// Execute executes the take_screenshot tool
+func (t *TakeScreenshotTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
+	t.logger.Info("Executing take_screenshot tool", zap.Any("args", args))
+
+	// Arguments were validated against the input schema before Execute
+	mode := "viewport" // default value
+	if modeArg, ok := args["mode"].(string); ok {
+		mode = modeArg
+	}
+
+	format := "png" // default value
+	if formatArg, ok := args["image_format"].(string); ok {
+		format = formatArg
+	}
+
+	rpcParams := map[string]interface{}{
+		"mode":   mode,
+		"format": format,
+	}
+
+	if mode == "element" {
+		elementIndex, ok := args["element_index"].(float64)
+		if !ok {
+			return types.ToolResult{}, fmt.Errorf("element_index is required for 'element' mode")
+		}
+		rpcParams["element_index"] = int(elementIndex)
+	}
+
+	if quality, ok := args["quality"].(float64); ok {
+		if format != "jpeg" {
+			return types.ToolResult{}, fmt.Errorf("quality is only supported for 'jpeg' image_format")
+		}
+		rpcParams["quality"] = int(quality)
+	} else if format == "jpeg" {
+		rpcParams["quality"] = 80
+	}
+
+	// Full-page captures of long pages take noticeably longer
+	timeout := 15000
+	if mode == "full_page" {
+		timeout = 30000
+	}
+
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
+		Method: "take_screenshot",
+		Params: types.AddTargetTab(ctx, rpcParams),
+	}, types.RpcOptions{Timeout: timeout})
+	if err != nil {
+		t.logger.Error("Error calling take_screenshot", zap.Error(err))
+		return types.ToolResult{}, fmt.Errorf("take_screenshot RPC failed: %w", err)
+	}
+
+	if resp.Error != nil {
+		t.logger.Error("RPC error in take_screenshot", zap.Any("respError", resp.Error))
+		return types.ToolResult{}, fmt.Errorf("RPC error: %s", resp.Error.Message)
+	}
+
+	var screenshot ScreenshotData
+	jsonBytes, err := json.Marshal(resp.Result)
+	if err != nil {
+		return types.ToolResult{}, fmt.Errorf("failed to marshal screenshot response: %w", err)
+	}
+	if err := json.Unmarshal(jsonBytes, &screenshot); err != nil {
+		return types.ToolResult{}, fmt.Errorf("failed to parse screenshot response: %w", err)
+	}
+
+	if screenshot.Data == "" {
+		return types.ToolResult{}, fmt.Errorf("extension returned an empty screenshot")
+	}
+	if screenshot.MimeType == "" {
+		screenshot.MimeType = "image/" + format
+	}
+
+	t.logger.Debug("Screenshot captured",
+		zap.String("mode", mode),
+		zap.String("mimeType", screenshot.MimeType),
+		zap.Int("size", len(screenshot.Data)))
+
+	summary := fmt.Sprintf("Captured %s screenshot (%s)", describeScreenshotMode(mode, rpcParams), screenshot.MimeType)
+	if screenshot.URL != "" {
+		summary += fmt.Sprintf(" of %s", screenshot.URL)
+	}
+
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := ScreenshotResult{
+			Message:  summary,
+			Mode:     mode,
+			MimeType: screenshot.MimeType,
+			URL:      screenshot.URL,
+			Title:    screenshot.Title,
+		}
+		if elementIndex, ok := rpcParams["element_index"].(int); ok {
+			result.ElementIndex = &elementIndex
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return types.ToolResult{}, fmt.Errorf("failed to encode result: %w", err)
+		}
+		summary = string(data)
+	}
+
+	return types.ToolResult{
+		Content: []types.ToolResultItem{
+			{
+				Type: types.ToolResultTypeText,
+				Text: summary,
+			},
+			{
+				Type:     types.ToolResultTypeImage,
+				Data:     screenshot.Data,
+				MimeType: screenshot.MimeType,
+			},
+		},
+	}, nil
+}
+
+// describeScreenshotMode returns a human-readable description of the captured area
+func describeScreenshotMode(mode string, rpcParams map[string]interface{}) string {
+	switch mode {
+	case "full_page":
+		return "full page"
+	case "element":
+		return fmt.Sprintf("element %d", rpcParams["element_index"])
+	default:
+		return "viewport"
+	}
+}
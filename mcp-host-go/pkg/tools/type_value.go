@@ -1,11 +1,13 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
 	"go.uber.org/zap"
 )
@@ -24,6 +26,19 @@ type TypeValueConfig struct {
 	Messaging types.Messaging
 }
 
+// TypeValueResult is the typed result of type_value in JSON format
+type TypeValueResult struct {
+	Message             string                   `json:"message"`
+	ElementIndex        int                      `json:"elementIndex"`
+	ElementType         string                   `json:"elementType"`
+	InputMethod         string                   `json:"inputMethod"` // "keyboard", "text" or "text-fallback"
+	ActualValue         interface{}              `json:"actualValue,omitempty"`
+	DomChanged          bool                     `json:"domChanged"`    // Interactive elements changed; re-read the DOM state before the next interaction
+	ExecutionTime       float64                  `json:"executionTime"` // Seconds
+	ElementInfo         map[string]interface{}   `json:"elementInfo,omitempty"`
+	OperationsPerformed []map[string]interface{} `json:"operationsPerformed,omitempty"` // Keyboard operations, in order
+}
+
 // NewTypeValueTool creates a new TypeValueTool
 func NewTypeValueTool(config TypeValueConfig) (*TypeValueTool, error) {
 	if config.Logger == nil {
@@ -93,24 +108,36 @@ func (t *TypeValueTool) GetInputSchema() interface{} {
 				},
 				"additionalProperties": false,
 			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
 		},
 		"required":             []string{"element_index", "value"},
 		"additionalProperties": false,
 	}
 }
 
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *TypeValueTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *TypeValueTool) GetOutputSchema() interface{} {
+	return schema.FromType(TypeValueResult{})
+}
+
 // Execute executes the type_value tool
-func (t *TypeValueTool) Execute(args map[string]interface{}) (types.ToolResult, error) {
+func (t *TypeValueTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
 	startTime := time.Now()
 	t.logger.Info("Executing type_value tool", zap.Any("args", args))
 
-	// Extract and validate element_index
-	elementIndexArg, exists := args["element_index"]
-	if !exists {
+	// Arguments were validated against the input schema before Execute
+	elementIndexArg, ok := args["element_index"].(float64)
+	if !ok {
 		return types.ToolResult{}, fmt.Errorf("element_index is required")
 	}
+	elementIndex := int(elementIndexArg)
 
-	// Extract and validate value
 	valueArg, exists := args["value"]
 	if !exists {
 		return types.ToolResult{}, fmt.Errorf("value is required")
@@ -151,56 +178,11 @@ func (t *TypeValueTool) Execute(args map[string]interface{}) (types.ToolResult,
 		"wait_after":  1.0,
 	}
 
-	if optionsArg, exists := args["options"]; exists {
-		if optionsMap, ok := optionsArg.(map[string]interface{}); ok {
-			// Merge user options with defaults
-			for key, value := range optionsMap {
-				switch key {
-				case "clear_first":
-					if boolVal, ok := value.(bool); ok {
-						options[key] = boolVal
-					} else {
-						return types.ToolResult{}, fmt.Errorf("options.clear_first must be a boolean")
-					}
-				case "submit":
-					if boolVal, ok := value.(bool); ok {
-						options[key] = boolVal
-					} else {
-						return types.ToolResult{}, fmt.Errorf("options.submit must be a boolean")
-					}
-				case "wait_after":
-					if floatVal, ok := value.(float64); ok {
-						if floatVal < 0 || floatVal > 30 {
-							return types.ToolResult{}, fmt.Errorf("options.wait_after must be between 0 and 30 seconds")
-						}
-						options[key] = floatVal
-					} else {
-						return types.ToolResult{}, fmt.Errorf("options.wait_after must be a number")
-					}
-				default:
-					return types.ToolResult{}, fmt.Errorf("unknown option: %s", key)
-				}
-			}
-		} else {
-			return types.ToolResult{}, fmt.Errorf("options must be an object")
-		}
-	}
-
-	// Validate element_index parameter
-	var elementIndex int
-	switch v := elementIndexArg.(type) {
-	case float64:
-		if v < 0 {
-			return types.ToolResult{}, fmt.Errorf("element_index must be non-negative, got: %v", v)
-		}
-		elementIndex = int(v)
-	case int:
-		if v < 0 {
-			return types.ToolResult{}, fmt.Errorf("element_index must be non-negative, got: %v", v)
+	if optionsMap, ok := args["options"].(map[string]interface{}); ok {
+		// Merge user options with defaults
+		for key, value := range optionsMap {
+			options[key] = value
 		}
-		elementIndex = v
-	default:
-		return types.ToolResult{}, fmt.Errorf("element_index must be a number, got: %T", elementIndexArg)
 	}
 
 	// Prepare RPC parameters
@@ -209,6 +191,7 @@ func (t *TypeValueTool) Execute(args map[string]interface{}) (types.ToolResult,
 		"value":         valueArg,
 		"options":       options,
 	}
+	rpcParams = types.AddTargetTab(ctx, rpcParams)
 
 	// Calculate enhanced buffer time
 	bufferTime := int(float64(rpcTimeout) * 0.25) // 25% buffer
@@ -238,7 +221,7 @@ func (t *TypeValueTool) Execute(args map[string]interface{}) (types.ToolResult,
 		zap.Int("rpc_timeout", rpcTimeout),
 		zap.Int("total_timeout", rpcTimeout+bufferTime))
 
-	resp, err := t.messaging.RpcRequest(types.RpcRequest{
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
 		Method: "type_value",
 		Params: rpcParams,
 	}, types.RpcOptions{Timeout: rpcTimeout + bufferTime})
@@ -363,6 +346,25 @@ func (t *TypeValueTool) Execute(args map[string]interface{}) (types.ToolResult,
 		zap.Bool("dom_changed", domChanged),
 		zap.Float64("execution_time", executionTime))
 
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := TypeValueResult{
+			Message:       message,
+			ElementIndex:  elementIndex,
+			ElementType:   elementType,
+			InputMethod:   inputMethod,
+			ActualValue:   actualValue,
+			DomChanged:    domChanged,
+			ExecutionTime: executionTime,
+			ElementInfo:   elementInfo,
+		}
+		for _, op := range operationsPerformed {
+			if opMap, ok := op.(map[string]interface{}); ok {
+				result.OperationsPerformed = append(result.OperationsPerformed, opMap)
+			}
+		}
+		return jsonResult(result)
+	}
+
 	// Create detailed success response
 	inputModeText := "standard form input"
 	if keyboardMode || inputMethod == "keyboard" {
@@ -0,0 +1,285 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/schema"
+	"github.com/algonius/algonius-browser/mcp-host-go/pkg/types"
+	"go.uber.org/zap"
+)
+
+// wait_for limits, in milliseconds
+const (
+	defaultWaitForTimeout = 10000
+	maxWaitForTimeout     = 120000
+	waitForRpcMargin      = 5000 // Extra time for the extension to report a timeout itself
+)
+
+// waitForConditions are the condition arguments of wait_for, in the order they are reported
+var waitForConditions = []string{"selector", "text", "url_pattern", "dom_stable_ms", "network_idle_ms"}
+
+// WaitForTool implements a tool that waits until a condition holds on the page
+type WaitForTool struct {
+	name        string
+	description string
+	logger      logger.Logger
+	messaging   types.Messaging
+	domStateRes types.Resource
+}
+
+// WaitForConfig contains configuration for WaitForTool
+type WaitForConfig struct {
+	Logger      logger.Logger
+	Messaging   types.Messaging
+	DomStateRes types.Resource
+}
+
+// WaitForResult is the typed result of wait_for in JSON format
+type WaitForResult struct {
+	Message   string  `json:"message"`
+	Condition string  `json:"condition" description:"The condition that was met first: selector, text, url, dom_stable or network_idle"`
+	ElapsedMs float64 `json:"elapsedMs"`
+	URL       string  `json:"url,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	DomStateSnapshot
+}
+
+// NewWaitForTool creates a new WaitForTool
+func NewWaitForTool(config WaitForConfig) (*WaitForTool, error) {
+	if config.Logger == nil {
+		return nil, fmt.Errorf("logger is required")
+	}
+
+	if config.Messaging == nil {
+		return nil, fmt.Errorf("messaging is required")
+	}
+
+	if config.DomStateRes == nil {
+		return nil, fmt.Errorf("domStateRes is required")
+	}
+
+	return &WaitForTool{
+		name:        "wait_for",
+		description: "Wait until a CSS or XPath selector appears or disappears, text becomes visible, the URL matches a pattern, the DOM stops changing, or network activity goes idle. With several conditions, returns as soon as any of them is met and reports which one",
+		logger:      config.Logger,
+		messaging:   config.Messaging,
+		domStateRes: config.DomStateRes,
+	}, nil
+}
+
+// GetName returns the tool name
+func (t *WaitForTool) GetName() string {
+	return t.name
+}
+
+// GetDescription returns the tool description
+func (t *WaitForTool) GetDescription() string {
+	return t.description
+}
+
+// GetInputSchema returns the tool input schema
+func (t *WaitForTool) GetInputSchema() interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"type":        "string",
+				"description": "CSS selector, or XPath expression with selector_type 'xpath', of an element to wait for",
+				"minLength":   1,
+			},
+			"selector_type": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"css", "xpath"},
+				"description": "How selector is written",
+				"default":     "css",
+			},
+			"selector_state": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"appear", "disappear"},
+				"description": "Wait for the element to become visible, or to be removed or hidden",
+				"default":     "appear",
+			},
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to wait for in the visible text of the page",
+				"minLength":   1,
+			},
+			"url_pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "JavaScript regular expression the page URL must match, e.g. '/checkout/complete'. The browser compiles it and reports invalid patterns",
+				"minLength":   1,
+			},
+			"dom_stable_ms": map[string]interface{}{
+				"type":        "number",
+				"description": "Wait until the DOM has not changed for this many milliseconds",
+				"minimum":     50,
+				"maximum":     30000,
+			},
+			"network_idle_ms": map[string]interface{}{
+				"type":        "number",
+				"description": "Wait until no network request has been in flight for this many milliseconds",
+				"minimum":     50,
+				"maximum":     30000,
+			},
+			"timeout": map[string]interface{}{
+				"type":        "number",
+				"description": "How long to wait for a condition (milliseconds)",
+				"minimum":     100,
+				"maximum":     maxWaitForTimeout,
+				"default":     defaultWaitForTimeout,
+			},
+			"return_dom_state": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether to return DOM state content once the condition is met",
+				"default":     false,
+			},
+			"tab_id": tabIDProperty(),
+			"format": outputFormatProperty(t.name),
+		},
+		"additionalProperties": false,
+	}
+}
+
+// TargetTab returns the tab named by the optional tab_id argument
+func (t *WaitForTool) TargetTab(args map[string]interface{}) (int, bool) {
+	return types.ParseTabID(args["tab_id"])
+}
+
+// GetOutputSchema returns the schema of the JSON output format
+func (t *WaitForTool) GetOutputSchema() interface{} {
+	return schema.FromType(WaitForResult{})
+}
+
+// IsReadOnly reports that the tool only watches the page, so it does not hold
+// up the actions it may be waiting for
+func (t *WaitForTool) IsReadOnly() bool {
+	return true
+}
+
+// Execute executes the wait_for tool
+func (t *WaitForTool) Execute(ctx context.Context, args map[string]interface{}) (types.ToolResult, error) {
+	t.logger.Info("Executing wait_for tool", zap.Any("args", args))
+
+	// Arguments were validated against the input schema before Execute
+	rpcParams := map[string]interface{}{}
+	for _, name := range waitForConditions {
+		if value, ok := args[name]; ok {
+			rpcParams[name] = value
+		}
+	}
+	if len(rpcParams) == 0 {
+		return types.ToolResult{}, fmt.Errorf("at least one condition is required: %s", strings.Join(waitForConditions, ", "))
+	}
+
+	if _, ok := rpcParams["selector"]; ok {
+		rpcParams["selector_type"] = "css"
+		if selectorType, ok := args["selector_type"].(string); ok {
+			rpcParams["selector_type"] = selectorType
+		}
+		rpcParams["selector_state"] = "appear"
+		if state, ok := args["selector_state"].(string); ok {
+			rpcParams["selector_state"] = state
+		}
+	}
+
+	timeout := float64(defaultWaitForTimeout)
+	if timeoutVal, ok := args["timeout"].(float64); ok {
+		timeout = timeoutVal
+	}
+	rpcParams["timeout"] = timeout
+
+	returnDomState := false // default value
+	if returnVal, ok := args["return_dom_state"].(bool); ok {
+		returnDomState = returnVal
+	}
+
+	rpcParams = types.AddTargetTab(ctx, rpcParams)
+
+	// Send RPC request to the extension, which answers when a condition is met or the wait times out
+	resp, err := t.messaging.RpcRequest(ctx, types.RpcRequest{
+		Method: "wait_for",
+		Params: rpcParams,
+	}, types.RpcOptions{Timeout: int(timeout) + waitForRpcMargin})
+
+	if err != nil {
+		t.logger.Error("Error calling wait_for", zap.Error(err))
+		return types.ToolResult{}, fmt.Errorf("wait_for RPC failed: %w", err)
+	}
+
+	if resp.Error != nil {
+		t.logger.Warn("RPC error in wait_for", zap.Any("respError", resp.Error))
+		return types.ToolResult{}, fmt.Errorf("RPC error: %s", resp.Error.Message)
+	}
+
+	resultData, _ := resp.Result.(map[string]interface{})
+	condition, _ := resultData["condition"].(string)
+	elapsed, _ := resultData["elapsed_ms"].(float64)
+	url, _ := resultData["url"].(string)
+	title, _ := resultData["title"].(string)
+
+	message := fmt.Sprintf("Condition %s met after %.0f ms", condition, elapsed)
+	if msg, ok := resultData["message"].(string); ok && msg != "" {
+		message = msg
+	}
+
+	t.logger.Info("wait_for condition met", zap.String("condition", condition), zap.Float64("elapsed_ms", elapsed))
+
+	if outputFormat(args) == types.OutputFormatJSON {
+		result := WaitForResult{
+			Message:   message,
+			Condition: condition,
+			ElapsedMs: elapsed,
+			URL:       url,
+			Title:     title,
+		}
+		if returnDomState {
+			if err := result.readDomState(ctx, t.domStateRes); err != nil {
+				t.logger.Warn("Failed to get DOM state after wait", zap.Error(err))
+			}
+		}
+		return jsonResult(result)
+	}
+
+	responseText := fmt.Sprintf(`Wait For Result:
+- Status: Success
+- Message: %s
+- Condition: %s
+- Elapsed: %.0f ms`, message, condition, elapsed)
+	if url != "" {
+		responseText += fmt.Sprintf("\n- URL: %s", url)
+	}
+
+	// Create result content
+	resultContent := []types.ToolResultItem{
+		{
+			Type: "text",
+			Text: responseText,
+		},
+	}
+
+	// If return_dom_state is true, fetch and append DOM state
+	if returnDomState {
+		t.logger.Debug("Fetching DOM state after wait")
+		domContent, err := t.domStateRes.Read(ctx)
+		if err != nil {
+			t.logger.Warn("Failed to get DOM state after wait", zap.Error(err))
+			// Don't fail the entire operation, just add a note
+			resultContent[0].Text += "\n\nNote: Failed to retrieve DOM state after wait: " + err.Error()
+		} else if len(domContent.Contents) > 0 {
+			resultContent = append(resultContent, types.ToolResultItem{
+				Type: "text",
+				Text: "\n--- DOM State ---\n\n" + domContent.Contents[0].Text,
+			})
+		} else {
+			t.logger.Warn("DOM state result is empty")
+			resultContent[0].Text += "\n\nNote: DOM state result is empty"
+		}
+	}
+
+	return types.ToolResult{
+		Content: resultContent,
+	}, nil
+}
@@ -0,0 +1,24 @@
+package types
+
+import "errors"
+
+// ErrorCodeExtensionDisconnected identifies calls that failed because the browser extension is not connected
+const ErrorCodeExtensionDisconnected = "EXTENSION_DISCONNECTED"
+
+// ErrExtensionDisconnected is returned, wrapped, by RPC requests that cannot reach
+// the extension or that were pending when it went away. Match it with errors.Is.
+var ErrExtensionDisconnected = errors.New(ErrorCodeExtensionDisconnected + ": browser extension is not connected")
+
+// Error codes of calls the connected extension cannot serve
+const (
+	ErrorCodeExtensionIncompatible = "EXTENSION_INCOMPATIBLE"
+	ErrorCodeMethodUnsupported     = "METHOD_UNSUPPORTED"
+)
+
+// ErrExtensionIncompatible is returned, wrapped, by RPC requests to an extension
+// whose protocol version this host does not support
+var ErrExtensionIncompatible = errors.New(ErrorCodeExtensionIncompatible + ": browser extension protocol is not compatible with this MCP host")
+
+// ErrMethodUnsupported is returned, wrapped, by RPC requests for methods the
+// extension did not announce in the init handshake
+var ErrMethodUnsupported = errors.New(ErrorCodeMethodUnsupported + ": browser extension does not support this method")
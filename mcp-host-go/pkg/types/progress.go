@@ -0,0 +1,27 @@
+package types
+
+import "context"
+
+// RpcProgress is a progress update the extension reports for a running RPC request
+type RpcProgress struct {
+	Progress float64 `json:"progress"`          // Increases with every update
+	Total    float64 `json:"total,omitempty"`   // Value of Progress when done, if known
+	Message  string  `json:"message,omitempty"` // Human-readable stage, e.g. "Typed 120 of 480 characters"
+}
+
+// ProgressFunc receives progress updates of an RPC request
+type ProgressFunc func(progress RpcProgress)
+
+// progressContextKey is the context key of the ProgressFunc of a call
+type progressContextKey struct{}
+
+// WithProgress returns a context whose RPC requests report progress to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, fn)
+}
+
+// ProgressFromContext returns the ProgressFunc attached by WithProgress, or nil
+func ProgressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressContextKey{}).(ProgressFunc)
+	return fn
+}
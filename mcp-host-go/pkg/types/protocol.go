@@ -0,0 +1,99 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Versions of the native messaging protocol. ProtocolVersion increases with
+// every change that peers speaking an older version cannot handle. Version 1
+// is the protocol of extensions that do not announce a version in init;
+// version 2 adds heartbeats and chunked messages.
+const (
+	ProtocolVersion    = 2 // Spoken by this host
+	MinProtocolVersion = 2 // Oldest version this host works with
+)
+
+// ExtensionInfo describes the extension that completed the init handshake
+type ExtensionInfo struct {
+	Version         string   `json:"version,omitempty"` // Release of the extension
+	ProtocolVersion int      `json:"protocolVersion"`   // Newest protocol version the extension speaks
+	Methods         []string `json:"methods"`           // RPC methods the extension implements
+	Compatible      bool     `json:"compatible"`
+	Message         string   `json:"message,omitempty"` // Why the extension is not compatible
+
+	// Tools the extension offers beyond the host's own
+	Tools []ExtensionToolDefinition `json:"tools,omitempty"`
+}
+
+// ExtensionToolDefinition is a tool advertised by the extension in the init
+// handshake. The host offers it to MCP clients and forwards calls to the
+// extension RPC method of the same name.
+type ExtensionToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"` // JSON Schema of the arguments, any object if missing
+	ReadOnly    bool                   `json:"readOnly,omitempty"`    // The tool only inspects the page
+}
+
+// ExtensionCapabilities holds the result of the init handshake. Until the
+// extension has announced itself every method is assumed to be supported.
+type ExtensionCapabilities struct {
+	mutex    sync.Mutex
+	info     ExtensionInfo
+	known    bool
+	handlers []func(info ExtensionInfo)
+}
+
+// Get returns the announced extension, if the handshake has happened
+func (c *ExtensionCapabilities) Get() (ExtensionInfo, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.info, c.known
+}
+
+// Set records the extension announced in the init handshake and calls the change handlers
+func (c *ExtensionCapabilities) Set(info ExtensionInfo) {
+	c.mutex.Lock()
+	c.info = info
+	c.known = true
+	handlers := append([]func(info ExtensionInfo){}, c.handlers...)
+	c.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(info)
+	}
+}
+
+// OnChange registers a handler called whenever an extension is announced
+func (c *ExtensionCapabilities) OnChange(handler func(info ExtensionInfo)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handlers = append(c.handlers, handler)
+}
+
+// Supports reports whether the extension implements an RPC method
+func (c *ExtensionCapabilities) Supports(method string) bool {
+	return c.Check(method) == nil
+}
+
+// Check returns an error wrapping ErrExtensionIncompatible or ErrMethodUnsupported
+// if the extension cannot serve method
+func (c *ExtensionCapabilities) Check(method string) error {
+	info, known := c.Get()
+	if !known {
+		return nil
+	}
+	if !info.Compatible {
+		return fmt.Errorf("%w: %s", ErrExtensionIncompatible, info.Message)
+	}
+	for _, supported := range info.Methods {
+		if supported == method {
+			return nil
+		}
+	}
+	if info.Version != "" {
+		method = fmt.Sprintf("%s (extension %s)", method, info.Version)
+	}
+	return fmt.Errorf("%w: %s; update the browser extension to use it", ErrMethodUnsupported, method)
+}
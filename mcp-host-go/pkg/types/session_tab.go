@@ -0,0 +1,108 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// SessionTab holds the tab an MCP session works in. It is set when the session
+// opens or switches tabs, and page-level tools then target it instead of the
+// globally active tab.
+type SessionTab struct {
+	mutex sync.Mutex
+	tabID int
+	set   bool
+}
+
+// Get returns the tab ID of the session, if any
+func (t *SessionTab) Get() (int, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.tabID, t.set
+}
+
+// Set makes tabID the tab of the session
+func (t *SessionTab) Set(tabID int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.tabID = tabID
+	t.set = true
+}
+
+// Forget clears the tab of the session if it is tabID, e.g. because the tab was closed
+func (t *SessionTab) Forget(tabID int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.set && t.tabID == tabID {
+		t.set = false
+	}
+}
+
+// sessionTabContextKey is the context key of the SessionTab of a call
+type sessionTabContextKey struct{}
+
+// WithSessionTab returns a context whose calls act on the tab of the session
+func WithSessionTab(ctx context.Context, tab *SessionTab) context.Context {
+	return context.WithValue(ctx, sessionTabContextKey{}, tab)
+}
+
+// SessionTabFromContext returns the SessionTab attached by WithSessionTab, or nil
+func SessionTabFromContext(ctx context.Context) *SessionTab {
+	tab, _ := ctx.Value(sessionTabContextKey{}).(*SessionTab)
+	return tab
+}
+
+// SessionTabID returns the tab of the calling session, if it has one
+func SessionTabID(ctx context.Context) (int, bool) {
+	if tab := SessionTabFromContext(ctx); tab != nil {
+		return tab.Get()
+	}
+	return 0, false
+}
+
+// targetTabContextKey is the context key of the tab named by the tab_id argument of a call
+type targetTabContextKey struct{}
+
+// WithTargetTab returns a context whose calls act on tabID instead of the tab of the session
+func WithTargetTab(ctx context.Context, tabID int) context.Context {
+	return context.WithValue(ctx, targetTabContextKey{}, tabID)
+}
+
+// TargetTabID returns the tab a call acts on: the tab named by its tab_id
+// argument, else the tab of the calling session. It returns false if the call
+// acts on the active tab.
+func TargetTabID(ctx context.Context) (int, bool) {
+	if tabID, ok := ctx.Value(targetTabContextKey{}).(int); ok {
+		return tabID, true
+	}
+	return SessionTabID(ctx)
+}
+
+// AddTargetTab sets the "tab_id" RPC parameter to the tab the call acts on, if
+// any, so the extension acts on that tab instead of the active one
+func AddTargetTab(ctx context.Context, params map[string]interface{}) map[string]interface{} {
+	tabID, ok := TargetTabID(ctx)
+	if !ok {
+		return params
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	params["tab_id"] = tabID
+	return params
+}
+
+// ParseTabID converts a tab_id argument, a JSON number or an integer, to a tab
+// ID. It returns false if raw is not a non-negative integer.
+func ParseTabID(raw interface{}) (int, bool) {
+	switch tabID := raw.(type) {
+	case float64:
+		if tabID < 0 || tabID != float64(int(tabID)) {
+			return 0, false
+		}
+		return int(tabID), true
+	case int:
+		return tabID, tabID >= 0
+	}
+	return 0, false
+}
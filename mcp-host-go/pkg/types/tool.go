@@ -0,0 +1,20 @@
+package types
+
+// ReadOnlyTool is implemented by tools that only inspect the page. Other tools
+// are serialized per tab so their actions cannot interleave.
+type ReadOnlyTool interface {
+	IsReadOnly() bool
+}
+
+// TabTargetingTool is implemented by page-level tools that take an optional
+// tab_id argument. The call then acts on that tab instead of the session's tab.
+type TabTargetingTool interface {
+	TargetTab(args map[string]interface{}) (int, bool)
+}
+
+// RpcMethodsTool is implemented by tools that call extension RPC methods other
+// than the one named like the tool. The tool is hidden while the connected
+// extension does not support all of them.
+type RpcMethodsTool interface {
+	RpcMethods() []string
+}
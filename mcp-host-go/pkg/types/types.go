@@ -1,6 +1,8 @@
 package types
 
 import (
+	"context"
+
 	"github.com/algonius/algonius-browser/mcp-host-go/pkg/logger"
 )
 
@@ -16,6 +18,17 @@ type Message struct {
 	Error  *ErrorInfo  `json:"error,omitempty"`
 }
 
+// MessageChunk is the data of a "chunk" message, one part of a native message
+// too large to send in one piece. The receiver joins the decoded payloads of
+// all chunks with the same ChunkID in Seq order and handles the result as a
+// single message.
+type MessageChunk struct {
+	ChunkID string `json:"chunkId"` // Shared by every chunk of a message
+	Seq     int    `json:"seq"`     // Position of the chunk, from 0
+	Total   int    `json:"total"`   // Number of chunks in the message
+	Payload string `json:"payload"` // Base64 encoding of this part of the message JSON
+}
+
 // ErrorInfo represents error information in JSON-RPC format
 type ErrorInfo struct {
 	Code    int         `json:"code"`
@@ -39,7 +52,8 @@ type RpcResponse struct {
 
 // RpcOptions represents options for RPC requests
 type RpcOptions struct {
-	Timeout int // Timeout in milliseconds
+	Timeout    int          // Timeout in milliseconds
+	OnProgress ProgressFunc // Optional, receives progress of the request; defaults to ProgressFromContext
 }
 
 // BrowserState represents the state of the browser
@@ -75,8 +89,18 @@ type HostInfo struct {
 type HostStatus struct {
 	HostInfo
 	IsConnected bool  `json:"isConnected"`
-	StartTime   int64 `json:"startTime"`
-	LastPing    int64 `json:"lastPing"`
+	StartTime   int64 `json:"startTime"` // Unix milliseconds
+	LastPing    int64 `json:"lastPing"`  // Unix milliseconds of the last message from the extension, 0 if none
+	// Queue reports the queue of inbound messages, if the messaging backend has one
+	Queue *MessageQueueStats `json:"queue,omitempty"`
+	// Extension describes the connected extension once it has completed the init handshake
+	Extension *ExtensionInfo `json:"extension,omitempty"`
+}
+
+// ConnectionStatus describes the link between the host and the browser extension
+type ConnectionStatus struct {
+	IsConnected bool
+	LastPing    int64 // Unix milliseconds of the last message from the extension, 0 if none
 }
 
 // MessageHandler is a function that handles a message
@@ -92,8 +116,25 @@ type Messaging interface {
 	RegisterHandler(messageType string, handler MessageHandler)
 	RegisterRpcMethod(method string, handler RpcHandler)
 	SendMessage(message Message) error
-	RpcRequest(request RpcRequest, options RpcOptions) (RpcResponse, error)
+	RpcRequest(ctx context.Context, request RpcRequest, options RpcOptions) (RpcResponse, error)
 	Start() error
+	ConnectionStatus() ConnectionStatus
+	OnConnectionChange(handler func(status ConnectionStatus))
+}
+
+// MessageQueueStats reports the queue of messages from the extension waiting for a worker
+type MessageQueueStats struct {
+	Workers   int    `json:"workers"`   // Messages handled at the same time
+	Depth     int    `json:"depth"`     // Messages waiting for a worker
+	Capacity  int    `json:"capacity"`  // Messages that may wait before new ones are refused
+	Processed uint64 `json:"processed"` // Messages handled so far
+	Dropped   uint64 `json:"dropped"`   // Events discarded because the queue was full
+	Rejected  uint64 `json:"rejected"`  // RPC requests answered with a busy error because the queue was full
+}
+
+// MessageQueueReporter is implemented by Messaging backends that queue inbound messages
+type MessageQueueReporter interface {
+	QueueStats() MessageQueueStats
 }
 
 // Resource defines the interface for MCP resources
@@ -102,11 +143,38 @@ type Resource interface {
 	GetName() string
 	GetMimeType() string
 	GetDescription() string
-	Read() (ResourceContent, error)
-	ReadWithArguments(uri string, arguments map[string]any) (ResourceContent, error)
+	Read(ctx context.Context) (ResourceContent, error)
+	ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (ResourceContent, error)
 	NotifyStateChange(state interface{})
 }
 
+// ResourceTemplate defines the interface for MCP resources addressed by an RFC 6570 URI template
+type ResourceTemplate interface {
+	GetURITemplate() string
+	GetName() string
+	GetMimeType() string
+	GetDescription() string
+	GetVariables() []ResourceTemplateVariable
+	ReadWithArguments(ctx context.Context, uri string, arguments map[string]any) (ResourceContent, error)
+}
+
+// ResourceTemplateVariable declares the type of a variable in a resource URI template
+type ResourceTemplateVariable struct {
+	Name string
+	Type string // "string" or "integer"
+}
+
+// ToolRegistrar adds tools to and removes tools from the MCP server while it runs
+type ToolRegistrar interface {
+	RegisterTool(tool Tool) error
+	UnregisterTool(name string)
+}
+
+// ResourceNotifier delivers resource change notifications to subscribed MCP clients
+type ResourceNotifier interface {
+	NotifyResourceUpdated(uri string)
+}
+
 // ResourceContent represents the content of an MCP resource
 type ResourceContent struct {
 	Contents []ResourceItem `json:"contents"`
@@ -116,7 +184,8 @@ type ResourceContent struct {
 type ResourceItem struct {
 	URI      string `json:"uri"`
 	MimeType string `json:"mimeType"`
-	Text     string `json:"text"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"` // Base64-encoded binary content, used instead of Text
 }
 
 // Tool defines the interface for MCP tools
@@ -124,7 +193,22 @@ type Tool interface {
 	GetName() string
 	GetDescription() string
 	GetInputSchema() interface{}
-	Execute(args map[string]interface{}) (ToolResult, error)
+	Execute(ctx context.Context, args map[string]interface{}) (ToolResult, error)
+}
+
+// Output formats of tools and resources
+const (
+	OutputFormatMarkdown = "markdown" // Human-readable text, the default
+	OutputFormatJSON     = "json"     // Typed result serialized as JSON
+)
+
+// MimeTypeJSON is the MIME type of JSON resource variants
+const MimeTypeJSON = "application/json"
+
+// OutputSchemaProvider is implemented by tools and resources that can return
+// typed JSON results. The schema describes the JSON output format.
+type OutputSchemaProvider interface {
+	GetOutputSchema() interface{}
 }
 
 // ToolResult represents the result of executing a tool
@@ -132,15 +216,26 @@ type ToolResult struct {
 	Content []ToolResultItem `json:"content"`
 }
 
+// Tool result item types
+const (
+	ToolResultTypeText     = "text"
+	ToolResultTypeImage    = "image"
+	ToolResultTypeResource = "resource"
+)
+
 // ToolResultItem represents a single item in a tool result
 type ToolResultItem struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	Data     string        `json:"data,omitempty"`     // Base64-encoded image data for image items
+	MimeType string        `json:"mimeType,omitempty"` // MIME type of image items
+	Resource *ResourceItem `json:"resource,omitempty"` // Embedded resource for resource items
 }
 
 // McpServer defines the interface for the MCP server
 type McpServer interface {
 	RegisterResource(resource Resource) error
+	RegisterResourceTemplate(template ResourceTemplate) error
 	RegisterTool(tool Tool) error
 	Start() error
 	Shutdown() error
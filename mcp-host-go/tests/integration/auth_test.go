@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBearerTokenAuthentication(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	initializeBody := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"auth-test","version":"1.0.0"},"capabilities":{}}}`
+
+	post := func(t *testing.T, authorization string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, testEnv.GetStreamableHTTPURL(), strings.NewReader(initializeBody))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("rejects missing token", func(t *testing.T) {
+		resp := post(t, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Bearer")
+	})
+
+	t.Run("rejects wrong token", func(t *testing.T) {
+		resp := post(t, "Bearer not-the-token")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("rejects SSE stream without token", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, testEnv.GetSSEURL(), nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("accepts valid token", func(t *testing.T) {
+		resp := post(t, "Bearer "+testEnv.GetAuthToken())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("authenticated clients work", func(t *testing.T) {
+		err := testEnv.GetMcpClient().Initialize(ctx)
+		require.NoError(t, err)
+
+		tools, err := testEnv.GetMcpClient().ListTools()
+		require.NoError(t, err)
+		assert.NotEmpty(t, tools.Tools)
+	})
+}
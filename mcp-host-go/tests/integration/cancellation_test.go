@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	// Simulate a navigation that never finishes, so the call can only end through
+	// cancellation. The handler is never released: replying after Cleanup would
+	// write to a closed host.
+	started := make(chan struct{}, 1)
+	never := make(chan struct{})
+	testEnv.GetNativeMsg().RegisterRpcHandler("navigate_to", func(params map[string]interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-never
+		return map[string]interface{}{
+			"success": true,
+		}, nil
+	})
+
+	conn, err := net.Dial("unix", testEnv.GetSocketPath())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+	client.initialize(t, "cancellation-test")
+
+	callID := client.start(t, "tools/call", map[string]interface{}{
+		"name": "navigate_to",
+		"arguments": map[string]interface{}{
+			"url": "https://example.com",
+		},
+	})
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("navigate_to was not forwarded to the extension")
+	}
+
+	client.send(t, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/cancelled",
+		"params": map[string]interface{}{
+			"requestId": callID,
+			"reason":    "user aborted",
+		},
+	})
+
+	// The extension is told to abort the in-flight operation
+	select {
+	case <-testEnv.GetNativeMsg().RpcCancellations():
+	case <-time.After(5 * time.Second):
+		t.Fatal("host did not send rpc_cancel to the extension")
+	}
+
+	// The call finishes promptly instead of waiting for the navigation timeout
+	response := client.response(t, callID)
+	require.Nil(t, response["error"])
+	result := response["result"].(map[string]interface{})
+	assert.Equal(t, true, result["isError"])
+
+	content := result["content"].([]interface{})
+	require.NotEmpty(t, content)
+	assert.Contains(t, content[0].(map[string]interface{})["text"], "cancelled")
+}
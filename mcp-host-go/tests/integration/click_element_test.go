@@ -802,7 +802,7 @@ func TestClickElementToolReturnDomState(t *testing.T) {
 			assert.True(t, result.IsError, "Tool execution should result in error for invalid parameter type")
 			if len(result.Content) > 0 {
 				if textContent, ok := mcp.AsTextContent(result.Content[0]); ok {
-					assert.Contains(t, textContent.Text, "return_dom_state must be a boolean")
+					assert.Contains(t, textContent.Text, "return_dom_state: must be a boolean")
 				}
 			}
 		}
@@ -6,30 +6,55 @@ import (
 	"time"
 
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // McpSSEClient wraps the real MCP client from mark3labs/mcp-go
 type McpSSEClient struct {
-	baseURL   string
-	client    *client.Client
-	connected bool
+	baseURL    string
+	streamable bool   // Use the Streamable HTTP transport instead of SSE
+	authToken  string // Bearer token sent with every request, empty to omit
+	client     *client.Client
+	connected  bool
 }
 
-func NewMcpSSEClient(baseURL string) *McpSSEClient {
+func NewMcpSSEClient(baseURL string, authToken string) *McpSSEClient {
 	return &McpSSEClient{
 		baseURL:   baseURL,
+		authToken: authToken,
 		connected: false,
 	}
 }
 
+// NewMcpStreamableHTTPClient creates a client that talks to the Streamable HTTP endpoint
+func NewMcpStreamableHTTPClient(baseURL string, authToken string) *McpSSEClient {
+	return &McpSSEClient{
+		baseURL:    baseURL,
+		streamable: true,
+		authToken:  authToken,
+		connected:  false,
+	}
+}
+
 func (c *McpSSEClient) Connect() error {
 	if c.connected {
 		return nil
 	}
 
+	if c.streamable {
+		// Create Streamable HTTP client using mark3labs/mcp-go
+		mcpClient, err := client.NewStreamableHttpClient(c.baseURL, transport.WithHTTPHeaders(c.headers()))
+		if err != nil {
+			return fmt.Errorf("failed to create Streamable HTTP MCP client: %w", err)
+		}
+		c.client = mcpClient
+		c.connected = true
+		return nil
+	}
+
 	// Create SSE client using mark3labs/mcp-go
-	mcpClient, err := client.NewSSEMCPClient(c.baseURL)
+	mcpClient, err := client.NewSSEMCPClient(c.baseURL, transport.WithHeaders(c.headers()))
 	if err != nil {
 		return fmt.Errorf("failed to create SSE MCP client: %w", err)
 	}
@@ -39,6 +64,15 @@ func (c *McpSSEClient) Connect() error {
 	return nil
 }
 
+// headers returns the HTTP headers sent with every request
+func (c *McpSSEClient) headers() map[string]string {
+	headers := make(map[string]string)
+	if c.authToken != "" {
+		headers["Authorization"] = "Bearer " + c.authToken
+	}
+	return headers
+}
+
 func (c *McpSSEClient) Initialize(ctx context.Context) error {
 	if err := c.Connect(); err != nil {
 		return err
@@ -91,6 +125,22 @@ func (c *McpSSEClient) ListResources() (*mcp.ListResourcesResult, error) {
 	return result, nil
 }
 
+func (c *McpSSEClient) ListResourceTemplates() (*mcp.ListResourceTemplatesResult, error) {
+	if !c.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := c.client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource templates: %w", err)
+	}
+
+	return result, nil
+}
+
 func (c *McpSSEClient) ReadResource(uri string) (*mcp.ReadResourceResult, error) {
 	if !c.connected {
 		return nil, fmt.Errorf("not connected")
@@ -21,6 +21,10 @@ type McpHostTestEnvironment struct {
 	port           int
 	baseURL        string
 	logFilePath    string
+	socketPath     string
+	authToken      string
+	authTokenPath  string
+	extraEnv       []string
 	testDataDir    string
 	logMonitorStop chan struct{}
 }
@@ -29,6 +33,7 @@ type TestConfig struct {
 	Port        int
 	LogLevel    string
 	TestDataDir string
+	Env         []string // Extra environment variables for the host, e.g. "MCP_HEARTBEAT_INTERVAL=100ms"
 }
 
 func NewMcpHostTestEnvironment(config *TestConfig) (*McpHostTestEnvironment, error) {
@@ -58,11 +63,22 @@ func NewMcpHostTestEnvironment(config *TestConfig) (*McpHostTestEnvironment, err
 		return nil, fmt.Errorf("failed to create test data directory: %w", err)
 	}
 
+	// Pre-provision the bearer token so clients know it before the host starts
+	authToken := fmt.Sprintf("test-token-%d-%d", port, time.Now().UnixNano())
+	authTokenPath := filepath.Join(testDataDir, fmt.Sprintf("auth-token-%d", port))
+	if err := os.WriteFile(authTokenPath, []byte(authToken), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write auth token file: %w", err)
+	}
+
 	return &McpHostTestEnvironment{
 		port:           port,
 		baseURL:        baseURL,
 		testDataDir:    testDataDir,
 		logFilePath:    filepath.Join(testDataDir, "mcp-host.log"),
+		socketPath:     filepath.Join(testDataDir, fmt.Sprintf("mcp-host-%d.sock", port)),
+		authToken:      authToken,
+		authTokenPath:  authTokenPath,
+		extraEnv:       config.Env,
 		logMonitorStop: make(chan struct{}),
 	}, nil
 }
@@ -89,7 +105,7 @@ func (env *McpHostTestEnvironment) Setup(ctx context.Context) error {
 	}
 
 	// Create MCP client
-	env.mcpClient = NewMcpSSEClient(env.baseURL)
+	env.mcpClient = NewMcpSSEClient(env.baseURL, env.authToken)
 
 	return nil
 }
@@ -107,9 +123,12 @@ func (env *McpHostTestEnvironment) startMcpHost(ctx context.Context) error {
 		fmt.Sprintf("SSE_PORT=:%d", env.port),
 		fmt.Sprintf("SSE_BASE_URL=%s", env.baseURL),
 		fmt.Sprintf("LOG_FILE=%s", env.logFilePath),
+		fmt.Sprintf("MCP_SOCKET_PATH=%s", env.socketPath),
+		fmt.Sprintf("MCP_AUTH_TOKEN_FILE=%s", env.authTokenPath),
 		"LOG_LEVEL=debug",
 		"RUN_MODE=test",
 	)
+	environ = append(environ, env.extraEnv...)
 
 	// Start the MCP host process
 	env.hostProcess = exec.CommandContext(ctx, "../../bin/mcp-host")
@@ -138,13 +157,14 @@ func (env *McpHostTestEnvironment) startMcpHost(ctx context.Context) error {
 
 	// Store pipes for Native Messaging
 	env.nativeMsg = &NativeMessagingManager{
-		stdin:       stdin,
-		stdout:      stdout,
-		stderr:      stderr,
-		pid:         env.hostProcess.Process.Pid,
-		responses:   make(chan map[string]interface{}, 10),
-		errors:      make(chan error, 10),
-		rpcHandlers: make(map[string]RpcHandler),
+		stdin:         stdin,
+		stdout:        stdout,
+		stderr:        stderr,
+		pid:           env.hostProcess.Process.Pid,
+		responses:     make(chan map[string]interface{}, 10),
+		errors:        make(chan error, 10),
+		rpcHandlers:   make(map[string]RpcHandler),
+		cancellations: make(chan string, 10),
 	}
 
 	// Start reading messages from stdout
@@ -302,6 +322,42 @@ func (env *McpHostTestEnvironment) GetMcpClient() *McpSSEClient {
 	return env.mcpClient
 }
 
+// GetSSEURL returns the legacy SSE endpoint
+func (env *McpHostTestEnvironment) GetSSEURL() string {
+	return env.baseURL
+}
+
+// GetStreamableHTTPURL returns the Streamable HTTP endpoint served next to the SSE endpoint
+func (env *McpHostTestEnvironment) GetStreamableHTTPURL() string {
+	return fmt.Sprintf("http://localhost:%d/mcp", env.port)
+}
+
+// NewStreamableHTTPClient creates an additional MCP client using the Streamable HTTP transport.
+// The caller is responsible for closing it.
+func (env *McpHostTestEnvironment) NewStreamableHTTPClient() *McpSSEClient {
+	return NewMcpStreamableHTTPClient(env.GetStreamableHTTPURL(), env.authToken)
+}
+
+// GetAuthToken returns the bearer token the host requires on its HTTP transports
+func (env *McpHostTestEnvironment) GetAuthToken() string {
+	return env.authToken
+}
+
+// GetSocketPath returns the Unix socket used by the `mcp-host stdio` bridge
+func (env *McpHostTestEnvironment) GetSocketPath() string {
+	return env.socketPath
+}
+
+// NewStdioBridgeCommand returns an `mcp-host stdio` command connected to this host
+func (env *McpHostTestEnvironment) NewStdioBridgeCommand(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "../../bin/mcp-host", "stdio")
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("MCP_SOCKET_PATH=%s", env.socketPath),
+		fmt.Sprintf("LOG_FILE=%s", filepath.Join(env.testDataDir, "mcp-host-stdio.log")),
+	)
+	return cmd
+}
+
 func (env *McpHostTestEnvironment) GetNativeMsg() *NativeMessagingManager {
 	return env.nativeMsg
 }
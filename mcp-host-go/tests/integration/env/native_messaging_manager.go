@@ -17,6 +17,12 @@ import (
 // RpcHandler defines the signature for RPC method handlers
 type RpcHandler func(params map[string]interface{}) (interface{}, error)
 
+// ProgressReporter sends an rpc_progress message for the RPC request being handled
+type ProgressReporter func(progress, total float64, message string) error
+
+// RpcProgressHandler defines the signature for RPC method handlers that report progress
+type RpcProgressHandler func(params map[string]interface{}, reportProgress ProgressReporter) (interface{}, error)
+
 // pendingRpcRequest represents a pending RPC request
 type pendingRpcRequest struct {
 	done     chan struct{}
@@ -27,16 +33,21 @@ type pendingRpcRequest struct {
 
 // NativeMessagingManager handles communication with the MCP host process via Native Messaging protocol
 type NativeMessagingManager struct {
-	stdin           io.WriteCloser
-	stdout          io.ReadCloser
-	stderr          io.ReadCloser
-	pid             int
-	responses       chan map[string]interface{}
-	errors          chan error
-	actionHandler   func(action string, params map[string]interface{}) map[string]interface{}
-	rpcHandlers     map[string]RpcHandler // method name -> handler
-	pendingRequests map[string]*pendingRpcRequest
-	mutex           sync.Mutex
+	stdin            io.WriteCloser
+	stdout           io.ReadCloser
+	stderr           io.ReadCloser
+	pid              int
+	responses        chan map[string]interface{}
+	errors           chan error
+	actionHandler    func(action string, params map[string]interface{}) map[string]interface{}
+	rpcHandlers      map[string]RpcHandler         // method name -> handler
+	progressHandlers map[string]RpcProgressHandler // method name -> handler that reports progress
+	pendingRequests  map[string]*pendingRpcRequest
+	cancellations    chan string // IDs of RPC requests cancelled by the host via rpc_cancel
+	ignorePings      bool        // Stop answering heartbeats, as a hung extension would
+	stdinClosed      bool
+	mutex            sync.Mutex
+	writeMutex       sync.Mutex
 }
 
 func (nm *NativeMessagingManager) SendMessage(ctx context.Context, message map[string]interface{}) error {
@@ -46,6 +57,10 @@ func (nm *NativeMessagingManager) SendMessage(ctx context.Context, message map[s
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
+	// Responses are sent from concurrent handlers, so keep prefix and data together
+	nm.writeMutex.Lock()
+	defer nm.writeMutex.Unlock()
+
 	// Write length prefix (4 bytes, little endian)
 	length := uint32(len(jsonData))
 	if err := binary.Write(nm.stdin, binary.LittleEndian, length); err != nil {
@@ -137,6 +152,14 @@ func (nm *NativeMessagingManager) RegisterRpcHandlers(handlers map[string]RpcHan
 	}
 }
 
+// RegisterRpcProgressHandler registers an RPC method handler that reports progress while it runs
+func (nm *NativeMessagingManager) RegisterRpcProgressHandler(method string, handler RpcProgressHandler) {
+	if nm.progressHandlers == nil {
+		nm.progressHandlers = make(map[string]RpcProgressHandler)
+	}
+	nm.progressHandlers[method] = handler
+}
+
 // UnregisterRpcHandler removes an RPC method handler
 func (nm *NativeMessagingManager) UnregisterRpcHandler(method string) {
 	if nm.rpcHandlers != nil {
@@ -191,6 +214,16 @@ func (nm *NativeMessagingManager) startMessageReader(ctx context.Context) {
 				continue
 			}
 
+			// Handle RPC cancellations
+			if nm.handleRpcCancel(message) {
+				continue
+			}
+
+			// Handle heartbeats
+			if nm.handlePing(ctx, message) {
+				continue
+			}
+
 			// Handle action messages
 			if actionType, ok := message["action"].(string); ok && nm.actionHandler != nil {
 				params, _ := message["params"].(map[string]interface{})
@@ -264,6 +297,21 @@ func (nm *NativeMessagingManager) handleRpcRequest(ctx context.Context, message
 
 	// Look up the handler
 	handler, exists := nm.rpcHandlers[method]
+	if progressHandler, ok := nm.progressHandlers[method]; ok {
+		handler, exists = func(params map[string]interface{}) (interface{}, error) {
+			return progressHandler(params, func(progress, total float64, message string) error {
+				return nm.SendMessage(ctx, map[string]interface{}{
+					"type": "rpc_progress",
+					"id":   id,
+					"data": map[string]interface{}{
+						"progress": progress,
+						"total":    total,
+						"message":  message,
+					},
+				})
+			})
+		}, true
+	}
 	if !exists {
 		// Send error response for unknown method
 		nm.sendRpcResponse(ctx, id, nil, fmt.Errorf("unknown RPC method: %s", method))
@@ -276,14 +324,72 @@ func (nm *NativeMessagingManager) handleRpcRequest(ctx context.Context, message
 		params = make(map[string]interface{})
 	}
 
-	// Execute the handler
-	result, err := handler(params)
+	// Execute the handler without blocking the reader, like the real extension,
+	// so that rpc_cancel messages can arrive while it runs
+	go func() {
+		result, err := handler(params)
+		nm.sendRpcResponse(ctx, id, result, err)
+	}()
+	return true
+}
 
-	// Send response
-	nm.sendRpcResponse(ctx, id, result, err)
+// handleRpcCancel records rpc_cancel messages and returns true if the message was handled
+func (nm *NativeMessagingManager) handleRpcCancel(message map[string]interface{}) bool {
+	msgType, hasType := message["type"].(string)
+	if !hasType || msgType != "rpc_cancel" {
+		return false
+	}
+
+	id, _ := message["id"].(string)
+	select {
+	case nm.cancellations <- id:
+	default:
+	}
 	return true
 }
 
+// handlePing answers host heartbeats like the extension and returns true if the message was a ping
+func (nm *NativeMessagingManager) handlePing(ctx context.Context, message map[string]interface{}) bool {
+	msgType, hasType := message["type"].(string)
+	if !hasType || msgType != "ping" {
+		return false
+	}
+
+	nm.mutex.Lock()
+	ignore := nm.ignorePings
+	nm.mutex.Unlock()
+	if ignore {
+		return true
+	}
+
+	go func() {
+		if err := nm.SendMessage(ctx, map[string]interface{}{"type": "pong", "id": message["id"]}); err != nil {
+			nm.errors <- fmt.Errorf("failed to send pong: %w", err)
+		}
+	}()
+	return true
+}
+
+// SetIgnorePings stops or resumes answering host heartbeats
+func (nm *NativeMessagingManager) SetIgnorePings(ignore bool) {
+	nm.mutex.Lock()
+	defer nm.mutex.Unlock()
+	nm.ignorePings = ignore
+}
+
+// CloseStdin closes the host's stdin, as Chrome does when the extension disconnects
+func (nm *NativeMessagingManager) CloseStdin() error {
+	nm.writeMutex.Lock()
+	defer nm.writeMutex.Unlock()
+	nm.stdinClosed = true
+	return nm.stdin.Close()
+}
+
+// RpcCancellations returns the IDs of RPC requests the host has cancelled
+func (nm *NativeMessagingManager) RpcCancellations() <-chan string {
+	return nm.cancellations
+}
+
 // sendRpcResponse sends an RPC response back to the MCP host
 func (nm *NativeMessagingManager) sendRpcResponse(ctx context.Context, id interface{}, result interface{}, err error) {
 	response := map[string]interface{}{
@@ -310,7 +416,7 @@ func (nm *NativeMessagingManager) sendRpcResponse(ctx context.Context, id interf
 func (nm *NativeMessagingManager) Close() error {
 	var errors []error
 
-	if nm.stdin != nil {
+	if nm.stdin != nil && !nm.stdinClosed {
 		if err := nm.stdin.Close(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to close stdin: %w", err))
 		}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensionConnectionTracking(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(&env.TestConfig{
+		Env: []string{"MCP_HEARTBEAT_INTERVAL=100ms", "MCP_HEARTBEAT_TIMEOUT=500ms"},
+	})
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	nativeMsg := testEnv.GetNativeMsg()
+
+	// navigate_to never finishes, so only a disconnect can end it
+	navigateStarted := make(chan struct{}, 1)
+	never := make(chan struct{})
+	nativeMsg.RegisterRpcHandler("navigate_to", func(params map[string]interface{}) (interface{}, error) {
+		navigateStarted <- struct{}{}
+		<-never
+		return map[string]interface{}{"success": true}, nil
+	})
+
+	conn, err := net.Dial("unix", testEnv.GetSocketPath())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+	client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+	client.initialize(t, "connection-test")
+
+	readHostStatus := func() map[string]interface{} {
+		response := client.request(t, "resources/read", map[string]interface{}{"uri": "browser://host/status"})
+		require.Nil(t, response["error"])
+		contents := response["result"].(map[string]interface{})["contents"].([]interface{})
+		require.Len(t, contents, 1)
+
+		var status map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(contents[0].(map[string]interface{})["text"].(string)), &status))
+		return status
+	}
+
+	// waitForHostStatusUpdate skips other messages until the host status changes
+	waitForHostStatusUpdate := func() {
+		for {
+			message := client.next(t)
+			if message["method"] != "notifications/resources/updated" {
+				continue
+			}
+			params := message["params"].(map[string]interface{})
+			if params["uri"] == "browser://host/status" {
+				return
+			}
+		}
+	}
+
+	// The extension answers heartbeats, so the host sees it as connected
+	require.Eventually(t, func() bool {
+		status := readHostStatus()
+		return status["isConnected"] == true && status["lastPing"].(float64) > 0
+	}, 5*time.Second, 100*time.Millisecond)
+	hostStatus := readHostStatus()
+	assert.Equal(t, "ai.algonius.mcp.host", hostStatus["name"])
+	queue := hostStatus["queue"].(map[string]interface{})
+	assert.Equal(t, float64(8), queue["workers"])
+	assert.Equal(t, float64(0), queue["rejected"])
+
+	// The status RPC reports it too
+	response, err := nativeMsg.RpcRequest(ctx, "status", nil)
+	require.NoError(t, err)
+	statusResult := response["result"].(map[string]interface{})
+	assert.Equal(t, true, statusResult["is_connected"])
+	assert.NotZero(t, statusResult["last_ping"])
+
+	response = client.request(t, "resources/subscribe", map[string]interface{}{"uri": "browser://host/status"})
+	require.Nil(t, response["error"])
+
+	// A hung extension is detected by the heartbeat and tool calls fail fast
+	nativeMsg.SetIgnorePings(true)
+	waitForHostStatusUpdate()
+	assert.Equal(t, false, readHostStatus()["isConnected"])
+
+	response = client.request(t, "tools/call", map[string]interface{}{
+		"name":      "click_element",
+		"arguments": map[string]interface{}{"element_index": 1},
+	})
+	require.Nil(t, response["error"])
+	result := response["result"].(map[string]interface{})
+	assert.Equal(t, true, result["isError"])
+	assert.Contains(t, result["content"].([]interface{})[0].(map[string]interface{})["text"], "EXTENSION_DISCONNECTED")
+	errorMeta := result["_meta"].(map[string]interface{})["algonius/error"].(map[string]interface{})
+	assert.Equal(t, "EXTENSION_DISCONNECTED", errorMeta["code"])
+
+	// Answering again restores the connection
+	nativeMsg.SetIgnorePings(false)
+	waitForHostStatusUpdate()
+	assert.Equal(t, true, readHostStatus()["isConnected"])
+
+	// Closing stdin fails the pending call at once instead of at its timeout
+	callID := client.start(t, "tools/call", map[string]interface{}{
+		"name":      "navigate_to",
+		"arguments": map[string]interface{}{"url": "https://example.com", "timeout": "60000"},
+	})
+	select {
+	case <-navigateStarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("navigate_to was not forwarded to the extension")
+	}
+
+	start := time.Now()
+	require.NoError(t, nativeMsg.CloseStdin())
+
+	response = client.response(t, callID)
+	require.Nil(t, response["error"])
+	result = response["result"].(map[string]interface{})
+	assert.Equal(t, true, result["isError"])
+	assert.Contains(t, result["content"].([]interface{})[0].(map[string]interface{})["text"], "EXTENSION_DISCONNECTED")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	assert.Equal(t, false, readHostStatus()["isConnected"])
+
+	response = client.request(t, "resources/read", map[string]interface{}{"uri": "browser://dom/state"})
+	require.NotNil(t, response["error"])
+	assert.Contains(t, response["error"].(map[string]interface{})["message"], "EXTENSION_DISCONNECTED")
+}
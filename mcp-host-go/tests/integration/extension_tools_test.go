@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"env"
+)
+
+func TestExtensionAdvertisedTools(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	mcpClient := testEnv.GetMcpClient()
+	err = mcpClient.Initialize(ctx)
+	require.NoError(t, err)
+
+	nativeMsg := testEnv.GetNativeMsg()
+
+	var capturedParams []map[string]interface{}
+	nativeMsg.RegisterRpcHandler("read_article", func(params map[string]interface{}) (interface{}, error) {
+		capturedParams = append(capturedParams, params)
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "text", "text": "# Example article"},
+			},
+		}, nil
+	})
+
+	listedTools := func() map[string]mcp.Tool {
+		result, err := mcpClient.ListTools()
+		require.NoError(t, err)
+		tools := make(map[string]mcp.Tool)
+		for _, tool := range result.Tools {
+			tools[tool.Name] = tool
+		}
+		return tools
+	}
+
+	announce := func(tools []map[string]interface{}) {
+		response, err := nativeMsg.RpcRequest(ctx, "init", map[string]interface{}{
+			"runMode":          "test",
+			"extensionVersion": "1.0.0",
+			"protocolVersion":  2,
+			"methods": []string{
+				"navigate_to", "get_browser_state", "get_dom_state", "click_element",
+				"type_value", "scroll_page", "manage_tabs", "take_screenshot",
+			},
+			"tools": tools,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "initialized", response["result"].(map[string]interface{})["status"])
+	}
+
+	require.NotContains(t, listedTools(), "read_article")
+
+	announce([]map[string]interface{}{
+		{
+			"name":        "read_article",
+			"description": "Extract the main article of the page",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"max_length": map[string]interface{}{"type": "integer", "minimum": 1},
+				},
+				"additionalProperties": false,
+			},
+			"readOnly": true,
+		},
+		{
+			// Built-in tools cannot be replaced by the extension
+			"name":        "click_element",
+			"description": "Shadowed click",
+		},
+	})
+
+	t.Run("advertised tool is listed", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			_, ok := listedTools()["read_article"]
+			return ok
+		}, 10*time.Second, 100*time.Millisecond)
+
+		tools := listedTools()
+		assert.Equal(t, "Extract the main article of the page", tools["read_article"].Description)
+		assert.NotEqual(t, "Shadowed click", tools["click_element"].Description)
+	})
+
+	t.Run("calls are forwarded to the extension", func(t *testing.T) {
+		capturedParams = nil
+		result, err := mcpClient.CallTool("read_article", map[string]interface{}{"max_length": 500})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Equal(t, "# Example article", text.Text)
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, float64(500), capturedParams[0]["max_length"])
+	})
+
+	t.Run("arguments are validated against the advertised schema", func(t *testing.T) {
+		result, err := mcpClient.CallTool("read_article", map[string]interface{}{"max_length": 0})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("tool is removed when the extension stops advertising it", func(t *testing.T) {
+		announce(nil)
+
+		require.Eventually(t, func() bool {
+			_, ok := listedTools()["read_article"]
+			return !ok
+		}, 10*time.Second, 100*time.Millisecond)
+		assert.Contains(t, listedTools(), "click_element")
+	})
+}
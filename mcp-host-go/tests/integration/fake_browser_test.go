@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeBrowserTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	script, err := filepath.Abs("../../pkg/fakebrowser/testdata/shop.yaml")
+	require.NoError(t, err)
+
+	testEnv, err := env.NewMcpHostTestEnvironment(&env.TestConfig{
+		Env: []string{"MCP_EXTENSION_TRANSPORT=fake", "MCP_FAKE_BROWSER_SCRIPT=" + script},
+	})
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	require.NoError(t, testEnv.Setup(ctx))
+	client := testEnv.GetMcpClient()
+	require.NoError(t, client.Initialize(ctx))
+
+	readText := func(uri string) string {
+		result, err := client.ReadResource(uri)
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+		content, ok := result.Contents[0].(mcp.TextResourceContents)
+		require.True(t, ok, "Expected TextResourceContents")
+		return content.Text
+	}
+	callTool := func(name string, arguments map[string]interface{}) *mcp.CallToolResult {
+		result, err := client.CallTool(name, arguments)
+		require.NoError(t, err)
+		return result
+	}
+
+	// No extension connects, yet the scripted pages are served through the usual tools
+	assert.Contains(t, readText("browser://current/state"), "https://shop.example/")
+
+	result := callTool("type_value", map[string]interface{}{"element_index": 0, "value": "lamp{Enter}"})
+	require.False(t, result.IsError, "type_value failed: %+v", result.Content)
+	assert.Contains(t, readText("browser://dom/state"), "Desk lamp")
+
+	result = callTool("click_element", map[string]interface{}{"element_index": 1, "wait_after": 0})
+	assert.True(t, result.IsError, "Scripted click errors should fail the tool")
+
+	result = callTool("click_element", map[string]interface{}{"element_index": 0, "wait_after": 0})
+	require.False(t, result.IsError, "click_element failed: %+v", result.Content)
+	assert.Contains(t, readText("browser://dom/state"), "Add to cart")
+
+	result = callTool("navigate_history", map[string]interface{}{"action": "back"})
+	require.False(t, result.IsError, "navigate_history failed: %+v", result.Content)
+	assert.Contains(t, readText("browser://dom/state"), "Floor lamp")
+
+	result = callTool("navigate_to", map[string]interface{}{"url": "https://unscripted.example/"})
+	assert.True(t, result.IsError, "Navigating to an unscripted page should fail")
+}
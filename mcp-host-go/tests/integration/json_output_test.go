@@ -0,0 +1,223 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"env"
+)
+
+// readJSONResource reads a resource and decodes its JSON text into target
+func readJSONResource(t *testing.T, client *env.McpSSEClient, uri string, mimeType string, target interface{}) {
+	t.Helper()
+
+	result, err := client.ReadResource(uri)
+	require.NoError(t, err)
+	require.Len(t, result.Contents, 1)
+
+	content, ok := result.Contents[0].(mcp.TextResourceContents)
+	require.True(t, ok, "expected text resource contents, got %T", result.Contents[0])
+	assert.Equal(t, uri, content.URI)
+	assert.Equal(t, mimeType, content.MIMEType)
+	require.NoError(t, json.Unmarshal([]byte(content.Text), target), content.Text)
+}
+
+func TestJSONOutputFormat(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	elements := make([]map[string]interface{}, 25)
+	for i := range elements {
+		elements[i] = map[string]interface{}{"index": i, "tagName": "button", "text": "Button", "isInViewport": true}
+	}
+
+	testEnv.GetNativeMsg().RegisterRpcHandlers(map[string]env.RpcHandler{
+		"get_dom_state": func(params map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{
+				"formattedDom":        "[Start of page]\n<button>0</button> Button\n[End of page]",
+				"interactiveElements": elements,
+				"meta":                map[string]interface{}{"url": "https://example.com", "title": "Example", "tabId": params["tab_id"]},
+			}, nil
+		},
+		"get_browser_state": func(params map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{
+				"activeTab": map[string]interface{}{"id": 123},
+				"tabs": []map[string]interface{}{
+					{"id": 123, "url": "https://example.com", "title": "Example", "active": true},
+				},
+			}, nil
+		},
+		"click_element": func(params map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{
+				"success":      true,
+				"message":      "Successfully clicked element",
+				"page_changed": true,
+				"element_info": map[string]interface{}{"tag_name": "button", "text": "Submit"},
+			}, nil
+		},
+		"take_screenshot": func(params map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{
+				"data":     onePixelPNG,
+				"mimeType": "image/png",
+				"mode":     params["mode"],
+				"url":      "https://example.com",
+				"title":    "Example",
+			}, nil
+		},
+	})
+
+	client := testEnv.GetMcpClient()
+
+	t.Run("tool returns a typed result", func(t *testing.T) {
+		result, err := client.CallTool("click_element", map[string]interface{}{
+			"element_index":    3,
+			"return_dom_state": true,
+			"format":           "json",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		require.Len(t, result.Content, 1)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+
+		var click struct {
+			Message      string                 `json:"message"`
+			ElementIndex int                    `json:"elementIndex"`
+			PageChanged  bool                   `json:"pageChanged"`
+			ElementInfo  map[string]interface{} `json:"elementInfo"`
+			DomState     struct {
+				TotalElements    int                      `json:"totalElements"`
+				HasMoreElements  bool                     `json:"hasMoreElements"`
+				OverviewElements []map[string]interface{} `json:"overviewElements"`
+			} `json:"domState"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &click), text.Text)
+		assert.Equal(t, "Successfully clicked element", click.Message)
+		assert.Equal(t, 3, click.ElementIndex)
+		assert.True(t, click.PageChanged)
+		assert.Equal(t, "Submit", click.ElementInfo["text"])
+		assert.Equal(t, 25, click.DomState.TotalElements)
+		assert.True(t, click.DomState.HasMoreElements)
+		assert.Len(t, click.DomState.OverviewElements, 20)
+	})
+
+	t.Run("markdown stays the default", func(t *testing.T) {
+		result, err := client.CallTool("click_element", map[string]interface{}{"element_index": 3})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, text.Text, "Click Element Result:")
+	})
+
+	t.Run("screenshot keeps the image next to the typed result", func(t *testing.T) {
+		result, err := client.CallTool("take_screenshot", map[string]interface{}{"format": "json"})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		require.Len(t, result.Content, 2)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		var screenshot map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &screenshot), text.Text)
+		assert.Equal(t, "viewport", screenshot["mode"])
+		assert.Equal(t, "image/png", screenshot["mimeType"])
+
+		_, ok = mcp.AsImageContent(result.Content[1])
+		assert.True(t, ok)
+	})
+
+	t.Run("JSON resource variants", func(t *testing.T) {
+		var domState struct {
+			TotalElements    int                      `json:"totalElements"`
+			OverviewLimit    int                      `json:"overviewLimit"`
+			OverviewElements []map[string]interface{} `json:"overviewElements"`
+			Meta             map[string]interface{}   `json:"meta"`
+		}
+		readJSONResource(t, client, "browser://dom/state.json", "application/json", &domState)
+		assert.Equal(t, 25, domState.TotalElements)
+		assert.Equal(t, 20, domState.OverviewLimit)
+		assert.Len(t, domState.OverviewElements, 20)
+		assert.Equal(t, "Example", domState.Meta["title"])
+
+		var tabDomState struct {
+			Meta map[string]interface{} `json:"meta"`
+		}
+		readJSONResource(t, client, "browser://tab/42/dom/state.json", "application/json", &tabDomState)
+		assert.Equal(t, float64(42), tabDomState.Meta["tabId"])
+
+		var browserState struct {
+			Tabs []map[string]interface{} `json:"tabs"`
+		}
+		readJSONResource(t, client, "browser://current/state.json", "application/json", &browserState)
+		require.Len(t, browserState.Tabs, 1)
+		assert.Equal(t, "Example", browserState.Tabs[0]["title"])
+	})
+
+	t.Run("output schemas are published", func(t *testing.T) {
+		templates, err := client.ListResourceTemplates()
+		require.NoError(t, err)
+		found := false
+		for _, template := range templates.ResourceTemplates {
+			if template.URITemplate.Raw() == "browser://schema/{name}" {
+				found = true
+			}
+		}
+		assert.True(t, found, "output schema template should be listed")
+
+		for _, name := range []string{
+			"click_element", "type_value", "navigate_to", "scroll_page",
+			"manage_tabs", "get_dom_extra_elements", "take_screenshot",
+			"dom_state", "current_state",
+		} {
+			var schema struct {
+				Type       string                 `json:"type"`
+				Properties map[string]interface{} `json:"properties"`
+				Required   []string               `json:"required"`
+			}
+			readJSONResource(t, client, "browser://schema/"+name, "application/schema+json", &schema)
+			assert.Equal(t, "object", schema.Type, name)
+			assert.NotEmpty(t, schema.Properties, name)
+		}
+
+		var clickSchema struct {
+			Properties map[string]interface{} `json:"properties"`
+			Required   []string               `json:"required"`
+		}
+		readJSONResource(t, client, "browser://schema/click_element", "application/schema+json", &clickSchema)
+		assert.Contains(t, clickSchema.Properties, "domState")
+		assert.Contains(t, clickSchema.Required, "elementIndex")
+		assert.NotContains(t, clickSchema.Required, "domState")
+
+		_, err = client.ReadResource("browser://schema/unknown")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown output schema")
+	})
+
+	t.Run("invalid format is rejected", func(t *testing.T) {
+		result, err := client.CallTool("click_element", map[string]interface{}{
+			"element_index": 3,
+			"format":        "xml",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
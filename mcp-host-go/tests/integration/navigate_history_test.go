@@ -0,0 +1,158 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNavigateHistoryTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	var capturedParams []map[string]interface{}
+	testEnv.GetNativeMsg().RegisterRpcHandler("navigate_history", func(params map[string]interface{}) (interface{}, error) {
+		capturedParams = append(capturedParams, params)
+		if params["action"] == "forward" {
+			return nil, fmt.Errorf("Cannot go forward: no page in the tab's history")
+		}
+		return map[string]interface{}{
+			"success": true,
+			"action":  params["action"],
+			"url":     "https://example.com/previous",
+			"title":   "Previous",
+		}, nil
+	})
+	testEnv.GetNativeMsg().RegisterRpcHandler("get_dom_state", func(params map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"formattedDom":        "[0]<a>Next page</a>",
+			"interactiveElements": []interface{}{},
+			"meta": map[string]interface{}{
+				"url":   "https://example.com/previous",
+				"title": "Previous",
+			},
+		}, nil
+	})
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	t.Run("back with default timeout", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("navigate_history", map[string]interface{}{
+			"action": "back",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "navigate_history failed: %+v", result.Content)
+		require.Len(t, result.Content, 1)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Equal(t, "Successfully went back to https://example.com/previous (strategy: auto)", text.Text)
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, map[string]interface{}{"action": "back", "timeout": "auto"}, capturedParams[0])
+	})
+
+	t.Run("hard reload with DOM state", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("navigate_history", map[string]interface{}{
+			"action":           "hard_reload",
+			"timeout":          "5000",
+			"return_dom_state": true,
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "navigate_history failed: %+v", result.Content)
+		require.Len(t, result.Content, 2)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, text.Text, "bypassing the cache (strategy: 5000)")
+		domText, ok := mcp.AsTextContent(result.Content[1])
+		require.True(t, ok)
+		assert.Contains(t, domText.Text, "Next page")
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, "5000", capturedParams[0]["timeout"])
+	})
+
+	t.Run("json format", func(t *testing.T) {
+		result, err := testEnv.GetMcpClient().CallTool("navigate_history", map[string]interface{}{
+			"action": "reload",
+			"format": "json",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "navigate_history failed: %+v", result.Content)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		var output map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &output))
+		assert.Equal(t, "reload", output["action"])
+		assert.Equal(t, "https://example.com/previous", output["url"])
+		assert.Equal(t, "auto", output["strategy"])
+	})
+
+	t.Run("nothing to go forward to", func(t *testing.T) {
+		result, err := testEnv.GetMcpClient().CallTool("navigate_history", map[string]interface{}{
+			"action": "forward",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, text.Text, "Cannot go forward")
+	})
+
+	errorCases := []struct {
+		name          string
+		arguments     map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:          "action is required",
+			arguments:     map[string]interface{}{},
+			expectedError: "action",
+		},
+		{
+			name:          "unknown action",
+			arguments:     map[string]interface{}{"action": "home"},
+			expectedError: "action",
+		},
+		{
+			name:          "timeout out of range",
+			arguments:     map[string]interface{}{"action": "reload", "timeout": "500"},
+			expectedError: "timeout must be between 1000 and 120000 milliseconds",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			capturedParams = nil
+			result, err := testEnv.GetMcpClient().CallTool("navigate_history", tc.arguments)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+
+			text, ok := mcp.AsTextContent(result.Content[0])
+			require.True(t, ok)
+			assert.Contains(t, text.Text, tc.expectedError)
+			assert.Empty(t, capturedParams, "invalid calls should not reach the extension")
+		})
+	}
+}
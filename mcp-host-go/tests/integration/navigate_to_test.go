@@ -68,6 +68,22 @@ func TestNavigateToToolTimeout(t *testing.T) {
 		assert.Equal(t, "auto", capturedNavigation["timeout"]) // Should default to auto
 	})
 
+	t.Run("navigate a tab by tab_id", func(t *testing.T) {
+		capturedNavigation = nil // Reset
+
+		result, err := testEnv.GetMcpClient().CallTool("navigate_to", map[string]interface{}{
+			"url":    "https://example.com",
+			"tab_id": 7,
+		})
+
+		require.NoError(t, err, "navigate_to tool should succeed")
+		require.False(t, result.IsError, "Tool should not return error")
+
+		// The extension navigates the named tab instead of the active one
+		require.NotNil(t, capturedNavigation, "Navigation should be captured")
+		assert.Equal(t, float64(7), capturedNavigation["tab_id"])
+	})
+
 	t.Run("navigate with custom timeout", func(t *testing.T) {
 		capturedNavigation = nil // Reset
 
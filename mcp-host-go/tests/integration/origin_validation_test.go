@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAndOriginValidation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	initializeBody := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"origin-test","version":"1.0.0"},"capabilities":{}}}`
+
+	post := func(t *testing.T, host, origin string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, testEnv.GetStreamableHTTPURL(), strings.NewReader(initializeBody))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		req.Header.Set("Authorization", "Bearer "+testEnv.GetAuthToken())
+		if host != "" {
+			req.Host = host
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	tests := []struct {
+		name           string
+		host           string
+		origin         string
+		expectedStatus int
+	}{
+		{"no origin", "", "", http.StatusOK},
+		{"localhost origin", "", "http://localhost:3000", http.StatusOK},
+		{"loopback host", "127.0.0.1", "http://127.0.0.1", http.StatusOK},
+		{"rebound host", "attacker.example.com", "", http.StatusForbidden},
+		{"rebound host with port", "attacker.example.com:9333", "", http.StatusForbidden},
+		{"foreign origin", "", "http://attacker.example.com", http.StatusForbidden},
+		{"opaque origin", "", "null", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, tt.host, tt.origin)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode,
+				fmt.Sprintf("host=%q origin=%q", tt.host, tt.origin))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressNotifications(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	// The extension types in chunks and reports each one, including a stale update
+	testEnv.GetNativeMsg().RegisterRpcProgressHandler("type_value", func(params map[string]interface{}, reportProgress env.ProgressReporter) (interface{}, error) {
+		for _, typed := range []float64{80, 160, 120, 240} {
+			if err := reportProgress(typed, 240, fmt.Sprintf("Typed %.0f of 240 characters", typed)); err != nil {
+				return nil, err
+			}
+		}
+		// Give the host time to relay the updates before the response ends the call
+		time.Sleep(300 * time.Millisecond)
+		return map[string]interface{}{
+			"success":      true,
+			"message":      "Successfully typed value",
+			"element_type": "textarea",
+			"input_method": "text",
+		}, nil
+	})
+
+	conn, err := net.Dial("unix", testEnv.GetSocketPath())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+	client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+	client.initialize(t, "progress-test")
+
+	callTypeValue := func(meta map[string]interface{}) int {
+		params := map[string]interface{}{
+			"name": "type_value",
+			"arguments": map[string]interface{}{
+				"element_index": 2,
+				"value":         "long text",
+			},
+		}
+		if meta != nil {
+			params["_meta"] = meta
+		}
+		return client.start(t, "tools/call", params)
+	}
+
+	t.Run("progress is relayed with the client's token", func(t *testing.T) {
+		id := callTypeValue(map[string]interface{}{"progressToken": "typing-1"})
+
+		var notifications []map[string]interface{}
+		for {
+			message := client.next(t)
+			if message["method"] == "notifications/progress" {
+				notifications = append(notifications, message["params"].(map[string]interface{}))
+				continue
+			}
+			if responseID, ok := message["id"].(float64); ok && int(responseID) == id {
+				require.Nil(t, message["error"])
+				result := message["result"].(map[string]interface{})
+				assert.NotEqual(t, true, result["isError"])
+				break
+			}
+		}
+
+		require.NotEmpty(t, notifications, "expected progress notifications")
+		last := -1.0
+		for _, params := range notifications {
+			assert.Equal(t, "typing-1", params["progressToken"])
+			assert.Equal(t, float64(240), params["total"])
+			progress := params["progress"].(float64)
+			assert.Greater(t, progress, last, "progress must increase")
+			last = progress
+		}
+		assert.Equal(t, float64(240), last)
+		assert.Equal(t, "Typed 240 of 240 characters", notifications[len(notifications)-1]["message"])
+	})
+
+	t.Run("no progress without a token", func(t *testing.T) {
+		id := callTypeValue(nil)
+		for {
+			message := client.next(t)
+			require.NotEqual(t, "notifications/progress", message["method"], "unexpected progress: %v", message)
+			if responseID, ok := message["id"].(float64); ok && int(responseID) == id {
+				break
+			}
+		}
+	})
+}
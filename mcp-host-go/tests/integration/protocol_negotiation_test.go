@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolNegotiation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	nativeMsg := testEnv.GetNativeMsg()
+
+	conn, err := net.Dial("unix", testEnv.GetSocketPath())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+	client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+	client.initialize(t, "negotiation-test")
+
+	listTools := func() map[string]bool {
+		response := client.request(t, "tools/list", map[string]interface{}{})
+		require.Nil(t, response["error"])
+		names := map[string]bool{}
+		for _, item := range response["result"].(map[string]interface{})["tools"].([]interface{}) {
+			names[item.(map[string]interface{})["name"].(string)] = true
+		}
+		return names
+	}
+
+	// waitForToolListChange skips other messages until the tool list changes
+	waitForToolListChange := func() {
+		for {
+			if client.next(t)["method"] == "notifications/tools/list_changed" {
+				return
+			}
+		}
+	}
+
+	callTool := func(name string, args map[string]interface{}) map[string]interface{} {
+		response := client.request(t, "tools/call", map[string]interface{}{"name": name, "arguments": args})
+		require.Nil(t, response["error"])
+		return response["result"].(map[string]interface{})
+	}
+
+	errorCode := func(result map[string]interface{}) interface{} {
+		meta, _ := result["_meta"].(map[string]interface{})
+		errorMeta, _ := meta["algonius/error"].(map[string]interface{})
+		return errorMeta["code"]
+	}
+
+	// Before the handshake every tool is offered
+	tools := listTools()
+	assert.True(t, tools["click_element"])
+	assert.True(t, tools["take_screenshot"])
+
+	// An extension without screenshots announces what it implements
+	response, err := nativeMsg.RpcRequest(ctx, "init", map[string]interface{}{
+		"runMode":          "test",
+		"extensionVersion": "0.9.0",
+		"protocolVersion":  2,
+		"methods": []string{
+			"navigate_to", "get_browser_state", "get_dom_state", "click_element",
+			"type_value", "scroll_page", "manage_tabs",
+		},
+	})
+	require.NoError(t, err)
+	result := response["result"].(map[string]interface{})
+	assert.Equal(t, "initialized", result["status"])
+	assert.Equal(t, float64(2), result["protocolVersion"])
+	assert.ElementsMatch(t, []interface{}{"init", "shutdown", "status"}, result["methods"])
+
+	waitForToolListChange()
+	tools = listTools()
+	assert.True(t, tools["click_element"])
+	assert.True(t, tools["get_dom_extra_elements"])
+	assert.False(t, tools["take_screenshot"], "take_screenshot should be hidden")
+
+	// Calling the hidden tool anyway explains why it is unavailable
+	toolResult := callTool("take_screenshot", map[string]interface{}{})
+	assert.Equal(t, true, toolResult["isError"])
+	assert.Contains(t, toolResult["content"].([]interface{})[0].(map[string]interface{})["text"], "take_screenshot (extension 0.9.0)")
+	assert.Equal(t, "METHOD_UNSUPPORTED", errorCode(toolResult))
+
+	// The host status reports the extension
+	statusResponse := client.request(t, "resources/read", map[string]interface{}{"uri": "browser://host/status"})
+	require.Nil(t, statusResponse["error"])
+	var status map[string]interface{}
+	contents := statusResponse["result"].(map[string]interface{})["contents"].([]interface{})
+	require.NoError(t, json.Unmarshal([]byte(contents[0].(map[string]interface{})["text"].(string)), &status))
+	extension := status["extension"].(map[string]interface{})
+	assert.Equal(t, "0.9.0", extension["version"])
+	assert.Equal(t, true, extension["compatible"])
+
+	// An extension from before the handshake is told to update, and tool calls say so too
+	response, err = nativeMsg.RpcRequest(ctx, "init", map[string]interface{}{"runMode": "test"})
+	require.NoError(t, err)
+	result = response["result"].(map[string]interface{})
+	assert.Equal(t, "incompatible", result["status"])
+	assert.Contains(t, result["message"], "Update the browser extension")
+
+	waitForToolListChange()
+	assert.True(t, listTools()["take_screenshot"], "tools stay listed for an incompatible extension")
+
+	toolResult = callTool("click_element", map[string]interface{}{"element_index": 0})
+	assert.Equal(t, true, toolResult["isError"])
+	assert.Contains(t, toolResult["content"].([]interface{})[0].(map[string]interface{})["text"], "Update the browser extension")
+	assert.Equal(t, "EXTENSION_INCOMPATIBLE", errorCode(toolResult))
+}
@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	conn, err := net.Dial("unix", testEnv.GetSocketPath())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+	client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+	client.initialize(t, "subscription-test")
+
+	sendStateChange := func(event string) {
+		err := testEnv.GetNativeMsg().SendMessage(ctx, map[string]interface{}{
+			"type": "browser_state_changed",
+			"data": map[string]interface{}{
+				"event": event,
+				"tabId": 1,
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	response := client.request(t, "resources/subscribe", map[string]interface{}{
+		"uri": "browser://dom/state",
+	})
+	require.Nil(t, response["error"], "subscribe should succeed")
+	assert.NotNil(t, response["result"])
+
+	// A DOM change reaches the subscribed client
+	sendStateChange("dom_changed")
+	notification := client.next(t)
+	assert.Equal(t, "notifications/resources/updated", notification["method"])
+	params := notification["params"].(map[string]interface{})
+	assert.Equal(t, "browser://dom/state", params["uri"])
+
+	// Changes to resources the client did not subscribe to are not sent
+	sendStateChange("tab_created")
+
+	response = client.request(t, "resources/unsubscribe", map[string]interface{}{
+		"uri": "browser://dom/state",
+	})
+	require.Nil(t, response["error"], "unsubscribe should succeed")
+
+	// After unsubscribing, no notification arrives before the ping response
+	sendStateChange("dom_changed")
+	pingID := client.start(t, "ping", nil)
+	for {
+		message := client.next(t)
+		require.NotEqual(t, "notifications/resources/updated", message["method"],
+			"unexpected notification after unsubscribe: %v", message)
+		if id, ok := message["id"].(float64); ok && int(id) == pingID {
+			break
+		}
+	}
+
+	// Resources the host does not serve cannot be subscribed to
+	response = client.request(t, "resources/subscribe", map[string]interface{}{
+		"uri": "browser://no/such/resource",
+	})
+	require.NotNil(t, response["error"], "subscribing to an unknown resource should fail")
+	assert.Equal(t, float64(-32002), response["error"].(map[string]interface{})["code"])
+
+	// Subscriptions may be batched; other requests in the batch are refused
+	client.send(t, []interface{}{
+		map[string]interface{}{"jsonrpc": "2.0", "id": 1001, "method": "resources/subscribe", "params": map[string]interface{}{"uri": "browser://dom/state"}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": 1002, "method": "ping"},
+	})
+	require.True(t, client.scanner.Scan(), "batch response should arrive")
+	var batch []map[string]interface{}
+	require.NoError(t, json.Unmarshal(client.scanner.Bytes(), &batch))
+	require.Len(t, batch, 2)
+	assert.Equal(t, float64(1001), batch[0]["id"])
+	assert.Nil(t, batch[0]["error"])
+	assert.Equal(t, float64(1002), batch[1]["id"])
+	assert.NotNil(t, batch[1]["error"])
+
+	sendStateChange("dom_changed")
+	notification = client.next(t)
+	assert.Equal(t, "notifications/resources/updated", notification["method"])
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"env"
+)
+
+func TestTabDomStateResourceTemplate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	requestedTabs := make(chan interface{}, 1)
+	testEnv.GetNativeMsg().RegisterRpcHandler("get_dom_state", func(params map[string]interface{}) (interface{}, error) {
+		requestedTabs <- params["tab_id"]
+		return map[string]interface{}{
+			"formattedDom":        "[Start of page]\n<button>1</button> Submit (button)\n[End of page]",
+			"interactiveElements": []map[string]interface{}{{"index": 1, "tagName": "button", "text": "Submit"}},
+			"meta": map[string]interface{}{
+				"url":   "https://example.com/background",
+				"title": "Background Tab",
+				"tabId": 42,
+			},
+		}, nil
+	})
+
+	t.Run("template is listed", func(t *testing.T) {
+		templates, err := testEnv.GetMcpClient().ListResourceTemplates()
+		require.NoError(t, err)
+
+		found := false
+		for _, template := range templates.ResourceTemplates {
+			if template.URITemplate.Raw() == "browser://tab/{tabId}/dom/state" {
+				found = true
+				assert.Equal(t, "Tab DOM State", template.Name)
+				assert.Equal(t, "text/markdown", template.MIMEType)
+			}
+		}
+		assert.True(t, found, "tab DOM state template should be listed")
+	})
+
+	t.Run("reads the requested tab", func(t *testing.T) {
+		result, err := testEnv.GetMcpClient().ReadResource("browser://tab/42/dom/state")
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+
+		content, ok := result.Contents[0].(mcp.TextResourceContents)
+		require.True(t, ok, "expected text resource contents, got %T", result.Contents[0])
+		assert.Equal(t, "browser://tab/42/dom/state", content.URI)
+		assert.Contains(t, content.Text, "Background Tab")
+
+		// The tab ID reaches the extension as an integer
+		assert.Equal(t, float64(42), <-requestedTabs)
+	})
+
+	t.Run("rejects a non-integer tab ID", func(t *testing.T) {
+		_, err := testEnv.GetMcpClient().ReadResource("browser://tab/abc/dom/state")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tabId must be an integer")
+	})
+
+	t.Run("fixed DOM state resource still works", func(t *testing.T) {
+		result, err := testEnv.GetMcpClient().ReadResource("browser://dom/state")
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+		assert.Nil(t, <-requestedTabs, "the fixed resource reads the current tab")
+	})
+}
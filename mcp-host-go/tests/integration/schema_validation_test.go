@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"env"
+)
+
+func TestToolInputSchemaValidation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	rpcCalls := 0
+	testEnv.GetNativeMsg().RegisterRpcHandler("type_value", func(params map[string]interface{}) (interface{}, error) {
+		rpcCalls++
+		return map[string]interface{}{"success": true}, nil
+	})
+
+	t.Run("full schema is passed to clients", func(t *testing.T) {
+		// Read tools/list as raw JSON, since the client library decodes only part of the schema
+		conn, err := net.Dial("unix", testEnv.GetSocketPath())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+		client.scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		client.initialize(t, "schema-test")
+
+		response := client.request(t, "tools/list", map[string]interface{}{})
+		require.Nil(t, response["error"])
+
+		var typeValueSchema map[string]interface{}
+		for _, tool := range response["result"].(map[string]interface{})["tools"].([]interface{}) {
+			tool := tool.(map[string]interface{})
+			if tool["name"] == "type_value" {
+				typeValueSchema = tool["inputSchema"].(map[string]interface{})
+			}
+		}
+		require.NotNil(t, typeValueSchema, "type_value should be listed")
+
+		assert.Equal(t, false, typeValueSchema["additionalProperties"])
+		options := typeValueSchema["properties"].(map[string]interface{})["options"].(map[string]interface{})
+		assert.Equal(t, false, options["additionalProperties"])
+		waitAfter := options["properties"].(map[string]interface{})["wait_after"].(map[string]interface{})
+		assert.Equal(t, float64(30), waitAfter["maximum"])
+	})
+
+	tests := []struct {
+		name          string
+		arguments     map[string]interface{}
+		expectedError string
+	}{
+		{
+			name: "nested maximum",
+			arguments: map[string]interface{}{
+				"element_index": 1,
+				"value":         "hello",
+				"options":       map[string]interface{}{"wait_after": 31},
+			},
+			expectedError: "Invalid arguments: options.wait_after: must be <= 30",
+		},
+		{
+			name: "unknown nested option",
+			arguments: map[string]interface{}{
+				"element_index": 1,
+				"value":         "hello",
+				"options":       map[string]interface{}{"retry": true},
+			},
+			expectedError: "Invalid arguments: options.retry: is not allowed",
+		},
+		{
+			name: "missing required argument",
+			arguments: map[string]interface{}{
+				"value": "hello",
+			},
+			expectedError: "Invalid arguments: element_index: is required",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := testEnv.GetMcpClient().CallTool("type_value", tc.arguments)
+			require.NoError(t, err, "MCP call itself should succeed")
+			assert.True(t, result.IsError)
+
+			require.NotEmpty(t, result.Content)
+			textContent, ok := mcp.AsTextContent(result.Content[0])
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedError, textContent.Text)
+		})
+	}
+
+	// Invalid calls never reach the extension
+	assert.Equal(t, 0, rpcCalls)
+}
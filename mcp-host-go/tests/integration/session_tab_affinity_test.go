@@ -0,0 +1,146 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionTabAffinity(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	// Record the tab_id the extension is asked to act on, per method
+	var (
+		mutex     sync.Mutex
+		targetTab = map[string]interface{}{}
+	)
+	recordTab := func(method string, params map[string]interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		targetTab[method] = params["tab_id"]
+	}
+	lastTab := func(method string) interface{} {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return targetTab[method]
+	}
+
+	nativeMsg := testEnv.GetNativeMsg()
+	nativeMsg.RegisterRpcHandler("manage_tabs", func(params map[string]interface{}) (interface{}, error) {
+		switch params["action"] {
+		case "open":
+			return map[string]interface{}{"success": true, "new_tab_id": "101"}, nil
+		default:
+			return map[string]interface{}{"success": true, "tab_id": params["tab_id"]}, nil
+		}
+	})
+	nativeMsg.RegisterRpcHandler("click_element", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("click_element", params)
+		return map[string]interface{}{"success": true, "message": "Successfully clicked element"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("scroll_page", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("scroll_page", params)
+		return map[string]interface{}{"success": true, "message": "Scrolled"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("type_value", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("type_value", params)
+		return map[string]interface{}{"success": true, "message": "Successfully typed value"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("get_dom_state", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("get_dom_state", params)
+		return map[string]interface{}{
+			"formattedDom":        "[Start of page]\n[End of page]\n",
+			"interactiveElements": []interface{}{},
+			"meta":                map[string]interface{}{"url": "https://example.com", "title": "Example"},
+		}, nil
+	})
+
+	connect := func(name string) *jsonLineClient {
+		conn, err := net.Dial("unix", testEnv.GetSocketPath())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+		client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+		client.initialize(t, name)
+		return client
+	}
+
+	callTool := func(client *jsonLineClient, name string, args map[string]interface{}) {
+		response := client.request(t, "tools/call", map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		})
+		require.Nil(t, response["error"])
+		result := response["result"].(map[string]interface{})
+		require.NotEqual(t, true, result["isError"], "tool %s failed: %v", name, result)
+	}
+
+	// The page-level calls whose target tab is checked
+	actOnPage := func(client *jsonLineClient) {
+		callTool(client, "click_element", map[string]interface{}{"element_index": 1})
+		callTool(client, "scroll_page", map[string]interface{}{"action": "down"})
+		callTool(client, "type_value", map[string]interface{}{"element_index": 2, "value": "hello"})
+		response := client.request(t, "resources/read", map[string]interface{}{"uri": "browser://dom/state"})
+		require.Nil(t, response["error"])
+	}
+	assertTargetTab := func(expected interface{}) {
+		for _, method := range []string{"click_element", "scroll_page", "type_value", "get_dom_state"} {
+			assert.Equal(t, expected, lastTab(method), "tab of %s", method)
+		}
+	}
+
+	first := connect("first-agent")
+	second := connect("second-agent")
+
+	// Without a session tab the extension uses the active tab
+	actOnPage(first)
+	assertTargetTab(nil)
+
+	// Opening a tab makes it the session's tab, even in the background
+	callTool(first, "manage_tabs", map[string]interface{}{"action": "open", "url": "https://example.com", "background": true})
+	actOnPage(first)
+	assertTargetTab(float64(101))
+
+	// Other sessions are not affected
+	actOnPage(second)
+	assertTargetTab(nil)
+
+	callTool(second, "manage_tabs", map[string]interface{}{"action": "switch", "tab_id": "202"})
+	actOnPage(second)
+	assertTargetTab(float64(202))
+
+	actOnPage(first)
+	assertTargetTab(float64(101))
+
+	// Closing the session's tab, in the browser or through manage_tabs, falls back to the active tab
+	err = nativeMsg.SendMessage(ctx, map[string]interface{}{
+		"type": "browser_state_changed",
+		"data": map[string]interface{}{"event": "tab_removed", "tabId": 101},
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		callTool(first, "click_element", map[string]interface{}{"element_index": 1})
+		return lastTab("click_element") == nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	callTool(second, "manage_tabs", map[string]interface{}{"action": "close", "tab_id": "202"})
+	actOnPage(second)
+	assertTargetTab(nil)
+}
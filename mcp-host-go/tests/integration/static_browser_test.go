@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticBrowserTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Docs</title></head><body>
+<form action="/search"><input name="q" placeholder="Search docs"></form>
+<a href="/guide">Guide</a>
+</body></html>`)
+	})
+	mux.HandleFunc("/guide", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Guide</title></head><body><p>Getting started</p></body></html>`)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><title>Search</title></head><body><p>Results for %s</p></body></html>`, r.URL.Query().Get("q"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(&env.TestConfig{
+		Env: []string{"MCP_EXTENSION_TRANSPORT=static", "MCP_STATIC_START_URL=" + server.URL + "/"},
+	})
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	require.NoError(t, testEnv.Setup(ctx))
+	client := testEnv.GetMcpClient()
+	require.NoError(t, client.Initialize(ctx))
+
+	readText := func(uri string) string {
+		result, err := client.ReadResource(uri)
+		require.NoError(t, err)
+		require.Len(t, result.Contents, 1)
+		content, ok := result.Contents[0].(mcp.TextResourceContents)
+		require.True(t, ok, "Expected TextResourceContents")
+		return content.Text
+	}
+	callTool := func(name string, arguments map[string]interface{}) *mcp.CallToolResult {
+		result, err := client.CallTool(name, arguments)
+		require.NoError(t, err)
+		return result
+	}
+
+	// No extension connects, yet the fetched pages are served through the usual tools
+	assert.Contains(t, readText("browser://current/state"), server.URL+"/")
+	assert.Contains(t, readText("browser://dom/state"), "Search docs")
+
+	result := callTool("type_value", map[string]interface{}{"element_index": 0, "value": "tabs{Enter}"})
+	require.False(t, result.IsError, "type_value failed: %+v", result.Content)
+	assert.Contains(t, readText("browser://dom/state"), "Results for tabs")
+
+	result = callTool("navigate_to", map[string]interface{}{"url": server.URL + "/"})
+	require.False(t, result.IsError, "navigate_to failed: %+v", result.Content)
+	result = callTool("click_element", map[string]interface{}{"element_index": 1, "wait_after": 0})
+	require.False(t, result.IsError, "click_element failed: %+v", result.Content)
+	assert.Contains(t, readText("browser://dom/state"), "Getting started")
+
+	// Pages are not laid out, so scrolling is not offered
+	tools, err := client.ListTools()
+	require.NoError(t, err)
+	for _, tool := range tools.Tools {
+		assert.NotEqual(t, "scroll_page", tool.Name)
+	}
+}
@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonLineClient speaks newline-delimited JSON-RPC to an `mcp-host stdio` process or the host socket
+type jsonLineClient struct {
+	stdin   io.WriteCloser
+	scanner *bufio.Scanner
+	nextID  int
+}
+
+func (c *jsonLineClient) request(t *testing.T, method string, params interface{}) map[string]interface{} {
+	id := c.start(t, method, params)
+	return c.response(t, id)
+}
+
+// start sends a request without waiting for its response and returns its id
+func (c *jsonLineClient) start(t *testing.T, method string, params interface{}) int {
+	c.nextID++
+	c.send(t, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	return c.nextID
+}
+
+// response skips notifications until the response with the given id arrives
+func (c *jsonLineClient) response(t *testing.T, id int) map[string]interface{} {
+	for c.scanner.Scan() {
+		var message map[string]interface{}
+		require.NoError(t, json.Unmarshal(c.scanner.Bytes(), &message))
+		if messageID, ok := message["id"].(float64); ok && int(messageID) == id {
+			return message
+		}
+	}
+	require.NoError(t, c.scanner.Err())
+	t.Fatalf("connection closed before response to request %d", id)
+	return nil
+}
+
+// next returns the next message of any kind, failing if none arrives
+func (c *jsonLineClient) next(t *testing.T) map[string]interface{} {
+	if !c.scanner.Scan() {
+		require.NoError(t, c.scanner.Err())
+		t.Fatal("connection closed while waiting for a message")
+	}
+	var message map[string]interface{}
+	require.NoError(t, json.Unmarshal(c.scanner.Bytes(), &message))
+	return message
+}
+
+// initialize performs the MCP initialization handshake
+func (c *jsonLineClient) initialize(t *testing.T, clientName string) {
+	response := c.request(t, "initialize", map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"clientInfo": map[string]interface{}{
+			"name":    clientName,
+			"version": "1.0.0",
+		},
+		"capabilities": map[string]interface{}{},
+	})
+	require.Nil(t, response["error"], "initialize should succeed")
+
+	c.send(t, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/initialized",
+	})
+}
+
+func (c *jsonLineClient) send(t *testing.T, message interface{}) {
+	data, err := json.Marshal(message)
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(c.stdin, "%s\n", data)
+	require.NoError(t, err)
+}
+
+func TestStdioBridge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	var capturedURL interface{}
+	testEnv.GetNativeMsg().RegisterRpcHandler("navigate_to", func(params map[string]interface{}) (interface{}, error) {
+		capturedURL = params["url"]
+		return map[string]interface{}{
+			"success": true,
+		}, nil
+	})
+
+	bridgeCmd := testEnv.NewStdioBridgeCommand(ctx)
+	stdin, err := bridgeCmd.StdinPipe()
+	require.NoError(t, err)
+	stdout, err := bridgeCmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, bridgeCmd.Start())
+
+	client := &jsonLineClient{stdin: stdin, scanner: bufio.NewScanner(stdout)}
+	client.scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	client.initialize(t, "stdio-bridge-test")
+
+	t.Run("lists tools", func(t *testing.T) {
+		response := client.request(t, "tools/list", map[string]interface{}{})
+		require.Nil(t, response["error"])
+
+		result := response["result"].(map[string]interface{})
+		names := make(map[string]bool)
+		for _, tool := range result["tools"].([]interface{}) {
+			names[tool.(map[string]interface{})["name"].(string)] = true
+		}
+		assert.True(t, names["navigate_to"], "navigate_to should be available over the stdio bridge")
+	})
+
+	t.Run("calls tool", func(t *testing.T) {
+		response := client.request(t, "tools/call", map[string]interface{}{
+			"name": "navigate_to",
+			"arguments": map[string]interface{}{
+				"url": "https://example.com",
+			},
+		})
+		require.Nil(t, response["error"])
+
+		result := response["result"].(map[string]interface{})
+		assert.NotEqual(t, true, result["isError"])
+		assert.Equal(t, "https://example.com", capturedURL)
+	})
+
+	// Closing stdin ends the bridge cleanly
+	require.NoError(t, stdin.Close())
+	assert.NoError(t, bridgeCmd.Wait())
+	assert.True(t, testEnv.IsHostRunning(), "host should keep running after the bridge disconnects")
+}
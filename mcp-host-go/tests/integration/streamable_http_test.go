@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamableHTTPTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	// Register RPC handler for navigate_to method
+	var capturedURL interface{}
+	testEnv.GetNativeMsg().RegisterRpcHandler("navigate_to", func(params map[string]interface{}) (interface{}, error) {
+		capturedURL = params["url"]
+		return map[string]interface{}{
+			"success": true,
+		}, nil
+	})
+
+	// Connect a Streamable HTTP client alongside the default SSE client
+	streamableClient := testEnv.NewStreamableHTTPClient()
+	defer streamableClient.Close()
+
+	err = streamableClient.Initialize(ctx)
+	require.NoError(t, err)
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	t.Run("shares tools with SSE transport", func(t *testing.T) {
+		streamableTools, err := streamableClient.ListTools()
+		require.NoError(t, err)
+
+		sseTools, err := testEnv.GetMcpClient().ListTools()
+		require.NoError(t, err)
+
+		assert.Equal(t, len(sseTools.Tools), len(streamableTools.Tools))
+
+		names := make(map[string]bool)
+		for _, tool := range streamableTools.Tools {
+			names[tool.Name] = true
+		}
+		assert.True(t, names["navigate_to"], "navigate_to should be available over Streamable HTTP")
+		assert.True(t, names["click_element"], "click_element should be available over Streamable HTTP")
+	})
+
+	t.Run("shares resources with SSE transport", func(t *testing.T) {
+		resources, err := streamableClient.ListResources()
+		require.NoError(t, err)
+
+		uris := make(map[string]bool)
+		for _, resource := range resources.Resources {
+			uris[resource.URI] = true
+		}
+		assert.True(t, uris["browser://current/state"])
+		assert.True(t, uris["browser://dom/state"])
+	})
+
+	t.Run("calls tools through the extension", func(t *testing.T) {
+		result, err := streamableClient.CallTool("navigate_to", map[string]interface{}{
+			"url": "https://example.com",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		require.NotEmpty(t, result.Content)
+
+		textContent, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "Successfully navigated to https://example.com")
+		assert.Equal(t, "https://example.com", capturedURL)
+	})
+
+	t.Run("answers resource subscriptions", func(t *testing.T) {
+		post := func(sessionID string, body []byte) *http.Response {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, testEnv.GetStreamableHTTPURL(), bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+testEnv.GetAuthToken())
+			if sessionID != "" {
+				req.Header.Set("Mcp-Session-Id", sessionID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			return resp
+		}
+		request := func(sessionID string, method string, params interface{}) map[string]interface{} {
+			body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+			require.NoError(t, err)
+			resp := post(sessionID, body)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var message map[string]interface{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&message))
+			return message
+		}
+
+		initialize := post("", []byte(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"subscriber","version":"1.0.0"}}}`))
+		initialize.Body.Close()
+		sessionID := initialize.Header.Get("Mcp-Session-Id")
+		require.NotEmpty(t, sessionID)
+
+		response := request(sessionID, "resources/subscribe", map[string]interface{}{"uri": "browser://dom/state"})
+		assert.Nil(t, response["error"])
+		assert.NotNil(t, response["result"])
+
+		response = request(sessionID, "resources/subscribe", map[string]interface{}{"uri": "browser://no/such/resource"})
+		require.NotNil(t, response["error"])
+		assert.Equal(t, float64(-32002), response["error"].(map[string]interface{})["code"])
+
+		// Bodies over the limit are refused rather than cut short
+		resp := post(sessionID, bytes.Repeat([]byte(" "), 17*1024*1024))
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+}
@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTabIDArgument(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	// Record the tab_id the extension is asked to act on, per method
+	var (
+		mutex     sync.Mutex
+		targetTab = map[string]interface{}{}
+	)
+	recordTab := func(method string, params map[string]interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		targetTab[method] = params["tab_id"]
+	}
+	lastTab := func(method string) interface{} {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return targetTab[method]
+	}
+
+	nativeMsg := testEnv.GetNativeMsg()
+	nativeMsg.RegisterRpcHandler("manage_tabs", func(params map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{"success": true, "new_tab_id": "101"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("click_element", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("click_element", params)
+		return map[string]interface{}{"success": true, "message": "Successfully clicked element"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("scroll_page", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("scroll_page", params)
+		return map[string]interface{}{"success": true, "message": "Scrolled"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("type_value", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("type_value", params)
+		return map[string]interface{}{"success": true, "message": "Successfully typed value"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("get_dom_state", func(params map[string]interface{}) (interface{}, error) {
+		recordTab("get_dom_state", params)
+		return map[string]interface{}{
+			"formattedDom": "[Start of page]\n[End of page]\n",
+			"interactiveElements": []interface{}{
+				map[string]interface{}{"index": 0, "tagName": "button", "text": "OK", "type": "button", "isInViewport": true},
+			},
+			"meta": map[string]interface{}{"url": "https://example.com", "title": "Example"},
+		}, nil
+	})
+
+	conn, err := net.Dial("unix", testEnv.GetSocketPath())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+	client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+	client.scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	client.initialize(t, "tab-id-test")
+
+	callTool := func(name string, args map[string]interface{}) map[string]interface{} {
+		response := client.request(t, "tools/call", map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		})
+		require.Nil(t, response["error"])
+		return response["result"].(map[string]interface{})
+	}
+
+	// Every page-level tool declares the optional tab_id argument
+	response := client.request(t, "tools/list", map[string]interface{}{})
+	require.Nil(t, response["error"])
+	declared := map[string]bool{}
+	for _, item := range response["result"].(map[string]interface{})["tools"].([]interface{}) {
+		tool := item.(map[string]interface{})
+		properties := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+		_, declared[tool["name"].(string)] = properties["tab_id"]
+	}
+	for _, name := range []string{"click_element", "type_value", "scroll_page", "get_dom_extra_elements"} {
+		assert.True(t, declared[name], "%s should declare tab_id", name)
+	}
+
+	// The session works in tab 101
+	result := callTool("manage_tabs", map[string]interface{}{"action": "open", "url": "https://example.com", "background": true})
+	require.NotEqual(t, true, result["isError"], "manage_tabs failed: %v", result)
+
+	// tab_id targets another tab for a single call
+	result = callTool("click_element", map[string]interface{}{"element_index": 0, "tab_id": 7})
+	require.NotEqual(t, true, result["isError"], "click_element failed: %v", result)
+	assert.Equal(t, float64(7), lastTab("click_element"))
+	queue := result["_meta"].(map[string]interface{})["algonius/queue"].(map[string]interface{})
+	assert.Equal(t, "7", queue["tab"])
+
+	result = callTool("scroll_page", map[string]interface{}{"action": "down", "tab_id": 7})
+	require.NotEqual(t, true, result["isError"], "scroll_page failed: %v", result)
+	assert.Equal(t, float64(7), lastTab("scroll_page"))
+
+	result = callTool("type_value", map[string]interface{}{"element_index": 0, "value": "hello", "tab_id": 7})
+	require.NotEqual(t, true, result["isError"], "type_value failed: %v", result)
+	assert.Equal(t, float64(7), lastTab("type_value"))
+
+	result = callTool("get_dom_extra_elements", map[string]interface{}{"tab_id": 8})
+	require.NotEqual(t, true, result["isError"], "get_dom_extra_elements failed: %v", result)
+	assert.Equal(t, float64(8), lastTab("get_dom_state"))
+
+	response = client.request(t, "resources/read", map[string]interface{}{
+		"uri":       "browser://dom/state",
+		"arguments": map[string]interface{}{"tab_id": 9},
+	})
+	require.Nil(t, response["error"])
+	assert.Equal(t, float64(9), lastTab("get_dom_state"))
+
+	// The session's tab is unchanged for calls without tab_id
+	result = callTool("click_element", map[string]interface{}{"element_index": 0})
+	require.NotEqual(t, true, result["isError"], "click_element failed: %v", result)
+	assert.Equal(t, float64(101), lastTab("click_element"))
+
+	response = client.request(t, "resources/read", map[string]interface{}{"uri": "browser://dom/state"})
+	require.Nil(t, response["error"])
+	assert.Equal(t, float64(101), lastTab("get_dom_state"))
+
+	// Invalid tab IDs are rejected before reaching the extension
+	result = callTool("click_element", map[string]interface{}{"element_index": 0, "tab_id": -1})
+	assert.Equal(t, true, result["isError"])
+	result = callTool("click_element", map[string]interface{}{"element_index": 0, "tab_id": 1.5})
+	assert.Equal(t, true, result["isError"])
+
+	response = client.request(t, "resources/read", map[string]interface{}{
+		"uri":       "browser://dom/state",
+		"arguments": map[string]interface{}{"tab_id": "nine"},
+	})
+	assert.NotNil(t, response["error"])
+}
@@ -0,0 +1,160 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTabActionSerialization(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	// scroll_page blocks until released
+	scrollStarted := make(chan struct{}, 1)
+	releaseScroll := make(chan struct{})
+	typeStarted := make(chan struct{}, 1)
+
+	nativeMsg := testEnv.GetNativeMsg()
+	nativeMsg.RegisterRpcHandler("scroll_page", func(params map[string]interface{}) (interface{}, error) {
+		scrollStarted <- struct{}{}
+		<-releaseScroll
+		return map[string]interface{}{"success": true, "message": "Scrolled"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("type_value", func(params map[string]interface{}) (interface{}, error) {
+		typeStarted <- struct{}{}
+		return map[string]interface{}{"success": true, "message": "Successfully typed value"}, nil
+	})
+	nativeMsg.RegisterRpcHandler("click_element", func(params map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{"success": true, "message": "Successfully clicked element"}, nil
+	})
+	var openedTabs atomic.Int32
+	nativeMsg.RegisterRpcHandler("manage_tabs", func(params map[string]interface{}) (interface{}, error) {
+		tabID := 101 + openedTabs.Add(1) - 1
+		return map[string]interface{}{"success": true, "new_tab_id": strconv.Itoa(int(tabID))}, nil
+	})
+	nativeMsg.RegisterRpcHandler("get_dom_state", func(params map[string]interface{}) (interface{}, error) {
+		return map[string]interface{}{
+			"formattedDom":        "[Start of page]\n[End of page]\n",
+			"interactiveElements": []interface{}{},
+			"meta":                map[string]interface{}{"url": "https://example.com", "title": "Example"},
+		}, nil
+	})
+
+	connect := func(name string) *jsonLineClient {
+		conn, err := net.Dial("unix", testEnv.GetSocketPath())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		require.NoError(t, conn.SetDeadline(time.Now().Add(time.Minute)))
+
+		client := &jsonLineClient{stdin: conn, scanner: bufio.NewScanner(conn)}
+		client.initialize(t, name)
+		return client
+	}
+
+	// queueMeta returns the queue report of a successful tool call
+	queueMeta := func(response map[string]interface{}) map[string]interface{} {
+		require.Nil(t, response["error"])
+		result := response["result"].(map[string]interface{})
+		require.NotEqual(t, true, result["isError"], "tool call failed: %v", result)
+		meta, ok := result["_meta"].(map[string]interface{})
+		require.True(t, ok, "result should carry _meta: %v", result)
+		queue, ok := meta["algonius/queue"].(map[string]interface{})
+		require.True(t, ok, "_meta should report queueing: %v", meta)
+		return queue
+	}
+
+	agent := connect("agent")
+	observer := connect("observer")
+	other := connect("other-agent")
+
+	// Both agents work in their own tab
+	openTab := func(client *jsonLineClient) {
+		queueMeta(client.request(t, "tools/call", map[string]interface{}{
+			"name":      "manage_tabs",
+			"arguments": map[string]interface{}{"action": "open", "url": "https://example.com", "background": true},
+		}))
+	}
+	openTab(other)
+	openTab(agent)
+
+	scrollID := agent.start(t, "tools/call", map[string]interface{}{
+		"name":      "scroll_page",
+		"arguments": map[string]interface{}{"action": "down"},
+	})
+	select {
+	case <-scrollStarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("scroll_page was not forwarded to the extension")
+	}
+
+	typeID := agent.start(t, "tools/call", map[string]interface{}{
+		"name":      "type_value",
+		"arguments": map[string]interface{}{"element_index": 2, "value": "hello"},
+	})
+
+	// Resources are read-only and are not held up by the running scroll
+	response := observer.request(t, "resources/read", map[string]interface{}{"uri": "browser://dom/state"})
+	require.Nil(t, response["error"])
+
+	// Mutating calls on another tab run in parallel
+	clickQueue := queueMeta(other.request(t, "tools/call", map[string]interface{}{
+		"name":      "click_element",
+		"arguments": map[string]interface{}{"element_index": 1},
+	}))
+	assert.Equal(t, "101", clickQueue["tab"])
+	assert.Equal(t, float64(0), clickQueue["depth"])
+
+	// type_value waits for the scroll on the same tab
+	select {
+	case <-typeStarted:
+		t.Fatal("type_value ran while scroll_page was still in progress")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// A call on the active tab may act on any tab and waits for both calls on 102
+	activeID := observer.start(t, "tools/call", map[string]interface{}{
+		"name":      "click_element",
+		"arguments": map[string]interface{}{"element_index": 3},
+	})
+	time.Sleep(300 * time.Millisecond)
+
+	close(releaseScroll)
+
+	scrollQueue := queueMeta(agent.response(t, scrollID))
+	assert.Equal(t, "102", scrollQueue["tab"])
+	assert.Equal(t, float64(0), scrollQueue["depth"])
+	assert.Equal(t, float64(0), scrollQueue["waitMs"])
+
+	typeQueue := queueMeta(agent.response(t, typeID))
+	assert.Equal(t, "102", typeQueue["tab"])
+	assert.Equal(t, float64(1), typeQueue["depth"])
+	assert.GreaterOrEqual(t, typeQueue["waitMs"].(float64), float64(300))
+
+	select {
+	case <-typeStarted:
+	default:
+		t.Fatal("type_value was not forwarded to the extension")
+	}
+
+	activeQueue := queueMeta(observer.response(t, activeID))
+	assert.Equal(t, "active", activeQueue["tab"])
+	assert.Equal(t, float64(2), activeQueue["depth"])
+	assert.GreaterOrEqual(t, activeQueue["waitMs"].(float64), float64(300))
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"env"
+)
+
+// onePixelPNG is a valid 1x1 PNG image
+const onePixelPNG = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+func TestTakeScreenshotTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	var capturedParams []map[string]interface{}
+	testEnv.GetNativeMsg().RegisterRpcHandler("take_screenshot", func(params map[string]interface{}) (interface{}, error) {
+		capturedParams = append(capturedParams, params)
+		mimeType := "image/png"
+		if params["format"] == "jpeg" {
+			mimeType = "image/jpeg"
+		}
+		return map[string]interface{}{
+			"data":     onePixelPNG,
+			"mimeType": mimeType,
+			"mode":     params["mode"],
+			"url":      "https://example.com",
+			"title":    "Example",
+		}, nil
+	})
+
+	t.Run("viewport screenshot returns image content", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("take_screenshot", map[string]interface{}{})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		require.Len(t, result.Content, 2)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, text.Text, "Captured viewport screenshot (image/png)")
+
+		image, ok := mcp.AsImageContent(result.Content[1])
+		require.True(t, ok, "second item should be image content, got %T", result.Content[1])
+		assert.Equal(t, "image", image.Type)
+		assert.Equal(t, "image/png", image.MIMEType)
+		assert.Equal(t, onePixelPNG, image.Data)
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, "viewport", capturedParams[0]["mode"])
+		assert.Equal(t, "png", capturedParams[0]["format"])
+		assert.NotContains(t, capturedParams[0], "quality")
+	})
+
+	t.Run("element screenshot as jpeg", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("take_screenshot", map[string]interface{}{
+			"mode":          "element",
+			"element_index": 3,
+			"image_format":  "jpeg",
+			"quality":       60,
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		image, ok := mcp.AsImageContent(result.Content[1])
+		require.True(t, ok)
+		assert.Equal(t, "image/jpeg", image.MIMEType)
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, float64(3), capturedParams[0]["element_index"])
+		assert.Equal(t, float64(60), capturedParams[0]["quality"])
+	})
+
+	t.Run("tab_id is forwarded", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("take_screenshot", map[string]interface{}{
+			"tab_id": 7,
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, float64(7), capturedParams[0]["tab_id"])
+	})
+
+	errorCases := []struct {
+		name          string
+		arguments     map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:          "element mode requires element_index",
+			arguments:     map[string]interface{}{"mode": "element"},
+			expectedError: "element_index is required for 'element' mode",
+		},
+		{
+			name:          "quality requires jpeg",
+			arguments:     map[string]interface{}{"quality": 50},
+			expectedError: "quality is only supported for 'jpeg' image_format",
+		},
+		{
+			name:          "quality out of range",
+			arguments:     map[string]interface{}{"image_format": "jpeg", "quality": 101},
+			expectedError: "quality: must be <= 100",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			capturedParams = nil
+			result, err := testEnv.GetMcpClient().CallTool("take_screenshot", tc.arguments)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+
+			text, ok := mcp.AsTextContent(result.Content[0])
+			require.True(t, ok)
+			assert.Contains(t, text.Text, tc.expectedError)
+			assert.Empty(t, capturedParams, "invalid calls should not reach the extension")
+		})
+	}
+}
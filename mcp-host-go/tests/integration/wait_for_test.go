@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"env"
+)
+
+func TestWaitForTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	testEnv, err := env.NewMcpHostTestEnvironment(nil)
+	require.NoError(t, err)
+	defer testEnv.Cleanup()
+
+	err = testEnv.Setup(ctx)
+	require.NoError(t, err)
+
+	err = testEnv.GetMcpClient().Initialize(ctx)
+	require.NoError(t, err)
+
+	var capturedParams []map[string]interface{}
+	testEnv.GetNativeMsg().RegisterRpcHandler("wait_for", func(params map[string]interface{}) (interface{}, error) {
+		capturedParams = append(capturedParams, params)
+		if params["text"] == "Never" {
+			return nil, fmt.Errorf("Timed out after %.0fms waiting for text 'Never'", params["timeout"])
+		}
+		return map[string]interface{}{
+			"success":    true,
+			"message":    "Waited 120ms for selector '#results' to appear",
+			"condition":  "selector",
+			"elapsed_ms": 120,
+			"url":        "https://example.com/search",
+			"title":      "Search",
+		}, nil
+	})
+
+	t.Run("selector wait reports the condition met", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("wait_for", map[string]interface{}{
+			"selector": "#results",
+			"text":     "Results",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "wait_for failed: %+v", result.Content)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, text.Text, "Wait For Result:")
+		assert.Contains(t, text.Text, "- Condition: selector")
+		assert.Contains(t, text.Text, "- Elapsed: 120 ms")
+		assert.Contains(t, text.Text, "- URL: https://example.com/search")
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, "#results", capturedParams[0]["selector"])
+		assert.Equal(t, "css", capturedParams[0]["selector_type"])
+		assert.Equal(t, "appear", capturedParams[0]["selector_state"])
+		assert.Equal(t, "Results", capturedParams[0]["text"])
+		assert.Equal(t, float64(10000), capturedParams[0]["timeout"])
+		assert.NotContains(t, capturedParams[0], "return_dom_state")
+	})
+
+	t.Run("json format", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("wait_for", map[string]interface{}{
+			"network_idle_ms": 500,
+			"timeout":         3000,
+			"format":          "json",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "wait_for failed: %+v", result.Content)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		var output map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &output))
+		assert.Equal(t, "selector", output["condition"])
+		assert.Equal(t, float64(120), output["elapsedMs"])
+		assert.Equal(t, "Search", output["title"])
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, float64(500), capturedParams[0]["network_idle_ms"])
+		assert.Equal(t, float64(3000), capturedParams[0]["timeout"])
+		assert.NotContains(t, capturedParams[0], "selector_type")
+	})
+
+	t.Run("url_pattern is left to the browser's regular expressions", func(t *testing.T) {
+		capturedParams = nil
+		// Lookbehind is JavaScript syntax that Go's regexp does not support
+		result, err := testEnv.GetMcpClient().CallTool("wait_for", map[string]interface{}{
+			"url_pattern": "(?<=/checkout/)complete",
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError, "wait_for failed: %+v", result.Content)
+
+		require.Len(t, capturedParams, 1)
+		assert.Equal(t, "(?<=/checkout/)complete", capturedParams[0]["url_pattern"])
+	})
+
+	t.Run("timeout is reported as an error", func(t *testing.T) {
+		capturedParams = nil
+		result, err := testEnv.GetMcpClient().CallTool("wait_for", map[string]interface{}{
+			"text":    "Never",
+			"timeout": 500,
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		text, ok := mcp.AsTextContent(result.Content[0])
+		require.True(t, ok)
+		assert.Contains(t, text.Text, "Timed out after 500ms waiting for text 'Never'")
+	})
+
+	errorCases := []struct {
+		name          string
+		arguments     map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:          "a condition is required",
+			arguments:     map[string]interface{}{"timeout": 1000},
+			expectedError: "at least one condition is required",
+		},
+		{
+			name:          "timeout out of range",
+			arguments:     map[string]interface{}{"text": "Saved", "timeout": 600000},
+			expectedError: "timeout: must be <= 120000",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			capturedParams = nil
+			result, err := testEnv.GetMcpClient().CallTool("wait_for", tc.arguments)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+
+			text, ok := mcp.AsTextContent(result.Content[0])
+			require.True(t, ok)
+			assert.Contains(t, text.Text, tc.expectedError)
+			assert.Empty(t, capturedParams, "invalid calls should not reach the extension")
+		})
+	}
+}
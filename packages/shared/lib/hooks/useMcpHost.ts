@@ -13,6 +13,7 @@ export interface McpHostStatus {
   uptime?: string;
   ssePort?: string;
   sseBaseURL?: string;
+  authToken?: string;
 }
 
 /**
@@ -138,6 +138,17 @@ export const StatusDisplay: React.FC<StatusDisplayProps> = ({ status, loading, e
             </div>
           )}
 
+          {status.authToken && (
+            <div className="flex items-center">
+              <span className="flex-1 text-gray-600 dark:text-gray-300">Auth Token:</span>
+              <span
+                className="max-w-48 select-all truncate font-mono text-sm font-medium text-gray-900 dark:text-white"
+                title="Send as 'Authorization: Bearer <token>'">
+                {status.authToken}
+              </span>
+            </div>
+          )}
+
           <div className="flex items-center">
             <span className="flex-1 text-gray-600 dark:text-gray-300">Start Time:</span>
             <span className="font-medium text-gray-900 dark:text-white">{formatTimestamp(status.startTime)}</span>